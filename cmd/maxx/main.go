@@ -1,24 +1,36 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/awsl-project/maxx/internal/adapter/client"
-	_ "github.com/awsl-project/maxx/internal/adapter/provider/antigravity" // Register antigravity adapter
-	_ "github.com/awsl-project/maxx/internal/adapter/provider/custom"      // Register custom adapter
+	"github.com/awsl-project/maxx/internal/adapter/provider/antigravity" // Register antigravity adapter
+	_ "github.com/awsl-project/maxx/internal/adapter/provider/custom"    // Register custom adapter
+	"github.com/awsl-project/maxx/internal/budget"
+	"github.com/awsl-project/maxx/internal/circuit"
 	"github.com/awsl-project/maxx/internal/cooldown"
+	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/executor"
 	"github.com/awsl-project/maxx/internal/handler"
+	"github.com/awsl-project/maxx/internal/health"
+	"github.com/awsl-project/maxx/internal/metrics"
+	"github.com/awsl-project/maxx/internal/pricing"
+	"github.com/awsl-project/maxx/internal/ratelimit"
 	"github.com/awsl-project/maxx/internal/repository/cached"
 	"github.com/awsl-project/maxx/internal/repository/sqlite"
 	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/secret"
 	"github.com/awsl-project/maxx/internal/service"
+	"github.com/awsl-project/maxx/internal/tracing"
 	"github.com/awsl-project/maxx/internal/version"
 	"github.com/awsl-project/maxx/internal/waiter"
 )
@@ -33,6 +45,35 @@ func getDefaultDataDir() string {
 	return filepath.Join(homeDir, ".config", "maxx")
 }
 
+// Subcommands accepted after the flags; no subcommand starts the server
+const commandRotateKey = "rotate-key"
+
+// parseCommand returns the subcommand to run, or "" to start the server
+func parseCommand(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	switch args[0] {
+	case commandRotateKey:
+		if len(args) > 1 {
+			return "", fmt.Errorf("%s takes no arguments, got %q", args[0], args[1:])
+		}
+		return args[0], nil
+	default:
+		return "", fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// usage prints flags and subcommands
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: maxx [flags] [command]\n\n")
+	fmt.Fprintf(out, "Commands:\n")
+	fmt.Fprintf(out, "  %s\trewrap provider secrets with a new master key and exit (stop the server first)\n\n", commandRotateKey)
+	fmt.Fprintf(out, "Without a command, maxx starts the server.\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
 // generateInstanceID generates a unique instance ID for this server run
 func generateInstanceID() string {
 	hostname, _ := os.Hostname()
@@ -44,8 +85,18 @@ func main() {
 	addr := flag.String("addr", ":9880", "Server address")
 	dataDir := flag.String("data", "", "Data directory for database and logs (default: ~/.config/maxx)")
 	showVersion := flag.Bool("version", false, "Show version information and exit")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector URL for traces, e.g. http://localhost:4318 (default: $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	flag.Usage = usage
 	flag.Parse()
 
+	// Reject unknown subcommands before anything starts, so a typo never runs the server
+	command, err := parseCommand(flag.Args())
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "maxx: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	// Show version and exit if requested
 	if *showVersion {
 		fmt.Println("maxx", version.Full())
@@ -77,6 +128,27 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Key rotation runs against the database and exits
+	if command == commandRotateKey {
+		if err := rotateMasterKey(db, dataDirPath); err != nil {
+			log.Fatalf("Failed to rotate master key: %v", err)
+		}
+		os.Exit(0)
+	}
+
+	// Encrypt provider secrets at rest
+	keyring, keySource, err := secret.LoadKeyring(dataDirPath)
+	if err != nil {
+		log.Fatalf("Failed to load master key: %v", err)
+	}
+	db.SetKeyring(keyring)
+	log.Printf("Provider secrets encrypted with master key %s (from %s)", keyring.ID(), keySource)
+	if count, err := db.EncryptProviderSecrets(); err != nil {
+		log.Fatalf("Failed to encrypt provider secrets: %v", err)
+	} else if count > 0 {
+		log.Printf("Encrypted secrets of %d existing providers", count)
+	}
+
 	// Create repositories
 	providerRepo := sqlite.NewProviderRepository(db)
 	routeRepo := sqlite.NewRouteRepository(db)
@@ -90,6 +162,16 @@ func main() {
 	antigravityQuotaRepo := sqlite.NewAntigravityQuotaRepository(db)
 	cooldownRepo := sqlite.NewCooldownRepository(db)
 	failureCountRepo := sqlite.NewFailureCountRepository(db)
+	apiKeyRepo := sqlite.NewAPIKeyRepository(db)
+	rateLimitRepo := sqlite.NewRateLimitRepository(db)
+	budgetRepo := sqlite.NewBudgetRepository(db)
+	probeResultRepo := sqlite.NewProbeResultRepository(db)
+	cooldownPolicyRepo := sqlite.NewCooldownPolicyRepository(db)
+	modelPriceRepo := sqlite.NewModelPriceRepository(db)
+	userRepo := sqlite.NewUserRepository(db)
+	adminSessionRepo := sqlite.NewAdminSessionRepository(db)
+	thoughtSignatureRepo := sqlite.NewThoughtSignatureRepository(db)
+	signatureCacheRepo := sqlite.NewSignatureCacheRepository(db)
 
 	// Initialize cooldown manager with database persistence
 	cooldown.Default().SetRepository(cooldownRepo)
@@ -97,6 +179,38 @@ func main() {
 	if err := cooldown.Default().LoadFromDatabase(); err != nil {
 		log.Printf("Warning: Failed to load cooldowns from database: %v", err)
 	}
+	cooldown.Default().SetPolicyRepository(cooldownPolicyRepo)
+	if err := cooldown.Default().LoadPolicies(); err != nil {
+		log.Printf("Warning: Failed to load cooldown policies: %v", err)
+	}
+
+	// Overlay custom model prices on the built-in price table
+	if err := pricing.LoadPrices(modelPriceRepo); err != nil {
+		log.Printf("Warning: Failed to load model prices: %v", err)
+	}
+
+	// Persist Antigravity thought signatures so restarts don't break in-flight tool loops
+	antigravity.DefaultThoughtSignatureStore().SetRepository(thoughtSignatureRepo)
+	if err := antigravity.DefaultThoughtSignatureStore().LoadFromDatabase(); err != nil {
+		log.Printf("Warning: Failed to load thought signatures from database: %v", err)
+	}
+	antigravity.GlobalSignatureCache().SetRepository(signatureCacheRepo)
+	antigravity.GlobalSignatureCache().ApplySettings(settingRepo)
+
+	// Circuit breaker thresholds (circuit_breaker_* settings)
+	circuit.Default().ApplySettings(settingRepo)
+
+	// Initialize rate limits
+	ratelimit.Default().SetRepository(rateLimitRepo)
+	if err := ratelimit.Default().Load(); err != nil {
+		log.Printf("Warning: Failed to load rate limits: %v", err)
+	}
+
+	// Initialize budgets (spend is summed from stored attempts)
+	budget.Default().SetRepository(budgetRepo)
+	if err := budget.Default().Load(); err != nil {
+		log.Printf("Warning: Failed to load budgets: %v", err)
+	}
 
 	// Generate instance ID and mark stale requests as failed
 	instanceID := generateInstanceID()
@@ -113,6 +227,7 @@ func main() {
 	cachedRoutingStrategyRepo := cached.NewRoutingStrategyRepository(routingStrategyRepo)
 	cachedSessionRepo := cached.NewSessionRepository(sessionRepo)
 	cachedProjectRepo := cached.NewProjectRepository(projectRepo)
+	cachedAPIKeyRepo := cached.NewAPIKeyRepository(apiKeyRepo)
 
 	// Load cached data
 	if err := cachedProviderRepo.Load(); err != nil {
@@ -130,6 +245,9 @@ func main() {
 	if err := cachedProjectRepo.Load(); err != nil {
 		log.Printf("Warning: Failed to load projects cache: %v", err)
 	}
+	if err := cachedAPIKeyRepo.Load(); err != nil {
+		log.Printf("Warning: Failed to load api keys cache: %v", err)
+	}
 
 	// Create router
 	r := router.NewRouter(cachedRouteRepo, cachedProviderRepo, cachedRoutingStrategyRepo, cachedRetryConfigRepo, cachedProjectRepo)
@@ -139,6 +257,9 @@ func main() {
 		log.Printf("Warning: Failed to initialize adapters: %v", err)
 	}
 
+	// Start provider health prober (interval from the health_check_interval setting)
+	go health.NewProber(cachedProviderRepo, r, probeResultRepo, settingRepo).Run(context.Background())
+
 	// Start cooldown cleanup goroutine
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour)
@@ -152,12 +273,25 @@ func main() {
 			if before != after {
 				log.Printf("[Cooldown] Cleanup completed: removed %d expired entries", before-after)
 			}
+
+			if count, err := adminSessionRepo.DeleteExpired(); err == nil && count > 0 {
+				log.Printf("[Auth] Removed %d expired admin sessions", count)
+			}
+
+			antigravity.DefaultThoughtSignatureStore().CleanupExpired()
+			antigravity.GlobalSignatureCache().CleanupExpired()
+
+			if err := probeResultRepo.DeleteBefore(time.Now().Add(-health.HistoryRetention)); err != nil {
+				log.Printf("[Health] Failed to clean up probe history: %v", err)
+			}
 		}
 	}()
 	log.Println("[Cooldown] Background cleanup started (runs every 1 hour)")
 
 	// Create WebSocket hub
 	wsHub := handler.NewWebSocketHub()
+	budget.Default().SetBroadcaster(wsHub)
+	circuit.Default().SetBroadcaster(wsHub)
 
 	// Setup log output to broadcast via WebSocket
 	logWriter := handler.NewWebSocketLogWriter(wsHub, os.Stdout, logPath)
@@ -183,30 +317,74 @@ func main() {
 		proxyRequestRepo,
 		attemptRepo,
 		settingRepo,
+		cachedAPIKeyRepo,
+		rateLimitRepo,
+		budgetRepo,
+		probeResultRepo,
+		cooldownPolicyRepo,
+		modelPriceRepo,
 		*addr,
 		r, // Router implements ProviderAdapterRefresher interface
+		r, // and RouteExplainer
+		antigravity.GlobalSignatureCache(),
 	)
 
+	// Create auth service (admin login and roles)
+	authService := service.NewAuthService(userRepo, adminSessionRepo)
+	if err := authService.Bootstrap(os.Getenv("MAXX_ADMIN_USER"), os.Getenv("MAXX_ADMIN_PASSWORD")); err != nil {
+		log.Printf("Warning: Failed to create initial admin user: %v", err)
+	}
+	if setupToken, err := authService.NewSetupToken(); err != nil {
+		log.Printf("Warning: Failed to create setup token: %v", err)
+	} else if setupToken != "" {
+		log.Printf("No admin users configured. Open the web UI and create the first admin with setup token: %s", setupToken)
+	}
+	authMiddleware := handler.NewAuthMiddleware(authService)
+	authHandler := handler.NewAuthHandler(authService)
+
+	if value, _ := settingRepo.Get(domain.SettingKeyProxyAuthEnabled); value == "false" {
+		log.Printf("Warning: Proxy authentication is disabled (proxy_auth_enabled=false), proxy endpoints accept any caller")
+	}
+
 	// Create handlers
-	proxyHandler := handler.NewProxyHandler(clientAdapter, exec, cachedSessionRepo)
+	proxyHandler := handler.NewProxyHandler(clientAdapter, exec, cachedSessionRepo, cachedAPIKeyRepo, settingRepo)
 	adminHandler := handler.NewAdminHandler(adminService, logPath)
 	antigravityHandler := handler.NewAntigravityHandler(adminService, antigravityQuotaRepo, wsHub)
 
 	// Use already-created cached project repository for project proxy handler
 	projectProxyHandler := handler.NewProjectProxyHandler(proxyHandler, cachedProjectRepo)
 
+	// Prometheus metrics (admin session, or the MAXX_METRICS_TOKEN scrape token)
+	metrics.SetProviderRepository(cachedProviderRepo)
+	metricsHandler := handler.NewMetricsHandler(os.Getenv("MAXX_METRICS_TOKEN"), authMiddleware)
+
+	// OpenTelemetry traces: CLI flag > OTEL_EXPORTER_OTLP_* env vars
+	tracingConfig := tracing.ConfigFromEnv()
+	if *otlpEndpoint != "" {
+		tracingConfig.Endpoint = tracing.TracesURL(*otlpEndpoint)
+	}
+	if err := tracing.Init(tracingConfig); err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	}
+
 	// Setup routes
 	mux := http.NewServeMux()
 
+	// Auth routes (login, users)
+	mux.Handle("/admin/auth/", authMiddleware.Wrap(authHandler))
+	mux.Handle("/admin/users", authMiddleware.Wrap(authHandler))
+	mux.Handle("/admin/users/", authMiddleware.Wrap(authHandler))
+
 	// Admin API routes
-	mux.Handle("/admin/", adminHandler)
+	mux.Handle("/admin/", authMiddleware.Wrap(adminHandler))
 
 	// Antigravity API routes
-	mux.Handle("/antigravity/", antigravityHandler)
+	mux.Handle("/antigravity/", authMiddleware.Wrap(antigravityHandler))
 
 	// Proxy routes - catch all AI API endpoints
 	// Claude API
 	mux.Handle("/v1/messages", proxyHandler)
+	mux.Handle("/v1/messages/count_tokens", proxyHandler)
 	// OpenAI API
 	mux.Handle("/v1/chat/completions", proxyHandler)
 	// Codex API
@@ -221,8 +399,11 @@ func main() {
 		w.Write([]byte(`{"status":"ok"}`))
 	})
 
+	// Prometheus metrics
+	mux.Handle("/metrics", metricsHandler)
+
 	// WebSocket endpoint
-	mux.HandleFunc("/ws", wsHub.HandleWebSocket)
+	mux.Handle("/ws", authMiddleware.WrapFunc(wsHub.HandleWebSocket))
 
 	// Serve static files (Web UI) with project proxy support - must be last (default route)
 	staticHandler := handler.NewStaticHandler()
@@ -246,8 +427,23 @@ func main() {
 	log.Printf("  Gemini: http://localhost%s/v1beta/models/{model}:generateContent", *addr)
 	log.Printf("Project proxy: http://localhost%s/{project-slug}/v1/messages (etc.)", *addr)
 
-	if err := http.ListenAndServe(*addr, loggedMux); err != nil {
-		log.Printf("Server error: %v", err)
-		os.Exit(1)
+	server := &http.Server{Addr: *addr, Handler: loggedMux}
+	stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("Server error: %v", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-stopCtx.Done()
+	log.Printf("Shutting down Maxx server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error during server shutdown: %v", err)
 	}
+	// Export the spans still queued and stop the exporter
+	tracing.Shutdown(shutdownCtx)
 }
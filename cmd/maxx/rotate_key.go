@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/awsl-project/maxx/internal/repository/sqlite"
+	"github.com/awsl-project/maxx/internal/secret"
+)
+
+// envNewMasterKey optionally supplies the key to rotate to (otherwise one is generated)
+const envNewMasterKey = "MAXX_NEW_MASTER_KEY"
+
+// rotateMasterKey rewraps every provider secret with a new master key
+// Usage: maxx [-data dir] rotate-key (stop the server first)
+func rotateMasterKey(db *sqlite.DB, dataDir string) error {
+	current, source, err := secret.LoadKeyring(dataDir)
+	if err != nil {
+		return fmt.Errorf("load current master key: %w", err)
+	}
+	db.SetKeyring(current)
+
+	var newKey []byte
+	if env := strings.TrimSpace(os.Getenv(envNewMasterKey)); env != "" {
+		if newKey, err = secret.DecodeKey(env); err != nil {
+			return fmt.Errorf("%s: %w", envNewMasterKey, err)
+		}
+	} else if newKey, err = secret.GenerateKey(); err != nil {
+		return err
+	}
+	next, err := secret.NewKeyring(newKey)
+	if err != nil {
+		return err
+	}
+	if next.ID() == current.ID() {
+		return fmt.Errorf("new master key is the same as the current one")
+	}
+
+	if source == secret.KeySourceEnv {
+		// Print before touching the database so the key can't be lost
+		fmt.Printf("New master key (set %s to this value before restarting):\n%s\n", secret.EnvMasterKey, secret.EncodeKey(newKey))
+		count, err := db.RotateProviderSecrets(next)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rotated secrets of %d providers: %s -> %s", count, current.ID(), next.ID())
+		return nil
+	}
+
+	// Stage the new key file first; only replace the old one after the database commit
+	path := secret.KeyFilePath(dataDir)
+	staged := path + ".new"
+	if err := secret.WriteKeyFile(staged, newKey); err != nil {
+		return err
+	}
+	count, err := db.RotateProviderSecrets(next)
+	if err != nil {
+		os.Remove(staged)
+		return err
+	}
+	if err := os.Rename(staged, path); err != nil {
+		return fmt.Errorf("secrets were rotated but %s could not be replaced; move %s into place manually: %w", path, staged, err)
+	}
+	log.Printf("Rotated secrets of %d providers: %s -> %s", count, current.ID(), next.ID())
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository/sqlite"
+	"github.com/awsl-project/maxx/internal/secret"
+)
+
+func openTestDB(t *testing.T, dir string, kr *secret.Keyring) (*sqlite.DB, *sqlite.ProviderRepository) {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(dir, "maxx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetKeyring(kr)
+	return db, sqlite.NewProviderRepository(db)
+}
+
+func TestRotateMasterKey(t *testing.T) {
+	t.Setenv(secret.EnvMasterKey, "")
+	t.Setenv(envNewMasterKey, "")
+	dir := t.TempDir()
+
+	current, _, err := secret.LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, repo := openTestDB(t, dir, current)
+	active := &domain.Provider{Type: "custom", Name: "active", Config: &domain.ProviderConfig{
+		Custom: &domain.ProviderConfigCustom{BaseURL: "https://api.example.com", APIKey: "sk-active"},
+	}}
+	deleted := &domain.Provider{Type: "antigravity", Name: "deleted", Config: &domain.ProviderConfig{
+		Antigravity: &domain.ProviderConfigAntigravity{RefreshToken: "refresh-deleted"},
+	}}
+	for _, p := range []*domain.Provider{active, deleted} {
+		if err := repo.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.Delete(deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate-key opens the database without a keyring, like main does
+	db, _ := openTestDB(t, dir, nil)
+	if err := rotateMasterKey(db, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	next, _, err := secret.LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ID() == current.ID() {
+		t.Fatal("key file still holds the old master key")
+	}
+	if _, err := os.Stat(secret.KeyFilePath(dir) + ".new"); !os.IsNotExist(err) {
+		t.Error("staged key file was left behind")
+	}
+
+	// Every secret, including soft-deleted providers', now opens with the new key only
+	_, repo = openTestDB(t, dir, next)
+	got, err := repo.GetByID(active.ID)
+	if err != nil || got.Config.Custom.APIKey != "sk-active" {
+		t.Errorf("active provider with the new key: %v", err)
+	}
+	got, err = repo.GetByID(deleted.ID)
+	if err != nil || got.Config.Antigravity.RefreshToken != "refresh-deleted" {
+		t.Errorf("deleted provider with the new key: %v", err)
+	}
+
+	_, repo = openTestDB(t, dir, current)
+	if _, err := repo.GetByID(active.ID); !errors.Is(err, secret.ErrWrongKey) {
+		t.Errorf("old key: got %v, want ErrWrongKey", err)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: nil, want: ""},
+		{args: []string{"rotate-key"}, want: commandRotateKey},
+		{args: []string{"rotate-keys"}, wantErr: true},
+		{args: []string{"rotatekey"}, wantErr: true},
+		{args: []string{"rotate-key", "extra"}, wantErr: true},
+		// Flags after the command are not parsed by the flag package
+		{args: []string{"rotate-key", "-data", "/tmp"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCommand(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCommand(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseCommand(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -19,6 +19,14 @@ type ProviderAdapter interface {
 	Execute(ctx context.Context, w http.ResponseWriter, req *http.Request, provider *domain.Provider) error
 }
 
+// TokenCounter is implemented by adapters that can count tokens upstream
+// (Claude's /v1/messages/count_tokens). Other adapters get a local estimate.
+type TokenCounter interface {
+	// CountTokens returns the input tokens of the Claude request body in ctx
+	// Returns domain.ErrUnsupportedFormat when the provider can't count natively
+	CountTokens(ctx context.Context, provider *domain.Provider) (int, error)
+}
+
 // AdapterFactory creates ProviderAdapter instances
 type AdapterFactory func(provider *domain.Provider) (ProviderAdapter, error)
 
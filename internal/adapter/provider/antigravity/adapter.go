@@ -17,6 +17,8 @@ import (
 	"github.com/awsl-project/maxx/internal/adapter/provider"
 	ctxutil "github.com/awsl-project/maxx/internal/context"
 	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/pricing"
+	"github.com/awsl-project/maxx/internal/tracing"
 	"github.com/awsl-project/maxx/internal/usage"
 )
 
@@ -49,11 +51,24 @@ func NewAdapter(p *domain.Provider) (provider.ProviderAdapter, error) {
 }
 
 func (a *AntigravityAdapter) SupportedClientTypes() []domain.ClientType {
-	// Antigravity natively supports Claude, OpenAI, and Gemini by converting to Gemini/v1internal API
-	return []domain.ClientType{domain.ClientTypeClaude, domain.ClientTypeOpenAI, domain.ClientTypeGemini}
+	// Antigravity natively supports Claude, OpenAI, Codex, and Gemini by converting to Gemini/v1internal API
+	return []domain.ClientType{domain.ClientTypeClaude, domain.ClientTypeOpenAI, domain.ClientTypeCodex, domain.ClientTypeGemini}
 }
 
-func (a *AntigravityAdapter) Execute(ctx context.Context, w http.ResponseWriter, req *http.Request, provider *domain.Provider) error {
+// upstreamTimeouts mirror Antigravity-Manager's connect_timeout=20s and timeout=600s;
+// RetryConfig timeouts take precedence
+var upstreamTimeouts = provider.Timeouts{
+	Connect: 20 * time.Second,
+	Total:   600 * time.Second,
+}
+
+func (a *AntigravityAdapter) Execute(ctx context.Context, w http.ResponseWriter, req *http.Request, p *domain.Provider) error {
+	ctx, timer := provider.WithUpstreamTimeouts(ctx, upstreamTimeouts)
+	defer timer.Stop()
+	return timer.Wrap(a.execute(ctx, w, req, p))
+}
+
+func (a *AntigravityAdapter) execute(ctx context.Context, w http.ResponseWriter, req *http.Request, provider *domain.Provider) error {
 	clientType := ctxutil.GetClientType(ctx)
 	baseCtx := ctx
 	requestModel := ctxutil.GetRequestModel(ctx) // Original model from request (e.g., "claude-3-5-sonnet-20241022-online")
@@ -100,8 +115,8 @@ func (a *AntigravityAdapter) Execute(ctx context.Context, w http.ResponseWriter,
 		stream := ctxutil.GetIsStream(ctx)
 		clientWantsStream := stream
 		actualStream := stream
-		if clientType == domain.ClientTypeClaude && !clientWantsStream {
-			// Auto-convert Claude non-stream to stream internally for better quota (like Manager)
+		if clientType != domain.ClientTypeGemini && !clientWantsStream {
+			// Auto-convert Claude/OpenAI/Codex non-stream to stream internally for better quota (like Manager)
 			actualStream = true
 		}
 
@@ -124,25 +139,47 @@ func (a *AntigravityAdapter) Execute(ctx context.Context, w http.ResponseWriter,
 				effectiveMappedModel string
 				hasThinking          bool
 			)
+			_, span := tracing.StartConversion(ctx, "antigravity.transform_request", string(clientType), string(domain.ClientTypeGemini))
 			geminiBody, effectiveMappedModel, hasThinking, err = TransformClaudeToGemini(requestBody, mappedModel, actualStream, sessionID, GlobalSignatureCache())
+			span.SetAttr("maxx.mapped_model", effectiveMappedModel)
+			span.SetAttr("maxx.thinking", hasThinking)
+			span.SetError(err)
+			span.End()
 			if err != nil {
 				return domain.NewProxyErrorWithMessage(err, true, fmt.Sprintf("failed to transform Claude request: %v", err))
 			}
 			mappedModel = effectiveMappedModel
 
 			// Apply minimal post-processing for features not yet fully integrated
+			_, span = tracing.Start(ctx, "antigravity.claude_request_postprocess", tracing.KindInternal)
 			geminiBody = applyClaudePostProcess(geminiBody, sessionID, hasThinking, requestBody, mappedModel)
-		} else if clientType == domain.ClientTypeOpenAI {
-			// TODO: Implement OpenAI transformation in the future
-			return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, true, "OpenAI transformation not yet implemented")
+			span.End()
+		} else if clientType == domain.ClientTypeOpenAI || clientType == domain.ClientTypeCodex {
+			var hasThinking bool
+			_, span := tracing.StartConversion(ctx, "antigravity.transform_request", string(clientType), string(domain.ClientTypeGemini))
+			if clientType == domain.ClientTypeCodex {
+				geminiBody, hasThinking, err = TransformCodexToGemini(requestBody, mappedModel, sessionID, GlobalSignatureCache())
+			} else {
+				geminiBody, hasThinking, err = TransformOpenAIToGemini(requestBody, mappedModel, sessionID, GlobalSignatureCache())
+			}
+			span.SetAttr("maxx.mapped_model", mappedModel)
+			span.SetAttr("maxx.thinking", hasThinking)
+			span.SetError(err)
+			span.End()
+			if err != nil {
+				return domain.NewProxyErrorWithMessage(err, true, fmt.Sprintf("failed to transform %s request: %v", clientType, err))
+			}
 		} else {
 			// For Gemini, unwrap CLI envelope if present
 			geminiBody = unwrapGeminiCLIEnvelope(requestBody)
 		}
 
+		// Response handlers key the session signature store by the effective mapped model
+		ctx = ctxutil.WithMappedModel(ctx, mappedModel)
+
 		// Wrap request in v1internal format
 		var toolsForConfig []interface{}
-		if clientType == domain.ClientTypeClaude {
+		if clientType != domain.ClientTypeGemini {
 			var raw map[string]interface{}
 			if err := json.Unmarshal(requestBody, &raw); err == nil {
 				if tools, ok := raw["tools"].([]interface{}); ok {
@@ -309,7 +346,14 @@ func (a *AntigravityAdapter) Execute(ctx context.Context, w http.ResponseWriter,
 					case <-time.After(200 * time.Millisecond):
 					}
 
-					requestBody = stripThinkingFromClaude(requestBody)
+					switch clientType {
+					case domain.ClientTypeOpenAI:
+						requestBody = stripThinkingFromOpenAI(requestBody)
+					case domain.ClientTypeCodex:
+						requestBody = stripThinkingFromCodex(requestBody)
+					default:
+						requestBody = stripThinkingFromClaude(requestBody)
+					}
 					if newModel := extractModelFromBody(requestBody); newModel != "" {
 						requestModel = newModel
 					}
@@ -414,9 +458,9 @@ func refreshGoogleToken(ctx context.Context, refreshToken string) (string, int,
 
 func newUpstreamHTTPClient() *http.Client {
 	// Mirrors Antigravity-Manager's reqwest client settings:
-	// connect_timeout=20s, pool_max_idle_per_host=16, pool_idle_timeout=90s, tcp_keepalive=60s, timeout=600s.
+	// pool_max_idle_per_host=16, pool_idle_timeout=90s, tcp_keepalive=60s.
+	// Connect and total timeouts are applied per attempt (see upstreamTimeouts).
 	dialer := &net.Dialer{
-		Timeout:   20 * time.Second,
 		KeepAlive: 60 * time.Second,
 	}
 
@@ -431,8 +475,7 @@ func newUpstreamHTTPClient() *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
-		Timeout:   600 * time.Second,
+		Transport: tracing.NewTransport(provider.NewTimeoutTransport(transport)),
 	}
 }
 
@@ -511,6 +554,20 @@ func isThinkingSignatureError(body []byte) bool {
 		strings.Contains(bodyStr, "failed to deserialise")
 }
 
+// recordUsage stores token usage on the attempt and prices it against the mapped model
+// (the Gemini or Claude model actually served; thinking tokens are counted as output)
+// with the provider's pricing override applied
+func (a *AntigravityAdapter) recordUsage(ctx context.Context, attempt *domain.ProxyUpstreamAttempt, metrics *usage.Metrics) {
+	attempt.InputTokenCount = metrics.InputTokens
+	attempt.OutputTokenCount = metrics.OutputTokens
+	attempt.CacheReadCount = metrics.CacheReadCount
+	attempt.CacheWriteCount = metrics.CacheCreationCount
+	attempt.Cache5mWriteCount = metrics.Cache5mCreationCount
+	attempt.Cache1hWriteCount = metrics.Cache1hCreationCount
+	attempt.MappedModel = ctxutil.GetMappedModel(ctx)
+	attempt.Cost = pricing.GlobalCalculator().Calculate(attempt.MappedModel, metrics, a.provider.Pricing)
+}
+
 func (a *AntigravityAdapter) handleNonStreamResponse(ctx context.Context, w http.ResponseWriter, resp *http.Response, clientType domain.ClientType) error {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -530,12 +587,7 @@ func (a *AntigravityAdapter) handleNonStreamResponse(ctx context.Context, w http
 
 		// Extract token usage from unwrapped response
 		if metrics := usage.ExtractFromResponse(string(unwrappedBody)); metrics != nil {
-			attempt.InputTokenCount = metrics.InputTokens
-			attempt.OutputTokenCount = metrics.OutputTokens
-			attempt.CacheReadCount = metrics.CacheReadCount
-			attempt.CacheWriteCount = metrics.CacheCreationCount
-			attempt.Cache5mWriteCount = metrics.Cache5mCreationCount
-			attempt.Cache1hWriteCount = metrics.Cache1hCreationCount
+			a.recordUsage(ctx, attempt, metrics)
 		}
 
 		// Broadcast attempt update with token info
@@ -549,13 +601,28 @@ func (a *AntigravityAdapter) handleNonStreamResponse(ctx context.Context, w http
 	// Transform response based on client type
 	if clientType == domain.ClientTypeClaude {
 		requestModel := ctxutil.GetRequestModel(ctx)
+		_, span := tracing.StartConversion(ctx, "antigravity.transform_response", string(domain.ClientTypeGemini), string(clientType))
 		responseBody, err = convertGeminiToClaudeResponse(unwrappedBody, requestModel)
+		span.SetError(err)
+		span.End()
+		if err != nil {
+			return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "failed to transform response")
+		}
+	} else if clientType == domain.ClientTypeOpenAI || clientType == domain.ClientTypeCodex {
+		requestModel := ctxutil.GetRequestModel(ctx)
+		mappedModel := ctxutil.GetMappedModel(ctx)
+		sessionID := extractSessionID(ctxutil.GetRequestBody(ctx))
+		_, span := tracing.StartConversion(ctx, "antigravity.transform_response", string(domain.ClientTypeGemini), string(clientType))
+		if clientType == domain.ClientTypeCodex {
+			responseBody, err = convertGeminiToCodexResponse(unwrappedBody, requestModel, sessionID, mappedModel)
+		} else {
+			responseBody, err = convertGeminiToOpenAIResponse(unwrappedBody, requestModel, sessionID, mappedModel)
+		}
+		span.SetError(err)
+		span.End()
 		if err != nil {
 			return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "failed to transform response")
 		}
-	} else if clientType == domain.ClientTypeOpenAI {
-		// TODO: Implement OpenAI response transformation
-		return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "OpenAI response transformation not yet implemented")
 	} else {
 		// Gemini native
 		responseBody = unwrappedBody
@@ -604,10 +671,43 @@ func (a *AntigravityAdapter) handleStreamResponse(ctx context.Context, w http.Re
 
 	// Get original request model for Claude response (like Antigravity-Manager)
 	requestModel := ctxutil.GetRequestModel(ctx)
+	mappedModel := ctxutil.GetMappedModel(ctx)
 
 	var claudeState *ClaudeStreamingState
-	if isClaudeClient {
-		claudeState = NewClaudeStreamingStateWithSession(sessionID, requestModel)
+	var openaiState *OpenAIStreamingState
+	var codexState *CodexStreamingState
+	var convertedLines int
+	switch clientType {
+	case domain.ClientTypeClaude:
+		claudeState = NewClaudeStreamingStateWithSession(sessionID, requestModel, mappedModel)
+	case domain.ClientTypeOpenAI:
+		openaiState = NewOpenAIStreamingState(requestModel, sessionID, mappedModel)
+	case domain.ClientTypeCodex:
+		codexState = NewCodexStreamingState(requestModel, sessionID, mappedModel)
+	}
+	if clientType != domain.ClientTypeGemini {
+		// One span for the whole streaming conversion
+		_, span := tracing.StartConversion(ctx, "antigravity.transform_stream", string(domain.ClientTypeGemini), string(clientType))
+		defer func() {
+			span.SetAttr("maxx.lines", convertedLines)
+			span.End()
+		}()
+	}
+
+	// emitForceStop sends termination events for converted streams
+	emitForceStop := func() {
+		var forceStop []byte
+		if claudeState != nil {
+			forceStop = claudeState.EmitForceStop()
+		} else if openaiState != nil {
+			forceStop = openaiState.EmitForceStop()
+		} else if codexState != nil {
+			forceStop = codexState.EmitForceStop()
+		}
+		if len(forceStop) > 0 {
+			_, _ = w.Write(forceStop)
+			flusher.Flush()
+		}
 	}
 
 	// Collect all SSE events for response body and token extraction
@@ -622,12 +722,7 @@ func (a *AntigravityAdapter) handleStreamResponse(ctx context.Context, w http.Re
 			}
 			// Extract token usage
 			if metrics := usage.ExtractFromStreamContent(sseBuffer.String()); metrics != nil {
-				attempt.InputTokenCount = metrics.InputTokens
-				attempt.OutputTokenCount = metrics.OutputTokens
-				attempt.CacheReadCount = metrics.CacheReadCount
-				attempt.CacheWriteCount = metrics.CacheCreationCount
-				attempt.Cache5mWriteCount = metrics.Cache5mCreationCount
-				attempt.Cache1hWriteCount = metrics.Cache1hCreationCount
+				a.recordUsage(ctx, attempt, metrics)
 			}
 			// Broadcast attempt update with token info
 			if bc := ctxutil.GetBroadcaster(ctx); bc != nil {
@@ -676,9 +771,13 @@ func (a *AntigravityAdapter) handleStreamResponse(ctx context.Context, w http.Re
 				if isClaudeClient {
 					// Use specialized Claude SSE transformation
 					output = claudeState.ProcessGeminiSSELine(string(unwrappedLine))
-				} else if clientType == domain.ClientTypeOpenAI {
-					// TODO: Implement OpenAI streaming transformation
-					continue
+					convertedLines++
+				} else if openaiState != nil {
+					output = openaiState.ProcessGeminiSSELine(string(unwrappedLine))
+					convertedLines++
+				} else if codexState != nil {
+					output = codexState.ProcessGeminiSSELine(string(unwrappedLine))
+					convertedLines++
 				} else {
 					// Gemini native
 					output = unwrappedLine
@@ -698,35 +797,20 @@ func (a *AntigravityAdapter) handleStreamResponse(ctx context.Context, w http.Re
 
 		if err != nil {
 			if err == io.EOF {
-				// Ensure Claude clients get termination events
-				if isClaudeClient && claudeState != nil {
-					if forceStop := claudeState.EmitForceStop(); len(forceStop) > 0 {
-						_, _ = w.Write(forceStop)
-						flusher.Flush()
-					}
-				}
+				// Ensure converted clients get termination events
+				emitForceStop()
 				extractTokens()
 				return nil
 			}
 			// Upstream connection closed - check if client is still connected
 			if ctx.Err() != nil {
-				// Try to send termination events for Claude clients
-				if isClaudeClient && claudeState != nil {
-					if forceStop := claudeState.EmitForceStop(); len(forceStop) > 0 {
-						_, _ = w.Write(forceStop)
-						flusher.Flush()
-					}
-				}
+				// Try to send termination events for converted clients
+				emitForceStop()
 				extractTokens()
 				return domain.NewProxyErrorWithMessage(ctx.Err(), false, "client disconnected")
 			}
-			// Ensure Claude clients get termination events
-			if isClaudeClient && claudeState != nil {
-				if forceStop := claudeState.EmitForceStop(); len(forceStop) > 0 {
-					_, _ = w.Write(forceStop)
-					flusher.Flush()
-				}
-			}
+			// Ensure converted clients get termination events
+			emitForceStop()
 			extractTokens()
 			return nil
 		}
@@ -748,19 +832,25 @@ func (a *AntigravityAdapter) handleCollectedStreamResponse(ctx context.Context,
 	// Copy upstream headers (except those we override)
 	copyResponseHeaders(w.Header(), resp.Header)
 
+	// Extract sessionID for signature caching (like CLIProxyAPI)
+	sessionID := extractSessionID(ctxutil.GetRequestBody(ctx))
+	mappedModel := ctxutil.GetMappedModel(ctx)
+
 	isClaudeClient := clientType == domain.ClientTypeClaude
 	var claudeState *ClaudeStreamingState
 	var claudeSSE strings.Builder
 	if isClaudeClient {
-		// Extract sessionID for signature caching (like CLIProxyAPI)
-		requestBody := ctxutil.GetRequestBody(ctx)
-		sessionID := extractSessionID(requestBody)
-		claudeState = NewClaudeStreamingStateWithSession(sessionID, requestModel)
+		claudeState = NewClaudeStreamingStateWithSession(sessionID, requestModel, mappedModel)
+
+		// Covers converting the stream and collecting it into a single response
+		_, span := tracing.StartConversion(ctx, "antigravity.collect_stream", string(domain.ClientTypeGemini), string(clientType))
+		defer span.End()
 	}
 
 	// Collect upstream SSE for attempt/debug, and (for Claude) collect converted Claude SSE for JSON reconstruction.
 	var upstreamSSE strings.Builder
 	var lastPayload []byte
+	var payloads [][]byte // All Gemini payloads, merged into one response for OpenAI/Codex clients
 	var responseBody []byte
 
 	var lineBuffer bytes.Buffer
@@ -798,6 +888,9 @@ func (a *AntigravityAdapter) handleCollectedStreamResponse(ctx context.Context,
 					dataStr := strings.TrimSpace(strings.TrimPrefix(lineStr, "data: "))
 					if dataStr != "" && dataStr != "[DONE]" {
 						lastPayload = []byte(dataStr)
+						if clientType == domain.ClientTypeOpenAI || clientType == domain.ClientTypeCodex {
+							payloads = append(payloads, lastPayload)
+						}
 					}
 				}
 
@@ -839,12 +932,7 @@ func (a *AntigravityAdapter) handleCollectedStreamResponse(ctx context.Context,
 			metricsSource = claudeSSE.String()
 		}
 		if metrics := usage.ExtractFromStreamContent(metricsSource); metrics != nil {
-			attempt.InputTokenCount = metrics.InputTokens
-			attempt.OutputTokenCount = metrics.OutputTokens
-			attempt.CacheReadCount = metrics.CacheReadCount
-			attempt.CacheWriteCount = metrics.CacheCreationCount
-			attempt.Cache5mWriteCount = metrics.Cache5mCreationCount
-			attempt.Cache1hWriteCount = metrics.Cache1hCreationCount
+			a.recordUsage(ctx, attempt, metrics)
 		}
 		if bc := ctxutil.GetBroadcaster(ctx); bc != nil {
 			bc.BroadcastProxyUpstreamAttempt(attempt)
@@ -867,8 +955,21 @@ func (a *AntigravityAdapter) handleCollectedStreamResponse(ctx context.Context,
 		switch clientType {
 		case domain.ClientTypeGemini:
 			responseBody = lastPayload
-		case domain.ClientTypeOpenAI:
-			return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "OpenAI response transformation not yet implemented")
+		case domain.ClientTypeOpenAI, domain.ClientTypeCodex:
+			_, span := tracing.StartConversion(ctx, "antigravity.collect_stream", string(domain.ClientTypeGemini), string(clientType))
+			merged, mergeErr := mergeGeminiStreamPayloads(payloads)
+			if mergeErr == nil {
+				if clientType == domain.ClientTypeCodex {
+					responseBody, mergeErr = convertGeminiToCodexResponse(merged, requestModel, sessionID, mappedModel)
+				} else {
+					responseBody, mergeErr = convertGeminiToOpenAIResponse(merged, requestModel, sessionID, mappedModel)
+				}
+			}
+			span.SetError(mergeErr)
+			span.End()
+			if mergeErr != nil {
+				return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "failed to collect streamed response")
+			}
 		default:
 			responseBody = lastPayload
 		}
@@ -39,6 +39,10 @@ type ClaudeStreamingState struct {
 	modelVersion string // Gemini model version from upstream (for debugging)
 	responseID   string
 
+	// Session fallback signature store key
+	sessionID   string
+	mappedModel string
+
 	// Grounding (web search) captured during streaming, emitted at finish (like Antigravity-Manager)
 	webSearchQuery  string
 	groundingChunks []GeminiGroundingChunk
@@ -52,12 +56,15 @@ func NewClaudeStreamingState() *ClaudeStreamingState {
 	}
 }
 
-// NewClaudeStreamingStateWithSession creates a new streaming state with session ID and request model
-func NewClaudeStreamingStateWithSession(_ string, requestModel string) *ClaudeStreamingState {
+// NewClaudeStreamingStateWithSession creates a new streaming state with session ID, request model
+// and the mapped upstream model (used to key the session fallback signature store)
+func NewClaudeStreamingStateWithSession(sessionID, requestModel, mappedModel string) *ClaudeStreamingState {
 	return &ClaudeStreamingState{
 		blockType:    BlockTypeNone,
 		blockIndex:   0,
 		requestModel: requestModel,
+		sessionID:    sessionID,
+		mappedModel:  mappedModel,
 	}
 }
 
@@ -88,6 +95,7 @@ type GeminiUsageMetadata struct {
 	PromptTokenCount        int `json:"promptTokenCount"`
 	CandidatesTokenCount    int `json:"candidatesTokenCount"`
 	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
 }
 
 // GeminiStreamChunk represents a streaming chunk from Gemini
@@ -191,7 +199,7 @@ func (s *ClaudeStreamingState) emitMessageStart(chunk *GeminiStreamChunk) []byte
 
 		usage := map[string]interface{}{
 			"input_tokens":                inputTokens,
-			"output_tokens":               chunk.UsageMetadata.CandidatesTokenCount,
+			"output_tokens":               chunk.UsageMetadata.CandidatesTokenCount + chunk.UsageMetadata.ThoughtsTokenCount,
 			"cache_creation_input_tokens": 0, // Gemini doesn't provide this, set to 0
 		}
 		if cachedTokens > 0 {
@@ -326,7 +334,7 @@ func (s *ClaudeStreamingState) emitFinish(finishReason string, usage *GeminiUsag
 			inputTokens = 0
 		}
 		usageMap["input_tokens"] = inputTokens
-		usageMap["output_tokens"] = usage.CandidatesTokenCount
+		usageMap["output_tokens"] = usage.CandidatesTokenCount + usage.ThoughtsTokenCount
 		if cachedTokens > 0 {
 			usageMap["cache_read_input_tokens"] = cachedTokens
 		}
@@ -361,8 +369,8 @@ func (s *ClaudeStreamingState) storeSignature(signature string) {
 			GlobalSignatureCache().CacheThinkingFamily(signature, s.modelVersion)
 		}
 
-		// Best-effort global fallback store
-		StoreThoughtSignature(signature)
+		// Best-effort session fallback store
+		StoreThoughtSignature(s.sessionID, s.mappedModel, signature)
 	}
 }
 
@@ -612,7 +620,7 @@ func (s *ClaudeStreamingState) ProcessGeminiSSELine(line string) []byte {
 			inputTokens = 0
 		}
 		s.inputTokens = inputTokens
-		s.outputTokens = chunk.UsageMetadata.CandidatesTokenCount
+		s.outputTokens = chunk.UsageMetadata.CandidatesTokenCount + chunk.UsageMetadata.ThoughtsTokenCount
 		s.cacheReadTokens = cachedTokens
 	}
 
@@ -0,0 +1,434 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/converter"
+)
+
+// convertGeminiToCodexResponse converts a non-streaming Gemini response to a Responses API response
+func convertGeminiToCodexResponse(geminiBody []byte, requestModel, sessionID, mappedModel string) ([]byte, error) {
+	var chunk GeminiStreamChunk
+	if err := json.Unmarshal(geminiBody, &chunk); err != nil {
+		return nil, err
+	}
+
+	// Run the response through the streaming state and keep only the final response object
+	state := NewCodexStreamingState(requestModel, sessionID, mappedModel)
+	state.processChunk(&chunk)
+	state.EmitForceStop()
+	return json.Marshal(state.buildResponse())
+}
+
+// ===== Streaming =====
+
+// CodexStreamingState maintains state for Gemini -> Responses API SSE conversion.
+// Output items (reasoning, message, function_call) are opened and closed as the
+// Gemini part type changes, and accumulated for the final response.completed event.
+type CodexStreamingState struct {
+	responseID   string
+	createdAt    int64
+	requestModel string
+	modelVersion string
+	sessionID    string
+	mappedModel  string
+	sequence     int
+
+	started   bool
+	completed bool
+
+	// Currently open output item (reasoning or message) and its text
+	current     *converter.CodexOutput
+	currentText strings.Builder
+
+	output       []converter.CodexOutput
+	hasToolCalls bool
+	finishReason string
+	usage        *GeminiUsageMetadata
+
+	// Grounding (web search) captured during streaming, emitted at finish
+	grounding *GeminiGroundingMetadata
+}
+
+// NewCodexStreamingState creates a new streaming state
+func NewCodexStreamingState(requestModel, sessionID, mappedModel string) *CodexStreamingState {
+	return &CodexStreamingState{
+		createdAt:    time.Now().Unix(),
+		requestModel: requestModel,
+		sessionID:    sessionID,
+		mappedModel:  mappedModel,
+	}
+}
+
+// ProcessGeminiSSELine processes a single Gemini SSE line and returns Responses API SSE events
+func (s *CodexStreamingState) ProcessGeminiSSELine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data: ") {
+		return nil
+	}
+
+	dataStr := strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+	if dataStr == "" {
+		return nil
+	}
+	if dataStr == "[DONE]" {
+		return s.EmitForceStop()
+	}
+	if s.completed {
+		return nil
+	}
+
+	var chunk GeminiStreamChunk
+	if err := json.Unmarshal([]byte(dataStr), &chunk); err != nil {
+		return s.handleParseError(dataStr)
+	}
+	return s.processChunk(&chunk)
+}
+
+// EmitForceStop closes open items and sends the final response event
+// Called when stream ends (EOF or [DONE])
+func (s *CodexStreamingState) EmitForceStop() []byte {
+	if s.completed {
+		return nil
+	}
+	return s.emitFinish()
+}
+
+func (s *CodexStreamingState) processChunk(chunk *GeminiStreamChunk) []byte {
+	if s.responseID == "" {
+		s.responseID = codexResponseID(chunk.ResponseID)
+	}
+	if chunk.ModelVersion != "" {
+		s.modelVersion = chunk.ModelVersion
+	}
+	if chunk.UsageMetadata != nil {
+		s.usage = chunk.UsageMetadata
+	}
+
+	output := s.emitStart()
+	if len(chunk.Candidates) == 0 {
+		return output
+	}
+
+	candidate := chunk.Candidates[0]
+	for i := range candidate.Content.Parts {
+		output = append(output, s.processPart(&candidate.Content.Parts[i])...)
+	}
+
+	if candidate.GroundingMetadata != nil {
+		s.grounding = candidate.GroundingMetadata
+	}
+
+	if candidate.FinishReason != "" {
+		s.finishReason = candidate.FinishReason
+		output = append(output, s.emitFinish()...)
+	}
+
+	return output
+}
+
+// processPart converts a Gemini part to output item events
+func (s *CodexStreamingState) processPart(part *GeminiPart) []byte {
+	if part.FunctionCall != nil {
+		return s.emitFunctionCall(part.FunctionCall, part.ThoughtSignature)
+	}
+
+	storeThoughtSignatureFallback(s.sessionID, s.mappedModel, part.ThoughtSignature, s.modelVersion)
+
+	if part.Text != "" {
+		if part.Thought {
+			return s.appendText("reasoning", part.Text)
+		}
+		return s.appendText("message", part.Text)
+	}
+
+	if part.InlineData != nil && part.InlineData.Data != "" {
+		return s.appendText("message", fmt.Sprintf("![image](data:%s;base64,%s)", part.InlineData.MimeType, part.InlineData.Data))
+	}
+
+	return nil
+}
+
+// emit formats a Responses API event; every event carries its type and a sequence number
+func (s *CodexStreamingState) emit(eventType string, data map[string]interface{}) []byte {
+	data["type"] = eventType
+	data["sequence_number"] = s.sequence
+	s.sequence++
+	return formatSSE(eventType, data)
+}
+
+// emitStart emits response.created and response.in_progress once
+func (s *CodexStreamingState) emitStart() []byte {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	if s.responseID == "" {
+		s.responseID = codexResponseID("")
+	}
+
+	response := s.buildResponse()
+	response.Status = "in_progress"
+	response.Output = []converter.CodexOutput{}
+	output := s.emit("response.created", map[string]interface{}{"response": response})
+	return append(output, s.emit("response.in_progress", map[string]interface{}{"response": response})...)
+}
+
+// appendText streams text into a reasoning or message item, opening it if needed
+func (s *CodexStreamingState) appendText(itemType, text string) []byte {
+	var output []byte
+	if s.current == nil || s.current.Type != itemType {
+		output = append(output, s.closeItem()...)
+		output = append(output, s.openItem(itemType)...)
+	}
+	s.currentText.WriteString(text)
+
+	outputIndex := len(s.output)
+	if itemType == "reasoning" {
+		return append(output, s.emit("response.reasoning_summary_text.delta", map[string]interface{}{
+			"item_id":       s.current.ID,
+			"output_index":  outputIndex,
+			"summary_index": 0,
+			"delta":         text,
+		})...)
+	}
+	return append(output, s.emit("response.output_text.delta", map[string]interface{}{
+		"item_id":       s.current.ID,
+		"output_index":  outputIndex,
+		"content_index": 0,
+		"delta":         text,
+	})...)
+}
+
+// openItem starts a reasoning or message output item
+func (s *CodexStreamingState) openItem(itemType string) []byte {
+	outputIndex := len(s.output)
+	s.currentText.Reset()
+
+	if itemType == "reasoning" {
+		s.current = &converter.CodexOutput{
+			Type:    "reasoning",
+			ID:      fmt.Sprintf("rs_%d", generateRandomID()),
+			Summary: []interface{}{},
+		}
+		output := s.emit("response.output_item.added", map[string]interface{}{
+			"output_index": outputIndex,
+			"item":         s.current,
+		})
+		return append(output, s.emit("response.reasoning_summary_part.added", map[string]interface{}{
+			"item_id":       s.current.ID,
+			"output_index":  outputIndex,
+			"summary_index": 0,
+			"part":          map[string]interface{}{"type": "summary_text", "text": ""},
+		})...)
+	}
+
+	s.current = &converter.CodexOutput{
+		Type:    "message",
+		ID:      fmt.Sprintf("msg_%d", generateRandomID()),
+		Role:    "assistant",
+		Status:  "in_progress",
+		Content: []interface{}{},
+	}
+	output := s.emit("response.output_item.added", map[string]interface{}{
+		"output_index": outputIndex,
+		"item":         s.current,
+	})
+	return append(output, s.emit("response.content_part.added", map[string]interface{}{
+		"item_id":       s.current.ID,
+		"output_index":  outputIndex,
+		"content_index": 0,
+		"part":          codexOutputText(""),
+	})...)
+}
+
+// closeItem finishes the open reasoning or message item
+func (s *CodexStreamingState) closeItem() []byte {
+	if s.current == nil {
+		return nil
+	}
+	item := s.current
+	text := s.currentText.String()
+	outputIndex := len(s.output)
+	s.current = nil
+
+	var output []byte
+	if item.Type == "reasoning" {
+		part := map[string]interface{}{"type": "summary_text", "text": text}
+		item.Summary = []interface{}{part}
+		output = append(output, s.emit("response.reasoning_summary_text.done", map[string]interface{}{
+			"item_id":       item.ID,
+			"output_index":  outputIndex,
+			"summary_index": 0,
+			"text":          text,
+		})...)
+		output = append(output, s.emit("response.reasoning_summary_part.done", map[string]interface{}{
+			"item_id":       item.ID,
+			"output_index":  outputIndex,
+			"summary_index": 0,
+			"part":          part,
+		})...)
+	} else {
+		part := codexOutputText(text)
+		item.Content = []interface{}{part}
+		item.Status = "completed"
+		output = append(output, s.emit("response.output_text.done", map[string]interface{}{
+			"item_id":       item.ID,
+			"output_index":  outputIndex,
+			"content_index": 0,
+			"text":          text,
+		})...)
+		output = append(output, s.emit("response.content_part.done", map[string]interface{}{
+			"item_id":       item.ID,
+			"output_index":  outputIndex,
+			"content_index": 0,
+			"part":          part,
+		})...)
+	}
+
+	s.output = append(s.output, *item)
+	return append(output, s.emit("response.output_item.done", map[string]interface{}{
+		"output_index": outputIndex,
+		"item":         item,
+	})...)
+}
+
+// emitFunctionCall emits a complete function_call item (Gemini sends arguments in one piece)
+func (s *CodexStreamingState) emitFunctionCall(fc *GeminiFunctionCall, signature string) []byte {
+	output := s.closeItem()
+	s.hasToolCalls = true
+
+	callID, arguments := resolveGeminiFunctionCall(fc, signature)
+	outputIndex := len(s.output)
+	item := converter.CodexOutput{
+		Type:   "function_call",
+		ID:     "fc_" + callID,
+		CallID: callID,
+		Name:   fc.Name,
+		Status: "in_progress",
+	}
+
+	output = append(output, s.emit("response.output_item.added", map[string]interface{}{
+		"output_index": outputIndex,
+		"item":         item,
+	})...)
+	output = append(output, s.emit("response.function_call_arguments.delta", map[string]interface{}{
+		"item_id":      item.ID,
+		"output_index": outputIndex,
+		"delta":        arguments,
+	})...)
+	output = append(output, s.emit("response.function_call_arguments.done", map[string]interface{}{
+		"item_id":      item.ID,
+		"output_index": outputIndex,
+		"arguments":    arguments,
+	})...)
+
+	item.Arguments = arguments
+	item.Status = "completed"
+	s.output = append(s.output, item)
+	return append(output, s.emit("response.output_item.done", map[string]interface{}{
+		"output_index": outputIndex,
+		"item":         item,
+	})...)
+}
+
+// emitFinish closes open items and emits response.completed (or response.incomplete on MAX_TOKENS)
+func (s *CodexStreamingState) emitFinish() []byte {
+	output := s.emitStart()
+
+	if s.grounding != nil {
+		if text := buildGroundingText(s.grounding); text != "" {
+			output = append(output, s.appendText("message", text)...)
+		}
+		s.grounding = nil
+	}
+	output = append(output, s.closeItem()...)
+	s.completed = true
+
+	response := s.buildResponse()
+	eventType := "response.completed"
+	if response.Status == "incomplete" {
+		eventType = "response.incomplete"
+	}
+	return append(output, s.emit(eventType, map[string]interface{}{"response": response})...)
+}
+
+// buildResponse builds the response object from the accumulated output items
+func (s *CodexStreamingState) buildResponse() *converter.CodexResponse {
+	model := s.requestModel
+	if model == "" {
+		model = s.modelVersion
+	}
+
+	status := "completed"
+	if s.finishReason == "MAX_TOKENS" && !s.hasToolCalls {
+		status = "incomplete"
+	}
+
+	output := s.output
+	if output == nil {
+		output = []converter.CodexOutput{}
+	}
+
+	return &converter.CodexResponse{
+		ID:        s.responseID,
+		Object:    "response",
+		CreatedAt: s.createdAt,
+		Model:     model,
+		Output:    output,
+		Status:    status,
+		Usage:     buildCodexUsage(s.usage),
+	}
+}
+
+// buildCodexUsage converts Gemini usage metadata to Responses API usage
+// (input_tokens includes cached tokens, output_tokens includes thinking tokens)
+func buildCodexUsage(meta *GeminiUsageMetadata) converter.CodexUsage {
+	if meta == nil {
+		return converter.CodexUsage{}
+	}
+	outputTokens := meta.CandidatesTokenCount + meta.ThoughtsTokenCount
+	return converter.CodexUsage{
+		InputTokens:         meta.PromptTokenCount,
+		OutputTokens:        outputTokens,
+		TotalTokens:         meta.PromptTokenCount + outputTokens,
+		InputTokensDetails:  &converter.CodexTokenDetails{CachedTokens: meta.CachedContentTokenCount},
+		OutputTokensDetails: &converter.CodexTokenDetails{ReasoningTokens: meta.ThoughtsTokenCount},
+	}
+}
+
+// handleParseError surfaces upstream error payloads as message text instead of dropping them
+func (s *CodexStreamingState) handleParseError(dataStr string) []byte {
+	var errorResp struct {
+		Error struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if !strings.Contains(dataStr, "error") || json.Unmarshal([]byte(dataStr), &errorResp) != nil || errorResp.Error.Message == "" {
+		return nil
+	}
+	errorText := fmt.Sprintf("\n\n[API Error: %s (code: %d, status: %s)]\n",
+		errorResp.Error.Message, errorResp.Error.Code, errorResp.Error.Status)
+	output := s.emitStart()
+	return append(output, s.appendText("message", errorText)...)
+}
+
+func codexOutputText(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "output_text",
+		"text":        text,
+		"annotations": []interface{}{},
+	}
+}
+
+func codexResponseID(responseID string) string {
+	if responseID == "" {
+		return fmt.Sprintf("resp_%d", generateRandomID())
+	}
+	return "resp_" + responseID
+}
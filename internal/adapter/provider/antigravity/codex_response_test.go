@@ -0,0 +1,230 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type codexEvent struct {
+	Type string
+	Data map[string]interface{}
+}
+
+// parseCodexEvents splits Responses API SSE output into events
+func parseCodexEvents(t *testing.T, data []byte) []codexEvent {
+	t.Helper()
+	var events []codexEvent
+	for _, block := range strings.Split(string(data), "\n\n") {
+		if block == "" {
+			continue
+		}
+		eventLine, dataLine, ok := strings.Cut(block, "\n")
+		if !ok || !strings.HasPrefix(eventLine, "event: ") || !strings.HasPrefix(dataLine, "data: ") {
+			t.Fatalf("malformed event %q", block)
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &payload); err != nil {
+			t.Fatalf("invalid event data %q: %v", dataLine, err)
+		}
+		events = append(events, codexEvent{Type: strings.TrimPrefix(eventLine, "event: "), Data: payload})
+	}
+	return events
+}
+
+func codexEventTypes(events []codexEvent) []string {
+	types := make([]string, len(events))
+	for i, event := range events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// codexOutputSummary reduces output items to "type:text" for comparison
+func codexOutputSummary(t *testing.T, response map[string]interface{}) []string {
+	t.Helper()
+	var summary []string
+	for _, raw := range response["output"].([]interface{}) {
+		item := raw.(map[string]interface{})
+		switch item["type"] {
+		case "reasoning":
+			part := item["summary"].([]interface{})[0].(map[string]interface{})
+			summary = append(summary, "reasoning:"+part["text"].(string))
+		case "message":
+			part := item["content"].([]interface{})[0].(map[string]interface{})
+			summary = append(summary, "message:"+part["text"].(string))
+		case "function_call":
+			summary = append(summary, "function_call:"+item["call_id"].(string)+" "+item["name"].(string)+item["arguments"].(string))
+		default:
+			t.Errorf("unexpected output item %v", item)
+		}
+	}
+	return summary
+}
+
+func TestCodexStreamingState(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantEvents []string
+		wantOutput []string
+		wantStatus string
+	}{
+		{
+			name: "reasoning then text deltas",
+			lines: []string{
+				geminiSSELine(`{"responseId":"r1","candidates":[{"content":{"parts":[{"text":"plan","thought":true}]}}]}`),
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"Hel"}]}}]}`),
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2}}`),
+			},
+			wantEvents: []string{
+				"response.created", "response.in_progress",
+				"response.output_item.added", "response.reasoning_summary_part.added", "response.reasoning_summary_text.delta",
+				"response.reasoning_summary_text.done", "response.reasoning_summary_part.done", "response.output_item.done",
+				"response.output_item.added", "response.content_part.added", "response.output_text.delta",
+				"response.output_text.delta",
+				"response.output_text.done", "response.content_part.done", "response.output_item.done",
+				"response.completed",
+			},
+			wantOutput: []string{"reasoning:plan", "message:Hello"},
+			wantStatus: "completed",
+		},
+		{
+			name: "function calls close the open message",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"checking"},{"functionCall":{"name":"get_weather","args":{"city":"Paris"},"id":"call_1"}},{"functionCall":{"name":"b","id":"call_2"}}]},"finishReason":"STOP"}]}`),
+			},
+			wantEvents: []string{
+				"response.created", "response.in_progress",
+				"response.output_item.added", "response.content_part.added", "response.output_text.delta",
+				"response.output_text.done", "response.content_part.done", "response.output_item.done",
+				"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.done", "response.output_item.done",
+				"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.done", "response.output_item.done",
+				"response.completed",
+			},
+			wantOutput: []string{"message:checking", `function_call:call_1 get_weather{"city":"Paris"}`, "function_call:call_2 b{}"},
+			wantStatus: "completed",
+		},
+		{
+			name: "max tokens is incomplete",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"cut"}]},"finishReason":"MAX_TOKENS"}]}`),
+			},
+			wantEvents: []string{
+				"response.created", "response.in_progress",
+				"response.output_item.added", "response.content_part.added", "response.output_text.delta",
+				"response.output_text.done", "response.content_part.done", "response.output_item.done",
+				"response.incomplete",
+			},
+			wantOutput: []string{"message:cut"},
+			wantStatus: "incomplete",
+		},
+		{
+			name: "max tokens after a tool call completes",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"f","id":"call_f"}}]},"finishReason":"MAX_TOKENS"}]}`),
+			},
+			wantEvents: []string{
+				"response.created", "response.in_progress",
+				"response.output_item.added", "response.function_call_arguments.delta", "response.function_call_arguments.done", "response.output_item.done",
+				"response.completed",
+			},
+			wantOutput: []string{"function_call:call_f f{}"},
+			wantStatus: "completed",
+		},
+		{
+			name: "stream ends without a finish reason",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"bye"}]}}]}`),
+				"data: [DONE]",
+			},
+			wantEvents: []string{
+				"response.created", "response.in_progress",
+				"response.output_item.added", "response.content_part.added", "response.output_text.delta",
+				"response.output_text.done", "response.content_part.done", "response.output_item.done",
+				"response.completed",
+			},
+			wantOutput: []string{"message:bye"},
+			wantStatus: "completed",
+		},
+	}
+	for _, tt := range tests {
+		state := NewCodexStreamingState("gpt-5-codex", "", "gemini-2.5-flash")
+		var output []byte
+		for _, line := range tt.lines {
+			output = append(output, state.ProcessGeminiSSELine(line)...)
+		}
+		output = append(output, state.EmitForceStop()...)
+		if extra := state.ProcessGeminiSSELine(geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"late"}]}}]}`)); extra != nil {
+			t.Errorf("%s: output after completion: %s", tt.name, extra)
+		}
+
+		events := parseCodexEvents(t, output)
+		if got := codexEventTypes(events); !reflect.DeepEqual(got, tt.wantEvents) {
+			t.Errorf("%s: events = %v, want %v", tt.name, got, tt.wantEvents)
+			continue
+		}
+		for i, event := range events {
+			if event.Data["type"] != event.Type || event.Data["sequence_number"] != float64(i) {
+				t.Errorf("%s: event %d type/sequence = %v/%v", tt.name, i, event.Data["type"], event.Data["sequence_number"])
+			}
+		}
+
+		final := events[len(events)-1].Data["response"].(map[string]interface{})
+		if final["status"] != tt.wantStatus || final["model"] != "gpt-5-codex" {
+			t.Errorf("%s: status/model = %v/%v", tt.name, final["status"], final["model"])
+		}
+		if got := codexOutputSummary(t, final); !reflect.DeepEqual(got, tt.wantOutput) {
+			t.Errorf("%s: output = %v, want %v", tt.name, got, tt.wantOutput)
+		}
+	}
+}
+
+func TestCodexStreamingState_Deltas(t *testing.T) {
+	state := NewCodexStreamingState("gpt-5-codex", "", "gemini-2.5-flash")
+	output := state.ProcessGeminiSSELine(geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"a"},{"text":"b"}]}}]}`))
+	output = append(output, state.ProcessGeminiSSELine(geminiSSELine(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"f","args":{"x":1},"id":"call_1"}}]}}]}`))...)
+
+	var deltas []string
+	var itemIDs []interface{}
+	for _, event := range parseCodexEvents(t, output) {
+		switch event.Type {
+		case "response.output_text.delta", "response.function_call_arguments.delta":
+			deltas = append(deltas, event.Data["delta"].(string))
+			itemIDs = append(itemIDs, event.Data["item_id"])
+		}
+	}
+	if want := []string{"a", "b", `{"x":1}`}; !reflect.DeepEqual(deltas, want) {
+		t.Errorf("deltas = %v, want %v", deltas, want)
+	}
+	if len(itemIDs) == 3 && (itemIDs[0] != itemIDs[1] || itemIDs[2] != "fc_call_1") {
+		t.Errorf("item ids = %v, want one message item and fc_call_1", itemIDs)
+	}
+}
+
+func TestConvertGeminiToCodexResponse(t *testing.T) {
+	gemini := `{"responseId":"abc","candidates":[{"content":{"parts":[
+		{"text":"think","thought":true},
+		{"text":"answer"},
+		{"functionCall":{"name":"get_weather","args":{"city":"Paris"},"id":"call_1"}}]},"finishReason":"STOP"}],
+		"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"thoughtsTokenCount":3,"cachedContentTokenCount":2}}`
+
+	out, err := convertGeminiToCodexResponse([]byte(gemini), "gpt-5-codex", "", "gemini-2.5-flash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := decodeJSON(t, out).(map[string]interface{})
+	if resp["id"] != "resp_abc" || resp["object"] != "response" || resp["status"] != "completed" {
+		t.Errorf("id/object/status = %v/%v/%v", resp["id"], resp["object"], resp["status"])
+	}
+	wantOutput := []string{"reasoning:think", "message:answer", `function_call:call_1 get_weather{"city":"Paris"}`}
+	if got := codexOutputSummary(t, resp); !reflect.DeepEqual(got, wantOutput) {
+		t.Errorf("output = %v, want %v", got, wantOutput)
+	}
+	wantUsage := decodeJSON(t, []byte(`{"input_tokens":10,"output_tokens":8,"total_tokens":18,
+		"input_tokens_details":{"cached_tokens":2},"output_tokens_details":{"reasoning_tokens":3}}`))
+	if !reflect.DeepEqual(resp["usage"], wantUsage) {
+		t.Errorf("usage = %v, want %v", resp["usage"], wantUsage)
+	}
+}
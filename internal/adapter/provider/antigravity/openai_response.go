@@ -0,0 +1,412 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// convertGeminiToOpenAIResponse converts a non-streaming Gemini response to an OpenAI chat.completion
+func convertGeminiToOpenAIResponse(geminiBody []byte, requestModel, sessionID, mappedModel string) ([]byte, error) {
+	var geminiResp GeminiStreamChunk
+	if err := json.Unmarshal(geminiBody, &geminiResp); err != nil {
+		return nil, err
+	}
+
+	model := requestModel
+	if model == "" {
+		model = geminiResp.ModelVersion
+	}
+
+	choices := make([]map[string]interface{}, 0, len(geminiResp.Candidates))
+	for i, candidate := range geminiResp.Candidates {
+		var content, reasoning strings.Builder
+		var toolCalls []map[string]interface{}
+
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				toolCalls = append(toolCalls, openAIToolCallFromGemini(part.FunctionCall, part.ThoughtSignature, len(toolCalls), false))
+			case part.Thought:
+				reasoning.WriteString(part.Text)
+				storeThoughtSignatureFallback(sessionID, mappedModel, part.ThoughtSignature, geminiResp.ModelVersion)
+			case part.Text != "":
+				content.WriteString(part.Text)
+				storeThoughtSignatureFallback(sessionID, mappedModel, part.ThoughtSignature, geminiResp.ModelVersion)
+			case part.InlineData != nil && part.InlineData.Data != "":
+				content.WriteString(fmt.Sprintf("![image](data:%s;base64,%s)", part.InlineData.MimeType, part.InlineData.Data))
+			default:
+				storeThoughtSignatureFallback(sessionID, mappedModel, part.ThoughtSignature, geminiResp.ModelVersion)
+			}
+		}
+
+		// Grounding (web search)
+		if candidate.GroundingMetadata != nil {
+			content.WriteString(buildGroundingText(candidate.GroundingMetadata))
+		}
+
+		message := map[string]interface{}{
+			"role":    "assistant",
+			"content": nil,
+		}
+		if content.Len() > 0 || len(toolCalls) == 0 {
+			message["content"] = content.String()
+		}
+		if reasoning.Len() > 0 {
+			message["reasoning_content"] = reasoning.String()
+		}
+		if len(toolCalls) > 0 {
+			message["tool_calls"] = toolCalls
+		}
+
+		choices = append(choices, map[string]interface{}{
+			"index":         i,
+			"message":       message,
+			"finish_reason": mapGeminiFinishReasonToOpenAI(candidate.FinishReason, len(toolCalls) > 0),
+		})
+	}
+
+	openaiResp := map[string]interface{}{
+		"id":      openAIResponseID(geminiResp.ResponseID),
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": choices,
+		"usage":   buildOpenAIUsage(geminiResp.UsageMetadata),
+	}
+
+	return json.Marshal(openaiResp)
+}
+
+// mergeGeminiStreamPayloads merges the chunks of a streamed Gemini response into
+// a single non-streaming response (used when a non-stream request was sent upstream as stream)
+func mergeGeminiStreamPayloads(payloads [][]byte) ([]byte, error) {
+	var merged GeminiStreamChunk
+	for _, payload := range payloads {
+		var chunk GeminiStreamChunk
+		if err := json.Unmarshal(payload, &chunk); err != nil {
+			continue
+		}
+		for i, candidate := range chunk.Candidates {
+			if i >= len(merged.Candidates) {
+				merged.Candidates = append(merged.Candidates, candidate)
+				continue
+			}
+			target := &merged.Candidates[i]
+			target.Content.Parts = append(target.Content.Parts, candidate.Content.Parts...)
+			if candidate.FinishReason != "" {
+				target.FinishReason = candidate.FinishReason
+			}
+			if candidate.GroundingMetadata != nil {
+				target.GroundingMetadata = candidate.GroundingMetadata
+			}
+		}
+		if chunk.UsageMetadata != nil {
+			merged.UsageMetadata = chunk.UsageMetadata
+		}
+		if chunk.ModelVersion != "" {
+			merged.ModelVersion = chunk.ModelVersion
+		}
+		if chunk.ResponseID != "" {
+			merged.ResponseID = chunk.ResponseID
+		}
+	}
+	if len(merged.Candidates) == 0 {
+		return nil, fmt.Errorf("no candidates in streamed response")
+	}
+	return json.Marshal(merged)
+}
+
+// resolveGeminiFunctionCall returns the call ID and JSON arguments of a Gemini function call,
+// caching its signature so the next request can send it back
+func resolveGeminiFunctionCall(fc *GeminiFunctionCall, signature string) (toolID, arguments string) {
+	toolID = fc.ID
+	if toolID == "" {
+		toolID = fmt.Sprintf("call_%s_%d", fc.Name, generateRandomID())
+	}
+
+	if signature != "" && len(signature) >= MinSignatureLength {
+		GlobalSignatureCache().CacheToolSignature(toolID, signature)
+	}
+
+	args := fc.Args
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	remapFunctionCallArgs(fc.Name, args)
+	argsJSON, _ := json.Marshal(args)
+	return toolID, string(argsJSON)
+}
+
+// openAIToolCallFromGemini converts a Gemini function call to an OpenAI tool call
+func openAIToolCallFromGemini(fc *GeminiFunctionCall, signature string, index int, withIndex bool) map[string]interface{} {
+	toolID, arguments := resolveGeminiFunctionCall(fc, signature)
+	toolCall := map[string]interface{}{
+		"id":   toolID,
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":      fc.Name,
+			"arguments": arguments,
+		},
+	}
+	if withIndex {
+		toolCall["index"] = index
+	}
+	return toolCall
+}
+
+// storeThoughtSignatureFallback keeps the latest signature as session fallback
+// (OpenAI and Codex clients drop it from history)
+func storeThoughtSignatureFallback(sessionID, mappedModel, signature, modelVersion string) {
+	if signature == "" {
+		return
+	}
+	if modelVersion != "" {
+		GlobalSignatureCache().CacheThinkingFamily(signature, modelVersion)
+	}
+	StoreThoughtSignature(sessionID, mappedModel, signature)
+}
+
+// mapGeminiFinishReasonToOpenAI maps Gemini finishReason to OpenAI finish_reason
+func mapGeminiFinishReasonToOpenAI(finishReason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	switch finishReason {
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// buildOpenAIUsage converts Gemini usage metadata to OpenAI usage
+// (prompt_tokens includes cached tokens, completion_tokens includes thinking tokens)
+func buildOpenAIUsage(meta *GeminiUsageMetadata) map[string]interface{} {
+	usage := map[string]interface{}{
+		"prompt_tokens":     0,
+		"completion_tokens": 0,
+		"total_tokens":      0,
+	}
+	if meta == nil {
+		return usage
+	}
+	completionTokens := meta.CandidatesTokenCount + meta.ThoughtsTokenCount
+	usage["prompt_tokens"] = meta.PromptTokenCount
+	usage["completion_tokens"] = completionTokens
+	usage["total_tokens"] = meta.PromptTokenCount + completionTokens
+	if meta.CachedContentTokenCount > 0 {
+		usage["prompt_tokens_details"] = map[string]interface{}{
+			"cached_tokens": meta.CachedContentTokenCount,
+		}
+	}
+	if meta.ThoughtsTokenCount > 0 {
+		usage["completion_tokens_details"] = map[string]interface{}{
+			"reasoning_tokens": meta.ThoughtsTokenCount,
+		}
+	}
+	return usage
+}
+
+func openAIResponseID(responseID string) string {
+	if responseID == "" {
+		return fmt.Sprintf("chatcmpl-%d", generateRandomID())
+	}
+	return "chatcmpl-" + responseID
+}
+
+// ===== Streaming =====
+
+// OpenAIStreamingState maintains state for Gemini -> OpenAI chat.completion.chunk conversion
+type OpenAIStreamingState struct {
+	id           string
+	created      int64
+	requestModel string
+	modelVersion string
+	sessionID    string
+	mappedModel  string
+
+	roleSent      bool
+	finished      bool
+	toolCallCount int
+	usage         *GeminiUsageMetadata
+
+	// Grounding (web search) captured during streaming, emitted at finish
+	grounding *GeminiGroundingMetadata
+}
+
+// NewOpenAIStreamingState creates a new streaming state
+func NewOpenAIStreamingState(requestModel, sessionID, mappedModel string) *OpenAIStreamingState {
+	return &OpenAIStreamingState{
+		created:      time.Now().Unix(),
+		requestModel: requestModel,
+		sessionID:    sessionID,
+		mappedModel:  mappedModel,
+	}
+}
+
+// ProcessGeminiSSELine processes a single Gemini SSE line and returns OpenAI SSE chunks
+func (s *OpenAIStreamingState) ProcessGeminiSSELine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data: ") {
+		return nil
+	}
+
+	dataStr := strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+	if dataStr == "" {
+		return nil
+	}
+	if dataStr == "[DONE]" {
+		return s.EmitForceStop()
+	}
+	if s.finished {
+		return nil
+	}
+
+	var chunk GeminiStreamChunk
+	if err := json.Unmarshal([]byte(dataStr), &chunk); err != nil {
+		return s.handleParseError(dataStr)
+	}
+
+	if s.id == "" {
+		s.id = openAIResponseID(chunk.ResponseID)
+	}
+	if chunk.ModelVersion != "" {
+		s.modelVersion = chunk.ModelVersion
+	}
+	if chunk.UsageMetadata != nil {
+		s.usage = chunk.UsageMetadata
+	}
+	if len(chunk.Candidates) == 0 {
+		return nil
+	}
+
+	var output []byte
+	candidate := chunk.Candidates[0]
+	for i := range candidate.Content.Parts {
+		if delta := s.processPart(&candidate.Content.Parts[i]); delta != nil {
+			output = append(output, s.emitChunk(delta, nil, nil)...)
+		}
+	}
+
+	if candidate.GroundingMetadata != nil {
+		s.grounding = candidate.GroundingMetadata
+	}
+
+	if candidate.FinishReason != "" {
+		output = append(output, s.emitFinish(candidate.FinishReason)...)
+	}
+
+	return output
+}
+
+// EmitForceStop ensures the final chunk and [DONE] are sent
+// Called when stream ends (EOF or [DONE])
+func (s *OpenAIStreamingState) EmitForceStop() []byte {
+	if s.finished {
+		return nil
+	}
+	return s.emitFinish("")
+}
+
+// processPart converts a Gemini part to a chunk delta
+func (s *OpenAIStreamingState) processPart(part *GeminiPart) map[string]interface{} {
+	if part.FunctionCall != nil {
+		toolCall := openAIToolCallFromGemini(part.FunctionCall, part.ThoughtSignature, s.toolCallCount, true)
+		s.toolCallCount++
+		return map[string]interface{}{"tool_calls": []map[string]interface{}{toolCall}}
+	}
+
+	storeThoughtSignatureFallback(s.sessionID, s.mappedModel, part.ThoughtSignature, s.modelVersion)
+
+	if part.Text != "" {
+		if part.Thought {
+			return map[string]interface{}{"reasoning_content": part.Text}
+		}
+		return map[string]interface{}{"content": part.Text}
+	}
+
+	if part.InlineData != nil && part.InlineData.Data != "" {
+		return map[string]interface{}{
+			"content": fmt.Sprintf("![image](data:%s;base64,%s)", part.InlineData.MimeType, part.InlineData.Data),
+		}
+	}
+
+	return nil
+}
+
+// emitFinish emits grounding text, the finish chunk (with usage) and [DONE]
+func (s *OpenAIStreamingState) emitFinish(finishReason string) []byte {
+	var output []byte
+
+	if s.grounding != nil {
+		if text := buildGroundingText(s.grounding); text != "" {
+			output = append(output, s.emitChunk(map[string]interface{}{"content": text}, nil, nil)...)
+		}
+		s.grounding = nil
+	}
+
+	reason := mapGeminiFinishReasonToOpenAI(finishReason, s.toolCallCount > 0)
+	output = append(output, s.emitChunk(map[string]interface{}{}, &reason, buildOpenAIUsage(s.usage))...)
+	output = append(output, []byte("data: [DONE]\n\n")...)
+	s.finished = true
+
+	return output
+}
+
+// emitChunk formats a chat.completion.chunk; the first chunk carries the assistant role
+func (s *OpenAIStreamingState) emitChunk(delta map[string]interface{}, finishReason *string, usage map[string]interface{}) []byte {
+	if s.id == "" {
+		s.id = openAIResponseID("")
+	}
+	if !s.roleSent {
+		delta["role"] = "assistant"
+		s.roleSent = true
+	}
+
+	model := s.requestModel
+	if model == "" {
+		model = s.modelVersion
+	}
+
+	chunk := map[string]interface{}{
+		"id":      s.id,
+		"object":  "chat.completion.chunk",
+		"created": s.created,
+		"model":   model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"delta":         delta,
+			"finish_reason": finishReason,
+		}},
+	}
+	if usage != nil {
+		chunk["usage"] = usage
+	}
+
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return nil
+	}
+	return []byte("data: " + string(data) + "\n\n")
+}
+
+// handleParseError surfaces upstream error payloads as content instead of dropping them
+func (s *OpenAIStreamingState) handleParseError(dataStr string) []byte {
+	var errorResp struct {
+		Error struct {
+			Message string `json:"message"`
+			Code    int    `json:"code"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if !strings.Contains(dataStr, "error") || json.Unmarshal([]byte(dataStr), &errorResp) != nil || errorResp.Error.Message == "" {
+		return nil
+	}
+	errorText := fmt.Sprintf("\n\n[API Error: %s (code: %d, status: %s)]\n",
+		errorResp.Error.Message, errorResp.Error.Code, errorResp.Error.Status)
+	return s.emitChunk(map[string]interface{}{"content": errorText}, nil, nil)
+}
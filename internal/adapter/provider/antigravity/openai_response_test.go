@@ -0,0 +1,246 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseOpenAIChunks splits OpenAI SSE output into chunk objects and reports whether [DONE] was sent
+func parseOpenAIChunks(t *testing.T, data []byte) (chunks []map[string]interface{}, done bool) {
+	t.Helper()
+	for _, event := range strings.Split(string(data), "\n\n") {
+		payload, ok := strings.CutPrefix(event, "data: ")
+		if !ok {
+			continue
+		}
+		if payload == "[DONE]" {
+			done = true
+			continue
+		}
+		var chunk map[string]interface{}
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", payload, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, done
+}
+
+func geminiSSELine(chunk string) string {
+	return "data: " + chunk
+}
+
+func TestMapGeminiFinishReasonToOpenAI(t *testing.T) {
+	tests := []struct {
+		reason       string
+		hasToolCalls bool
+		want         string
+	}{
+		{"STOP", false, "stop"},
+		{"", false, "stop"},
+		{"OTHER", false, "stop"},
+		{"MAX_TOKENS", false, "length"},
+		{"SAFETY", false, "content_filter"},
+		{"RECITATION", false, "content_filter"},
+		{"PROHIBITED_CONTENT", false, "content_filter"},
+		{"STOP", true, "tool_calls"},
+		{"MAX_TOKENS", true, "tool_calls"},
+	}
+	for _, tt := range tests {
+		if got := mapGeminiFinishReasonToOpenAI(tt.reason, tt.hasToolCalls); got != tt.want {
+			t.Errorf("mapGeminiFinishReasonToOpenAI(%q, %v) = %q, want %q", tt.reason, tt.hasToolCalls, got, tt.want)
+		}
+	}
+}
+
+func TestConvertGeminiToOpenAIResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		gemini      string
+		wantChoice  string
+		wantUsage   string
+		wantModelID string
+	}{
+		{
+			name: "text with reasoning",
+			gemini: `{"responseId":"abc","modelVersion":"gemini-2.5-flash","candidates":[{"content":{"parts":[
+				{"text":"let me think","thought":true},
+				{"text":"Hello"},{"text":" world"}]},"finishReason":"STOP"}],
+				"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":5,"thoughtsTokenCount":3,"cachedContentTokenCount":2}}`,
+			wantChoice: `{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hello world","reasoning_content":"let me think"}}`,
+			wantUsage: `{"prompt_tokens":10,"completion_tokens":8,"total_tokens":18,
+				"prompt_tokens_details":{"cached_tokens":2},"completion_tokens_details":{"reasoning_tokens":3}}`,
+			wantModelID: "chatcmpl-abc",
+		},
+		{
+			name: "tool calls only",
+			gemini: `{"responseId":"def","candidates":[{"content":{"parts":[
+				{"functionCall":{"name":"get_weather","args":{"city":"Paris"},"id":"call_1"}},
+				{"functionCall":{"name":"list_files","id":"call_2"}}]},"finishReason":"STOP"}]}`,
+			wantChoice: `{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":null,"tool_calls":[
+				{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}},
+				{"id":"call_2","type":"function","function":{"name":"list_files","arguments":"{}"}}]}}`,
+			wantUsage:   `{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}`,
+			wantModelID: "chatcmpl-def",
+		},
+		{
+			name: "truncated",
+			gemini: `{"responseId":"ghi","candidates":[{"content":{"parts":[{"text":"partial"}]},"finishReason":"MAX_TOKENS"}],
+				"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":6}}`,
+			wantChoice:  `{"index":0,"finish_reason":"length","message":{"role":"assistant","content":"partial"}}`,
+			wantUsage:   `{"prompt_tokens":4,"completion_tokens":6,"total_tokens":10}`,
+			wantModelID: "chatcmpl-ghi",
+		},
+		{
+			name:        "inline image",
+			gemini:      `{"responseId":"jkl","candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"AAAA"}}]},"finishReason":"STOP"}]}`,
+			wantChoice:  `{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"![image](data:image/png;base64,AAAA)"}}`,
+			wantUsage:   `{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}`,
+			wantModelID: "chatcmpl-jkl",
+		},
+	}
+	for _, tt := range tests {
+		out, err := convertGeminiToOpenAIResponse([]byte(tt.gemini), "gpt-4o", "", "gemini-2.5-flash")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp := decodeJSON(t, out).(map[string]interface{})
+		if resp["object"] != "chat.completion" || resp["model"] != "gpt-4o" || resp["id"] != tt.wantModelID {
+			t.Errorf("%s: object/model/id = %v/%v/%v", tt.name, resp["object"], resp["model"], resp["id"])
+		}
+		choices, _ := resp["choices"].([]interface{})
+		if len(choices) != 1 {
+			t.Fatalf("%s: choices = %v", tt.name, choices)
+		}
+		if want := decodeJSON(t, []byte(tt.wantChoice)); !reflect.DeepEqual(choices[0], want) {
+			t.Errorf("%s: choice = %v, want %v", tt.name, choices[0], want)
+		}
+		if want := decodeJSON(t, []byte(tt.wantUsage)); !reflect.DeepEqual(resp["usage"], want) {
+			t.Errorf("%s: usage = %v, want %v", tt.name, resp["usage"], want)
+		}
+	}
+}
+
+func TestMergeGeminiStreamPayloads(t *testing.T) {
+	merged, err := mergeGeminiStreamPayloads([][]byte{
+		[]byte(`{"responseId":"r1","candidates":[{"content":{"parts":[{"text":"Hel"}]}}]}`),
+		[]byte(`not json`),
+		[]byte(`{"candidates":[{"content":{"parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":2}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := convertGeminiToOpenAIResponse(merged, "gpt-4o", "", "gemini-2.5-flash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := decodeJSON(t, out).(map[string]interface{})
+	message := resp["choices"].([]interface{})[0].(map[string]interface{})["message"].(map[string]interface{})
+	if message["content"] != "Hello" {
+		t.Errorf("content = %v, want Hello", message["content"])
+	}
+	if resp["usage"].(map[string]interface{})["total_tokens"] != float64(3) {
+		t.Errorf("usage = %v", resp["usage"])
+	}
+
+	if _, err := mergeGeminiStreamPayloads([][]byte{[]byte(`{}`)}); err == nil {
+		t.Error("expected an error without candidates")
+	}
+}
+
+func TestOpenAIStreamingState(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantDeltas []string
+		wantFinish string
+	}{
+		{
+			name: "reasoning then text",
+			lines: []string{
+				geminiSSELine(`{"responseId":"s1","candidates":[{"content":{"parts":[{"text":"hmm","thought":true}]}}]}`),
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"Hel"}]}}]}`),
+				"",
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"lo"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":3,"candidatesTokenCount":2}}`),
+			},
+			wantDeltas: []string{
+				`{"role":"assistant","reasoning_content":"hmm"}`,
+				`{"content":"Hel"}`,
+				`{"content":"lo"}`,
+				`{}`,
+			},
+			wantFinish: "stop",
+		},
+		{
+			name: "tool calls are indexed",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"checking"}]}}]}`),
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"a","args":{"x":1},"id":"call_a"}},{"functionCall":{"name":"b","id":"call_b"}}]},"finishReason":"STOP"}]}`),
+			},
+			wantDeltas: []string{
+				`{"role":"assistant","content":"checking"}`,
+				`{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"a","arguments":"{\"x\":1}"}}]}`,
+				`{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"b","arguments":"{}"}}]}`,
+				`{}`,
+			},
+			wantFinish: "tool_calls",
+		},
+		{
+			name: "max tokens",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"cut"}]},"finishReason":"MAX_TOKENS"}]}`),
+			},
+			wantDeltas: []string{`{"role":"assistant","content":"cut"}`, `{}`},
+			wantFinish: "length",
+		},
+		{
+			name: "stream ends without a finish reason",
+			lines: []string{
+				geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"bye"}]}}]}`),
+				"data: [DONE]",
+			},
+			wantDeltas: []string{`{"role":"assistant","content":"bye"}`, `{}`},
+			wantFinish: "stop",
+		},
+	}
+	for _, tt := range tests {
+		state := NewOpenAIStreamingState("gpt-4o", "", "gemini-2.5-flash")
+		var output []byte
+		for _, line := range tt.lines {
+			output = append(output, state.ProcessGeminiSSELine(line)...)
+		}
+		output = append(output, state.EmitForceStop()...)
+		if extra := state.ProcessGeminiSSELine(geminiSSELine(`{"candidates":[{"content":{"parts":[{"text":"late"}]}}]}`)); extra != nil {
+			t.Errorf("%s: output after finish: %s", tt.name, extra)
+		}
+
+		chunks, done := parseOpenAIChunks(t, output)
+		if !done {
+			t.Errorf("%s: missing [DONE]", tt.name)
+		}
+		if len(chunks) != len(tt.wantDeltas) {
+			t.Fatalf("%s: got %d chunks, want %d: %s", tt.name, len(chunks), len(tt.wantDeltas), output)
+		}
+		for i, chunk := range chunks {
+			if chunk["object"] != "chat.completion.chunk" || chunk["model"] != "gpt-4o" || chunk["id"] != chunks[0]["id"] {
+				t.Errorf("%s: chunk %d object/model/id = %v/%v/%v", tt.name, i, chunk["object"], chunk["model"], chunk["id"])
+			}
+			choice := chunk["choices"].([]interface{})[0].(map[string]interface{})
+			if want := decodeJSON(t, []byte(tt.wantDeltas[i])); !reflect.DeepEqual(choice["delta"], want) {
+				t.Errorf("%s: delta %d = %v, want %v", tt.name, i, choice["delta"], want)
+			}
+			last := i == len(chunks)-1
+			if last && choice["finish_reason"] != tt.wantFinish {
+				t.Errorf("%s: finish_reason = %v, want %s", tt.name, choice["finish_reason"], tt.wantFinish)
+			}
+			if !last && choice["finish_reason"] != nil {
+				t.Errorf("%s: chunk %d has finish_reason %v", tt.name, i, choice["finish_reason"])
+			}
+			if _, hasUsage := chunk["usage"]; hasUsage != last {
+				t.Errorf("%s: chunk %d usage present = %v", tt.name, i, hasUsage)
+			}
+		}
+	}
+}
@@ -26,7 +26,7 @@ func isStreamRequest(body []byte) bool {
 	return stream
 }
 
-// extractSessionID extracts metadata.user_id from request body for use as sessionId
+// extractSessionID extracts metadata.user_id (or OpenAI "user" / Codex "prompt_cache_key") from request body for use as sessionId
 // (like Antigravity-Manager's sessionId support)
 func extractSessionID(body []byte) string {
 	var req map[string]interface{}
@@ -34,13 +34,18 @@ func extractSessionID(body []byte) string {
 		return ""
 	}
 
-	metadata, ok := req["metadata"].(map[string]interface{})
-	if !ok {
-		return ""
+	if metadata, ok := req["metadata"].(map[string]interface{}); ok {
+		if userID, _ := metadata["user_id"].(string); userID != "" {
+			return userID
+		}
 	}
 
-	userID, _ := metadata["user_id"].(string)
-	return userID
+	// OpenAI / Codex: top-level "user", then Codex's per-conversation prompt_cache_key
+	if user, _ := req["user"].(string); user != "" {
+		return user
+	}
+	promptCacheKey, _ := req["prompt_cache_key"].(string)
+	return promptCacheKey
 }
 
 // unwrapGeminiCLIEnvelope extracts the inner request from Gemini CLI envelope format
@@ -125,7 +130,7 @@ func detectsNetworkingTool(tools []interface{}) bool {
 		}
 		if t, _ := toolMap["type"].(string); t != "" {
 			switch t {
-			case "web_search", "google_search", "web_search_20250305", "google_search_retrieval":
+			case "web_search", "google_search", "web_search_20250305", "google_search_retrieval", "web_search_preview":
 				return true
 			}
 		}
@@ -289,6 +294,49 @@ func stripThinkingFromClaude(body []byte) []byte {
 	return data
 }
 
+// stripThinkingFromOpenAI disables reasoning for an OpenAI request to retry without thinking
+func stripThinkingFromOpenAI(body []byte) []byte {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body
+	}
+
+	// Explicit "none" also overrides thinking-by-default models
+	req["reasoning_effort"] = "none"
+
+	// Clean model suffix
+	if model, ok := req["model"].(string); ok {
+		req["model"] = strings.ReplaceAll(model, "-thinking", "")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
+// stripThinkingFromCodex disables reasoning for a Codex request to retry without thinking
+func stripThinkingFromCodex(body []byte) []byte {
+	var req map[string]interface{}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return body
+	}
+
+	req["reasoning"] = map[string]interface{}{"effort": "none"}
+
+	// Clean model suffix
+	if model, ok := req["model"].(string); ok {
+		req["model"] = strings.ReplaceAll(model, "-thinking", "")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return body
+	}
+	return data
+}
+
 // extractModelFromBody extracts model from a Claude request body
 func extractModelFromBody(body []byte) string {
 	var req map[string]interface{}
@@ -327,7 +327,8 @@ func convertGeminiToClaudeResponse(geminiBody []byte, requestModel string) ([]by
 			inputTokens = 0
 		}
 		usage["input_tokens"] = inputTokens
-		usage["output_tokens"] = geminiResp.UsageMetadata.CandidatesTokenCount
+		// Thinking tokens are billed as output, like Claude's own output_tokens
+		usage["output_tokens"] = geminiResp.UsageMetadata.CandidatesTokenCount + geminiResp.UsageMetadata.ThoughtsTokenCount
 		if cachedTokens > 0 {
 			usage["cache_read_input_tokens"] = cachedTokens
 		}
@@ -1,22 +1,37 @@
 package antigravity
 
 import (
+	"fmt"
+	"log"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
 )
 
 // SignatureCache provides a two-layer signature cache (like Antigravity-Manager):
 // 1) tool_use_id -> thought signature
 // 2) thought signature -> model family string
+// Entries live in a pluggable backend (memory by default, or the maxx SQLite database
+// so they survive restarts and are shared between instances using the same database).
 type SignatureCache struct {
-	mu sync.Mutex
+	mu          sync.RWMutex
+	backend     signatureCacheBackend
+	backendName string
+	ttl         time.Duration
+	maxEntries  int
 
-	// Layer 1: Tool Use ID -> Thinking Signature
-	toolSignatures map[string]signatureCacheEntry
+	// Used to build the SQLite backend
+	repository repository.SignatureCacheRepository
 
-	// Layer 2: Thinking Signature -> Model Family
-	thinkingFamilies map[string]signatureCacheEntry
+	toolHits     atomic.Int64
+	toolMisses   atomic.Int64
+	familyHits   atomic.Int64
+	familyMisses atomic.Int64
 }
 
 type signatureCacheEntry struct {
@@ -25,7 +40,7 @@ type signatureCacheEntry struct {
 }
 
 const (
-	// SignatureCacheTTL follows Antigravity-Manager (2 hours)
+	// SignatureCacheTTL follows Antigravity-Manager (2 hours), used unless overridden by settings
 	SignatureCacheTTL = 2 * time.Hour
 
 	// MinSignatureLength is the minimum length for a valid thought signature
@@ -37,14 +52,17 @@ const (
 	// [Aligned with Antigravity-Manager/src-tauri/src/proxy/handlers/claude.rs]
 	MinThinkingSignatureLength = 10
 
-	// signatureCacheMaxEntries matches Antigravity-Manager's simple cleanup strategy.
+	// signatureCacheMaxEntries matches Antigravity-Manager's simple cleanup strategy,
+	// used unless overridden by settings.
 	signatureCacheMaxEntries = 1000
 )
 
 func newSignatureCache() *SignatureCache {
 	return &SignatureCache{
-		toolSignatures:   make(map[string]signatureCacheEntry),
-		thinkingFamilies: make(map[string]signatureCacheEntry),
+		backend:     newMemorySignatureBackend(),
+		backendName: domain.SignatureCacheBackendMemory,
+		ttl:         SignatureCacheTTL,
+		maxEntries:  signatureCacheMaxEntries,
 	}
 }
 
@@ -55,8 +73,8 @@ func GlobalSignatureCache() *SignatureCache {
 	return globalSignatureCache
 }
 
-func (e signatureCacheEntry) expired(now time.Time) bool {
-	return now.Sub(e.timestamp) > SignatureCacheTTL
+func (e signatureCacheEntry) expired(now time.Time, ttl time.Duration) bool {
+	return now.Sub(e.timestamp) > ttl
 }
 
 // HasValidSignature checks if a signature is valid (non-empty and long enough)
@@ -65,42 +83,44 @@ func HasValidSignature(signature string) bool {
 	return signature != "" && len(signature) >= MinSignatureLength
 }
 
-// CacheToolSignature stores a signature for a specific tool call ID (Layer 1).
-func (c *SignatureCache) CacheToolSignature(toolID, signature string) {
-	if signature == "" || len(signature) < MinSignatureLength {
-		return
-	}
-
+// SetRepository sets the repository used by the SQLite backend
+func (c *SignatureCache) SetRepository(repo repository.SignatureCacheRepository) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.repository = repo
+}
 
-	now := time.Now()
-	c.toolSignatures[toolID] = signatureCacheEntry{data: signature, timestamp: now}
+// TTL returns the configured entry lifetime
+func (c *SignatureCache) TTL() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ttl
+}
 
-	if len(c.toolSignatures) > signatureCacheMaxEntries {
-		for key, entry := range c.toolSignatures {
-			if entry.expired(now) {
-				delete(c.toolSignatures, key)
-			}
-		}
+// MaxEntries returns the configured per-layer size limit
+func (c *SignatureCache) MaxEntries() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.maxEntries
+}
+
+// CacheToolSignature stores a signature for a specific tool call ID (Layer 1).
+func (c *SignatureCache) CacheToolSignature(toolID, signature string) {
+	if signature == "" || len(signature) < MinSignatureLength {
+		return
 	}
+	c.set(domain.SignatureCacheLayerTool, toolID, signature)
 }
 
 // GetToolSignature retrieves a cached signature for a tool call ID
 func (c *SignatureCache) GetToolSignature(toolID string) string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	entry, ok := c.toolSignatures[toolID]
-	if !ok {
-		return ""
-	}
-	now := time.Now()
-	if entry.expired(now) {
-		delete(c.toolSignatures, toolID)
-		return ""
+	value := c.get(domain.SignatureCacheLayerTool, toolID)
+	if value == "" {
+		c.toolMisses.Add(1)
+	} else {
+		c.toolHits.Add(1)
 	}
-	return entry.data
+	return value
 }
 
 // CacheThinkingFamily stores model family for a signature (Layer 2).
@@ -108,45 +128,177 @@ func (c *SignatureCache) CacheThinkingFamily(signature, family string) {
 	if signature == "" || len(signature) < MinSignatureLength {
 		return
 	}
+	c.set(domain.SignatureCacheLayerFamily, signature, family)
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// GetSignatureFamily returns the model family that generated a given signature
+func (c *SignatureCache) GetSignatureFamily(signature string) string {
+	value := c.get(domain.SignatureCacheLayerFamily, signature)
+	if value == "" {
+		c.familyMisses.Add(1)
+	} else {
+		c.familyHits.Add(1)
+	}
+	return value
+}
+
+func (c *SignatureCache) set(layer, key, value string) {
+	c.mu.RLock()
+	backend, ttl, maxEntries := c.backend, c.ttl, c.maxEntries
+	c.mu.RUnlock()
 
 	now := time.Now()
-	c.thinkingFamilies[signature] = signatureCacheEntry{data: family, timestamp: now}
+	backend.Set(layer, key, signatureCacheEntry{data: value, timestamp: now})
 
-	if len(c.thinkingFamilies) > signatureCacheMaxEntries {
-		for key, entry := range c.thinkingFamilies {
-			if entry.expired(now) {
-				delete(c.thinkingFamilies, key)
-			}
+	if backend.Len(layer) > maxEntries {
+		// Drop expired entries first, then the oldest ones (down to 90% to amortize the sweep)
+		backend.DeleteBefore(now.Add(-ttl))
+		if backend.Len(layer) > maxEntries {
+			backend.Trim(layer, maxEntries*9/10)
 		}
 	}
 }
 
-// GetSignatureFamily returns the model family that generated a given signature
-func (c *SignatureCache) GetSignatureFamily(signature string) string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *SignatureCache) get(layer, key string) string {
+	c.mu.RLock()
+	backend, ttl := c.backend, c.ttl
+	c.mu.RUnlock()
 
-	entry, ok := c.thinkingFamilies[signature]
+	entry, ok := backend.Get(layer, key)
 	if !ok {
 		return ""
 	}
-	now := time.Now()
-	if entry.expired(now) {
-		delete(c.thinkingFamilies, signature)
+	if entry.expired(time.Now(), ttl) {
+		backend.Delete(layer, key)
 		return ""
 	}
 	return entry.data
 }
 
+// CleanupExpired removes expired entries from the backend
+func (c *SignatureCache) CleanupExpired() {
+	c.mu.RLock()
+	backend, ttl := c.backend, c.ttl
+	c.mu.RUnlock()
+	backend.DeleteBefore(time.Now().Add(-ttl))
+}
+
 // Clear clears all caches (for tests or manual reset).
 func (c *SignatureCache) Clear() {
+	c.mu.RLock()
+	backend := c.backend
+	c.mu.RUnlock()
+	backend.Clear()
+}
+
+// ===== Settings =====
+
+// ApplySettings (re)configures backend, size and TTL from system settings.
+// Missing settings use the defaults; invalid ones are logged and ignored.
+func (c *SignatureCache) ApplySettings(settings repository.SystemSettingRepository) {
+	backendName := domain.SignatureCacheBackendMemory
+	maxEntries := signatureCacheMaxEntries
+	ttl := SignatureCacheTTL
+
+	if value, _ := settings.Get(domain.SettingKeySignatureCacheBackend); value != "" {
+		if err := ValidateSignatureCacheSetting(domain.SettingKeySignatureCacheBackend, value); err != nil {
+			log.Printf("[Antigravity] Ignoring signature cache setting: %v", err)
+		} else {
+			backendName = value
+		}
+	}
+	if value, _ := settings.Get(domain.SettingKeySignatureCacheMaxEntries); value != "" {
+		if err := ValidateSignatureCacheSetting(domain.SettingKeySignatureCacheMaxEntries, value); err != nil {
+			log.Printf("[Antigravity] Ignoring signature cache setting: %v", err)
+		} else {
+			maxEntries, _ = strconv.Atoi(value)
+		}
+	}
+	if value, _ := settings.Get(domain.SettingKeySignatureCacheTTL); value != "" {
+		if err := ValidateSignatureCacheSetting(domain.SettingKeySignatureCacheTTL, value); err != nil {
+			log.Printf("[Antigravity] Ignoring signature cache setting: %v", err)
+		} else {
+			seconds, _ := strconv.Atoi(value)
+			ttl = time.Duration(seconds) * time.Second
+		}
+	}
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.toolSignatures = make(map[string]signatureCacheEntry)
-	c.thinkingFamilies = make(map[string]signatureCacheEntry)
+	c.maxEntries = maxEntries
+	c.ttl = ttl
+
+	if backendName == domain.SignatureCacheBackendSQLite && c.repository == nil {
+		log.Printf("[Antigravity] Signature cache SQLite backend unavailable (no database), using memory")
+		backendName = domain.SignatureCacheBackendMemory
+	}
+	if backendName == c.backendName {
+		return
+	}
+	switch backendName {
+	case domain.SignatureCacheBackendSQLite:
+		c.backend = newSQLiteSignatureBackend(c.repository)
+	default:
+		c.backend = newMemorySignatureBackend()
+	}
+	c.backendName = backendName
+	log.Printf("[Antigravity] Signature cache backend: %s (max %d entries, TTL %s)", backendName, maxEntries, ttl)
+}
+
+// IsSignatureCacheSetting reports whether a system setting key configures the signature cache
+func IsSignatureCacheSetting(key string) bool {
+	switch key {
+	case domain.SettingKeySignatureCacheBackend, domain.SettingKeySignatureCacheMaxEntries, domain.SettingKeySignatureCacheTTL:
+		return true
+	}
+	return false
+}
+
+// ValidateSignatureCacheSetting checks a signature cache setting value before it is saved
+func ValidateSignatureCacheSetting(key, value string) error {
+	switch key {
+	case domain.SettingKeySignatureCacheBackend:
+		if value != domain.SignatureCacheBackendMemory && value != domain.SignatureCacheBackendSQLite {
+			return fmt.Errorf("%s must be %q or %q", key, domain.SignatureCacheBackendMemory, domain.SignatureCacheBackendSQLite)
+		}
+	case domain.SettingKeySignatureCacheMaxEntries, domain.SettingKeySignatureCacheTTL:
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Errorf("%s must be a positive integer", key)
+		}
+	}
+	return nil
+}
+
+// IsSetting reports whether key configures this cache (see IsSignatureCacheSetting)
+func (c *SignatureCache) IsSetting(key string) bool {
+	return IsSignatureCacheSetting(key)
+}
+
+// ValidateSetting checks a setting value before it is saved (see ValidateSignatureCacheSetting)
+func (c *SignatureCache) ValidateSetting(key, value string) error {
+	return ValidateSignatureCacheSetting(key, value)
+}
+
+// ===== Stats =====
+
+// Stats returns the cache configuration, sizes and hit/miss counters since startup
+func (c *SignatureCache) Stats() *domain.SignatureCacheStats {
+	c.mu.RLock()
+	backend := c.backend
+	stats := &domain.SignatureCacheStats{
+		Backend:    c.backendName,
+		MaxEntries: c.maxEntries,
+		TTLSeconds: int64(c.ttl / time.Second),
+	}
+	c.mu.RUnlock()
+
+	stats.ToolEntries = backend.Len(domain.SignatureCacheLayerTool)
+	stats.FamilyEntries = backend.Len(domain.SignatureCacheLayerFamily)
+	stats.ToolHits = c.toolHits.Load()
+	stats.ToolMisses = c.toolMisses.Load()
+	stats.FamilyHits = c.familyHits.Load()
+	stats.FamilyMisses = c.familyMisses.Load()
+	return stats
 }
 
 // IsModelCompatible checks if two models are compatible (same family)
@@ -0,0 +1,178 @@
+package antigravity
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// signatureCacheBackend stores signature cache entries per layer
+// (domain.SignatureCacheLayerTool / domain.SignatureCacheLayerFamily).
+// TTL and size limits are enforced by SignatureCache; backends must be safe for concurrent use.
+type signatureCacheBackend interface {
+	Get(layer, key string) (signatureCacheEntry, bool)
+	Set(layer, key string, entry signatureCacheEntry)
+	Delete(layer, key string)
+	Len(layer string) int
+	// DeleteBefore removes entries (in all layers) last updated before cutoff
+	DeleteBefore(cutoff time.Time)
+	// Trim keeps only the keep most recently updated entries of a layer
+	Trim(layer string, keep int)
+	Clear()
+}
+
+// ===== Memory =====
+
+// memorySignatureBackend keeps entries in process memory (lost on restart)
+type memorySignatureBackend struct {
+	mu     sync.Mutex
+	layers map[string]map[string]signatureCacheEntry
+}
+
+func newMemorySignatureBackend() *memorySignatureBackend {
+	return &memorySignatureBackend{
+		layers: make(map[string]map[string]signatureCacheEntry),
+	}
+}
+
+func (b *memorySignatureBackend) Get(layer, key string) (signatureCacheEntry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.layers[layer][key]
+	return entry, ok
+}
+
+func (b *memorySignatureBackend) Set(layer, key string, entry signatureCacheEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries, ok := b.layers[layer]
+	if !ok {
+		entries = make(map[string]signatureCacheEntry)
+		b.layers[layer] = entries
+	}
+	entries[key] = entry
+}
+
+func (b *memorySignatureBackend) Delete(layer, key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.layers[layer], key)
+}
+
+func (b *memorySignatureBackend) Len(layer string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.layers[layer])
+}
+
+func (b *memorySignatureBackend) DeleteBefore(cutoff time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, entries := range b.layers {
+		for key, entry := range entries {
+			if entry.timestamp.Before(cutoff) {
+				delete(entries, key)
+			}
+		}
+	}
+}
+
+func (b *memorySignatureBackend) Trim(layer string, keep int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entries := b.layers[layer]
+	if len(entries) <= keep {
+		return
+	}
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return entries[keys[i]].timestamp.After(entries[keys[j]].timestamp)
+	})
+	for _, key := range keys[keep:] {
+		delete(entries, key)
+	}
+}
+
+func (b *memorySignatureBackend) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.layers = make(map[string]map[string]signatureCacheEntry)
+}
+
+// ===== SQLite =====
+
+// sqliteSignatureBackend reads and writes the signature_cache table directly,
+// so entries survive restarts and are visible to every instance sharing the database.
+// Errors are logged and treated as cache misses.
+type sqliteSignatureBackend struct {
+	repo repository.SignatureCacheRepository
+}
+
+func newSQLiteSignatureBackend(repo repository.SignatureCacheRepository) *sqliteSignatureBackend {
+	return &sqliteSignatureBackend{repo: repo}
+}
+
+func (b *sqliteSignatureBackend) Get(layer, key string) (signatureCacheEntry, bool) {
+	entry, err := b.repo.Get(layer, key)
+	if err != nil {
+		log.Printf("[Antigravity] Signature cache read failed: %v", err)
+		return signatureCacheEntry{}, false
+	}
+	if entry == nil {
+		return signatureCacheEntry{}, false
+	}
+	return signatureCacheEntry{data: entry.Value, timestamp: entry.UpdatedAt}, true
+}
+
+func (b *sqliteSignatureBackend) Set(layer, key string, entry signatureCacheEntry) {
+	err := b.repo.Upsert(&domain.SignatureCacheEntry{
+		Layer:     layer,
+		Key:       key,
+		Value:     entry.data,
+		UpdatedAt: entry.timestamp,
+	})
+	if err != nil {
+		log.Printf("[Antigravity] Signature cache write failed: %v", err)
+	}
+}
+
+func (b *sqliteSignatureBackend) Delete(layer, key string) {
+	if err := b.repo.Delete(layer, key); err != nil {
+		log.Printf("[Antigravity] Signature cache delete failed: %v", err)
+	}
+}
+
+func (b *sqliteSignatureBackend) Len(layer string) int {
+	count, err := b.repo.Count(layer)
+	if err != nil {
+		log.Printf("[Antigravity] Signature cache count failed: %v", err)
+		return 0
+	}
+	return count
+}
+
+func (b *sqliteSignatureBackend) DeleteBefore(cutoff time.Time) {
+	if err := b.repo.DeleteBefore(cutoff); err != nil {
+		log.Printf("[Antigravity] Signature cache cleanup failed: %v", err)
+	}
+}
+
+func (b *sqliteSignatureBackend) Trim(layer string, keep int) {
+	if err := b.repo.Trim(layer, keep); err != nil {
+		log.Printf("[Antigravity] Signature cache trim failed: %v", err)
+	}
+}
+
+func (b *sqliteSignatureBackend) Clear() {
+	if err := b.repo.DeleteAll(); err != nil {
+		log.Printf("[Antigravity] Signature cache clear failed: %v", err)
+	}
+}
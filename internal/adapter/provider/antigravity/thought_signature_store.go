@@ -1,38 +1,288 @@
 package antigravity
 
-import "sync"
+import (
+	"container/list"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
-// Global thought_signature storage (like Antigravity-Manager's signature_store.rs).
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// ThoughtSignatureStore keeps the latest thought signature per session and model family
+// (like Antigravity-Manager's signature_store.rs, but not shared across sessions).
 // Used as a last-resort fallback when clients strip thoughtSignature in tool loops.
-var globalThoughtSignatureStore struct {
-	mu  sync.Mutex
-	sig string
+// Requests without a session ID are never stored or looked up, since they would share one slot.
+// Entries share the signature cache TTL and size limit (least recently used entries are evicted first);
+// with a repository set they are also persisted, so a restart doesn't break tool loops that are in flight.
+type ThoughtSignatureStore struct {
+	mu         sync.Mutex
+	entries    map[thoughtSignatureKey]*list.Element
+	lru        *list.List // *thoughtSignatureEntry, most recently used first
+	maxEntries int        // 0 uses the signature cache's max entries
+	repository repository.ThoughtSignatureRepository
+}
+
+type thoughtSignatureKey struct {
+	sessionID string
+	family    string
+}
+
+type thoughtSignatureEntry struct {
+	key thoughtSignatureKey
+	signatureCacheEntry
+}
+
+// NewThoughtSignatureStore creates an in-memory store
+func NewThoughtSignatureStore() *ThoughtSignatureStore {
+	return &ThoughtSignatureStore{
+		entries: make(map[thoughtSignatureKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+var defaultThoughtSignatureStore = NewThoughtSignatureStore()
+
+// DefaultThoughtSignatureStore returns the process-wide store
+func DefaultThoughtSignatureStore() *ThoughtSignatureStore {
+	return defaultThoughtSignatureStore
+}
+
+// StoreThoughtSignature stores the latest signature for a session and model.
+func StoreThoughtSignature(sessionID, model, sig string) {
+	defaultThoughtSignatureStore.Store(sessionID, model, sig)
+}
+
+// GetThoughtSignature returns the stored signature for a session and model (or "" if none).
+func GetThoughtSignature(sessionID, model string) string {
+	return defaultThoughtSignatureStore.Get(sessionID, model)
+}
+
+// ClearThoughtSignature clears all stored signatures (mainly for tests).
+func ClearThoughtSignature() {
+	defaultThoughtSignatureStore.Clear()
+}
+
+// SetRepository enables persistence
+func (s *ThoughtSignatureStore) SetRepository(repo repository.ThoughtSignatureRepository) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repository = repo
+}
+
+// LoadFromDatabase drops expired rows and loads the most recent ones into memory
+func (s *ThoughtSignatureStore) LoadFromDatabase() error {
+	s.mu.Lock()
+	repo := s.repository
+	s.mu.Unlock()
+	if repo == nil {
+		return nil
+	}
+
+	cutoff := time.Now().Add(-GlobalSignatureCache().TTL())
+	if err := repo.DeleteBefore(cutoff); err != nil {
+		return err
+	}
+	sigs, err := repo.ListSince(cutoff)
+	if err != nil {
+		return err
+	}
+
+	// Oldest first, so the most recent rows end up at the front of the LRU list
+	sort.Slice(sigs, func(i, j int) bool {
+		return sigs[i].UpdatedAt.Before(sigs[j].UpdatedAt)
+	})
+
+	s.mu.Lock()
+	for _, sig := range sigs {
+		if sig.SessionID == "" {
+			continue
+		}
+		key := thoughtSignatureKey{sessionID: sig.SessionID, family: sig.ModelFamily}
+		if el, ok := s.entries[key]; ok {
+			if el.Value.(*thoughtSignatureEntry).timestamp.After(sig.UpdatedAt) {
+				continue
+			}
+			s.removeLocked(el)
+		}
+		s.entries[key] = s.lru.PushFront(&thoughtSignatureEntry{
+			key:                 key,
+			signatureCacheEntry: signatureCacheEntry{data: sig.Signature, timestamp: sig.UpdatedAt},
+		})
+	}
+	evicted := s.evictOverflowLocked()
+	s.mu.Unlock()
+
+	s.deletePersisted(repo, evicted)
+	log.Printf("[Antigravity] Loaded %d thought signatures from database", len(sigs)-len(evicted))
+	return nil
 }
 
-// StoreThoughtSignature stores the signature if it's longer than the existing one.
-func StoreThoughtSignature(sig string) {
-	if sig == "" {
+// Store replaces the session's signature for the model family
+func (s *ThoughtSignatureStore) Store(sessionID, model, sig string) {
+	if sessionID == "" || !HasValidSignature(sig) {
 		return
 	}
+	key := thoughtSignatureKey{sessionID: sessionID, family: thoughtSignatureFamily(model)}
+	now := time.Now()
 
-	globalThoughtSignatureStore.mu.Lock()
-	defer globalThoughtSignatureStore.mu.Unlock()
+	s.mu.Lock()
+	if el, ok := s.entries[key]; ok {
+		entry := el.Value.(*thoughtSignatureEntry)
+		s.lru.MoveToFront(el)
+		if entry.data == sig {
+			// Same signature streamed again: refresh TTL only
+			entry.timestamp = now
+			s.mu.Unlock()
+			return
+		}
+		entry.signatureCacheEntry = signatureCacheEntry{data: sig, timestamp: now}
+	} else {
+		s.entries[key] = s.lru.PushFront(&thoughtSignatureEntry{
+			key:                 key,
+			signatureCacheEntry: signatureCacheEntry{data: sig, timestamp: now},
+		})
+	}
+	evicted := s.evictOverflowLocked()
+	repo := s.repository
+	s.mu.Unlock()
 
-	if globalThoughtSignatureStore.sig == "" || len(sig) > len(globalThoughtSignatureStore.sig) {
-		globalThoughtSignatureStore.sig = sig
+	if repo != nil {
+		err := repo.Upsert(&domain.ThoughtSignature{
+			SessionID:   key.sessionID,
+			ModelFamily: key.family,
+			Signature:   sig,
+			UpdatedAt:   now,
+		})
+		if err != nil {
+			log.Printf("[Antigravity] Failed to persist thought signature: %v", err)
+		}
+		s.deletePersisted(repo, evicted)
 	}
 }
 
-// GetThoughtSignature returns the currently stored signature (or "" if none).
-func GetThoughtSignature() string {
-	globalThoughtSignatureStore.mu.Lock()
-	defer globalThoughtSignatureStore.mu.Unlock()
-	return globalThoughtSignatureStore.sig
+// Get returns the session's signature for the model family, or "" if none or expired
+func (s *ThoughtSignatureStore) Get(sessionID, model string) string {
+	if sessionID == "" {
+		return ""
+	}
+	key := thoughtSignatureKey{sessionID: sessionID, family: thoughtSignatureFamily(model)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return ""
+	}
+	entry := el.Value.(*thoughtSignatureEntry)
+	if entry.expired(time.Now(), GlobalSignatureCache().TTL()) {
+		s.removeLocked(el)
+		return ""
+	}
+	s.lru.MoveToFront(el)
+	return entry.data
 }
 
-// ClearThoughtSignature clears the stored signature (mainly for tests).
-func ClearThoughtSignature() {
-	globalThoughtSignatureStore.mu.Lock()
-	defer globalThoughtSignatureStore.mu.Unlock()
-	globalThoughtSignatureStore.sig = ""
+// CleanupExpired removes expired signatures from memory and the database
+func (s *ThoughtSignatureStore) CleanupExpired() {
+	now := time.Now()
+	s.mu.Lock()
+	s.evictExpiredLocked(now)
+	repo := s.repository
+	s.mu.Unlock()
+
+	if repo != nil {
+		if err := repo.DeleteBefore(now.Add(-GlobalSignatureCache().TTL())); err != nil {
+			log.Printf("[Antigravity] Failed to delete expired thought signatures: %v", err)
+		}
+	}
+}
+
+// Clear removes all signatures
+func (s *ThoughtSignatureStore) Clear() {
+	s.mu.Lock()
+	s.entries = make(map[thoughtSignatureKey]*list.Element)
+	s.lru.Init()
+	repo := s.repository
+	s.mu.Unlock()
+
+	if repo != nil {
+		if err := repo.DeleteAll(); err != nil {
+			log.Printf("[Antigravity] Failed to clear thought signatures: %v", err)
+		}
+	}
+}
+
+func (s *ThoughtSignatureStore) limit() int {
+	if s.maxEntries > 0 {
+		return s.maxEntries
+	}
+	return GlobalSignatureCache().MaxEntries()
+}
+
+func (s *ThoughtSignatureStore) removeLocked(el *list.Element) {
+	delete(s.entries, el.Value.(*thoughtSignatureEntry).key)
+	s.lru.Remove(el)
+}
+
+// evictOverflowLocked drops expired entries, then least recently used ones, until the store fits its limit.
+// Returns the keys evicted for size so they can be removed from the database too.
+func (s *ThoughtSignatureStore) evictOverflowLocked() []thoughtSignatureKey {
+	limit := s.limit()
+	if s.lru.Len() <= limit {
+		return nil
+	}
+	s.evictExpiredLocked(time.Now())
+
+	var evicted []thoughtSignatureKey
+	for s.lru.Len() > limit {
+		el := s.lru.Back()
+		evicted = append(evicted, el.Value.(*thoughtSignatureEntry).key)
+		s.removeLocked(el)
+	}
+	return evicted
+}
+
+func (s *ThoughtSignatureStore) evictExpiredLocked(now time.Time) {
+	ttl := GlobalSignatureCache().TTL()
+	for el := s.lru.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*thoughtSignatureEntry).expired(now, ttl) {
+			s.removeLocked(el)
+		}
+		el = next
+	}
+}
+
+func (s *ThoughtSignatureStore) deletePersisted(repo repository.ThoughtSignatureRepository, keys []thoughtSignatureKey) {
+	for _, key := range keys {
+		if err := repo.Delete(key.sessionID, key.family); err != nil {
+			log.Printf("[Antigravity] Failed to delete evicted thought signature: %v", err)
+		}
+	}
+}
+
+// thoughtSignatureFamily groups model names whose signatures are interchangeable:
+// the Claude tier (opus/sonnet/haiku) or the Gemini generation (gemini-2.5, gemini-3, ...).
+// Suffixes like -thinking, -online or -high don't change the family.
+func thoughtSignatureFamily(model string) string {
+	m := strings.ToLower(model)
+	if strings.Contains(m, "claude") {
+		for _, tier := range []string{"opus", "sonnet", "haiku"} {
+			if strings.Contains(m, tier) {
+				return "claude-" + tier
+			}
+		}
+		return "claude"
+	}
+	if strings.HasPrefix(m, "gemini-") {
+		if version, _, _ := strings.Cut(strings.TrimPrefix(m, "gemini-"), "-"); version != "" {
+			return "gemini-" + version
+		}
+	}
+	return m
 }
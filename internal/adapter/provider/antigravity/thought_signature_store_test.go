@@ -0,0 +1,160 @@
+package antigravity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// memoryThoughtSignatures records persisted rows and deletions
+type memoryThoughtSignatures struct {
+	repository.ThoughtSignatureRepository
+	rows    map[thoughtSignatureKey]*domain.ThoughtSignature
+	deleted []thoughtSignatureKey
+}
+
+func newMemoryThoughtSignatures() *memoryThoughtSignatures {
+	return &memoryThoughtSignatures{rows: make(map[thoughtSignatureKey]*domain.ThoughtSignature)}
+}
+
+func (r *memoryThoughtSignatures) Upsert(sig *domain.ThoughtSignature) error {
+	r.rows[thoughtSignatureKey{sessionID: sig.SessionID, family: sig.ModelFamily}] = sig
+	return nil
+}
+
+func (r *memoryThoughtSignatures) Delete(sessionID, modelFamily string) error {
+	key := thoughtSignatureKey{sessionID: sessionID, family: modelFamily}
+	delete(r.rows, key)
+	r.deleted = append(r.deleted, key)
+	return nil
+}
+
+func (r *memoryThoughtSignatures) DeleteBefore(before time.Time) error {
+	for key, row := range r.rows {
+		if row.UpdatedAt.Before(before) {
+			delete(r.rows, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryThoughtSignatures) ListSince(since time.Time) ([]*domain.ThoughtSignature, error) {
+	var rows []*domain.ThoughtSignature
+	for _, row := range r.rows {
+		if !row.UpdatedAt.Before(since) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func testSignature(name string) string {
+	return name + strings.Repeat("x", MinSignatureLength)
+}
+
+func TestThoughtSignatureStore_EmptySession(t *testing.T) {
+	repo := newMemoryThoughtSignatures()
+	store := NewThoughtSignatureStore()
+	store.SetRepository(repo)
+
+	store.Store("", "claude-sonnet-4-5", testSignature("a"))
+	if got := store.Get("", "claude-sonnet-4-5"); got != "" {
+		t.Errorf("Get without session = %q, want empty", got)
+	}
+	if store.lru.Len() != 0 || len(repo.rows) != 0 {
+		t.Errorf("stored %d entries and persisted %d rows without a session", store.lru.Len(), len(repo.rows))
+	}
+}
+
+func TestThoughtSignatureStore_LRUEviction(t *testing.T) {
+	repo := newMemoryThoughtSignatures()
+	store := NewThoughtSignatureStore()
+	store.SetRepository(repo)
+	store.maxEntries = 2
+
+	store.Store("s1", "claude-sonnet-4-5", testSignature("1"))
+	store.Store("s2", "claude-sonnet-4-5", testSignature("2"))
+	// Reading s1 makes s2 the least recently used entry
+	if got := store.Get("s1", "claude-sonnet-4-5-thinking"); got != testSignature("1") {
+		t.Fatalf("Get(s1) = %q", got)
+	}
+	store.Store("s3", "claude-sonnet-4-5", testSignature("3"))
+
+	tests := []struct {
+		session string
+		want    string
+	}{
+		{"s1", testSignature("1")},
+		{"s2", ""},
+		{"s3", testSignature("3")},
+	}
+	for _, tt := range tests {
+		if got := store.Get(tt.session, "claude-sonnet-4-5"); got != tt.want {
+			t.Errorf("Get(%s) = %q, want %q", tt.session, got, tt.want)
+		}
+	}
+	if store.lru.Len() != 2 || len(store.entries) != 2 {
+		t.Errorf("store holds %d/%d entries, want 2", store.lru.Len(), len(store.entries))
+	}
+	evicted := thoughtSignatureKey{sessionID: "s2", family: "claude-sonnet"}
+	if len(repo.deleted) != 1 || repo.deleted[0] != evicted {
+		t.Errorf("deleted rows = %v, want %v", repo.deleted, evicted)
+	}
+	if _, ok := repo.rows[evicted]; ok {
+		t.Error("evicted signature is still persisted")
+	}
+}
+
+func TestThoughtSignatureStore_UpdateKeepsOneEntry(t *testing.T) {
+	store := NewThoughtSignatureStore()
+	store.maxEntries = 2
+
+	store.Store("s1", "gemini-3-pro", testSignature("old"))
+	store.Store("s1", "gemini-3-pro-high", testSignature("new"))
+	store.Store("s1", "claude-opus-4-5", testSignature("claude"))
+
+	if got := store.Get("s1", "gemini-3-pro"); got != testSignature("new") {
+		t.Errorf("Get(gemini) = %q, want the latest signature", got)
+	}
+	if got := store.Get("s1", "claude-opus-4-5"); got != testSignature("claude") {
+		t.Errorf("Get(claude) = %q", got)
+	}
+	if store.lru.Len() != 2 {
+		t.Errorf("store holds %d entries, want 2", store.lru.Len())
+	}
+}
+
+func TestThoughtSignatureStore_LoadFromDatabaseKeepsMostRecent(t *testing.T) {
+	repo := newMemoryThoughtSignatures()
+	now := time.Now()
+	for i, session := range []string{"old", "mid", "new", ""} {
+		repo.rows[thoughtSignatureKey{sessionID: session, family: "claude-opus"}] = &domain.ThoughtSignature{
+			SessionID:   session,
+			ModelFamily: "claude-opus",
+			Signature:   testSignature(session),
+			UpdatedAt:   now.Add(time.Duration(i-4) * time.Minute),
+		}
+	}
+
+	store := NewThoughtSignatureStore()
+	store.SetRepository(repo)
+	store.maxEntries = 2
+	if err := store.LoadFromDatabase(); err != nil {
+		t.Fatal(err)
+	}
+
+	for session, want := range map[string]string{"old": "", "mid": testSignature("mid"), "new": testSignature("new")} {
+		if got := store.Get(session, "claude-opus-4-5"); got != want {
+			t.Errorf("Get(%s) = %q, want %q", session, got, want)
+		}
+	}
+	if store.lru.Len() != 2 {
+		t.Errorf("store holds %d entries, want 2", store.lru.Len())
+	}
+	if _, ok := repo.rows[thoughtSignatureKey{sessionID: "old", family: "claude-opus"}]; ok {
+		t.Error("row evicted on load is still persisted")
+	}
+}
@@ -0,0 +1,216 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/awsl-project/maxx/internal/converter"
+)
+
+// TransformCodexToGemini converts a Codex (OpenAI Responses API) request to Gemini v1internal format.
+// Input items are converted to chat messages first, then share the OpenAI transform
+// (tools, images, structured output, reasoning effort).
+func TransformCodexToGemini(
+	codexReqBody []byte,
+	mappedModel string,
+	sessionID string,
+	signatureCache *SignatureCache,
+) (geminiReqBody []byte, hasThinking bool, err error) {
+	var codexReq CodexRequest
+	if err := json.Unmarshal(codexReqBody, &codexReq); err != nil {
+		return nil, false, fmt.Errorf("failed to parse Codex request: %w", err)
+	}
+	return transformOpenAIRequest(codexToOpenAIChatRequest(&codexReq), mappedModel, sessionID, signatureCache)
+}
+
+// CodexRequest extends the converter's Responses API request with the fields Antigravity uses
+type CodexRequest struct {
+	converter.CodexRequest
+	Reasoning *CodexReasoning `json:"reasoning,omitempty"`
+	Text      *CodexText      `json:"text,omitempty"`
+	User      string          `json:"user,omitempty"`
+}
+
+// CodexReasoning represents the reasoning settings
+type CodexReasoning struct {
+	Effort  string `json:"effort,omitempty"` // "minimal", "low", "medium", "high"
+	Summary string `json:"summary,omitempty"`
+}
+
+// CodexText represents text output settings (structured output)
+type CodexText struct {
+	Format *struct {
+		Type   string                 `json:"type"` // "text", "json_object", "json_schema"
+		Name   string                 `json:"name,omitempty"`
+		Schema map[string]interface{} `json:"schema,omitempty"`
+	} `json:"format,omitempty"`
+}
+
+// codexToOpenAIChatRequest converts a Responses API request to a Chat Completions request
+func codexToOpenAIChatRequest(req *CodexRequest) *OpenAIChatRequest {
+	openaiReq := &OpenAIChatRequest{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		MaxTokens:   req.MaxOutputTokens,
+		Stream:      req.Stream,
+		User:        req.User,
+	}
+
+	// 1. Instructions -> system message
+	if req.Instructions != "" {
+		openaiReq.Messages = append(openaiReq.Messages, OpenAIChatMessage{
+			Role:    "system",
+			Content: req.Instructions,
+		})
+	}
+
+	// 2. Input items -> messages
+	openaiReq.Messages = append(openaiReq.Messages, codexInputToMessages(req.Input)...)
+
+	// 3. Tools (function and web search; other built-in tools are not supported upstream)
+	for _, tool := range req.Tools {
+		switch {
+		case tool.Type == "function":
+			parameters, _ := tool.Parameters.(map[string]interface{})
+			openaiReq.Tools = append(openaiReq.Tools, OpenAITool{
+				Type: "function",
+				Function: &OpenAIFunction{
+					Name:        tool.Name,
+					Description: tool.Description,
+					Parameters:  parameters,
+				},
+			})
+		case strings.HasPrefix(tool.Type, "web_search"):
+			openaiReq.Tools = append(openaiReq.Tools, OpenAITool{Type: tool.Type})
+		}
+	}
+
+	// 4. Tool choice: {"type":"function","name":"x"} -> {"type":"function","function":{"name":"x"}}
+	openaiReq.ToolChoice = req.ToolChoice
+	if choice, ok := req.ToolChoice.(map[string]interface{}); ok {
+		if name, _ := choice["name"].(string); name != "" {
+			openaiReq.ToolChoice = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": name},
+			}
+		}
+	}
+
+	// 5. Reasoning effort
+	if req.Reasoning != nil {
+		openaiReq.ReasoningEffort = req.Reasoning.Effort
+	}
+
+	// 6. Structured output
+	if req.Text != nil && req.Text.Format != nil {
+		switch req.Text.Format.Type {
+		case "json_object":
+			openaiReq.ResponseFormat = &OpenAIResponseFormat{Type: "json_object"}
+		case "json_schema":
+			openaiReq.ResponseFormat = &OpenAIResponseFormat{
+				Type:       "json_schema",
+				JSONSchema: &OpenAIJSONSchema{Name: req.Text.Format.Name, Schema: req.Text.Format.Schema},
+			}
+		}
+	}
+
+	return openaiReq
+}
+
+// codexInputToMessages converts Responses API input (string or item list) to chat messages
+func codexInputToMessages(input interface{}) []OpenAIChatMessage {
+	if text, ok := input.(string); ok {
+		return []OpenAIChatMessage{{Role: "user", Content: text}}
+	}
+
+	items, ok := input.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var messages []OpenAIChatMessage
+	for _, raw := range items {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		itemType, _ := item["type"].(string)
+		role, _ := item["role"].(string)
+		if itemType == "" && role != "" {
+			itemType = "message"
+		}
+
+		switch itemType {
+		case "message":
+			if role == "" {
+				role = "user"
+			}
+			messages = append(messages, OpenAIChatMessage{
+				Role:    role,
+				Content: codexContentToOpenAI(item["content"]),
+			})
+
+		case "function_call":
+			// Outputs reference call_id; id is the item id
+			callID, _ := item["call_id"].(string)
+			if callID == "" {
+				callID, _ = item["id"].(string)
+			}
+			toolCall := OpenAIToolCall{ID: callID, Type: "function"}
+			toolCall.Function.Name, _ = item["name"].(string)
+			toolCall.Function.Arguments, _ = item["arguments"].(string)
+			messages = append(messages, OpenAIChatMessage{
+				Role:      "assistant",
+				ToolCalls: []OpenAIToolCall{toolCall},
+			})
+
+		case "function_call_output":
+			callID, _ := item["call_id"].(string)
+			messages = append(messages, OpenAIChatMessage{
+				Role:       "tool",
+				Content:    openAIContentText(item["output"]),
+				ToolCallID: callID,
+			})
+
+			// "reasoning" items carry OpenAI-encrypted content that Gemini can't use
+		}
+	}
+	return messages
+}
+
+// codexContentToOpenAI converts Responses API content parts to Chat Completions content parts
+func codexContentToOpenAI(content interface{}) interface{} {
+	parts, ok := content.([]interface{})
+	if !ok {
+		return content
+	}
+
+	var result []interface{}
+	for _, raw := range parts {
+		part, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch part["type"] {
+		case "input_text", "output_text", "text":
+			result = append(result, map[string]interface{}{"type": "text", "text": part["text"]})
+		case "input_image":
+			if url, _ := part["image_url"].(string); url != "" {
+				result = append(result, map[string]interface{}{
+					"type":      "image_url",
+					"image_url": map[string]interface{}{"url": url},
+				})
+			}
+		case "input_file":
+			if data, _ := part["file_data"].(string); data != "" {
+				result = append(result, map[string]interface{}{
+					"type": "file",
+					"file": map[string]interface{}{"file_data": data},
+				})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,179 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTransformCodexToGemini_Contents(t *testing.T) {
+	ClearThoughtSignature()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "string input",
+			input: `"hi"`,
+			want:  `[{"role":"user","parts":[{"text":"hi"}]}]`,
+		},
+		{
+			name: "messages with typed content parts",
+			input: `[
+				{"type":"message","role":"user","content":[{"type":"input_text","text":"describe"},{"type":"input_image","image_url":"data:image/png;base64,AAAA"}]},
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"a cat"}]}]`,
+			want: `[
+				{"role":"user","parts":[{"text":"describe"},{"inlineData":{"mimeType":"image/png","data":"AAAA"}}]},
+				{"role":"model","parts":[{"text":"a cat"}]}]`,
+		},
+		{
+			name:  "role-only items are messages",
+			input: `[{"role":"user","content":"hello"}]`,
+			want:  `[{"role":"user","parts":[{"text":"hello"}]}]`,
+		},
+		{
+			name: "function call and output",
+			input: `[
+				{"type":"message","role":"user","content":"weather?"},
+				{"type":"function_call","id":"fc_1","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"},
+				{"type":"function_call_output","call_id":"call_1","output":"sunny"}]`,
+			want: `[
+				{"role":"user","parts":[{"text":"weather?"}]},
+				{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"},"id":"call_1"}}]},
+				{"role":"user","parts":[{"functionResponse":{"name":"get_weather","response":{"result":"sunny"},"id":"call_1"}}]}]`,
+		},
+		{
+			name: "parallel function calls are merged into one model turn",
+			input: `[
+				{"type":"function_call","call_id":"call_a","name":"a","arguments":"{}"},
+				{"type":"function_call","id":"call_b","name":"b","arguments":"{\"n\":1}"},
+				{"type":"function_call_output","call_id":"call_a","output":"A"},
+				{"type":"function_call_output","call_id":"call_b","output":""}]`,
+			want: `[
+				{"role":"model","parts":[
+					{"functionCall":{"name":"a","args":{},"id":"call_a"}},
+					{"functionCall":{"name":"b","args":{"n":1},"id":"call_b"}}]},
+				{"role":"user","parts":[
+					{"functionResponse":{"name":"a","response":{"result":"A"},"id":"call_a"}},
+					{"functionResponse":{"name":"b","response":{"result":"Command executed successfully."},"id":"call_b"}}]}]`,
+		},
+		{
+			name: "reasoning items are dropped",
+			input: `[
+				{"type":"message","role":"user","content":"q"},
+				{"type":"reasoning","id":"rs_1","encrypted_content":"opaque","summary":[]},
+				{"type":"message","role":"assistant","content":[{"type":"output_text","text":"a"}]}]`,
+			want: `[{"role":"user","parts":[{"text":"q"}]},{"role":"model","parts":[{"text":"a"}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		body := `{"model":"gpt-5-codex","instructions":"be brief","input":` + tt.input + `}`
+		out, _, err := TransformCodexToGemini([]byte(body), "gemini-2.5-flash", "", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := decodeJSON(t, out).(map[string]interface{})["contents"]
+		if want := decodeJSON(t, []byte(tt.want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: contents = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestTransformCodexToGemini_Errors(t *testing.T) {
+	if _, _, err := TransformCodexToGemini([]byte(`{"input":[]}`), "gemini-2.5-flash", "", nil); err == nil {
+		t.Error("expected an error for empty input")
+	}
+	if _, _, err := TransformCodexToGemini([]byte(`[`), "gemini-2.5-flash", "", nil); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestCodexToOpenAIChatRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		check func(t *testing.T, req *OpenAIChatRequest)
+	}{
+		{
+			name: "instructions become a system message",
+			body: `{"instructions":"be brief","input":"hi","max_output_tokens":100}`,
+			check: func(t *testing.T, req *OpenAIChatRequest) {
+				if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != "be brief" {
+					t.Errorf("messages = %+v", req.Messages)
+				}
+				if req.MaxTokens != 100 {
+					t.Errorf("max tokens = %d, want 100", req.MaxTokens)
+				}
+			},
+		},
+		{
+			name: "function and web search tools",
+			body: `{"input":"hi","tools":[
+				{"type":"function","name":"get_weather","description":"d","parameters":{"type":"object"}},
+				{"type":"web_search_preview"},
+				{"type":"code_interpreter"}]}`,
+			check: func(t *testing.T, req *OpenAIChatRequest) {
+				if len(req.Tools) != 2 {
+					t.Fatalf("tools = %+v, want function and web search", req.Tools)
+				}
+				if fn := req.Tools[0].Function; fn == nil || fn.Name != "get_weather" || fn.Description != "d" || fn.Parameters["type"] != "object" {
+					t.Errorf("function tool = %+v", fn)
+				}
+				if req.Tools[1].Type != "web_search_preview" || req.Tools[1].Function != nil {
+					t.Errorf("web search tool = %+v", req.Tools[1])
+				}
+			},
+		},
+		{
+			name: "named tool choice",
+			body: `{"input":"hi","tool_choice":{"type":"function","name":"get_weather"}}`,
+			check: func(t *testing.T, req *OpenAIChatRequest) {
+				want := map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": "get_weather"}}
+				if !reflect.DeepEqual(req.ToolChoice, want) {
+					t.Errorf("tool choice = %v, want %v", req.ToolChoice, want)
+				}
+			},
+		},
+		{
+			name: "string tool choice",
+			body: `{"input":"hi","tool_choice":"required"}`,
+			check: func(t *testing.T, req *OpenAIChatRequest) {
+				if req.ToolChoice != "required" {
+					t.Errorf("tool choice = %v, want required", req.ToolChoice)
+				}
+			},
+		},
+		{
+			name: "reasoning effort and json schema",
+			body: `{"input":"hi","reasoning":{"effort":"high"},"text":{"format":{"type":"json_schema","name":"out","schema":{"type":"object"}}}}`,
+			check: func(t *testing.T, req *OpenAIChatRequest) {
+				if req.ReasoningEffort != "high" {
+					t.Errorf("reasoning effort = %q, want high", req.ReasoningEffort)
+				}
+				if rf := req.ResponseFormat; rf == nil || rf.Type != "json_schema" || rf.JSONSchema == nil || rf.JSONSchema.Name != "out" {
+					t.Errorf("response format = %+v", rf)
+				}
+			},
+		},
+		{
+			name: "json object format",
+			body: `{"input":"hi","text":{"format":{"type":"json_object"}}}`,
+			check: func(t *testing.T, req *OpenAIChatRequest) {
+				if rf := req.ResponseFormat; rf == nil || rf.Type != "json_object" {
+					t.Errorf("response format = %+v", rf)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		var codexReq CodexRequest
+		if err := json.Unmarshal([]byte(tt.body), &codexReq); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, codexToOpenAIChatRequest(&codexReq))
+		})
+	}
+}
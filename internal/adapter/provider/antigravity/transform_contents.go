@@ -59,7 +59,7 @@ func buildContents(
 					})
 
 				case "tool_use":
-					part := processToolUseBlock(block, lastThoughtSignature, signatureCache)
+					part := processToolUseBlock(block, mappedModel, sessionID, lastThoughtSignature, signatureCache)
 					parts = append(parts, part)
 					toolIDToName[block.ID] = block.Name
 
@@ -154,6 +154,8 @@ func processThinkingBlock(
 // Reference: Antigravity-Manager's ToolUse processing
 func processToolUseBlock(
 	block ContentBlock,
+	mappedModel string,
+	sessionID string,
 	lastThoughtSignature string,
 	signatureCache *SignatureCache,
 ) map[string]interface{} {
@@ -178,7 +180,7 @@ func processToolUseBlock(
 	// 1. Client-provided signature
 	// 2. Context signature (last_thought_signature)
 	// 3. Cached signature (from previous tool calls)
-	// 4. Global fallback signature (from cache)
+	// 4. Session fallback signature (per session and model family)
 	// Reference: Antigravity-Manager's multi-layer signature recovery
 	signature := block.Signature
 	if signature == "" && lastThoughtSignature != "" {
@@ -188,8 +190,8 @@ func processToolUseBlock(
 		signature = signatureCache.GetToolSignature(block.ID)
 	}
 	if signature == "" {
-		// Final fallback: global signature store (best-effort)
-		signature = GetThoughtSignature()
+		// Final fallback: session signature store (best-effort)
+		signature = GetThoughtSignature(sessionID, mappedModel)
 	}
 
 	if signature != "" {
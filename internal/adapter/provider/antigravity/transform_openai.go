@@ -0,0 +1,525 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"path"
+	"strings"
+)
+
+// TransformOpenAIToGemini converts an OpenAI Chat Completions request to Gemini v1internal format
+// Reference: Antigravity-Manager's transform_openai_request
+func TransformOpenAIToGemini(
+	openaiReqBody []byte,
+	mappedModel string,
+	sessionID string,
+	signatureCache *SignatureCache,
+) (geminiReqBody []byte, hasThinking bool, err error) {
+	// 1. Parse OpenAI request
+	var openaiReq OpenAIChatRequest
+	if err := json.Unmarshal(openaiReqBody, &openaiReq); err != nil {
+		return nil, false, fmt.Errorf("failed to parse OpenAI request: %w", err)
+	}
+	return transformOpenAIRequest(&openaiReq, mappedModel, sessionID, signatureCache)
+}
+
+// transformOpenAIRequest builds the Gemini request from a parsed OpenAI request
+// (shared with the Codex transform, which converts Responses API input to chat messages first)
+func transformOpenAIRequest(
+	openaiReq *OpenAIChatRequest,
+	mappedModel string,
+	sessionID string,
+	signatureCache *SignatureCache,
+) (geminiReqBody []byte, hasThinking bool, err error) {
+	// 2. Resolve thinking (reasoning_effort) for the target model
+	thinkingBudget := resolveOpenAIThinkingBudget(openaiReq, mappedModel)
+	hasThinking = thinkingBudget > 0
+
+	geminiReq := make(map[string]interface{})
+
+	// 3. System instruction (system + developer messages, with Antigravity identity)
+	if systemInstruction := buildSystemInstruction(&ClaudeRequest{System: extractOpenAISystemText(openaiReq.Messages)}, mappedModel); systemInstruction != nil {
+		geminiReq["systemInstruction"] = systemInstruction
+	}
+
+	// 4. Message contents
+	contents := buildOpenAIContents(openaiReq.Messages, mappedModel, sessionID, signatureCache)
+	if len(contents) == 0 {
+		return nil, hasThinking, fmt.Errorf("request has no messages")
+	}
+	geminiReq["contents"] = contents
+
+	// 5. Tools: reuse the Claude tool builder (schema cleaning, web search detection)
+	if tools := buildTools(&ClaudeRequest{Tools: openAIToolsAsClaudeTools(openaiReq.Tools)}); tools != nil {
+		geminiReq["tools"] = tools
+		geminiReq["toolConfig"] = buildOpenAIToolConfig(openaiReq.ToolChoice)
+	}
+
+	// 6. Generation config
+	geminiReq["generationConfig"] = buildOpenAIGenerationConfig(openaiReq, thinkingBudget)
+
+	// 7. Safety Settings (configurable via environment)
+	geminiReq["safetySettings"] = BuildSafetySettingsMap(GetSafetyThresholdFromEnv())
+
+	// 8. Deep clean [undefined] strings (Cherry Studio injection fix)
+	deepCleanUndefined(geminiReq)
+
+	geminiReqBody, err = json.Marshal(geminiReq)
+	if err != nil {
+		return nil, hasThinking, err
+	}
+	return geminiReqBody, hasThinking, nil
+}
+
+// OpenAIChatRequest represents an OpenAI Chat Completions request
+type OpenAIChatRequest struct {
+	Model               string                `json:"model"`
+	Messages            []OpenAIChatMessage   `json:"messages"`
+	Tools               []OpenAITool          `json:"tools,omitempty"`
+	ToolChoice          interface{}           `json:"tool_choice,omitempty"` // "none" | "auto" | "required" | {"type":"function",...}
+	Temperature         *float64              `json:"temperature,omitempty"`
+	TopP                *float64              `json:"top_p,omitempty"`
+	MaxTokens           int                   `json:"max_tokens,omitempty"`
+	MaxCompletionTokens int                   `json:"max_completion_tokens,omitempty"`
+	Stop                interface{}           `json:"stop,omitempty"` // string or []string
+	N                   int                   `json:"n,omitempty"`
+	Seed                *int64                `json:"seed,omitempty"`
+	PresencePenalty     *float64              `json:"presence_penalty,omitempty"`
+	FrequencyPenalty    *float64              `json:"frequency_penalty,omitempty"`
+	ResponseFormat      *OpenAIResponseFormat `json:"response_format,omitempty"`
+	ReasoningEffort     string                `json:"reasoning_effort,omitempty"` // "minimal", "low", "medium", "high"
+	Stream              bool                  `json:"stream,omitempty"`
+	User                string                `json:"user,omitempty"`
+}
+
+// OpenAIChatMessage represents a message in OpenAI format
+type OpenAIChatMessage struct {
+	Role       string           `json:"role"`
+	Content    interface{}      `json:"content"` // string or []content part
+	Name       string           `json:"name,omitempty"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIToolCall represents a tool call made by the assistant
+type OpenAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// OpenAITool represents a tool definition in OpenAI format
+type OpenAITool struct {
+	Type     string          `json:"type"` // "function", or built-in tools like "web_search_preview"
+	Function *OpenAIFunction `json:"function,omitempty"`
+}
+
+// OpenAIFunction represents a function tool definition
+type OpenAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// OpenAIResponseFormat represents response_format
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"` // "text", "json_object", "json_schema"
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema represents response_format.json_schema
+type OpenAIJSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+}
+
+// extractOpenAISystemText joins system and developer messages
+func extractOpenAISystemText(messages []OpenAIChatMessage) string {
+	var texts []string
+	for _, msg := range messages {
+		if msg.Role != "system" && msg.Role != "developer" {
+			continue
+		}
+		if text := strings.TrimSpace(openAIContentText(msg.Content)); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// buildOpenAIContents converts OpenAI messages to Gemini contents
+func buildOpenAIContents(messages []OpenAIChatMessage, mappedModel, sessionID string, signatureCache *SignatureCache) []map[string]interface{} {
+	contents := []map[string]interface{}{}
+	toolIDToName := make(map[string]string)
+
+	for _, msg := range messages {
+		var parts []map[string]interface{}
+		role := "user"
+
+		switch msg.Role {
+		case "system", "developer":
+			// Already merged into systemInstruction
+			continue
+
+		case "assistant":
+			role = "model"
+			if text := openAIContentText(msg.Content); strings.TrimSpace(text) != "" {
+				parts = append(parts, map[string]interface{}{"text": text})
+			}
+			for _, tc := range msg.ToolCalls {
+				toolIDToName[tc.ID] = tc.Function.Name
+				parts = append(parts, buildOpenAIFunctionCallPart(tc, mappedModel, sessionID, signatureCache))
+			}
+
+		case "tool", "function":
+			name := toolIDToName[msg.ToolCallID]
+			if name == "" {
+				name = msg.Name
+			}
+			if name == "" {
+				name = msg.ToolCallID
+			}
+			result := openAIContentText(msg.Content)
+			if strings.TrimSpace(result) == "" {
+				result = "Command executed successfully."
+			}
+			functionResponse := map[string]interface{}{
+				"name":     name,
+				"response": map[string]interface{}{"result": result},
+			}
+			if msg.ToolCallID != "" {
+				functionResponse["id"] = msg.ToolCallID
+			}
+			parts = append(parts, map[string]interface{}{"functionResponse": functionResponse})
+
+		default: // user
+			parts = buildOpenAIUserParts(msg.Content)
+		}
+
+		if len(parts) == 0 {
+			continue
+		}
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": parts,
+		})
+	}
+
+	// Gemini API strictly requires alternating user/model roles
+	return mergeAdjacentRoles(contents)
+}
+
+// buildOpenAIFunctionCallPart converts an assistant tool call, recovering its thought signature
+// OpenAI clients never send signatures back, so only the cache layers apply
+func buildOpenAIFunctionCallPart(tc OpenAIToolCall, mappedModel, sessionID string, signatureCache *SignatureCache) map[string]interface{} {
+	args := map[string]interface{}{}
+	if strings.TrimSpace(tc.Function.Arguments) != "" {
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			log.Printf("[Antigravity] Invalid tool call arguments for %s, sending empty args: %v", tc.Function.Name, err)
+			args = map[string]interface{}{}
+		}
+	}
+	CleanJSONSchema(args)
+
+	part := map[string]interface{}{
+		"functionCall": map[string]interface{}{
+			"name": tc.Function.Name,
+			"args": args,
+			"id":   tc.ID,
+		},
+	}
+
+	signature := ""
+	if signatureCache != nil {
+		signature = signatureCache.GetToolSignature(tc.ID)
+	}
+	if signature == "" {
+		signature = GetThoughtSignature(sessionID, mappedModel)
+	}
+	if HasValidSignature(signature) {
+		part["thoughtSignature"] = signature
+	}
+	return part
+}
+
+// buildOpenAIUserParts converts user content (string or text/image/audio/file parts)
+func buildOpenAIUserParts(content interface{}) []map[string]interface{} {
+	if text, ok := content.(string); ok {
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return []map[string]interface{}{{"text": text}}
+	}
+
+	items, ok := content.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var parts []map[string]interface{}
+	for _, item := range items {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch block["type"] {
+		case "text":
+			if text, _ := block["text"].(string); text != "" {
+				parts = append(parts, map[string]interface{}{"text": text})
+			}
+		case "image_url":
+			var url string
+			switch v := block["image_url"].(type) {
+			case string:
+				url = v
+			case map[string]interface{}:
+				url, _ = v["url"].(string)
+			}
+			if part := openAIImagePart(url); part != nil {
+				parts = append(parts, part)
+			}
+		case "input_audio":
+			if audio, ok := block["input_audio"].(map[string]interface{}); ok {
+				data, _ := audio["data"].(string)
+				format, _ := audio["format"].(string)
+				if data != "" {
+					parts = append(parts, map[string]interface{}{
+						"inlineData": map[string]interface{}{"mimeType": "audio/" + format, "data": data},
+					})
+				}
+			}
+		case "file":
+			if file, ok := block["file"].(map[string]interface{}); ok {
+				fileData, _ := file["file_data"].(string)
+				if part := openAIImagePart(fileData); part != nil {
+					parts = append(parts, part)
+				}
+			}
+		}
+	}
+	return parts
+}
+
+// openAIImagePart converts a data URI to inlineData and a remote URL to fileData
+func openAIImagePart(url string) map[string]interface{} {
+	if url == "" {
+		return nil
+	}
+	if strings.HasPrefix(url, "data:") {
+		// data:image/png;base64,xxxx
+		meta, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+		if !ok || data == "" {
+			return nil
+		}
+		mimeType := strings.TrimSuffix(meta, ";base64")
+		if mimeType == "" {
+			mimeType = "image/png"
+		}
+		return map[string]interface{}{
+			"inlineData": map[string]interface{}{"mimeType": mimeType, "data": data},
+		}
+	}
+
+	mimeType := mime.TypeByExtension(path.Ext(strings.SplitN(url, "?", 2)[0]))
+	if mimeType == "" {
+		mimeType = "image/jpeg"
+	}
+	return map[string]interface{}{
+		"fileData": map[string]interface{}{"mimeType": mimeType, "fileUri": url},
+	}
+}
+
+// openAIContentText extracts the text of string or array content
+func openAIContentText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var texts []string
+		for _, item := range c {
+			if block, ok := item.(map[string]interface{}); ok {
+				if text, ok := block["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, "\n")
+	case nil:
+		return ""
+	default:
+		if data, err := json.Marshal(c); err == nil {
+			return string(data)
+		}
+		return ""
+	}
+}
+
+// openAIToolsAsClaudeTools adapts OpenAI tool definitions for buildTools
+func openAIToolsAsClaudeTools(tools []OpenAITool) []ClaudeTool {
+	var result []ClaudeTool
+	for _, tool := range tools {
+		if tool.Function == nil {
+			// Built-in tools, e.g. "web_search_preview"
+			if strings.HasPrefix(tool.Type, "web_search") {
+				result = append(result, ClaudeTool{Type: "web_search"})
+			}
+			continue
+		}
+		result = append(result, ClaudeTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: tool.Function.Parameters,
+		})
+	}
+	return result
+}
+
+// buildOpenAIToolConfig maps tool_choice to functionCallingConfig
+func buildOpenAIToolConfig(toolChoice interface{}) map[string]interface{} {
+	config := map[string]interface{}{"mode": "VALIDATED"}
+	switch choice := toolChoice.(type) {
+	case string:
+		switch choice {
+		case "none":
+			config["mode"] = "NONE"
+		case "required":
+			config["mode"] = "ANY"
+		}
+	case map[string]interface{}:
+		if fn, ok := choice["function"].(map[string]interface{}); ok {
+			if name, _ := fn["name"].(string); name != "" {
+				config["mode"] = "ANY"
+				config["allowedFunctionNames"] = []string{name}
+			}
+		}
+	}
+	return map[string]interface{}{"functionCallingConfig": config}
+}
+
+// OpenAI reasoning_effort -> Gemini thinkingBudget
+var openAIThinkingBudgets = map[string]int{
+	"minimal": 1024,
+	"low":     4096,
+	"medium":  8192,
+	"high":    24576,
+}
+
+// resolveOpenAIThinkingBudget returns the thinking budget for the request, 0 when thinking is off
+func resolveOpenAIThinkingBudget(req *OpenAIChatRequest, mappedModel string) int {
+	effort := strings.ToLower(req.ReasoningEffort)
+	if effort == "none" || !openAIModelSupportsThinking(mappedModel) {
+		return 0
+	}
+	if effort == "" {
+		// Thinking models think by default (like the Claude path)
+		if !shouldEnableThinkingByDefault(mappedModel) {
+			return 0
+		}
+		effort = "medium"
+	}
+	budget, ok := openAIThinkingBudgets[effort]
+	if !ok {
+		budget = openAIThinkingBudgets["medium"]
+	}
+	// Flash models have a thinking budget limit of 24576
+	if isFlashModel(mappedModel) && budget > 24576 {
+		budget = 24576
+	}
+	return budget
+}
+
+// openAIModelSupportsThinking reports whether the target model accepts a thinkingConfig
+// Unlike Claude requests, OpenAI history carries no thinking blocks, so native Gemini
+// thinking models are safe to enable too
+func openAIModelSupportsThinking(mappedModel string) bool {
+	model := strings.ToLower(mappedModel)
+	if strings.Contains(model, "image") {
+		return false
+	}
+	return TargetModelSupportsThinking(model) ||
+		strings.HasPrefix(model, "gemini-2.5-") ||
+		strings.HasPrefix(model, "gemini-3")
+}
+
+// buildOpenAIGenerationConfig builds Gemini generationConfig from an OpenAI request
+func buildOpenAIGenerationConfig(req *OpenAIChatRequest, thinkingBudget int) map[string]interface{} {
+	config := make(map[string]interface{})
+
+	// 1. Thinking
+	if thinkingBudget > 0 {
+		config["thinkingConfig"] = map[string]interface{}{
+			"includeThoughts": true,
+			"thinkingBudget":  thinkingBudget,
+		}
+	}
+
+	// 2. Basic parameters
+	if req.Temperature != nil {
+		config["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		config["topP"] = *req.TopP
+	}
+	if req.N > 1 {
+		config["candidateCount"] = req.N
+	}
+	if req.Seed != nil {
+		config["seed"] = *req.Seed
+	}
+	if req.PresencePenalty != nil {
+		config["presencePenalty"] = *req.PresencePenalty
+	}
+	if req.FrequencyPenalty != nil {
+		config["frequencyPenalty"] = *req.FrequencyPenalty
+	}
+
+	// 3. Max output tokens (must leave room for the thinking budget)
+	maxTokens := req.MaxCompletionTokens
+	if maxTokens == 0 {
+		maxTokens = req.MaxTokens
+	}
+	if maxTokens == 0 {
+		maxTokens = 64000
+	} else if thinkingBudget > 0 && maxTokens <= thinkingBudget {
+		maxTokens += thinkingBudget
+	}
+	config["maxOutputTokens"] = maxTokens
+
+	// 4. Stop sequences
+	switch stop := req.Stop.(type) {
+	case string:
+		config["stopSequences"] = []string{stop}
+	case []interface{}:
+		var sequences []string
+		for _, s := range stop {
+			if str, ok := s.(string); ok && str != "" {
+				sequences = append(sequences, str)
+			}
+		}
+		config["stopSequences"] = sequences
+	default:
+		config["stopSequences"] = DefaultStopSequences
+	}
+
+	// 5. Structured output
+	if req.ResponseFormat != nil {
+		switch req.ResponseFormat.Type {
+		case "json_object":
+			config["responseMimeType"] = "application/json"
+		case "json_schema":
+			config["responseMimeType"] = "application/json"
+			if req.ResponseFormat.JSONSchema != nil && req.ResponseFormat.JSONSchema.Schema != nil {
+				schema := deepCopyMap(req.ResponseFormat.JSONSchema.Schema)
+				CleanJSONSchema(schema)
+				config["responseSchema"] = schema
+			}
+		}
+	}
+
+	return config
+}
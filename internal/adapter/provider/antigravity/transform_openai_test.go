@@ -0,0 +1,277 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeJSON unmarshals data into generic JSON values so outputs can be compared with reflect.DeepEqual
+func decodeJSON(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON %q: %v", data, err)
+	}
+	return v
+}
+
+// transformField runs a request transform and returns one top-level field of the Gemini request
+func transformField(t *testing.T, body []byte, field string) interface{} {
+	t.Helper()
+	out, _, err := TransformOpenAIToGemini(body, "gemini-2.5-flash", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decodeJSON(t, out).(map[string]interface{})[field]
+}
+
+func TestTransformOpenAIToGemini_Contents(t *testing.T) {
+	ClearThoughtSignature()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "plain text",
+			body: `{"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"}]}`,
+			want: `[{"role":"user","parts":[{"text":"hi"}]},{"role":"model","parts":[{"text":"hello"}]}]`,
+		},
+		{
+			name: "system and developer messages move to systemInstruction",
+			body: `{"messages":[{"role":"system","content":"be brief"},{"role":"developer","content":"no markdown"},{"role":"user","content":"hi"}]}`,
+			want: `[{"role":"user","parts":[{"text":"hi"}]}]`,
+		},
+		{
+			name: "adjacent user messages are merged",
+			body: `{"messages":[{"role":"user","content":"a"},{"role":"user","content":"b"}]}`,
+			want: `[{"role":"user","parts":[{"text":"a"},{"text":"b"}]}]`,
+		},
+		{
+			name: "tool call and result",
+			body: `{"messages":[
+				{"role":"user","content":"weather?"},
+				{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]},
+				{"role":"tool","tool_call_id":"call_1","content":"sunny"}]}`,
+			want: `[
+				{"role":"user","parts":[{"text":"weather?"}]},
+				{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"},"id":"call_1"}}]},
+				{"role":"user","parts":[{"functionResponse":{"name":"get_weather","response":{"result":"sunny"},"id":"call_1"}}]}]`,
+		},
+		{
+			name: "parallel tool calls with text and an empty result",
+			body: `{"messages":[
+				{"role":"user","content":"go"},
+				{"role":"assistant","content":"running both","tool_calls":[
+					{"id":"call_a","type":"function","function":{"name":"ls","arguments":""}},
+					{"id":"call_b","type":"function","function":{"name":"pwd","arguments":"{}"}}]},
+				{"role":"tool","tool_call_id":"call_a","content":""},
+				{"role":"tool","tool_call_id":"call_b","content":[{"type":"text","text":"/tmp"}]}]}`,
+			want: `[
+				{"role":"user","parts":[{"text":"go"}]},
+				{"role":"model","parts":[
+					{"text":"running both"},
+					{"functionCall":{"name":"ls","args":{},"id":"call_a"}},
+					{"functionCall":{"name":"pwd","args":{},"id":"call_b"}}]},
+				{"role":"user","parts":[
+					{"functionResponse":{"name":"ls","response":{"result":"Command executed successfully."},"id":"call_a"}},
+					{"functionResponse":{"name":"pwd","response":{"result":"/tmp"},"id":"call_b"}}]}]`,
+		},
+		{
+			name: "invalid tool call arguments are sent as empty args",
+			body: `{"messages":[{"role":"assistant","tool_calls":[{"id":"call_x","type":"function","function":{"name":"f","arguments":"{not json"}}]}]}`,
+			want: `[{"role":"model","parts":[{"functionCall":{"name":"f","args":{},"id":"call_x"}}]}]`,
+		},
+		{
+			name: "legacy function message uses its name",
+			body: `{"messages":[{"role":"function","name":"lookup","content":"42"}]}`,
+			want: `[{"role":"user","parts":[{"functionResponse":{"name":"lookup","response":{"result":"42"}}}]}]`,
+		},
+		{
+			name: "multi-part user content",
+			body: `{"messages":[{"role":"user","content":[
+				{"type":"text","text":"describe"},
+				{"type":"image_url","image_url":{"url":"data:image/png;base64,AAAA"}},
+				{"type":"image_url","image_url":"https://example.com/cat.jpg?size=large"},
+				{"type":"input_audio","input_audio":{"data":"BBBB","format":"wav"}},
+				{"type":"file","file":{"file_data":"data:application/pdf;base64,CCCC"}},
+				{"type":"text","text":""}]}]}`,
+			want: `[{"role":"user","parts":[
+				{"text":"describe"},
+				{"inlineData":{"mimeType":"image/png","data":"AAAA"}},
+				{"fileData":{"mimeType":"image/jpeg","fileUri":"https://example.com/cat.jpg?size=large"}},
+				{"inlineData":{"mimeType":"audio/wav","data":"BBBB"}},
+				{"inlineData":{"mimeType":"application/pdf","data":"CCCC"}}]}]`,
+		},
+		{
+			name: "assistant content parts are joined",
+			body: `{"messages":[{"role":"assistant","content":[{"type":"text","text":"one"},{"type":"text","text":"two"}]}]}`,
+			want: `[{"role":"model","parts":[{"text":"one\ntwo"}]}]`,
+		},
+	}
+	for _, tt := range tests {
+		got := transformField(t, []byte(tt.body), "contents")
+		if want := decodeJSON(t, []byte(tt.want)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: contents = %v, want %v", tt.name, got, want)
+		}
+	}
+}
+
+func TestTransformOpenAIToGemini_NoMessages(t *testing.T) {
+	if _, _, err := TransformOpenAIToGemini([]byte(`{"messages":[{"role":"system","content":"x"}]}`), "gemini-2.5-flash", "", nil); err == nil {
+		t.Error("expected an error for a request without messages")
+	}
+	if _, _, err := TransformOpenAIToGemini([]byte(`{`), "gemini-2.5-flash", "", nil); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestTransformOpenAIToGemini_SystemInstruction(t *testing.T) {
+	body := `{"messages":[{"role":"system","content":"be brief"},{"role":"developer","content":[{"type":"text","text":"no markdown"}]},{"role":"user","content":"hi"}]}`
+	got, _ := transformField(t, []byte(body), "systemInstruction").(map[string]interface{})
+	parts, _ := got["parts"].([]interface{})
+	if len(parts) < 2 {
+		t.Fatalf("systemInstruction parts = %v, want identity followed by the user prompt", parts)
+	}
+	if text := parts[1].(map[string]interface{})["text"]; text != "be brief\n\nno markdown" {
+		t.Errorf("system prompt = %q", text)
+	}
+}
+
+func TestTransformOpenAIToGemini_Tools(t *testing.T) {
+	tests := []struct {
+		name       string
+		tools      string
+		toolChoice string
+		wantNames  []string
+		wantConfig string
+	}{
+		{
+			name:       "auto",
+			tools:      `[{"type":"function","function":{"name":"get_weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}}]`,
+			toolChoice: `"auto"`,
+			wantNames:  []string{"get_weather"},
+			wantConfig: `{"functionCallingConfig":{"mode":"VALIDATED"}}`,
+		},
+		{
+			name:       "none",
+			tools:      `[{"type":"function","function":{"name":"a"}}]`,
+			toolChoice: `"none"`,
+			wantNames:  []string{"a"},
+			wantConfig: `{"functionCallingConfig":{"mode":"NONE"}}`,
+		},
+		{
+			name:       "required",
+			tools:      `[{"type":"function","function":{"name":"a"}},{"type":"function","function":{"name":"b"}}]`,
+			toolChoice: `"required"`,
+			wantNames:  []string{"a", "b"},
+			wantConfig: `{"functionCallingConfig":{"mode":"ANY"}}`,
+		},
+		{
+			name:       "named function",
+			tools:      `[{"type":"function","function":{"name":"a"}},{"type":"function","function":{"name":"b"}}]`,
+			toolChoice: `{"type":"function","function":{"name":"b"}}`,
+			wantNames:  []string{"a", "b"},
+			wantConfig: `{"functionCallingConfig":{"mode":"ANY","allowedFunctionNames":["b"]}}`,
+		},
+		{
+			name:       "no tool choice",
+			tools:      `[{"type":"function","function":{"name":"a"}}]`,
+			toolChoice: `null`,
+			wantNames:  []string{"a"},
+			wantConfig: `{"functionCallingConfig":{"mode":"VALIDATED"}}`,
+		},
+	}
+	for _, tt := range tests {
+		body := `{"messages":[{"role":"user","content":"hi"}],"tools":` + tt.tools + `,"tool_choice":` + tt.toolChoice + `}`
+		out, _, err := TransformOpenAIToGemini([]byte(body), "gemini-2.5-flash", "", nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		req := decodeJSON(t, out).(map[string]interface{})
+
+		var names []string
+		for _, tool := range req["tools"].([]interface{}) {
+			for _, decl := range tool.(map[string]interface{})["functionDeclarations"].([]interface{}) {
+				names = append(names, decl.(map[string]interface{})["name"].(string))
+			}
+		}
+		if !reflect.DeepEqual(names, tt.wantNames) {
+			t.Errorf("%s: function declarations = %v, want %v", tt.name, names, tt.wantNames)
+		}
+		if want := decodeJSON(t, []byte(tt.wantConfig)); !reflect.DeepEqual(req["toolConfig"], want) {
+			t.Errorf("%s: toolConfig = %v, want %v", tt.name, req["toolConfig"], want)
+		}
+	}
+}
+
+func TestResolveOpenAIThinkingBudget(t *testing.T) {
+	tests := []struct {
+		model  string
+		effort string
+		want   int
+	}{
+		{"gemini-2.5-flash", "low", 4096},
+		{"gemini-2.5-flash", "high", 24576},
+		{"gemini-2.5-flash", "none", 0},
+		{"gemini-2.5-flash", "", 0},
+		{"gemini-2.5-flash-image", "high", 0},
+		{"gemini-3-pro", "minimal", 1024},
+		{"claude-sonnet-4-5", "medium", 8192},
+		{"claude-sonnet-4-5", "extreme", 8192},
+		{"claude-opus-4-5-thinking", "", 8192},
+		{"gpt-4o", "high", 0},
+	}
+	for _, tt := range tests {
+		got := resolveOpenAIThinkingBudget(&OpenAIChatRequest{ReasoningEffort: tt.effort}, tt.model)
+		if got != tt.want {
+			t.Errorf("resolveOpenAIThinkingBudget(%q, %q) = %d, want %d", tt.model, tt.effort, got, tt.want)
+		}
+	}
+}
+
+func TestTransformOpenAIToGemini_GenerationConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want map[string]interface{}
+	}{
+		{
+			name: "max tokens leave room for thinking",
+			body: `{"messages":[{"role":"user","content":"hi"}],"reasoning_effort":"low","max_tokens":1000,"stop":"END"}`,
+			want: map[string]interface{}{
+				"thinkingConfig":  map[string]interface{}{"includeThoughts": true, "thinkingBudget": float64(4096)},
+				"maxOutputTokens": float64(5096),
+				"stopSequences":   []interface{}{"END"},
+			},
+		},
+		{
+			name: "max_completion_tokens wins over max_tokens",
+			body: `{"messages":[{"role":"user","content":"hi"}],"max_tokens":100,"max_completion_tokens":200,"stop":["a","","b"],"temperature":0.2,"n":2}`,
+			want: map[string]interface{}{
+				"maxOutputTokens": float64(200),
+				"stopSequences":   []interface{}{"a", "b"},
+				"temperature":     0.2,
+				"candidateCount":  float64(2),
+			},
+		},
+		{
+			name: "json schema response format",
+			body: `{"messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"out","schema":{"type":"object","properties":{"n":{"type":"integer"}}}}}}`,
+			want: map[string]interface{}{
+				"responseMimeType": "application/json",
+				"responseSchema":   map[string]interface{}{"type": "object", "properties": map[string]interface{}{"n": map[string]interface{}{"type": "integer"}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		config := transformField(t, []byte(tt.body), "generationConfig").(map[string]interface{})
+		for key, want := range tt.want {
+			if !reflect.DeepEqual(config[key], want) {
+				t.Errorf("%s: generationConfig.%s = %v, want %v", tt.name, key, config[key], want)
+			}
+		}
+	}
+}
@@ -48,7 +48,7 @@ func TransformClaudeToGemini(
 
 	// 7. Calculate final thinking mode state (before building request)
 	// Reference: Antigravity-Manager's thinking mode resolution (line 170-251)
-	hasThinking = calculateFinalThinkingState(&claudeReq, mappedModel, signatureCache)
+	hasThinking = calculateFinalThinkingState(&claudeReq, mappedModel, sessionID, signatureCache)
 
 	// 8. Build Gemini request
 	geminiReq := make(map[string]interface{})
@@ -449,7 +449,7 @@ func detectWebSearchTool(claudeReq *ClaudeRequest) bool {
 // calculateFinalThinkingState determines the final thinking mode state
 // after all checks (model defaults, target support, history compatibility)
 // Reference: Antigravity-Manager's thinking mode resolution (line 170-251)
-func calculateFinalThinkingState(claudeReq *ClaudeRequest, mappedModel string, signatureCache *SignatureCache) bool {
+func calculateFinalThinkingState(claudeReq *ClaudeRequest, mappedModel, sessionID string, signatureCache *SignatureCache) bool {
 	// 1. Check explicit thinking config first
 	thinkingRequested := claudeReq.Thinking != nil && claudeReq.Thinking.Type == "enabled"
 
@@ -479,7 +479,7 @@ func calculateFinalThinkingState(claudeReq *ClaudeRequest, mappedModel string, s
 	// Reference: Antigravity-Manager's signature validation (line 204-251)
 	// This prevents Gemini 3 Pro from rejecting requests due to missing thought_signature
 	if thinkingRequested {
-		globalSig := GetThoughtSignature()
+		globalSig := GetThoughtSignature(sessionID, mappedModel)
 
 		// Check if there are thinking blocks in history
 		hasThinkingHistory := hasThinkingInMessages(claudeReq.Messages)
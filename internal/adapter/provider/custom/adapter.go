@@ -17,6 +17,7 @@ import (
 	"github.com/awsl-project/maxx/internal/converter"
 	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/pricing"
+	"github.com/awsl-project/maxx/internal/tracing"
 	"github.com/awsl-project/maxx/internal/usage"
 )
 
@@ -24,6 +25,12 @@ func init() {
 	provider.RegisterAdapterFactory("custom", NewAdapter)
 }
 
+// upstreamClient traces every upstream call, propagates traceparent
+// and enforces the RetryConfig timeouts (none by default)
+var upstreamClient = &http.Client{
+	Transport: tracing.NewTransport(provider.NewTimeoutTransport(http.DefaultTransport.(*http.Transport))),
+}
+
 type CustomAdapter struct {
 	provider  *domain.Provider
 	converter *converter.Registry
@@ -43,7 +50,13 @@ func (a *CustomAdapter) SupportedClientTypes() []domain.ClientType {
 	return a.provider.SupportedClientTypes
 }
 
-func (a *CustomAdapter) Execute(ctx context.Context, w http.ResponseWriter, req *http.Request, provider *domain.Provider) error {
+func (a *CustomAdapter) Execute(ctx context.Context, w http.ResponseWriter, req *http.Request, p *domain.Provider) error {
+	ctx, timer := provider.WithUpstreamTimeouts(ctx, provider.Timeouts{})
+	defer timer.Stop()
+	return timer.Wrap(a.execute(ctx, w, req, p))
+}
+
+func (a *CustomAdapter) execute(ctx context.Context, w http.ResponseWriter, req *http.Request, provider *domain.Provider) error {
 	clientType := ctxutil.GetClientType(ctx)
 	mappedModel := ctxutil.GetMappedModel(ctx)
 	requestBody := ctxutil.GetRequestBody(ctx)
@@ -76,6 +89,13 @@ func (a *CustomAdapter) Execute(ctx context.Context, w http.ResponseWriter, req
 
 	upstreamURL := buildUpstreamURL(baseURL, requestURI)
 
+	// Send the mapped model so route mappings and model fallback reach the upstream
+	if mappedModel != "" {
+		if body, err := updateModelInBody(requestBody, mappedModel, targetType); err == nil {
+			requestBody = body
+		}
+	}
+
 	// Create upstream request
 	upstreamReq, err := http.NewRequestWithContext(ctx, "POST", upstreamURL, bytes.NewReader(requestBody))
 	if err != nil {
@@ -83,8 +103,11 @@ func (a *CustomAdapter) Execute(ctx context.Context, w http.ResponseWriter, req
 	}
 
 	// Forward original headers (filtered) - preserves anthropic-version, anthropic-beta, user-agent, etc.
-	originalHeaders := ctxutil.GetRequestHeaders(ctx)
+	// Cloned: hedge legs run concurrently and must not write into the shared client headers
+	originalHeaders := ctxutil.GetRequestHeaders(ctx).Clone()
 	upstreamReq.Header = originalHeaders
+	// The body may have been rewritten above, so the client's length no longer applies
+	upstreamReq.Header.Del("Content-Length")
 
 	// Override auth headers with provider's credentials
 	if a.provider.Config.Custom.APIKey != "" {
@@ -102,8 +125,7 @@ func (a *CustomAdapter) Execute(ctx context.Context, w http.ResponseWriter, req
 	}
 
 	// Execute request
-	client := &http.Client{}
-	resp, err := client.Do(upstreamReq)
+	resp, err := upstreamClient.Do(upstreamReq)
 	if err != nil {
 		proxyErr := domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, true, "failed to connect to upstream")
 		proxyErr.IsNetworkError = true // Mark as network error (connection timeout, DNS failure, etc.)
@@ -151,6 +173,62 @@ func (a *CustomAdapter) Execute(ctx context.Context, w http.ResponseWriter, req
 	return a.handleNonStreamResponse(ctx, w, resp, clientType, targetType, needsConversion)
 }
 
+// CountTokens forwards a count_tokens request to providers that speak Claude natively
+func (a *CustomAdapter) CountTokens(ctx context.Context, provider *domain.Provider) (int, error) {
+	if ctxutil.GetClientType(ctx) != domain.ClientTypeClaude || !a.supportsClientType(domain.ClientTypeClaude) {
+		return 0, domain.ErrUnsupportedFormat
+	}
+
+	requestBody := ctxutil.GetRequestBody(ctx)
+	if mappedModel := ctxutil.GetMappedModel(ctx); mappedModel != "" {
+		if body, err := updateModelInBody(requestBody, mappedModel, domain.ClientTypeClaude); err == nil {
+			requestBody = body
+		}
+	}
+
+	upstreamURL := buildUpstreamURL(a.getBaseURL(domain.ClientTypeClaude), ctxutil.GetRequestURI(ctx))
+	upstreamReq, err := http.NewRequestWithContext(ctx, "POST", upstreamURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return 0, domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, false, "failed to create upstream request")
+	}
+	// Cloned so the provider key never ends up in the client headers kept for logging and retries
+	upstreamReq.Header = ctxutil.GetRequestHeaders(ctx).Clone()
+	upstreamReq.Header.Del("Content-Length")
+	if a.provider.Config.Custom.APIKey != "" {
+		setAuthHeader(upstreamReq, domain.ClientTypeClaude, a.provider.Config.Custom.APIKey)
+	}
+
+	resp, err := upstreamClient.Do(upstreamReq)
+	if err != nil {
+		proxyErr := domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, true, "failed to connect to upstream")
+		proxyErr.IsNetworkError = true
+		return 0, proxyErr
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, true, "failed to read upstream response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		proxyErr := domain.NewProxyErrorWithMessage(
+			fmt.Errorf("upstream error: %s", string(body)),
+			isRetryableStatusCode(resp.StatusCode),
+			fmt.Sprintf("upstream returned status %d", resp.StatusCode),
+		)
+		proxyErr.HTTPStatusCode = resp.StatusCode
+		return 0, proxyErr
+	}
+
+	var result struct {
+		InputTokens *int `json:"input_tokens"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.InputTokens == nil {
+		return 0, domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "invalid count_tokens response")
+	}
+	return *result.InputTokens, nil
+}
+
 func (a *CustomAdapter) supportsClientType(ct domain.ClientType) bool {
 	for _, supported := range a.provider.SupportedClientTypes {
 		if supported == ct {
@@ -194,7 +272,7 @@ func (a *CustomAdapter) handleNonStreamResponse(ctx context.Context, w http.Resp
 			attempt.Cache1hWriteCount = metrics.Cache1hCreationCount
 
 			// Calculate cost
-			attempt.Cost = pricing.GlobalCalculator().Calculate(ctxutil.GetMappedModel(ctx), metrics)
+			attempt.Cost = pricing.GlobalCalculator().Calculate(ctxutil.GetMappedModel(ctx), metrics, a.provider.Pricing)
 		}
 
 		// Broadcast attempt update with token info
@@ -205,7 +283,10 @@ func (a *CustomAdapter) handleNonStreamResponse(ctx context.Context, w http.Resp
 
 	var responseBody []byte
 	if needsConversion {
+		_, span := tracing.StartConversion(ctx, "converter.transform_response", string(targetType), string(clientType))
 		responseBody, err = a.converter.TransformResponse(targetType, clientType, body)
+		span.SetError(err)
+		span.End()
 		if err != nil {
 			return domain.NewProxyErrorWithMessage(domain.ErrFormatConversion, false, "failed to transform response")
 		}
@@ -256,8 +337,17 @@ func (a *CustomAdapter) handleStreamResponse(ctx context.Context, w http.Respons
 	}
 
 	var state *converter.TransformState
+	var convertedChunks, skippedChunks int
 	if needsConversion {
 		state = converter.NewTransformState()
+
+		// One span for the whole stream conversion, a span per chunk would drown the trace
+		_, span := tracing.StartConversion(ctx, "converter.transform_stream", string(targetType), string(clientType))
+		defer func() {
+			span.SetAttr("maxx.chunks", convertedChunks)
+			span.SetAttr("maxx.chunks_skipped", skippedChunks)
+			span.End()
+		}()
 	}
 
 	// Collect all SSE events for response body and token extraction
@@ -283,7 +373,7 @@ func (a *CustomAdapter) handleStreamResponse(ctx context.Context, w http.Respons
 				attempt.Cache1hWriteCount = metrics.Cache1hCreationCount
 
 				// Calculate cost
-				attempt.Cost = pricing.GlobalCalculator().Calculate(ctxutil.GetMappedModel(ctx), metrics)
+				attempt.Cost = pricing.GlobalCalculator().Calculate(ctxutil.GetMappedModel(ctx), metrics, a.provider.Pricing)
 			}
 			// Broadcast attempt update with token info
 			if bc := ctxutil.GetBroadcaster(ctx); bc != nil {
@@ -374,7 +464,9 @@ func (a *CustomAdapter) handleStreamResponse(ctx context.Context, w http.Respons
 				if needsConversion {
 					// Transform the chunk
 					transformed, transformErr := a.converter.TransformStreamChunk(targetType, clientType, []byte(line), state)
+					convertedChunks++
 					if transformErr != nil {
+						skippedChunks++
 						continue // Skip malformed chunks
 					}
 					output = transformed
@@ -457,25 +549,38 @@ func updateGeminiModelInPath(path string, newModel string) string {
 }
 
 func setAuthHeader(req *http.Request, clientType domain.ClientType, apiKey string) {
-	// Only update authentication headers that already exist in the request
-	// Do not create new headers - preserve the original request format
-
-	// Check which auth header the client used and update only that one
+	// Update the authentication headers the request already carries to
+	// preserve the original request format (proxy auth disabled)
 
+	found := false
 	if req.Header.Get("x-api-key") != "" {
 		// Claude-style auth
 		req.Header.Set("x-api-key", apiKey)
+		found = true
 	}
 	if req.Header.Get("Authorization") != "" {
 		// OpenAI/Codex-style auth
 		req.Header.Set("Authorization", "Bearer "+apiKey)
+		found = true
 	}
 	if req.Header.Get("x-goog-api-key") != "" {
 		// Gemini-style auth
 		req.Header.Set("x-goog-api-key", apiKey)
+		found = true
+	}
+	if found {
+		return
+	}
+
+	// The proxy strips the client's maxx key, so set the header the provider's format expects
+	switch clientType {
+	case domain.ClientTypeClaude:
+		req.Header.Set("x-api-key", apiKey)
+	case domain.ClientTypeGemini:
+		req.Header.Set("x-goog-api-key", apiKey)
+	default:
+		req.Header.Set("Authorization", "Bearer "+apiKey)
 	}
-	// If no auth header exists, don't create one
-	// The request will be sent as-is (useful for providers that use query params or other auth methods)
 }
 
 func isRetryableStatusCode(code int) bool {
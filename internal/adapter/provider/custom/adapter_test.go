@@ -0,0 +1,213 @@
+package custom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestCountTokens_LeavesClientHeadersUnchanged(t *testing.T) {
+	var upstreamKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamKey = r.Header.Get("x-api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"input_tokens":42}`))
+	}))
+	defer srv.Close()
+
+	p := &domain.Provider{
+		ID:                   1,
+		Type:                 "custom",
+		SupportedClientTypes: []domain.ClientType{domain.ClientTypeClaude},
+		Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			BaseURL: srv.URL,
+			APIKey:  "sk-provider",
+		}},
+	}
+	adapter, err := NewAdapter(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientHeaders := http.Header{}
+	clientHeaders.Set("x-api-key", "maxx-client-key")
+	clientHeaders.Set("Content-Length", "64")
+	clientHeaders.Set("anthropic-version", "2023-06-01")
+	before := clientHeaders.Clone()
+
+	ctx := context.Background()
+	ctx = ctxutil.WithClientType(ctx, domain.ClientTypeClaude)
+	ctx = ctxutil.WithRequestHeaders(ctx, clientHeaders)
+	ctx = ctxutil.WithRequestURI(ctx, "/v1/messages/count_tokens")
+	ctx = ctxutil.WithRequestBody(ctx, []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`))
+
+	tokens, err := adapter.(*CustomAdapter).CountTokens(ctx, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 42 {
+		t.Errorf("tokens = %d, want 42", tokens)
+	}
+	if upstreamKey != "sk-provider" {
+		t.Errorf("upstream saw x-api-key %q, want the provider key", upstreamKey)
+	}
+	for key, values := range before {
+		if got := clientHeaders.Values(key); len(got) != len(values) || got[0] != values[0] {
+			t.Errorf("client header %s = %q, want %q", key, got, values)
+		}
+	}
+	if len(clientHeaders) != len(before) {
+		t.Errorf("client headers = %v, want %v", clientHeaders, before)
+	}
+}
+
+func TestExecute_SendsMappedModel(t *testing.T) {
+	var upstreamModels []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		upstreamModels = append(upstreamModels, body.Model)
+		w.Header().Set("Content-Type", "application/json")
+		if body.Model != "claude-fallback" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"not_found_error","message":"model not found"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-fallback","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	p := &domain.Provider{
+		ID:                   1,
+		Type:                 "custom",
+		SupportedClientTypes: []domain.ClientType{domain.ClientTypeClaude},
+		Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			BaseURL: srv.URL,
+			APIKey:  "sk-provider",
+		}},
+	}
+	adapter, err := NewAdapter(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientHeaders := http.Header{}
+	clientHeaders.Set("x-api-key", "maxx-client-key")
+	ctx := context.Background()
+	ctx = ctxutil.WithClientType(ctx, domain.ClientTypeClaude)
+	ctx = ctxutil.WithRequestHeaders(ctx, clientHeaders)
+	ctx = ctxutil.WithRequestURI(ctx, "/v1/messages")
+	ctx = ctxutil.WithRequestBody(ctx, []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`))
+
+	// The executor falls back by retrying with the next model of the route
+	var lastErr error
+	var attempt *domain.ProxyUpstreamAttempt
+	for _, model := range []string{"claude-primary", "claude-fallback"} {
+		attempt = &domain.ProxyUpstreamAttempt{}
+		attemptCtx := ctxutil.WithUpstreamAttempt(ctxutil.WithMappedModel(ctx, model), attempt)
+		rec := httptest.NewRecorder()
+		lastErr = adapter.Execute(attemptCtx, rec, httptest.NewRequest(http.MethodPost, "/v1/messages", nil), p)
+		if lastErr == nil {
+			if rec.Code != http.StatusOK {
+				t.Errorf("response status = %d, want 200", rec.Code)
+			}
+			break
+		}
+		var proxyErr *domain.ProxyError
+		if !errors.As(lastErr, &proxyErr) || proxyErr.HTTPStatusCode != http.StatusNotFound {
+			t.Fatalf("%s: err = %v, want an upstream 404", model, lastErr)
+		}
+	}
+	if lastErr != nil {
+		t.Fatalf("fallback model failed: %v", lastErr)
+	}
+
+	if want := []string{"claude-primary", "claude-fallback"}; !reflect.DeepEqual(upstreamModels, want) {
+		t.Errorf("upstream models = %v, want %v", upstreamModels, want)
+	}
+	if attempt.RequestInfo == nil || !strings.Contains(attempt.RequestInfo.Body, `"model":"claude-fallback"`) {
+		t.Errorf("recorded request body does not carry the fallback model: %+v", attempt.RequestInfo)
+	}
+}
+
+func TestExecute_ProviderCredentials(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientType domain.ClientType
+		uri        string
+		apiKey     string
+		header     string
+		want       string
+	}{
+		{"claude", domain.ClientTypeClaude, "/v1/messages", "sk-provider", "x-api-key", "sk-provider"},
+		{"openai", domain.ClientTypeOpenAI, "/v1/chat/completions", "sk-provider", "Authorization", "Bearer sk-provider"},
+		{"codex", domain.ClientTypeCodex, "/responses", "sk-provider", "Authorization", "Bearer sk-provider"},
+		{"gemini", domain.ClientTypeGemini, "/v1beta/models/gemini-2.5-flash:generateContent", "sk-provider", "x-goog-api-key", "sk-provider"},
+		{"no provider key", domain.ClientTypeClaude, "/v1/messages", "", "", ""},
+	}
+	for _, tt := range tests {
+		var upstreamHeaders http.Header
+		var upstreamQuery string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			upstreamHeaders = r.Header.Clone()
+			upstreamQuery = r.URL.RawQuery
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		}))
+
+		p := &domain.Provider{
+			ID:                   1,
+			Type:                 "custom",
+			SupportedClientTypes: []domain.ClientType{tt.clientType},
+			Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+				BaseURL: srv.URL,
+				APIKey:  tt.apiKey,
+			}},
+		}
+		adapter, err := NewAdapter(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The proxy strips the maxx key after authentication, so no credential reaches the adapter
+		clientHeaders := http.Header{}
+		clientHeaders.Set("Content-Type", "application/json")
+		ctx := context.Background()
+		ctx = ctxutil.WithClientType(ctx, tt.clientType)
+		ctx = ctxutil.WithRequestHeaders(ctx, clientHeaders)
+		ctx = ctxutil.WithRequestURI(ctx, tt.uri)
+		ctx = ctxutil.WithRequestBody(ctx, []byte(`{"messages":[{"role":"user","content":"hi"}]}`))
+
+		err = adapter.Execute(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, tt.uri, nil), p)
+		srv.Close()
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, name := range []string{"Authorization", "x-api-key", "x-goog-api-key"} {
+			want := ""
+			if name == tt.header {
+				want = tt.want
+			}
+			if got := upstreamHeaders.Get(name); got != want {
+				t.Errorf("%s: upstream %s = %q, want %q", tt.name, name, got, want)
+			}
+		}
+		if upstreamQuery != "" {
+			t.Errorf("%s: upstream query = %q, want none", tt.name, upstreamQuery)
+		}
+		if len(clientHeaders) != 1 {
+			t.Errorf("%s: client headers were modified: %v", tt.name, clientHeaders)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// Timeouts bounds a single upstream attempt. Zero disables a limit.
+type Timeouts struct {
+	// Connect limits establishing the TCP connection
+	Connect time.Duration
+	// FirstByte limits the wait for the first response body byte
+	FirstByte time.Duration
+	// StreamIdle limits the gap between two body reads once data is flowing
+	StreamIdle time.Duration
+	// Total limits the whole attempt, including reading the body
+	Total time.Duration
+}
+
+// ResolveTimeouts returns the RetryConfig timeouts from ctx, using defaults for unset values
+func ResolveTimeouts(ctx context.Context, defaults Timeouts) Timeouts {
+	t := defaults
+	cfg := ctxutil.GetRetryConfig(ctx)
+	if cfg == nil {
+		return t
+	}
+	if cfg.ConnectTimeout > 0 {
+		t.Connect = cfg.ConnectTimeout
+	}
+	if cfg.FirstByteTimeout > 0 {
+		t.FirstByte = cfg.FirstByteTimeout
+	}
+	if cfg.StreamIdleTimeout > 0 {
+		t.StreamIdle = cfg.StreamIdleTimeout
+	}
+	if cfg.TotalTimeout > 0 {
+		t.Total = cfg.TotalTimeout
+	}
+	return t
+}
+
+type upstreamTimerKey struct{}
+
+// UpstreamTimer enforces Timeouts for one Execute call.
+// The first byte, idle and total limits cancel the returned context;
+// requests must go through a client built with NewTimeoutTransport for
+// the connect, first byte and idle limits to apply.
+type UpstreamTimer struct {
+	parent   context.Context
+	cancel   context.CancelCauseFunc
+	timeouts Timeouts
+
+	mu              sync.Mutex
+	cause           error
+	connectTimedOut bool
+	gotFirstByte    bool
+	stopped         bool
+	totalTimer      *time.Timer
+	firstByteTimer  *time.Timer
+	streamIdleTimer *time.Timer
+}
+
+// WithUpstreamTimeouts starts the timers for an upstream attempt.
+// Callers must Stop the timer and pass the adapter's result through Wrap.
+func WithUpstreamTimeouts(ctx context.Context, defaults Timeouts) (context.Context, *UpstreamTimer) {
+	t := &UpstreamTimer{
+		parent:   ctx,
+		timeouts: ResolveTimeouts(ctx, defaults),
+	}
+	ctx, t.cancel = context.WithCancelCause(ctx)
+	ctx = context.WithValue(ctx, upstreamTimerKey{}, t)
+
+	if t.timeouts.Total > 0 {
+		t.totalTimer = time.AfterFunc(t.timeouts.Total, func() { t.fire(domain.ErrTotalTimeout) })
+	}
+	if t.timeouts.FirstByte > 0 {
+		t.firstByteTimer = time.AfterFunc(t.timeouts.FirstByte, func() { t.fire(domain.ErrFirstByteTimeout) })
+	}
+	return ctx, t
+}
+
+func upstreamTimerFrom(ctx context.Context) *UpstreamTimer {
+	t, _ := ctx.Value(upstreamTimerKey{}).(*UpstreamTimer)
+	return t
+}
+
+// Stop releases the timers and the context
+func (t *UpstreamTimer) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	stopTimer(t.totalTimer)
+	stopTimer(t.firstByteTimer)
+	stopTimer(t.streamIdleTimer)
+	t.mu.Unlock()
+	t.cancel(context.Canceled)
+}
+
+// Wrap replaces the adapter's error with a retryable timeout error when a
+// limit was hit, so the executor cools the provider down and fails over.
+// A nil error is returned unchanged.
+func (t *UpstreamTimer) Wrap(err error) error {
+	if err == nil || t.parent.Err() != nil {
+		// Success, or the client went away (not the upstream's fault)
+		return err
+	}
+
+	t.mu.Lock()
+	cause := t.cause
+	connectTimedOut := t.connectTimedOut
+	t.mu.Unlock()
+
+	if cause == nil && connectTimedOut {
+		// Only report a connect timeout when the adapter gave up on the network error
+		var proxyErr *domain.ProxyError
+		if errors.As(err, &proxyErr) && proxyErr.IsNetworkError {
+			cause = domain.ErrConnectTimeout
+		}
+	}
+	if cause == nil {
+		return err
+	}
+
+	return &domain.ProxyError{
+		Err:            cause,
+		Retryable:      true,
+		Message:        fmt.Sprintf("upstream timed out after %v", t.limitFor(cause)),
+		IsNetworkError: true,
+	}
+}
+
+func (t *UpstreamTimer) limitFor(cause error) time.Duration {
+	switch cause {
+	case domain.ErrConnectTimeout:
+		return t.timeouts.Connect
+	case domain.ErrFirstByteTimeout:
+		return t.timeouts.FirstByte
+	case domain.ErrStreamIdleTimeout:
+		return t.timeouts.StreamIdle
+	default:
+		return t.timeouts.Total
+	}
+}
+
+// fire records the first limit hit and cancels the attempt
+func (t *UpstreamTimer) fire(cause error) {
+	t.mu.Lock()
+	if t.stopped || t.cause != nil {
+		t.mu.Unlock()
+		return
+	}
+	t.cause = cause
+	t.mu.Unlock()
+	t.cancel(cause)
+}
+
+// onRead is called after each upstream body read
+func (t *UpstreamTimer) onRead(n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return
+	}
+	if n > 0 && !t.gotFirstByte {
+		t.gotFirstByte = true
+		stopTimer(t.firstByteTimer)
+	}
+	if err != nil {
+		// Body finished (or failed): nothing left to wait for
+		stopTimer(t.streamIdleTimer)
+		return
+	}
+	if n == 0 || t.timeouts.StreamIdle <= 0 {
+		return
+	}
+	if t.streamIdleTimer == nil {
+		t.streamIdleTimer = time.AfterFunc(t.timeouts.StreamIdle, func() { t.fire(domain.ErrStreamIdleTimeout) })
+		return
+	}
+	t.streamIdleTimer.Reset(t.timeouts.StreamIdle)
+}
+
+func (t *UpstreamTimer) onClose() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stopTimer(t.streamIdleTimer)
+}
+
+func (t *UpstreamTimer) onConnectTimeout() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connectTimedOut = true
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// ===== Transport =====
+
+// NewTimeoutTransport returns a transport based on a copy of base that applies
+// the UpstreamTimer of each request's context (see WithUpstreamTimeouts).
+// Requests without a timer are passed through unchanged.
+func NewTimeoutTransport(base *http.Transport) http.RoundTripper {
+	transport := base.Clone()
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		timer := upstreamTimerFrom(ctx)
+		if timer == nil || timer.timeouts.Connect <= 0 {
+			return dial(ctx, network, addr)
+		}
+		dialCtx, cancel := context.WithTimeout(ctx, timer.timeouts.Connect)
+		defer cancel()
+		conn, err := dial(dialCtx, network, addr)
+		if err != nil && errors.Is(dialCtx.Err(), context.DeadlineExceeded) {
+			// Don't cancel the attempt: the adapter may still try another endpoint
+			timer.onConnectTimeout()
+		}
+		return conn, err
+	}
+	return &timeoutTransport{base: transport}
+}
+
+type timeoutTransport struct {
+	base http.RoundTripper
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if timer := upstreamTimerFrom(req.Context()); timer != nil && resp.Body != nil {
+		resp.Body = &watchdogBody{ReadCloser: resp.Body, timer: timer}
+	}
+	return resp, nil
+}
+
+// watchdogBody reports body reads to the timer (first byte and idle limits)
+type watchdogBody struct {
+	io.ReadCloser
+	timer *UpstreamTimer
+}
+
+func (b *watchdogBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.timer.onRead(n, err)
+	return n, err
+}
+
+func (b *watchdogBody) Close() error {
+	b.timer.onClose()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,222 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// fetch does what the custom adapter does: send the request, read the whole body
+// and report connection and read failures as retryable network errors
+func fetch(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		proxyErr := domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, true, "failed to connect to upstream")
+		proxyErr.IsNetworkError = true
+		return proxyErr
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		proxyErr := domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, true, "failed to read upstream response")
+		proxyErr.IsNetworkError = true
+		return proxyErr
+	}
+	if resp.StatusCode >= 400 {
+		return domain.NewProxyErrorWithMessage(domain.ErrUpstreamError, false, resp.Status)
+	}
+	return nil
+}
+
+func newTimeoutClient() *http.Client {
+	return &http.Client{Transport: NewTimeoutTransport(http.DefaultTransport.(*http.Transport))}
+}
+
+// stall blocks until the client gives up (or the test runs far too long)
+func stall(r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(5 * time.Second):
+	}
+}
+
+func TestUpstreamTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts Timeouts
+		handler  http.HandlerFunc
+		want     error // nil when the request must succeed
+	}{
+		{
+			name:     "stall before headers",
+			timeouts: Timeouts{FirstByte: 50 * time.Millisecond, StreamIdle: time.Second},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				stall(r)
+			},
+			want: domain.ErrFirstByteTimeout,
+		},
+		{
+			name:     "headers but no body",
+			timeouts: Timeouts{FirstByte: 50 * time.Millisecond},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				stall(r)
+			},
+			want: domain.ErrFirstByteTimeout,
+		},
+		{
+			name:     "stall between chunks",
+			timeouts: Timeouts{FirstByte: time.Second, StreamIdle: 50 * time.Millisecond},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("data: first\n\n"))
+				w.(http.Flusher).Flush()
+				stall(r)
+			},
+			want: domain.ErrStreamIdleTimeout,
+		},
+		{
+			name:     "slow but steady stream",
+			timeouts: Timeouts{FirstByte: time.Second, StreamIdle: 100 * time.Millisecond, Total: 80 * time.Millisecond},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for {
+					if _, err := w.Write([]byte("data: chunk\n\n")); err != nil {
+						return
+					}
+					w.(http.Flusher).Flush()
+					select {
+					case <-r.Context().Done():
+						return
+					case <-time.After(20 * time.Millisecond):
+					}
+				}
+			},
+			want: domain.ErrTotalTimeout,
+		},
+		{
+			name:     "within limits",
+			timeouts: Timeouts{FirstByte: time.Second, StreamIdle: time.Second, Total: time.Second},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("data: first\n\n"))
+				w.(http.Flusher).Flush()
+				time.Sleep(20 * time.Millisecond)
+				w.Write([]byte("data: second\n\n"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(tt.handler)
+		client := newTimeoutClient()
+
+		start := time.Now()
+		ctx, timer := WithUpstreamTimeouts(context.Background(), tt.timeouts)
+		err := timer.Wrap(fetch(ctx, client, server.URL))
+		timer.Stop()
+		elapsed := time.Since(start)
+		server.Close()
+
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: got %v, want success", tt.name, err)
+			}
+			continue
+		}
+		var proxyErr *domain.ProxyError
+		if !errors.As(err, &proxyErr) {
+			t.Errorf("%s: got %v, want a ProxyError", tt.name, err)
+			continue
+		}
+		if !errors.Is(proxyErr.Err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, proxyErr.Err, tt.want)
+		}
+		if !proxyErr.Retryable || !proxyErr.IsNetworkError {
+			t.Errorf("%s: retryable = %v, network = %v, want both true", tt.name, proxyErr.Retryable, proxyErr.IsNetworkError)
+		}
+		if elapsed > 2*time.Second {
+			t.Errorf("%s: took %v, the limit did not cut the attempt short", tt.name, elapsed)
+		}
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	// A dialer that never completes stands in for an unreachable host
+	base := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	client := &http.Client{Transport: NewTimeoutTransport(base)}
+
+	ctx, timer := WithUpstreamTimeouts(context.Background(), Timeouts{Connect: 50 * time.Millisecond, Total: 5 * time.Second})
+	defer timer.Stop()
+	start := time.Now()
+	err := timer.Wrap(fetch(ctx, client, "http://upstream.invalid/v1/messages"))
+
+	var proxyErr *domain.ProxyError
+	if !errors.As(err, &proxyErr) || !errors.Is(proxyErr.Err, domain.ErrConnectTimeout) {
+		t.Fatalf("got %v, want a connect timeout", err)
+	}
+	if !proxyErr.Retryable {
+		t.Error("connect timeout is not retryable")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v, want ~50ms", elapsed)
+	}
+}
+
+func TestWrapKeepsOtherErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, timer := WithUpstreamTimeouts(context.Background(), Timeouts{FirstByte: time.Second, Total: time.Second})
+	upstreamErr := fetch(ctx, newTimeoutClient(), server.URL)
+	timer.Stop()
+	if err := timer.Wrap(upstreamErr); err != upstreamErr {
+		t.Errorf("got %v, want the upstream error unchanged", err)
+	}
+
+	// The client going away is not the upstream's fault
+	parent, cancel := context.WithCancel(context.Background())
+	ctx, timer = WithUpstreamTimeouts(parent, Timeouts{FirstByte: 10 * time.Millisecond})
+	defer timer.Stop()
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	canceledErr := errors.New("request canceled")
+	if err := timer.Wrap(canceledErr); err != canceledErr {
+		t.Errorf("client cancel: got %v, want the original error", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("attempt context not cancelled")
+	}
+}
+
+func TestResolveTimeouts(t *testing.T) {
+	defaults := Timeouts{Connect: time.Second, FirstByte: 2 * time.Second, StreamIdle: 3 * time.Second, Total: 4 * time.Second}
+	if got := ResolveTimeouts(context.Background(), defaults); got != defaults {
+		t.Errorf("without a retry config: got %+v, want the defaults", got)
+	}
+
+	ctx := ctxutil.WithRetryConfig(context.Background(), &domain.RetryConfig{
+		FirstByteTimeout: 10 * time.Second,
+		TotalTimeout:     time.Minute,
+	})
+	want := Timeouts{Connect: time.Second, FirstByte: 10 * time.Second, StreamIdle: 3 * time.Second, Total: time.Minute}
+	if got := ResolveTimeouts(ctx, defaults); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+// Package budget tracks spend against daily and monthly budgets per project and provider.
+// Spend is loaded from attempt costs on startup and kept in memory afterwards.
+package budget
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/event"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// ExceededError is returned when a hard budget is exhausted
+type ExceededError struct {
+	Scope    domain.BudgetScope
+	ScopeID  uint64
+	Period   domain.BudgetPeriod
+	Limit    uint64
+	Spent    uint64
+	ResetsAt time.Time
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("budget exceeded: %s %d %s budget of %s used (%s spent), resets at %s",
+		e.Scope, e.ScopeID, e.Period, formatUSD(e.Limit), formatUSD(e.Spent), e.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *ExceededError) Unwrap() error {
+	return domain.ErrBudgetExceeded
+}
+
+type tracker struct {
+	config      domain.Budget
+	loc         *time.Location
+	periodStart time.Time
+	resetsAt    time.Time
+	spent       uint64
+	warned      bool
+	exhausted   bool
+}
+
+func (t *tracker) matches(scope domain.BudgetScope, scopeID uint64) bool {
+	return t.config.Scope == scope && t.config.ScopeID == scopeID
+}
+
+// roll starts a new period once the current one has ended
+func (t *tracker) roll(now time.Time) {
+	if now.Before(t.resetsAt) {
+		return
+	}
+	t.periodStart, t.resetsAt = PeriodBounds(&t.config, t.loc, now)
+	t.spent = 0
+	t.warned = false
+	t.exhausted = false
+}
+
+func (t *tracker) status() *domain.BudgetStatus {
+	config := t.config
+	return &domain.BudgetStatus{
+		Budget:      &config,
+		Spent:       t.spent,
+		PeriodStart: t.periodStart,
+		ResetsAt:    t.resetsAt,
+		Warned:      t.warned,
+		Exhausted:   t.exhausted,
+	}
+}
+
+// Manager holds the spend of every enabled budget
+type Manager struct {
+	mu          sync.Mutex
+	trackers    map[uint64]*tracker
+	repo        repository.BudgetRepository
+	broadcaster event.Broadcaster
+	now         func() time.Time
+}
+
+// NewManager creates an empty manager
+func NewManager() *Manager {
+	return &Manager{
+		trackers: make(map[uint64]*tracker),
+		now:      time.Now,
+	}
+}
+
+// Default global manager
+var defaultManager = NewManager()
+
+// Default returns the default global budget manager
+func Default() *Manager {
+	return defaultManager
+}
+
+// SetRepository sets the repository budgets and historical spend are loaded from
+func (m *Manager) SetRepository(repo repository.BudgetRepository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.repo = repo
+}
+
+// SetBroadcaster sets where budget warnings are sent
+func (m *Manager) SetBroadcaster(b event.Broadcaster) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcaster = b
+}
+
+// Load (re)builds budgets from the repository
+// Spend is read from the database for every budget, so changing a budget's calendar takes effect immediately
+func (m *Manager) Load() error {
+	m.mu.Lock()
+	repo := m.repo
+	m.mu.Unlock()
+	if repo == nil {
+		return nil
+	}
+
+	budgets, err := repo.List()
+	if err != nil {
+		return err
+	}
+
+	now := m.now()
+	next := make(map[uint64]*tracker, len(budgets))
+	for _, b := range budgets {
+		if !b.IsEnabled || b.Limit == 0 {
+			continue
+		}
+		loc, err := LoadLocation(b.Timezone)
+		if err != nil {
+			log.Printf("[Budget] Budget %d: %v, using local time", b.ID, err)
+			loc = time.Local
+		}
+		t := &tracker{config: *b, loc: loc}
+		t.periodStart, t.resetsAt = PeriodBounds(b, loc, now)
+		spent, err := repo.GetSpend(b.Scope, b.ScopeID, t.periodStart)
+		if err != nil {
+			return err
+		}
+		t.spent = spent
+		// Don't re-announce thresholds that were already crossed before the reload
+		t.warned = b.WarnPercent > 0 && t.spent*100 >= b.Limit*uint64(b.WarnPercent)
+		t.exhausted = t.spent >= b.Limit
+		next[b.ID] = t
+	}
+
+	m.mu.Lock()
+	m.trackers = next
+	m.mu.Unlock()
+	log.Printf("[Budget] Loaded %d budgets", len(next))
+	return nil
+}
+
+// Check returns an *ExceededError if a hard budget of the scope is exhausted
+func (m *Manager) Check(scope domain.BudgetScope, scopeID uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for _, t := range m.trackers {
+		if !t.config.HardLimit || !t.matches(scope, scopeID) {
+			continue
+		}
+		t.roll(now)
+		if t.spent >= t.config.Limit {
+			return &ExceededError{
+				Scope:    scope,
+				ScopeID:  scopeID,
+				Period:   t.config.Period,
+				Limit:    t.config.Limit,
+				Spent:    t.spent,
+				ResetsAt: t.resetsAt,
+			}
+		}
+	}
+	return nil
+}
+
+// Record adds the cost of an upstream attempt to the project and provider budgets
+// and broadcasts "budget_warning" / "budget_exceeded" when a threshold is first crossed in a period
+func (m *Manager) Record(projectID, providerID uint64, cost uint64) {
+	if cost == 0 {
+		return
+	}
+
+	type notice struct {
+		messageType string
+		status      *domain.BudgetStatus
+	}
+	var notices []notice
+
+	m.mu.Lock()
+	now := m.now()
+	for _, t := range m.trackers {
+		if !t.matches(domain.BudgetScopeProject, projectID) && !t.matches(domain.BudgetScopeProvider, providerID) {
+			continue
+		}
+		t.roll(now)
+		t.spent += cost
+
+		if !t.warned && t.config.WarnPercent > 0 && t.spent*100 >= t.config.Limit*uint64(t.config.WarnPercent) {
+			t.warned = true
+			notices = append(notices, notice{"budget_warning", t.status()})
+		}
+		if !t.exhausted && t.spent >= t.config.Limit {
+			t.exhausted = true
+			notices = append(notices, notice{"budget_exceeded", t.status()})
+		}
+	}
+	broadcaster := m.broadcaster
+	m.mu.Unlock()
+
+	for _, n := range notices {
+		b := n.status.Budget
+		log.Printf("[Budget] %s: %s %d %s budget at %s of %s",
+			n.messageType, b.Scope, b.ScopeID, b.Period, formatUSD(n.status.Spent), formatUSD(b.Limit))
+		if broadcaster != nil {
+			broadcaster.BroadcastMessage(n.messageType, n.status)
+		}
+	}
+}
+
+// Status returns the current period of every enabled budget ordered by ID
+func (m *Manager) Status() []*domain.BudgetStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	result := make([]*domain.BudgetStatus, 0, len(m.trackers))
+	for _, t := range m.trackers {
+		t.roll(now)
+		result = append(result, t.status())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Budget.ID < result[j].Budget.ID
+	})
+	return result
+}
+
+// LoadLocation resolves a budget timezone; empty means the server's local time
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// PeriodBounds returns the start and end of the budget period containing now
+func PeriodBounds(b *domain.Budget, loc *time.Location, now time.Time) (time.Time, time.Time) {
+	t := now.In(loc)
+	switch b.Period {
+	case domain.BudgetPeriodMonthly:
+		day := b.ResetDay
+		if day < 1 {
+			day = 1
+		}
+		start := time.Date(t.Year(), t.Month(), day, b.ResetHour, 0, 0, 0, loc)
+		if start.After(t) {
+			start = start.AddDate(0, -1, 0)
+		}
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := time.Date(t.Year(), t.Month(), t.Day(), b.ResetHour, 0, 0, 0, loc)
+		if start.After(t) {
+			start = start.AddDate(0, 0, -1)
+		}
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// formatUSD formats micro-USD as dollars
+func formatUSD(micro uint64) string {
+	return fmt.Sprintf("$%.2f", float64(micro)/1_000_000)
+}
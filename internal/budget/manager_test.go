@@ -0,0 +1,114 @@
+package budget
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/event"
+)
+
+type recordingBroadcaster struct {
+	event.NopBroadcaster
+	messages []string
+}
+
+func (b *recordingBroadcaster) BroadcastMessage(messageType string, data interface{}) {
+	b.messages = append(b.messages, messageType)
+}
+
+func TestPeriodBounds(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip("tzdata not available")
+	}
+	// 2026-03-01 01:30 in Shanghai
+	now := time.Date(2026, 2, 28, 17, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		budget    domain.Budget
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "daily before reset hour",
+			budget:    domain.Budget{Period: domain.BudgetPeriodDaily, ResetHour: 8},
+			wantStart: time.Date(2026, 2, 28, 8, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2026, 3, 1, 8, 0, 0, 0, shanghai),
+		},
+		{
+			name:      "daily after reset hour",
+			budget:    domain.Budget{Period: domain.BudgetPeriodDaily},
+			wantStart: time.Date(2026, 3, 1, 0, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2026, 3, 2, 0, 0, 0, 0, shanghai),
+		},
+		{
+			name:      "monthly defaults to the 1st",
+			budget:    domain.Budget{Period: domain.BudgetPeriodMonthly},
+			wantStart: time.Date(2026, 3, 1, 0, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2026, 4, 1, 0, 0, 0, 0, shanghai),
+		},
+		{
+			name:      "monthly before reset day",
+			budget:    domain.Budget{Period: domain.BudgetPeriodMonthly, ResetDay: 15},
+			wantStart: time.Date(2026, 2, 15, 0, 0, 0, 0, shanghai),
+			wantEnd:   time.Date(2026, 3, 15, 0, 0, 0, 0, shanghai),
+		},
+	}
+	for _, tt := range tests {
+		start, end := PeriodBounds(&tt.budget, shanghai, now)
+		if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+			t.Errorf("%s: got [%s, %s), want [%s, %s)", tt.name, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+func TestRecordWarnsAndRejects(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	bc := &recordingBroadcaster{}
+	m := NewManager()
+	m.now = func() time.Time { return now }
+	m.broadcaster = bc
+
+	config := domain.Budget{
+		ID: 1, Scope: domain.BudgetScopeProject, ScopeID: 3, Period: domain.BudgetPeriodDaily,
+		Limit: 1_000_000, WarnPercent: 80, HardLimit: true, IsEnabled: true,
+	}
+	tr := &tracker{config: config, loc: time.UTC}
+	tr.periodStart, tr.resetsAt = PeriodBounds(&config, time.UTC, now)
+	m.trackers[1] = tr
+
+	m.Record(3, 9, 700_000)
+	if len(bc.messages) != 0 || m.Check(domain.BudgetScopeProject, 3) != nil {
+		t.Fatalf("no threshold crossed yet, got messages %v", bc.messages)
+	}
+
+	m.Record(3, 9, 200_000)
+	m.Record(3, 9, 50_000) // already warned this period
+	if len(bc.messages) != 1 || bc.messages[0] != "budget_warning" {
+		t.Fatalf("expected one warning, got %v", bc.messages)
+	}
+
+	// Other projects are not charged
+	m.Record(4, 9, 5_000_000)
+	if err := m.Check(domain.BudgetScopeProject, 3); err != nil {
+		t.Fatalf("budget should not be exhausted yet: %v", err)
+	}
+
+	m.Record(3, 9, 100_000)
+	err := m.Check(domain.BudgetScopeProject, 3)
+	if !errors.Is(err, domain.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	if len(bc.messages) != 2 || bc.messages[1] != "budget_exceeded" {
+		t.Fatalf("expected exceeded notice, got %v", bc.messages)
+	}
+
+	// The next period starts from zero
+	now = now.Add(24 * time.Hour)
+	if err := m.Check(domain.BudgetScopeProject, 3); err != nil {
+		t.Fatalf("budget should reset after the period ends: %v", err)
+	}
+}
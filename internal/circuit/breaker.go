@@ -0,0 +1,189 @@
+// Package circuit implements a circuit breaker per provider and client type.
+// A breaker opens when the failure rate over a sliding window is too high or when
+// a health probe fails, and only lets a few trial requests through (half-open)
+// before it closes again. Thresholds come from the circuit_breaker_* settings.
+package circuit
+
+import (
+	"time"
+)
+
+// State is the state of a circuit breaker
+type State string
+
+const (
+	StateClosed   State = "closed"    // All requests pass
+	StateOpen     State = "open"      // No requests pass until OpenUntil
+	StateHalfOpen State = "half_open" // A limited number of trial requests pass
+)
+
+// windowBuckets is the number of buckets the sliding window is split into
+const windowBuckets = 10
+
+// Config controls when breakers open and how they recover
+type Config struct {
+	// Window is the sliding window the failure rate is computed over
+	Window time.Duration
+	// MinRequests is the number of requests in the window before the failure rate counts
+	MinRequests int
+	// FailureRate (0-1) at or above which the breaker opens
+	FailureRate float64
+	// OpenDuration is how long the breaker stays open after the failure rate tripped it
+	OpenDuration time.Duration
+	// HalfOpenMaxRequests is the number of trial requests admitted while half-open
+	HalfOpenMaxRequests int
+}
+
+// DefaultConfig returns the default breaker configuration
+func DefaultConfig() Config {
+	return Config{
+		Window:              time.Minute,
+		MinRequests:         10,
+		FailureRate:         0.5,
+		OpenDuration:        30 * time.Second,
+		HalfOpenMaxRequests: 3,
+	}
+}
+
+type bucket struct {
+	epoch     int64 // window-relative bucket number, used to detect stale buckets
+	successes int
+	failures  int
+}
+
+// breaker is the state of one (provider, client type). Not safe for concurrent use.
+type breaker struct {
+	state         State
+	openUntil     time.Time
+	halfOpenSince time.Time
+	trials        int
+	buckets       [windowBuckets]bucket
+}
+
+func newBreaker() *breaker {
+	return &breaker{state: StateClosed}
+}
+
+// allow reports whether a request may be sent, moving an expired open breaker to half-open
+func (b *breaker) allow(cfg Config, now time.Time) bool {
+	if b.state == StateOpen {
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.toHalfOpen(now)
+	}
+	if b.state == StateHalfOpen {
+		if b.trials >= cfg.HalfOpenMaxRequests {
+			// Trials that never reported back (e.g. client disconnects) don't block the breaker forever
+			if now.Sub(b.halfOpenSince) < cfg.OpenDuration {
+				return false
+			}
+			b.halfOpenSince = now
+			b.trials = 0
+		}
+		b.trials++
+	}
+	return true
+}
+
+// recordSuccess closes a half-open breaker and counts the success while closed
+func (b *breaker) recordSuccess(cfg Config, now time.Time) {
+	switch b.state {
+	case StateHalfOpen:
+		b.toClosed()
+	case StateClosed:
+		b.bucketAt(cfg, now).successes++
+	}
+}
+
+// recordFailure reopens a half-open breaker, or opens a closed one when the failure rate is too high
+func (b *breaker) recordFailure(cfg Config, now time.Time) {
+	switch b.state {
+	case StateHalfOpen:
+		b.toOpen(now.Add(cfg.OpenDuration))
+	case StateClosed:
+		b.bucketAt(cfg, now).failures++
+		if b.failureRateTripped(cfg, now) {
+			b.toOpen(now.Add(cfg.OpenDuration))
+		}
+	}
+}
+
+// trip opens the breaker until the given time (extends an open breaker, never shortens it)
+func (b *breaker) trip(until time.Time, now time.Time) {
+	if !until.After(now) {
+		return
+	}
+	if b.state == StateOpen && !until.After(b.openUntil) {
+		return
+	}
+	b.toOpen(until)
+}
+
+// recover moves the breaker one step towards closed: open to half-open, half-open to closed
+func (b *breaker) recover(cfg Config, now time.Time) {
+	switch b.state {
+	case StateOpen:
+		b.toHalfOpen(now)
+	default:
+		b.recordSuccess(cfg, now)
+	}
+}
+
+func (b *breaker) toOpen(until time.Time) {
+	b.state = StateOpen
+	b.openUntil = until
+	b.trials = 0
+}
+
+func (b *breaker) toHalfOpen(now time.Time) {
+	b.state = StateHalfOpen
+	b.halfOpenSince = now
+	b.trials = 0
+}
+
+func (b *breaker) toClosed() {
+	b.state = StateClosed
+	b.openUntil = time.Time{}
+	b.trials = 0
+	b.buckets = [windowBuckets]bucket{}
+}
+
+// bucketAt returns the current bucket, clearing it if it belongs to an earlier window
+func (b *breaker) bucketAt(cfg Config, now time.Time) *bucket {
+	epoch := now.UnixNano() / int64(bucketWidth(cfg))
+	bk := &b.buckets[epoch%windowBuckets]
+	if bk.epoch != epoch {
+		*bk = bucket{epoch: epoch}
+	}
+	return bk
+}
+
+// counts sums the buckets within the window
+func (b *breaker) counts(cfg Config, now time.Time) (successes, failures int) {
+	epoch := now.UnixNano() / int64(bucketWidth(cfg))
+	for _, bk := range b.buckets {
+		if epoch-bk.epoch < windowBuckets {
+			successes += bk.successes
+			failures += bk.failures
+		}
+	}
+	return successes, failures
+}
+
+func (b *breaker) failureRateTripped(cfg Config, now time.Time) bool {
+	successes, failures := b.counts(cfg, now)
+	total := successes + failures
+	if total == 0 || total < cfg.MinRequests {
+		return false
+	}
+	return float64(failures)/float64(total) >= cfg.FailureRate
+}
+
+func bucketWidth(cfg Config) time.Duration {
+	width := cfg.Window / windowBuckets
+	if width <= 0 {
+		width = time.Millisecond
+	}
+	return width
+}
@@ -0,0 +1,229 @@
+package circuit
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/event"
+)
+
+// MessageStateChanged is the WebSocket message type sent on every state transition
+const MessageStateChanged = "circuit_breaker_state"
+
+// Key identifies a breaker
+type Key struct {
+	ProviderID uint64
+	ClientType string
+}
+
+// Status is the state of a breaker (shown in /admin/proxy-status and broadcast on transitions)
+type Status struct {
+	ProviderID    uint64     `json:"providerID"`
+	ClientType    string     `json:"clientType"`
+	State         State      `json:"state"`
+	PreviousState State      `json:"previousState,omitempty"` // Only set in transition messages
+	OpenUntil     *time.Time `json:"openUntil,omitempty"`
+	Requests      int        `json:"requests"` // Requests in the sliding window
+	Failures      int        `json:"failures"`
+}
+
+// Manager holds the breakers of all providers and client types
+type Manager struct {
+	mu          sync.Mutex
+	config      Config
+	breakers    map[Key]*breaker
+	broadcaster event.Broadcaster
+	now         func() time.Time
+}
+
+// NewManager creates a manager using DefaultConfig
+func NewManager() *Manager {
+	return &Manager{
+		config:   DefaultConfig(),
+		breakers: make(map[Key]*breaker),
+		now:      time.Now,
+	}
+}
+
+// Default global manager
+var defaultManager = NewManager()
+
+// Default returns the default global circuit breaker manager
+func Default() *Manager {
+	return defaultManager
+}
+
+// SetBroadcaster sets where state transitions are sent
+func (m *Manager) SetBroadcaster(b event.Broadcaster) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.broadcaster = b
+}
+
+// SetConfig replaces the configuration, existing breakers keep their state
+func (m *Manager) SetConfig(cfg Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = cfg
+}
+
+// Allow reports whether a request may be sent to the provider.
+// While half-open only HalfOpenMaxRequests trial requests are admitted;
+// callers must report the outcome with RecordSuccess or RecordFailure.
+func (m *Manager) Allow(providerID uint64, clientType string) bool {
+	return m.update(providerID, clientType, func(b *breaker, now time.Time) bool {
+		return b.allow(m.config, now)
+	})
+}
+
+// RetryAfter returns how long until the breaker admits requests again (0 if it does now)
+func (m *Manager) RetryAfter(providerID uint64, clientType string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.breakers[Key{ProviderID: providerID, ClientType: clientType}]
+	if !ok {
+		return 0
+	}
+	now := m.now()
+	switch {
+	case b.state == StateOpen:
+		return b.openUntil.Sub(now)
+	case b.state == StateHalfOpen && b.trials >= m.config.HalfOpenMaxRequests:
+		return b.halfOpenSince.Add(m.config.OpenDuration).Sub(now)
+	}
+	return 0
+}
+
+// RecordSuccess reports a successful request
+func (m *Manager) RecordSuccess(providerID uint64, clientType string) {
+	m.update(providerID, clientType, func(b *breaker, now time.Time) bool {
+		b.recordSuccess(m.config, now)
+		return true
+	})
+}
+
+// RecordFailure reports a failed request
+func (m *Manager) RecordFailure(providerID uint64, clientType string) {
+	m.update(providerID, clientType, func(b *breaker, now time.Time) bool {
+		b.recordFailure(m.config, now)
+		return true
+	})
+}
+
+// Trip opens the breaker until the given time (e.g. the end of a cooldown),
+// so the provider comes back half-open instead of receiving all queued requests at once
+func (m *Manager) Trip(providerID uint64, clientType string, until time.Time) {
+	m.update(providerID, clientType, func(b *breaker, now time.Time) bool {
+		b.trip(until, now)
+		return true
+	})
+}
+
+// Recover moves the breaker one step towards closed (used by health probes):
+// an open breaker becomes half-open, a half-open breaker closes
+func (m *Manager) Recover(providerID uint64, clientType string) {
+	m.update(providerID, clientType, func(b *breaker, now time.Time) bool {
+		b.recover(m.config, now)
+		return true
+	})
+}
+
+// Reset closes all breakers of a provider
+func (m *Manager) Reset(providerID uint64) {
+	m.mu.Lock()
+	var changes []*Status
+	for key, b := range m.breakers {
+		if key.ProviderID != providerID || b.state == StateClosed {
+			continue
+		}
+		previous := b.state
+		b.toClosed()
+		changes = append(changes, m.statusLocked(key, b, previous))
+	}
+	broadcaster := m.broadcaster
+	m.mu.Unlock()
+
+	for _, change := range changes {
+		notify(broadcaster, change)
+	}
+}
+
+// State returns the current state of a breaker
+func (m *Manager) State(providerID uint64, clientType string) State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := m.breakers[Key{ProviderID: providerID, ClientType: clientType}]; ok {
+		return b.state
+	}
+	return StateClosed
+}
+
+// Status returns all breakers that saw traffic, ordered by provider and client type
+func (m *Manager) Status() []*Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*Status, 0, len(m.breakers))
+	for key, b := range m.breakers {
+		result = append(result, m.statusLocked(key, b, ""))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ProviderID != result[j].ProviderID {
+			return result[i].ProviderID < result[j].ProviderID
+		}
+		return result[i].ClientType < result[j].ClientType
+	})
+	return result
+}
+
+// update applies fn to the breaker and broadcasts the transition if its state changed
+func (m *Manager) update(providerID uint64, clientType string, fn func(b *breaker, now time.Time) bool) bool {
+	key := Key{ProviderID: providerID, ClientType: clientType}
+
+	m.mu.Lock()
+	b, ok := m.breakers[key]
+	if !ok {
+		b = newBreaker()
+		m.breakers[key] = b
+	}
+	previous := b.state
+	result := fn(b, m.now())
+	var change *Status
+	if b.state != previous {
+		change = m.statusLocked(key, b, previous)
+	}
+	broadcaster := m.broadcaster
+	m.mu.Unlock()
+
+	if change != nil {
+		notify(broadcaster, change)
+	}
+	return result
+}
+
+func (m *Manager) statusLocked(key Key, b *breaker, previous State) *Status {
+	successes, failures := b.counts(m.config, m.now())
+	s := &Status{
+		ProviderID:    key.ProviderID,
+		ClientType:    key.ClientType,
+		State:         b.state,
+		PreviousState: previous,
+		Requests:      successes + failures,
+		Failures:      failures,
+	}
+	if b.state == StateOpen {
+		until := b.openUntil
+		s.OpenUntil = &until
+	}
+	return s
+}
+
+func notify(broadcaster event.Broadcaster, change *Status) {
+	log.Printf("[Circuit] Provider %d (clientType=%s): %s -> %s",
+		change.ProviderID, change.ClientType, change.PreviousState, change.State)
+	if broadcaster != nil {
+		broadcaster.BroadcastMessage(MessageStateChanged, change)
+	}
+}
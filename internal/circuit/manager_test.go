@@ -0,0 +1,188 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/event"
+)
+
+type recordingBroadcaster struct {
+	event.NopBroadcaster
+	changes []*Status
+}
+
+func (b *recordingBroadcaster) BroadcastMessage(messageType string, data interface{}) {
+	if messageType == MessageStateChanged {
+		b.changes = append(b.changes, data.(*Status))
+	}
+}
+
+func newTestManager(now *time.Time) (*Manager, *recordingBroadcaster) {
+	m := NewManager()
+	m.now = func() time.Time { return *now }
+	bc := &recordingBroadcaster{}
+	m.SetBroadcaster(bc)
+	return m, bc
+}
+
+func TestFailureRateOpensBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, bc := newTestManager(&now)
+
+	// 5 successes and 4 failures: below MinRequests, stays closed
+	for i := 0; i < 5; i++ {
+		m.RecordSuccess(1, "claude")
+	}
+	for i := 0; i < 4; i++ {
+		m.RecordFailure(1, "claude")
+	}
+	if got := m.State(1, "claude"); got != StateClosed {
+		t.Fatalf("below MinRequests: got %s, want closed", got)
+	}
+
+	// 10th request, 50% failure rate
+	m.RecordFailure(1, "claude")
+	if got := m.State(1, "claude"); got != StateOpen {
+		t.Fatalf("got %s, want open", got)
+	}
+	if m.Allow(1, "claude") {
+		t.Fatal("open breaker should reject requests")
+	}
+	if !m.Allow(1, "openai") || !m.Allow(2, "claude") {
+		t.Fatal("other breakers should not be affected")
+	}
+	if len(bc.changes) != 1 || bc.changes[0].PreviousState != StateClosed || bc.changes[0].State != StateOpen {
+		t.Fatalf("expected one closed -> open broadcast, got %+v", bc.changes)
+	}
+}
+
+func TestOldFailuresLeaveTheWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	for i := 0; i < 9; i++ {
+		m.RecordFailure(1, "claude")
+	}
+	now = now.Add(2 * time.Minute)
+	m.RecordFailure(1, "claude")
+	if got := m.State(1, "claude"); got != StateClosed {
+		t.Fatalf("failures outside the window should not count: got %s", got)
+	}
+}
+
+func TestHalfOpenAdmitsLimitedTrials(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, bc := newTestManager(&now)
+
+	m.Trip(1, "claude", now.Add(time.Minute))
+	if m.Allow(1, "claude") {
+		t.Fatal("tripped breaker should reject requests")
+	}
+
+	now = now.Add(time.Minute)
+	for i := 0; i < DefaultConfig().HalfOpenMaxRequests; i++ {
+		if !m.Allow(1, "claude") {
+			t.Fatalf("trial %d should be admitted", i+1)
+		}
+	}
+	if m.Allow(1, "claude") {
+		t.Fatal("half-open breaker should only admit HalfOpenMaxRequests trials")
+	}
+	if got := m.State(1, "claude"); got != StateHalfOpen {
+		t.Fatalf("got %s, want half_open", got)
+	}
+
+	// Trials that never report back don't block the breaker forever
+	now = now.Add(DefaultConfig().OpenDuration)
+	if !m.Allow(1, "claude") {
+		t.Fatal("stale trials should be released after OpenDuration")
+	}
+
+	m.RecordSuccess(1, "claude")
+	if got := m.State(1, "claude"); got != StateClosed {
+		t.Fatalf("success while half-open should close the breaker, got %s", got)
+	}
+
+	want := []State{StateOpen, StateHalfOpen, StateClosed}
+	if len(bc.changes) != len(want) {
+		t.Fatalf("expected %d broadcasts, got %+v", len(want), bc.changes)
+	}
+	for i, s := range want {
+		if bc.changes[i].State != s {
+			t.Fatalf("broadcast %d: got %s, want %s", i, bc.changes[i].State, s)
+		}
+	}
+}
+
+func TestHalfOpenFailureReopens(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	m.Trip(1, "claude", now.Add(time.Second))
+	now = now.Add(time.Second)
+	if !m.Allow(1, "claude") {
+		t.Fatal("trial should be admitted")
+	}
+	m.RecordFailure(1, "claude")
+	if got := m.State(1, "claude"); got != StateOpen {
+		t.Fatalf("failed trial should reopen the breaker, got %s", got)
+	}
+	if m.Allow(1, "claude") {
+		t.Fatal("reopened breaker should reject requests")
+	}
+}
+
+func TestTripNeverShortensOpen(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	m.Trip(1, "claude", now.Add(time.Hour))
+	m.Trip(1, "claude", now.Add(time.Minute))
+	now = now.Add(2 * time.Minute)
+	if m.Allow(1, "claude") {
+		t.Fatal("a shorter trip should not shorten the open period")
+	}
+}
+
+func TestRecoverAndReset(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	m.Trip(1, "claude", now.Add(time.Hour))
+	m.Recover(1, "claude")
+	if got := m.State(1, "claude"); got != StateHalfOpen {
+		t.Fatalf("recover should move open to half_open, got %s", got)
+	}
+
+	m.Trip(1, "claude", now.Add(time.Hour))
+	m.Trip(1, "openai", now.Add(time.Hour))
+	m.Reset(1)
+	if m.State(1, "claude") != StateClosed || m.State(1, "openai") != StateClosed {
+		t.Fatal("reset should close every breaker of the provider")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+
+	if got := m.RetryAfter(1, "claude"); got != 0 {
+		t.Fatalf("unknown breaker: RetryAfter = %v, want 0", got)
+	}
+	m.Trip(1, "claude", now.Add(20*time.Second))
+	if got := m.RetryAfter(1, "claude"); got != 20*time.Second {
+		t.Fatalf("open breaker: RetryAfter = %v, want 20s", got)
+	}
+
+	// Half-open with every trial slot taken waits for the trials to report back
+	now = now.Add(20 * time.Second)
+	for i := 0; i < DefaultConfig().HalfOpenMaxRequests; i++ {
+		if !m.Allow(1, "claude") {
+			t.Fatalf("trial %d rejected", i+1)
+		}
+	}
+	if got := m.RetryAfter(1, "claude"); got != DefaultConfig().OpenDuration {
+		t.Fatalf("half-open without trial slots: RetryAfter = %v, want %v", got, DefaultConfig().OpenDuration)
+	}
+}
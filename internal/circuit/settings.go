@@ -0,0 +1,83 @@
+package circuit
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// IsSetting reports whether a system setting key configures the circuit breakers
+func IsSetting(key string) bool {
+	switch key {
+	case domain.SettingKeyCircuitWindow,
+		domain.SettingKeyCircuitMinRequests,
+		domain.SettingKeyCircuitFailureRate,
+		domain.SettingKeyCircuitOpenDuration,
+		domain.SettingKeyCircuitHalfOpenRequests:
+		return true
+	}
+	return false
+}
+
+// ValidateSetting checks a circuit breaker setting value before it is saved
+func ValidateSetting(key, value string) error {
+	switch key {
+	case domain.SettingKeyCircuitWindow, domain.SettingKeyCircuitMinRequests,
+		domain.SettingKeyCircuitOpenDuration, domain.SettingKeyCircuitHalfOpenRequests:
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			return fmt.Errorf("%w: %s must be a positive integer", domain.ErrInvalidInput, key)
+		}
+	case domain.SettingKeyCircuitFailureRate:
+		if f, err := strconv.ParseFloat(value, 64); err != nil || f <= 0 || f > 1 {
+			return fmt.Errorf("%w: %s must be a number in (0, 1]", domain.ErrInvalidInput, key)
+		}
+	}
+	return nil
+}
+
+// ConfigFromSettings builds a Config from system settings, using DefaultConfig
+// for settings that are unset or invalid
+func ConfigFromSettings(settings repository.SystemSettingRepository) Config {
+	cfg := DefaultConfig()
+	get := func(key string) (string, bool) {
+		value, _ := settings.Get(key)
+		if value == "" {
+			return "", false
+		}
+		if err := ValidateSetting(key, value); err != nil {
+			log.Printf("[Circuit] Ignoring setting: %v", err)
+			return "", false
+		}
+		return value, true
+	}
+	seconds := func(value string) time.Duration {
+		n, _ := strconv.Atoi(value)
+		return time.Duration(n) * time.Second
+	}
+
+	if value, ok := get(domain.SettingKeyCircuitWindow); ok {
+		cfg.Window = seconds(value)
+	}
+	if value, ok := get(domain.SettingKeyCircuitMinRequests); ok {
+		cfg.MinRequests, _ = strconv.Atoi(value)
+	}
+	if value, ok := get(domain.SettingKeyCircuitFailureRate); ok {
+		cfg.FailureRate, _ = strconv.ParseFloat(value, 64)
+	}
+	if value, ok := get(domain.SettingKeyCircuitOpenDuration); ok {
+		cfg.OpenDuration = seconds(value)
+	}
+	if value, ok := get(domain.SettingKeyCircuitHalfOpenRequests); ok {
+		cfg.HalfOpenMaxRequests, _ = strconv.Atoi(value)
+	}
+	return cfg
+}
+
+// ApplySettings (re)configures the thresholds from system settings
+func (m *Manager) ApplySettings(settings repository.SystemSettingRepository) {
+	m.SetConfig(ConfigFromSettings(settings))
+}
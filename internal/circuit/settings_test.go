@@ -0,0 +1,85 @@
+package circuit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+type memorySettings struct {
+	repository.SystemSettingRepository
+	values map[string]string
+}
+
+func (r *memorySettings) Get(key string) (string, error) {
+	return r.values[key], nil
+}
+
+func TestConfigFromSettings(t *testing.T) {
+	cfg := ConfigFromSettings(&memorySettings{values: map[string]string{
+		domain.SettingKeyCircuitWindow:           "120",
+		domain.SettingKeyCircuitMinRequests:      "4",
+		domain.SettingKeyCircuitFailureRate:      "0.25",
+		domain.SettingKeyCircuitOpenDuration:     "90",
+		domain.SettingKeyCircuitHalfOpenRequests: "1",
+	}})
+	want := Config{
+		Window:              2 * time.Minute,
+		MinRequests:         4,
+		FailureRate:         0.25,
+		OpenDuration:        90 * time.Second,
+		HalfOpenMaxRequests: 1,
+	}
+	if cfg != want {
+		t.Errorf("ConfigFromSettings = %+v, want %+v", cfg, want)
+	}
+
+	// Unset and invalid values fall back to the defaults
+	cfg = ConfigFromSettings(&memorySettings{values: map[string]string{
+		domain.SettingKeyCircuitMinRequests: "-1",
+		domain.SettingKeyCircuitFailureRate: "1.5",
+	}})
+	if cfg != DefaultConfig() {
+		t.Errorf("ConfigFromSettings with invalid values = %+v, want defaults", cfg)
+	}
+}
+
+func TestValidateSetting(t *testing.T) {
+	tests := []struct {
+		key, value string
+		ok         bool
+	}{
+		{domain.SettingKeyCircuitWindow, "60", true},
+		{domain.SettingKeyCircuitWindow, "0", false},
+		{domain.SettingKeyCircuitMinRequests, "abc", false},
+		{domain.SettingKeyCircuitFailureRate, "1", true},
+		{domain.SettingKeyCircuitFailureRate, "0", false},
+		{domain.SettingKeyCircuitFailureRate, "0.5", true},
+		{domain.SettingKeyCircuitHalfOpenRequests, "2", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateSetting(tt.key, tt.value); (err == nil) != tt.ok {
+			t.Errorf("ValidateSetting(%s, %q) = %v, want ok=%v", tt.key, tt.value, err, tt.ok)
+		}
+	}
+}
+
+func TestApplySettingsUsesThresholds(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m, _ := newTestManager(&now)
+	m.ApplySettings(&memorySettings{values: map[string]string{
+		domain.SettingKeyCircuitMinRequests: "2",
+		domain.SettingKeyCircuitFailureRate: "1",
+	}})
+
+	m.RecordFailure(1, "claude")
+	if got := m.State(1, "claude"); got != StateClosed {
+		t.Fatalf("one failure: got %s, want closed", got)
+	}
+	m.RecordFailure(1, "claude")
+	if got := m.State(1, "claude"); got != StateOpen {
+		t.Fatalf("two failures with min_requests=2: got %s, want open", got)
+	}
+}
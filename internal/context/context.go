@@ -24,6 +24,9 @@ const (
 	CtxKeyRequestURI      contextKey = "request_uri"
 	CtxKeyBroadcaster     contextKey = "broadcaster"
 	CtxKeyIsStream        contextKey = "is_stream"
+	CtxKeyAPIKeyID        contextKey = "api_key_id"
+	CtxKeyUser            contextKey = "user"
+	CtxKeyRetryConfig     contextKey = "retry_config"
 )
 
 // Setters
@@ -170,3 +173,36 @@ func GetIsStream(ctx context.Context) bool {
 	}
 	return false
 }
+
+func WithAPIKeyID(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, CtxKeyAPIKeyID, id)
+}
+
+func GetAPIKeyID(ctx context.Context) uint64 {
+	if v, ok := ctx.Value(CtxKeyAPIKeyID).(uint64); ok {
+		return v
+	}
+	return 0
+}
+
+func WithUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, CtxKeyUser, user)
+}
+
+func GetUser(ctx context.Context) *domain.User {
+	if v, ok := ctx.Value(CtxKeyUser).(*domain.User); ok {
+		return v
+	}
+	return nil
+}
+
+func WithRetryConfig(ctx context.Context, config *domain.RetryConfig) context.Context {
+	return context.WithValue(ctx, CtxKeyRetryConfig, config)
+}
+
+func GetRetryConfig(ctx context.Context) *domain.RetryConfig {
+	if v, ok := ctx.Value(CtxKeyRetryConfig).(*domain.RetryConfig); ok {
+		return v
+	}
+	return nil
+}
@@ -55,6 +55,7 @@ type CodexOutput struct {
 	CallID    string      `json:"call_id,omitempty"`
 	Arguments string      `json:"arguments,omitempty"`
 	Status    string      `json:"status,omitempty"`
+	Summary   interface{} `json:"summary,omitempty"` // for reasoning items
 }
 
 type CodexUsage struct {
@@ -66,9 +67,10 @@ type CodexUsage struct {
 }
 
 type CodexTokenDetails struct {
-	CachedTokens int `json:"cached_tokens,omitempty"`
-	TextTokens   int `json:"text_tokens,omitempty"`
-	AudioTokens  int `json:"audio_tokens,omitempty"`
+	CachedTokens    int `json:"cached_tokens,omitempty"`
+	TextTokens      int `json:"text_tokens,omitempty"`
+	AudioTokens     int `json:"audio_tokens,omitempty"`
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
 }
 
 type CodexError struct {
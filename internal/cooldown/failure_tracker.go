@@ -90,6 +90,15 @@ func (ft *FailureTracker) GetFailureCount(providerID uint64, clientType string,
 	return ft.failureCounts[key]
 }
 
+// GetAll returns a copy of all failure counts
+func (ft *FailureTracker) GetAll() map[FailureKey]int {
+	result := make(map[FailureKey]int, len(ft.failureCounts))
+	for key, count := range ft.failureCounts {
+		result[key] = count
+	}
+	return result
+}
+
 // ResetFailures resets all failure counts for a provider+clientType
 func (ft *FailureTracker) ResetFailures(providerID uint64, clientType string) {
 	// Clear failure counts for all reasons for this provider+clientType
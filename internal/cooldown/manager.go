@@ -13,11 +13,13 @@ import (
 // Cooldown is stored in memory and persisted to database
 type Manager struct {
 	mu             sync.RWMutex
-	cooldowns      map[CooldownKey]time.Time         // cooldown key -> end time
-	reasons        map[CooldownKey]CooldownReason    // cooldown key -> reason
-	failureTracker *FailureTracker                   // tracks failure counts
-	policies       map[CooldownReason]CooldownPolicy // cooldown calculation strategies
+	cooldowns      map[CooldownKey]time.Time                    // cooldown key -> end time
+	reasons        map[CooldownKey]CooldownReason               // cooldown key -> reason
+	failureTracker *FailureTracker                              // tracks failure counts
+	policies       map[CooldownReason]CooldownPolicy            // cooldown calculation strategies
+	overrides      map[uint64]map[CooldownReason]CooldownPolicy // provider ID (0 = all) -> reason -> policy
 	repository     repository.CooldownRepository
+	policyRepo     repository.CooldownPolicyRepository
 }
 
 // NewManager creates a new cooldown manager
@@ -27,6 +29,7 @@ func NewManager() *Manager {
 		reasons:        make(map[CooldownKey]CooldownReason),
 		failureTracker: NewFailureTracker(),
 		policies:       DefaultPolicies(),
+		overrides:      make(map[uint64]map[CooldownReason]CooldownPolicy),
 	}
 }
 
@@ -52,6 +55,66 @@ func (m *Manager) SetFailureCountRepository(repo repository.FailureCountReposito
 	m.failureTracker.SetRepository(repo)
 }
 
+// SetPolicyRepository sets the repository for cooldown policy overrides
+func (m *Manager) SetPolicyRepository(repo repository.CooldownPolicyRepository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policyRepo = repo
+}
+
+// LoadPolicies (re)loads the cooldown policy overrides from the repository
+func (m *Manager) LoadPolicies() error {
+	m.mu.RLock()
+	repo := m.policyRepo
+	m.mu.RUnlock()
+	if repo == nil {
+		return nil
+	}
+	configs, err := repo.List()
+	if err != nil {
+		return err
+	}
+	m.SetPolicyConfigs(configs)
+	return nil
+}
+
+// SetPolicyConfigs replaces the active policy overrides
+// Disabled configs and unknown policy types are ignored
+func (m *Manager) SetPolicyConfigs(configs []*domain.CooldownPolicyConfig) {
+	overrides := make(map[uint64]map[CooldownReason]CooldownPolicy)
+	for _, cfg := range configs {
+		if !cfg.IsEnabled {
+			continue
+		}
+		policy := NewPolicy(cfg)
+		if policy == nil {
+			log.Printf("[Cooldown] Warning: Ignoring policy %d with unknown type %q", cfg.ID, cfg.Type)
+			continue
+		}
+		if overrides[cfg.ProviderID] == nil {
+			overrides[cfg.ProviderID] = make(map[CooldownReason]CooldownPolicy)
+		}
+		overrides[cfg.ProviderID][CooldownReason(cfg.Reason)] = policy
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.overrides = overrides
+}
+
+// policyLocked returns the policy for a provider and reason:
+// provider override, then the override for all providers, then the built-in default
+func (m *Manager) policyLocked(providerID uint64, reason CooldownReason) (CooldownPolicy, bool) {
+	if policy, ok := m.overrides[providerID][reason]; ok {
+		return policy, true
+	}
+	if policy, ok := m.overrides[0][reason]; ok {
+		return policy, true
+	}
+	policy, ok := m.policies[reason]
+	return policy, ok
+}
+
 // LoadFromDatabase loads all active cooldowns and failure counts from database into memory
 func (m *Manager) LoadFromDatabase() error {
 	m.mu.Lock()
@@ -107,7 +170,7 @@ func (m *Manager) RecordFailure(providerID uint64, clientType string, reason Coo
 	failureCount := m.failureTracker.IncrementFailure(providerID, clientType, reason)
 
 	// Get policy for this reason
-	policy, ok := m.policies[reason]
+	policy, ok := m.policyLocked(providerID, reason)
 	if !ok {
 		// Fallback to fixed 1-minute cooldown if no policy found
 		policy = &FixedDurationPolicy{Duration: 1 * time.Minute}
@@ -320,6 +383,13 @@ func (m *Manager) GetAllCooldowns() map[CooldownKey]time.Time {
 	return result
 }
 
+// GetFailureCounts returns the current consecutive failure counts
+func (m *Manager) GetFailureCounts() map[FailureKey]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.failureTracker.GetAll()
+}
+
 // CleanupExpired removes expired cooldowns from memory and database
 // Also resets failure counts for expired cooldowns
 func (m *Manager) CleanupExpired() {
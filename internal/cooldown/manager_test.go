@@ -0,0 +1,57 @@
+package cooldown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestPolicyLocked_OverridePrecedence(t *testing.T) {
+	m := NewManager()
+	m.SetPolicyConfigs([]*domain.CooldownPolicyConfig{
+		// Provider-wide overrides
+		{ProviderID: 0, Reason: domain.CooldownReasonServerError, Type: domain.CooldownPolicyFixed, Base: 2 * time.Minute, IsEnabled: true},
+		{ProviderID: 0, Reason: domain.CooldownReasonNetworkError, Type: domain.CooldownPolicyFixed, Base: 3 * time.Minute, IsEnabled: true},
+		// Overrides for provider 7
+		{ProviderID: 7, Reason: domain.CooldownReasonServerError, Type: domain.CooldownPolicyFixed, Base: 5 * time.Second, IsEnabled: true},
+		{ProviderID: 7, Reason: domain.CooldownReasonQuotaExhausted, Type: domain.CooldownPolicyFixed, Base: 7 * time.Second, IsEnabled: true},
+		// Ignored: disabled and unknown type
+		{ProviderID: 7, Reason: domain.CooldownReasonNetworkError, Type: domain.CooldownPolicyFixed, Base: time.Second, IsEnabled: false},
+		{ProviderID: 7, Reason: domain.CooldownReasonUnknown, Type: "random", Base: time.Second, IsEnabled: true},
+	})
+
+	tests := []struct {
+		name       string
+		providerID uint64
+		reason     CooldownReason
+		want       time.Duration
+	}{
+		{"provider override wins", 7, ReasonServerError, 5 * time.Second},
+		{"provider override without a provider-wide one", 7, ReasonQuotaExhausted, 7 * time.Second},
+		{"disabled provider override falls back to provider-wide", 7, ReasonNetworkError, 3 * time.Minute},
+		{"unknown type falls back to default", 7, ReasonUnknown, time.Minute},
+		{"other provider uses provider-wide", 8, ReasonServerError, 2 * time.Minute},
+		{"other provider uses default", 8, ReasonQuotaExhausted, time.Hour},
+		{"default", 8, ReasonConcurrentLimit, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		policy, ok := m.policyLocked(tt.providerID, tt.reason)
+		if !ok {
+			t.Errorf("%s: no policy found", tt.name)
+			continue
+		}
+		if got := policy.CalculateCooldown(1); got != tt.want {
+			t.Errorf("%s: cooldown = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, ok := m.policyLocked(7, "made_up"); ok {
+		t.Error("found a policy for an unknown reason")
+	}
+
+	m.SetPolicyConfigs(nil)
+	if policy, _ := m.policyLocked(7, ReasonServerError); policy.CalculateCooldown(1) != time.Minute {
+		t.Error("clearing overrides did not restore the default policy")
+	}
+}
@@ -1,7 +1,11 @@
 package cooldown
 
 import (
+	"math"
+	"math/rand"
 	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
 )
 
 // CooldownPolicy defines the interface for cooldown calculation strategies
@@ -13,49 +17,89 @@ type CooldownPolicy interface {
 // FixedDurationPolicy returns a fixed cooldown duration regardless of failure count
 type FixedDurationPolicy struct {
 	Duration time.Duration
+	Max      time.Duration // Optional cap on the jittered duration, 0 means no limit
+	Jitter   float64       // Optional random spread, e.g. 0.2 = ±20%
 }
 
 func (p *FixedDurationPolicy) CalculateCooldown(failureCount int) time.Duration {
-	return p.Duration
+	return applyJitter(p.Duration, p.Jitter, p.Max)
 }
 
 // LinearIncrementalPolicy increases cooldown linearly with each failure
-// Formula: baseMinutes * failureCount
+// Formula: base * failureCount
 type LinearIncrementalPolicy struct {
-	BaseMinutes int
-	MaxMinutes  int // Optional cap, 0 means no limit
+	Base   time.Duration
+	Max    time.Duration // Optional cap, 0 means no limit
+	Jitter float64       // Optional random spread, e.g. 0.2 = ±20%
 }
 
 func (p *LinearIncrementalPolicy) CalculateCooldown(failureCount int) time.Duration {
-	minutes := p.BaseMinutes * failureCount
-	if p.MaxMinutes > 0 && minutes > p.MaxMinutes {
-		minutes = p.MaxMinutes
+	if failureCount <= 0 {
+		return 0
 	}
-	return time.Duration(minutes) * time.Minute
+	duration := p.Base * time.Duration(failureCount)
+	if p.Max > 0 && duration > p.Max {
+		duration = p.Max
+	}
+	return applyJitter(duration, p.Jitter, p.Max)
 }
 
 // ExponentialBackoffPolicy increases cooldown exponentially with each failure
-// Formula: baseMinutes * (2 ^ (failureCount - 1))
+// Formula: base * (2 ^ (failureCount - 1))
 type ExponentialBackoffPolicy struct {
-	BaseMinutes int
-	MaxMinutes  int // Optional cap, 0 means no limit
+	Base   time.Duration
+	Max    time.Duration // Optional cap, 0 means no limit
+	Jitter float64       // Optional random spread, e.g. 0.2 = ±20%
 }
 
 func (p *ExponentialBackoffPolicy) CalculateCooldown(failureCount int) time.Duration {
-	if failureCount == 0 {
+	if failureCount <= 0 {
 		return 0
 	}
 
-	minutes := p.BaseMinutes
+	duration := p.Base
 	for i := 1; i < failureCount; i++ {
-		minutes *= 2
-		if p.MaxMinutes > 0 && minutes > p.MaxMinutes {
-			minutes = p.MaxMinutes
+		if duration > math.MaxInt64/2 {
+			break
+		}
+		duration *= 2
+		if p.Max > 0 && duration > p.Max {
+			duration = p.Max
 			break
 		}
 	}
 
-	return time.Duration(minutes) * time.Minute
+	return applyJitter(duration, p.Jitter, p.Max)
+}
+
+// applyJitter spreads d randomly by ±jitter (a fraction of d) so providers that
+// failed together don't all come back at the same moment. The result never exceeds max (0 = no cap).
+func applyJitter(d time.Duration, jitter float64, max time.Duration) time.Duration {
+	if d > 0 && jitter > 0 {
+		d = time.Duration(float64(d) + (rand.Float64()*2-1)*jitter*float64(d))
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// NewPolicy creates the policy described by a stored configuration
+// Returns nil for unknown policy types
+func NewPolicy(cfg *domain.CooldownPolicyConfig) CooldownPolicy {
+	switch cfg.Type {
+	case domain.CooldownPolicyFixed:
+		return &FixedDurationPolicy{Duration: cfg.Base, Max: cfg.Max, Jitter: cfg.Jitter}
+	case domain.CooldownPolicyLinear:
+		return &LinearIncrementalPolicy{Base: cfg.Base, Max: cfg.Max, Jitter: cfg.Jitter}
+	case domain.CooldownPolicyExponential:
+		return &ExponentialBackoffPolicy{Base: cfg.Base, Max: cfg.Max, Jitter: cfg.Jitter}
+	default:
+		return nil
+	}
 }
 
 // CooldownReason represents the reason for cooldown
@@ -71,19 +115,20 @@ const (
 )
 
 // DefaultPolicies returns the default policy configuration
+// Overrides stored in the database (see Manager.SetPolicyConfigs) take precedence
 // Note: For quota/rate limit errors with explicit reset times from API,
 // those times will be used directly instead of these policies
 func DefaultPolicies() map[CooldownReason]CooldownPolicy {
 	return map[CooldownReason]CooldownPolicy{
 		// Server errors (5xx): linear increment (1min, 2min, 3min, ... max 10min)
 		ReasonServerError: &LinearIncrementalPolicy{
-			BaseMinutes: 1,
-			MaxMinutes:  10,
+			Base: 1 * time.Minute,
+			Max:  10 * time.Minute,
 		},
 		// Network errors: exponential backoff (1min, 2min, 4min, 8min, ... max 30min)
 		ReasonNetworkError: &ExponentialBackoffPolicy{
-			BaseMinutes: 1,
-			MaxMinutes:  30,
+			Base: 1 * time.Minute,
+			Max:  30 * time.Minute,
 		},
 		// Quota exhausted: fixed 1 hour (only used as fallback when API doesn't return reset time)
 		ReasonQuotaExhausted: &FixedDurationPolicy{
@@ -99,8 +144,8 @@ func DefaultPolicies() map[CooldownReason]CooldownPolicy {
 		},
 		// Unknown error: linear increment (1min, 2min, 3min, ... max 5min)
 		ReasonUnknown: &LinearIncrementalPolicy{
-			BaseMinutes: 1,
-			MaxMinutes:  5,
+			Base: 1 * time.Minute,
+			Max:  5 * time.Minute,
 		},
 	}
 }
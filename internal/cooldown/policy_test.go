@@ -0,0 +1,144 @@
+package cooldown
+
+import (
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  domain.CooldownPolicyConfig
+		want []time.Duration // cooldown after 1, 2, 3, ... failures
+	}{
+		{
+			name: "fixed",
+			cfg:  domain.CooldownPolicyConfig{Type: domain.CooldownPolicyFixed, Base: time.Minute},
+			want: []time.Duration{time.Minute, time.Minute, time.Minute},
+		},
+		{
+			name: "linear",
+			cfg:  domain.CooldownPolicyConfig{Type: domain.CooldownPolicyLinear, Base: time.Minute},
+			want: []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute},
+		},
+		{
+			name: "linear capped",
+			cfg:  domain.CooldownPolicyConfig{Type: domain.CooldownPolicyLinear, Base: time.Minute, Max: 150 * time.Second},
+			want: []time.Duration{time.Minute, 2 * time.Minute, 150 * time.Second, 150 * time.Second},
+		},
+		{
+			name: "exponential",
+			cfg:  domain.CooldownPolicyConfig{Type: domain.CooldownPolicyExponential, Base: time.Second},
+			want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name: "exponential capped",
+			cfg:  domain.CooldownPolicyConfig{Type: domain.CooldownPolicyExponential, Base: time.Second, Max: 5 * time.Second},
+			want: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		policy := NewPolicy(&tt.cfg)
+		if policy == nil {
+			t.Errorf("%s: NewPolicy returned nil", tt.name)
+			continue
+		}
+		for i, want := range tt.want {
+			if got := policy.CalculateCooldown(i + 1); got != want {
+				t.Errorf("%s: cooldown after %d failures = %v, want %v", tt.name, i+1, got, want)
+			}
+		}
+		if tt.cfg.Type != domain.CooldownPolicyFixed {
+			if got := policy.CalculateCooldown(0); got != 0 {
+				t.Errorf("%s: cooldown without failures = %v, want 0", tt.name, got)
+			}
+		}
+	}
+
+	if policy := NewPolicy(&domain.CooldownPolicyConfig{Type: "random"}); policy != nil {
+		t.Errorf("NewPolicy(unknown type) = %T, want nil", policy)
+	}
+}
+
+func TestExponentialBackoffPolicy_NoOverflow(t *testing.T) {
+	policy := &ExponentialBackoffPolicy{Base: time.Hour}
+	if got := policy.CalculateCooldown(1000); got <= 0 {
+		t.Errorf("cooldown after 1000 failures = %v, want a positive duration", got)
+	}
+}
+
+func TestPolicyJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   CooldownPolicy
+		failures int
+		min, max time.Duration
+	}{
+		{
+			name:     "fixed ±20%",
+			policy:   &FixedDurationPolicy{Duration: 10 * time.Second, Jitter: 0.2},
+			failures: 1,
+			min:      8 * time.Second,
+			max:      12 * time.Second,
+		},
+		{
+			name:     "fixed capped by max",
+			policy:   NewPolicy(&domain.CooldownPolicyConfig{Type: domain.CooldownPolicyFixed, Base: 10 * time.Second, Max: 11 * time.Second, Jitter: 0.5}),
+			failures: 1,
+			min:      5 * time.Second,
+			max:      11 * time.Second,
+		},
+		{
+			name:     "linear ±50%",
+			policy:   &LinearIncrementalPolicy{Base: 10 * time.Second, Jitter: 0.5},
+			failures: 2,
+			min:      10 * time.Second,
+			max:      30 * time.Second,
+		},
+		{
+			name:     "exponential at the cap only jitters down",
+			policy:   &ExponentialBackoffPolicy{Base: 10 * time.Second, Max: 20 * time.Second, Jitter: 0.25},
+			failures: 5,
+			min:      15 * time.Second,
+			max:      20 * time.Second,
+		},
+		{
+			name:     "full jitter never goes negative",
+			policy:   &FixedDurationPolicy{Duration: time.Second, Jitter: 1},
+			failures: 1,
+			min:      0,
+			max:      2 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			got := tt.policy.CalculateCooldown(tt.failures)
+			if got < tt.min || got > tt.max {
+				t.Errorf("%s: cooldown = %v, want within [%v, %v]", tt.name, got, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestApplyJitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		d      time.Duration
+		jitter float64
+		max    time.Duration
+		want   time.Duration
+	}{
+		{"no jitter", time.Minute, 0, 0, time.Minute},
+		{"zero duration", 0, 0.5, 0, 0},
+		{"negative duration", -time.Second, 0, 0, 0},
+		{"max caps without jitter", time.Minute, 0, 30 * time.Second, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := applyJitter(tt.d, tt.jitter, tt.max); got != tt.want {
+			t.Errorf("%s: applyJitter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,22 +1,32 @@
 package core
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
 
 	"github.com/awsl-project/maxx/internal/adapter/client"
-	_ "github.com/awsl-project/maxx/internal/adapter/provider/antigravity"
+	"github.com/awsl-project/maxx/internal/adapter/provider/antigravity"
 	_ "github.com/awsl-project/maxx/internal/adapter/provider/custom"
+	"github.com/awsl-project/maxx/internal/budget"
+	"github.com/awsl-project/maxx/internal/circuit"
 	"github.com/awsl-project/maxx/internal/cooldown"
 	"github.com/awsl-project/maxx/internal/event"
+	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/executor"
 	"github.com/awsl-project/maxx/internal/handler"
+	"github.com/awsl-project/maxx/internal/health"
+	"github.com/awsl-project/maxx/internal/metrics"
+	"github.com/awsl-project/maxx/internal/pricing"
+	"github.com/awsl-project/maxx/internal/ratelimit"
 	"github.com/awsl-project/maxx/internal/repository"
 	"github.com/awsl-project/maxx/internal/repository/cached"
 	"github.com/awsl-project/maxx/internal/repository/sqlite"
 	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/secret"
 	"github.com/awsl-project/maxx/internal/service"
+	"github.com/awsl-project/maxx/internal/tracing"
 	"github.com/awsl-project/maxx/internal/waiter"
 )
 
@@ -42,12 +52,23 @@ type DatabaseRepos struct {
 	AntigravityQuotaRepo     repository.AntigravityQuotaRepository
 	CooldownRepo             repository.CooldownRepository
 	FailureCountRepo         repository.FailureCountRepository
+	APIKeyRepo               repository.APIKeyRepository
+	RateLimitRepo            repository.RateLimitRepository
+	BudgetRepo               repository.BudgetRepository
+	ProbeResultRepo          repository.ProbeResultRepository
+	CooldownPolicyRepo       repository.CooldownPolicyRepository
+	ModelPriceRepo           repository.ModelPriceRepository
+	UserRepo                 repository.UserRepository
+	AdminSessionRepo         repository.AdminSessionRepository
+	ThoughtSignatureRepo     repository.ThoughtSignatureRepository
+	SignatureCacheRepo       repository.SignatureCacheRepository
 	CachedProviderRepo        *cached.ProviderRepository
 	CachedRouteRepo          *cached.RouteRepository
 	CachedRetryConfigRepo    *cached.RetryConfigRepository
 	CachedRoutingStrategyRepo *cached.RoutingStrategyRepository
 	CachedSessionRepo        *cached.SessionRepository
 	CachedProjectRepo        *cached.ProjectRepository
+	CachedAPIKeyRepo         *cached.APIKeyRepository
 }
 
 // ServerComponents 包含服务器运行所需的所有组件
@@ -58,10 +79,14 @@ type ServerComponents struct {
 	Executor            *executor.Executor
 	ClientAdapter       *client.Adapter
 	AdminService        *service.AdminService
+	AuthService         *service.AuthService
 	ProxyHandler        *handler.ProxyHandler
 	AdminHandler        *handler.AdminHandler
+	AuthHandler         *handler.AuthHandler
+	AuthMiddleware      *handler.AuthMiddleware
 	AntigravityHandler  *handler.AntigravityHandler
 	ProjectProxyHandler *handler.ProjectProxyHandler
+	MetricsHandler      *handler.MetricsHandler
 }
 
 // InitializeDatabase 初始化数据库和所有仓库
@@ -73,6 +98,18 @@ func InitializeDatabase(config *DatabaseConfig) (*DatabaseRepos, error) {
 		return nil, err
 	}
 
+	keyring, source, err := secret.LoadKeyring(config.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	db.SetKeyring(keyring)
+	log.Printf("[Core] Provider secrets encrypted with master key %s (from %s)", keyring.ID(), source)
+	if count, err := db.EncryptProviderSecrets(); err != nil {
+		return nil, err
+	} else if count > 0 {
+		log.Printf("[Core] Encrypted secrets of %d existing providers", count)
+	}
+
 	providerRepo := sqlite.NewProviderRepository(db)
 	routeRepo := sqlite.NewRouteRepository(db)
 	projectRepo := sqlite.NewProjectRepository(db)
@@ -85,6 +122,16 @@ func InitializeDatabase(config *DatabaseConfig) (*DatabaseRepos, error) {
 	antigravityQuotaRepo := sqlite.NewAntigravityQuotaRepository(db)
 	cooldownRepo := sqlite.NewCooldownRepository(db)
 	failureCountRepo := sqlite.NewFailureCountRepository(db)
+	apiKeyRepo := sqlite.NewAPIKeyRepository(db)
+	rateLimitRepo := sqlite.NewRateLimitRepository(db)
+	budgetRepo := sqlite.NewBudgetRepository(db)
+	probeResultRepo := sqlite.NewProbeResultRepository(db)
+	cooldownPolicyRepo := sqlite.NewCooldownPolicyRepository(db)
+	modelPriceRepo := sqlite.NewModelPriceRepository(db)
+	userRepo := sqlite.NewUserRepository(db)
+	adminSessionRepo := sqlite.NewAdminSessionRepository(db)
+	thoughtSignatureRepo := sqlite.NewThoughtSignatureRepository(db)
+	signatureCacheRepo := sqlite.NewSignatureCacheRepository(db)
 
 	log.Printf("[Core] Creating cached repositories")
 
@@ -94,6 +141,7 @@ func InitializeDatabase(config *DatabaseConfig) (*DatabaseRepos, error) {
 	cachedRoutingStrategyRepo := cached.NewRoutingStrategyRepository(routingStrategyRepo)
 	cachedSessionRepo := cached.NewSessionRepository(sessionRepo)
 	cachedProjectRepo := cached.NewProjectRepository(projectRepo)
+	cachedAPIKeyRepo := cached.NewAPIKeyRepository(apiKeyRepo)
 
 	repos := &DatabaseRepos{
 		DB:                       db,
@@ -109,12 +157,23 @@ func InitializeDatabase(config *DatabaseConfig) (*DatabaseRepos, error) {
 		AntigravityQuotaRepo:     antigravityQuotaRepo,
 		CooldownRepo:             cooldownRepo,
 		FailureCountRepo:         failureCountRepo,
+		APIKeyRepo:               apiKeyRepo,
+		RateLimitRepo:            rateLimitRepo,
+		BudgetRepo:               budgetRepo,
+		ProbeResultRepo:          probeResultRepo,
+		CooldownPolicyRepo:       cooldownPolicyRepo,
+		ModelPriceRepo:           modelPriceRepo,
+		UserRepo:                 userRepo,
+		AdminSessionRepo:         adminSessionRepo,
+		ThoughtSignatureRepo:     thoughtSignatureRepo,
+		SignatureCacheRepo:       signatureCacheRepo,
 		CachedProviderRepo:        cachedProviderRepo,
 		CachedRouteRepo:          cachedRouteRepo,
 		CachedRetryConfigRepo:    cachedRetryConfigRepo,
 		CachedRoutingStrategyRepo: cachedRoutingStrategyRepo,
 		CachedSessionRepo:        cachedSessionRepo,
 		CachedProjectRepo:        cachedProjectRepo,
+		CachedAPIKeyRepo:         cachedAPIKeyRepo,
 	}
 
 	log.Printf("[Core] Database initialized successfully")
@@ -136,6 +195,38 @@ func InitializeServerComponents(
 	if err := cooldown.Default().LoadFromDatabase(); err != nil {
 		log.Printf("[Core] Warning: Failed to load cooldowns from database: %v", err)
 	}
+	cooldown.Default().SetPolicyRepository(repos.CooldownPolicyRepo)
+	if err := cooldown.Default().LoadPolicies(); err != nil {
+		log.Printf("[Core] Warning: Failed to load cooldown policies: %v", err)
+	}
+
+	log.Printf("[Core] Loading custom model prices")
+	if err := pricing.LoadPrices(repos.ModelPriceRepo); err != nil {
+		log.Printf("[Core] Warning: Failed to load model prices: %v", err)
+	}
+
+	log.Printf("[Core] Loading Antigravity thought signatures")
+	antigravity.DefaultThoughtSignatureStore().SetRepository(repos.ThoughtSignatureRepo)
+	if err := antigravity.DefaultThoughtSignatureStore().LoadFromDatabase(); err != nil {
+		log.Printf("[Core] Warning: Failed to load thought signatures: %v", err)
+	}
+	antigravity.GlobalSignatureCache().SetRepository(repos.SignatureCacheRepo)
+	antigravity.GlobalSignatureCache().ApplySettings(repos.SettingRepo)
+
+	// Circuit breaker thresholds (circuit_breaker_* settings)
+	circuit.Default().ApplySettings(repos.SettingRepo)
+
+	log.Printf("[Core] Loading rate limits")
+	ratelimit.Default().SetRepository(repos.RateLimitRepo)
+	if err := ratelimit.Default().Load(); err != nil {
+		log.Printf("[Core] Warning: Failed to load rate limits: %v", err)
+	}
+
+	log.Printf("[Core] Loading budgets")
+	budget.Default().SetRepository(repos.BudgetRepo)
+	if err := budget.Default().Load(); err != nil {
+		log.Printf("[Core] Warning: Failed to load budgets: %v", err)
+	}
 
 	log.Printf("[Core] Marking stale requests as failed")
 	if count, err := repos.ProxyRequestRepo.MarkStaleAsFailed(instanceID); err != nil {
@@ -160,6 +251,9 @@ func InitializeServerComponents(
 	if err := repos.CachedProjectRepo.Load(); err != nil {
 		log.Printf("[Core] Warning: Failed to load projects cache: %v", err)
 	}
+	if err := repos.CachedAPIKeyRepo.Load(); err != nil {
+		log.Printf("[Core] Warning: Failed to load api keys cache: %v", err)
+	}
 
 	log.Printf("[Core] Creating router")
 	r := router.NewRouter(
@@ -175,6 +269,9 @@ func InitializeServerComponents(
 		log.Printf("[Core] Warning: Failed to initialize adapters: %v", err)
 	}
 
+	log.Printf("[Core] Starting provider health prober")
+	go health.NewProber(repos.CachedProviderRepo, r, repos.ProbeResultRepo, repos.SettingRepo).Run(context.Background())
+
 	log.Printf("[Core] Starting cooldown cleanup goroutine")
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour)
@@ -188,6 +285,17 @@ func InitializeServerComponents(
 			if before != after {
 				log.Printf("[Core] Cooldown cleanup completed: removed %d expired entries", before-after)
 			}
+
+			if count, err := repos.AdminSessionRepo.DeleteExpired(); err == nil && count > 0 {
+				log.Printf("[Core] Removed %d expired admin sessions", count)
+			}
+
+			antigravity.DefaultThoughtSignatureStore().CleanupExpired()
+			antigravity.GlobalSignatureCache().CleanupExpired()
+
+			if err := repos.ProbeResultRepo.DeleteBefore(time.Now().Add(-health.HistoryRetention)); err != nil {
+				log.Printf("[Core] Failed to clean up probe history: %v", err)
+			}
 		}
 	}()
 
@@ -196,6 +304,8 @@ func InitializeServerComponents(
 
 	log.Printf("[Core] Creating Wails broadcaster (wraps WebSocket hub)")
 	wailsBroadcaster := event.NewWailsBroadcaster(wsHub)
+	budget.Default().SetBroadcaster(wailsBroadcaster)
+	circuit.Default().SetBroadcaster(wailsBroadcaster)
 
 	log.Printf("[Core] Setting up log output to broadcast via WebSocket")
 	logWriter := handler.NewWebSocketLogWriter(wsHub, os.Stdout, logPath)
@@ -230,15 +340,45 @@ func InitializeServerComponents(
 		repos.ProxyRequestRepo,
 		repos.AttemptRepo,
 		repos.SettingRepo,
+		repos.CachedAPIKeyRepo,
+		repos.RateLimitRepo,
+		repos.BudgetRepo,
+		repos.ProbeResultRepo,
+		repos.CooldownPolicyRepo,
+		repos.ModelPriceRepo,
 		addr,
 		r,
+		r,
+		antigravity.GlobalSignatureCache(),
 	)
 
+	log.Printf("[Core] Creating auth service")
+	authService := service.NewAuthService(repos.UserRepo, repos.AdminSessionRepo)
+	if err := authService.Bootstrap(os.Getenv("MAXX_ADMIN_USER"), os.Getenv("MAXX_ADMIN_PASSWORD")); err != nil {
+		log.Printf("[Core] Warning: Failed to create initial admin user: %v", err)
+	}
+	if setupToken, err := authService.NewSetupToken(); err != nil {
+		log.Printf("[Core] Warning: Failed to create setup token: %v", err)
+	} else if setupToken != "" {
+		log.Printf("[Core] No admin users configured. Open the web UI and create the first admin with setup token: %s", setupToken)
+	}
+
+	if value, _ := repos.SettingRepo.Get(domain.SettingKeyProxyAuthEnabled); value == "false" {
+		log.Printf("[Core] Warning: Proxy authentication is disabled (proxy_auth_enabled=false), proxy endpoints accept any caller")
+	}
+
 	log.Printf("[Core] Creating handlers")
-	proxyHandler := handler.NewProxyHandler(clientAdapter, exec, repos.CachedSessionRepo)
+	proxyHandler := handler.NewProxyHandler(clientAdapter, exec, repos.CachedSessionRepo, repos.CachedAPIKeyRepo, repos.SettingRepo)
 	adminHandler := handler.NewAdminHandler(adminService, logPath)
+	authHandler := handler.NewAuthHandler(authService)
+	authMiddleware := handler.NewAuthMiddleware(authService)
 	antigravityHandler := handler.NewAntigravityHandler(adminService, repos.AntigravityQuotaRepo, wailsBroadcaster)
 	projectProxyHandler := handler.NewProjectProxyHandler(proxyHandler, repos.CachedProjectRepo)
+	metrics.SetProviderRepository(repos.CachedProviderRepo)
+	metricsHandler := handler.NewMetricsHandler(os.Getenv("MAXX_METRICS_TOKEN"), authMiddleware)
+	if err := tracing.Init(tracing.ConfigFromEnv()); err != nil {
+		log.Printf("[Core] Warning: Failed to initialize tracing: %v", err)
+	}
 
 	components := &ServerComponents{
 		Router:              r,
@@ -247,10 +387,14 @@ func InitializeServerComponents(
 		Executor:            exec,
 		ClientAdapter:       clientAdapter,
 		AdminService:        adminService,
+		AuthService:         authService,
 		ProxyHandler:        proxyHandler,
 		AdminHandler:        adminHandler,
+		AuthHandler:         authHandler,
+		AuthMiddleware:      authMiddleware,
 		AntigravityHandler:  antigravityHandler,
 		ProjectProxyHandler: projectProxyHandler,
+		MetricsHandler:      metricsHandler,
 	}
 
 	log.Printf("[Core] Server components initialized successfully")
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/awsl-project/maxx/internal/handler"
+	"github.com/awsl-project/maxx/internal/tracing"
 )
 
 // ServerConfig 服务器配置
@@ -50,10 +51,15 @@ func (s *ManagedServer) setupRoutes() *http.ServeMux {
 
 	components := s.config.Components
 
-	mux.Handle("/admin/", components.AdminHandler)
-	mux.Handle("/antigravity/", components.AntigravityHandler)
+	auth := components.AuthMiddleware
+	mux.Handle("/admin/auth/", auth.Wrap(components.AuthHandler))
+	mux.Handle("/admin/users", auth.Wrap(components.AuthHandler))
+	mux.Handle("/admin/users/", auth.Wrap(components.AuthHandler))
+	mux.Handle("/admin/", auth.Wrap(components.AdminHandler))
+	mux.Handle("/antigravity/", auth.Wrap(components.AntigravityHandler))
 
 	mux.Handle("/v1/messages", components.ProxyHandler)
+	mux.Handle("/v1/messages/count_tokens", components.ProxyHandler)
 	mux.Handle("/v1/chat/completions", components.ProxyHandler)
 	mux.Handle("/responses", components.ProxyHandler)
 	mux.Handle("/v1beta/models/", components.ProxyHandler)
@@ -63,8 +69,9 @@ func (s *ManagedServer) setupRoutes() *http.ServeMux {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"ok"}`))
 	})
+	mux.Handle("/metrics", components.MetricsHandler)
 
-	mux.HandleFunc("/ws", components.WebSocketHub.HandleWebSocket)
+	mux.Handle("/ws", auth.WrapFunc(components.WebSocketHub.HandleWebSocket))
 
 	if s.config.ServeStatic {
 		staticHandler := handler.NewStaticHandler()
@@ -128,6 +135,10 @@ func (s *ManagedServer) Stop(ctx context.Context) error {
 		s.cancel()
 	}
 
+	// Export spans of the requests that just finished; the exporter keeps running
+	// because a restart reuses the components, the app shuts it down on exit
+	tracing.ForceFlush(shutdownCtx)
+
 	s.isRunning = false
 	log.Printf("[Server] Server stopped successfully")
 	return nil
@@ -3,8 +3,11 @@ package desktop
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/router"
 	"github.com/awsl-project/maxx/internal/service"
 )
 
@@ -86,6 +89,14 @@ func (a *DesktopApp) DeleteRoute(id uint64) error {
 	return a.components.AdminService.DeleteRoute(id)
 }
 
+func (a *DesktopApp) ValidateRouteMatchRules(rules []domain.RouteMatchRule) error {
+	return a.components.AdminService.ValidateRouteMatchRules(rules)
+}
+
+func (a *DesktopApp) ExplainRouteMatch(req service.RouteExplainRequest) (*router.MatchExplanation, error) {
+	return a.components.AdminService.ExplainRouteMatch(req)
+}
+
 // ===== Session API =====
 
 func (a *DesktopApp) GetSessions() ([]*domain.Session, error) {
@@ -100,6 +111,135 @@ func (a *DesktopApp) RejectSession(sessionID string) (*domain.Session, error) {
 	return a.components.AdminService.RejectSession(sessionID)
 }
 
+// ===== API Key API =====
+
+func (a *DesktopApp) GetAPIKeys() ([]*domain.APIKey, error) {
+	return a.components.AdminService.GetAPIKeys()
+}
+
+func (a *DesktopApp) GetAPIKey(id uint64) (*domain.APIKey, error) {
+	return a.components.AdminService.GetAPIKey(id)
+}
+
+func (a *DesktopApp) CreateAPIKey(key *domain.APIKey) (*domain.APIKey, error) {
+	return a.components.AdminService.CreateAPIKey(key)
+}
+
+func (a *DesktopApp) UpdateAPIKey(key *domain.APIKey) error {
+	return a.components.AdminService.UpdateAPIKey(key)
+}
+
+func (a *DesktopApp) DeleteAPIKey(id uint64) error {
+	return a.components.AdminService.DeleteAPIKey(id)
+}
+
+// ===== Rate Limit API =====
+
+func (a *DesktopApp) GetRateLimits() ([]*domain.RateLimit, error) {
+	return a.components.AdminService.GetRateLimits()
+}
+
+func (a *DesktopApp) GetRateLimit(id uint64) (*domain.RateLimit, error) {
+	return a.components.AdminService.GetRateLimit(id)
+}
+
+func (a *DesktopApp) CreateRateLimit(limit *domain.RateLimit) error {
+	return a.components.AdminService.CreateRateLimit(limit)
+}
+
+func (a *DesktopApp) UpdateRateLimit(limit *domain.RateLimit) error {
+	return a.components.AdminService.UpdateRateLimit(limit)
+}
+
+func (a *DesktopApp) DeleteRateLimit(id uint64) error {
+	return a.components.AdminService.DeleteRateLimit(id)
+}
+
+// ===== Budget API =====
+
+func (a *DesktopApp) GetBudgets() ([]*domain.Budget, error) {
+	return a.components.AdminService.GetBudgets()
+}
+
+func (a *DesktopApp) GetBudget(id uint64) (*domain.Budget, error) {
+	return a.components.AdminService.GetBudget(id)
+}
+
+func (a *DesktopApp) GetBudgetStatus() []*domain.BudgetStatus {
+	return a.components.AdminService.GetBudgetStatus()
+}
+
+func (a *DesktopApp) CreateBudget(b *domain.Budget) error {
+	return a.components.AdminService.CreateBudget(b)
+}
+
+func (a *DesktopApp) UpdateBudget(b *domain.Budget) error {
+	return a.components.AdminService.UpdateBudget(b)
+}
+
+func (a *DesktopApp) DeleteBudget(id uint64) error {
+	return a.components.AdminService.DeleteBudget(id)
+}
+
+// ===== Cooldown Policy API =====
+
+func (a *DesktopApp) GetCooldownPolicies() ([]*domain.CooldownPolicyConfig, error) {
+	return a.components.AdminService.GetCooldownPolicies()
+}
+
+func (a *DesktopApp) CreateCooldownPolicy(p *domain.CooldownPolicyConfig) error {
+	return a.components.AdminService.CreateCooldownPolicy(p)
+}
+
+func (a *DesktopApp) UpdateCooldownPolicy(p *domain.CooldownPolicyConfig) error {
+	return a.components.AdminService.UpdateCooldownPolicy(p)
+}
+
+func (a *DesktopApp) DeleteCooldownPolicy(id uint64) error {
+	return a.components.AdminService.DeleteCooldownPolicy(id)
+}
+
+// ===== Pricing API =====
+
+func (a *DesktopApp) GetModelPrices() ([]*domain.ModelPrice, error) {
+	return a.components.AdminService.GetModelPrices()
+}
+
+func (a *DesktopApp) CreateModelPrice(p *domain.ModelPrice) error {
+	return a.components.AdminService.CreateModelPrice(p)
+}
+
+func (a *DesktopApp) UpdateModelPrice(p *domain.ModelPrice) error {
+	return a.components.AdminService.UpdateModelPrice(p)
+}
+
+func (a *DesktopApp) DeleteModelPrice(id uint64) error {
+	return a.components.AdminService.DeleteModelPrice(id)
+}
+
+// ImportLiteLLMPrices imports a LiteLLM model_prices_and_context_window.json file
+// picked by the local desktop user
+func (a *DesktopApp) ImportLiteLLMPrices(path string) (*service.ImportResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+	return a.components.AdminService.ImportLiteLLMPrices(data)
+}
+
+// ExportLiteLLMPrices returns the effective price table as LiteLLM JSON
+func (a *DesktopApp) ExportLiteLLMPrices() (string, error) {
+	data, err := a.components.AdminService.ExportLiteLLMPrices()
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (a *DesktopApp) RecalculateCosts(since, until time.Time) (*service.RecalculateCostsResult, error) {
+	return a.components.AdminService.RecalculateCosts(since, until)
+}
+
 // ===== RetryConfig API =====
 
 func (a *DesktopApp) GetRetryConfigs() ([]*domain.RetryConfig, error) {
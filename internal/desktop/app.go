@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/awsl-project/maxx/internal/core"
+	"github.com/awsl-project/maxx/internal/tracing"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -117,6 +118,11 @@ func (a *DesktopApp) Shutdown(ctx context.Context) {
 		log.Printf("[Desktop] Failed to stop server: %v", err)
 	}
 
+	// Export the spans still queued and stop the exporter started by InitializeServerComponents
+	shutdownCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	tracing.Shutdown(shutdownCtx)
+	cancel()
+
 	if err := core.CloseDatabase(a.dbRepos); err != nil {
 		log.Printf("[Desktop] Failed to close database: %v", err)
 	}
@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// APIKeyPrefix maxx 签发的客户端 Key 统一前缀
+const APIKeyPrefix = "maxx-"
+
+// GenerateAPIKey 生成新的客户端 API Key
+// 返回明文 Key 和用于展示的前缀
+func GenerateAPIKey() (key, prefix string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:len(APIKeyPrefix)+8], nil
+}
+
+// HashAPIKey 计算 Key 的 SHA-256 哈希（十六进制），数据库只保存哈希
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+// 预算作用范围
+type BudgetScope string
+
+var (
+	BudgetScopeProject  BudgetScope = "project"
+	BudgetScopeProvider BudgetScope = "provider"
+)
+
+// 预算周期
+type BudgetPeriod string
+
+var (
+	BudgetPeriodDaily   BudgetPeriod = "daily"
+	BudgetPeriodMonthly BudgetPeriod = "monthly"
+)
+
+// 花费预算，每个 Scope + ScopeID + Period 最多一条
+type Budget struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Scope BudgetScope `json:"scope"`
+
+	// ProjectID / ProviderID，取决于 Scope
+	ScopeID uint64 `json:"scopeID"`
+
+	Period BudgetPeriod `json:"period"`
+
+	// 预算额度，单位：微美元（与 ProxyRequest.Cost 一致）
+	Limit uint64 `json:"limit"`
+
+	// 花费达到额度的百分比时发送警告，0 表示不警告
+	WarnPercent int `json:"warnPercent"`
+
+	// 超出额度后拒绝请求；否则只发送通知
+	HardLimit bool `json:"hardLimit"`
+
+	// 重置日历
+	// 时区（IANA 名称，如 Asia/Shanghai），为空表示服务器本地时区
+	Timezone string `json:"timezone"`
+	// 每天重置的小时（0-23）
+	ResetHour int `json:"resetHour"`
+	// 每月重置的日期（1-28），仅 monthly 使用，0 表示 1 号
+	ResetDay int `json:"resetDay"`
+
+	IsEnabled bool `json:"isEnabled"`
+}
+
+// 预算当前周期的使用情况
+type BudgetStatus struct {
+	Budget *Budget `json:"budget"`
+
+	// 当前周期内的花费（微美元）
+	Spent uint64 `json:"spent"`
+
+	PeriodStart time.Time `json:"periodStart"`
+	ResetsAt    time.Time `json:"resetsAt"`
+
+	Warned    bool `json:"warned"`
+	Exhausted bool `json:"exhausted"`
+}
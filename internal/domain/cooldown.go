@@ -24,3 +24,36 @@ type Cooldown struct {
 	UntilTime  time.Time      `json:"untilTime"`  // Absolute time when cooldown ends
 	Reason     CooldownReason `json:"reason"`     // Reason for cooldown
 }
+
+// CooldownPolicyType selects how the cooldown grows with consecutive failures
+type CooldownPolicyType string
+
+const (
+	CooldownPolicyFixed       CooldownPolicyType = "fixed"       // Always Base
+	CooldownPolicyLinear      CooldownPolicyType = "linear"      // Base * failureCount
+	CooldownPolicyExponential CooldownPolicyType = "exponential" // Base * 2^(failureCount-1)
+)
+
+// CooldownPolicyConfig overrides the built-in cooldown policy for a reason.
+// ProviderID 0 applies to all providers; a provider-specific policy wins over it.
+// At most one policy exists per ProviderID + Reason.
+type CooldownPolicyConfig struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	ProviderID uint64             `json:"providerID"` // 0 = all providers
+	Reason     CooldownReason     `json:"reason"`
+	Type       CooldownPolicyType `json:"type"`
+
+	// Base is the fixed duration, the linear step or the first exponential duration
+	Base time.Duration `json:"base"`
+
+	// Max caps the cooldown, 0 means no limit
+	Max time.Duration `json:"max"`
+
+	// Jitter randomizes the cooldown by up to ±Jitter (0-1, e.g. 0.2 = ±20%)
+	Jitter float64 `json:"jitter"`
+
+	IsEnabled bool `json:"isEnabled"`
+}
@@ -7,17 +7,32 @@ import (
 )
 
 var (
-    ErrNotFound          = errors.New("not found")
-    ErrAlreadyExists     = errors.New("already exists")
-    ErrSlugExists        = errors.New("slug already exists")
-    ErrInvalidInput      = errors.New("invalid input")
-    ErrNoRoutes          = errors.New("no routes available")
-    ErrAllRoutesFailed   = errors.New("all routes failed")
-    ErrFirstByteTimeout  = errors.New("first byte timeout")
-    ErrStreamIdleTimeout = errors.New("stream idle timeout")
-    ErrUpstreamError     = errors.New("upstream error")
-    ErrFormatConversion  = errors.New("format conversion error")
-    ErrUnsupportedFormat = errors.New("unsupported format")
+    ErrNotFound           = errors.New("not found")
+    ErrAlreadyExists      = errors.New("already exists")
+    ErrSlugExists         = errors.New("slug already exists")
+    ErrInvalidInput       = errors.New("invalid input")
+    ErrNoRoutes           = errors.New("no routes available")
+    ErrAllRoutesFailed    = errors.New("all routes failed")
+    ErrConnectTimeout     = errors.New("connect timeout")
+    ErrFirstByteTimeout   = errors.New("first byte timeout")
+    ErrStreamIdleTimeout  = errors.New("stream idle timeout")
+    ErrTotalTimeout       = errors.New("total timeout")
+    ErrUpstreamError      = errors.New("upstream error")
+    ErrFormatConversion   = errors.New("format conversion error")
+    ErrUnsupportedFormat  = errors.New("unsupported format")
+    ErrMissingAPIKey      = errors.New("missing api key")
+    ErrInvalidAPIKey      = errors.New("invalid api key")
+    ErrAPIKeyDisabled     = errors.New("api key disabled")
+    ErrAPIKeyExpired      = errors.New("api key expired")
+    ErrAPIKeyProject      = errors.New("api key not allowed for this project")
+    ErrInvalidCredentials = errors.New("invalid username or password")
+    ErrUnauthorized       = errors.New("authentication required")
+    ErrForbidden          = errors.New("permission denied")
+    ErrLastAdmin          = errors.New("cannot remove the last admin")
+    ErrInvalidSetupToken  = errors.New("invalid setup token")
+    ErrRateLimited        = errors.New("rate limit exceeded")
+    ErrBudgetExceeded     = errors.New("budget exceeded")
+    ErrCircuitOpen        = errors.New("circuit breaker open")
 )
 
 // ProxyError represents an error during proxy execution
@@ -33,6 +48,7 @@ type ProxyError struct {
     IsServerError      bool          // True for 5xx errors (triggers incremental cooldown)
     IsNetworkError     bool          // True for network errors (connection timeout, DNS failure, etc.)
     HTTPStatusCode     int           // HTTP status code (for logging and error handling)
+    ResponseCommitted  bool          // True when part of the response already reached the client (no failover possible)
 }
 
 // RateLimitInfo contains detailed rate limit information from providers
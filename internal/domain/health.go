@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// ProbeResult 是健康检查对某个 Provider + ClientType 的一次主动探测结果
+type ProbeResult struct {
+	ID         uint64        `json:"id"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ProviderID uint64        `json:"providerID"`
+	ClientType ClientType    `json:"clientType"`
+	Success    bool          `json:"success"`
+	StatusCode int           `json:"statusCode"` // 上游 HTTP 状态码，0 表示未收到响应
+	Latency    time.Duration `json:"latency"`
+	Error      string        `json:"error,omitempty"`
+	Rejected   bool          `json:"rejected"` // 上游拒绝了探测请求（不可重试的 4xx），说明 Provider 可达，不计入可用率
+}
+
+// ProbeSummary 是一个 Provider 在统计窗口内的探测汇总（Total/Succeeded/LastSuccess 不含被拒绝的探测）
+type ProbeSummary struct {
+	ProviderID  uint64
+	Total       uint64
+	Succeeded   uint64
+	LastProbeAt time.Time
+	LastSuccess bool
+}
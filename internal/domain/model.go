@@ -50,6 +50,9 @@ type ProviderConfigAntigravity struct {
 type ProviderConfig struct {
 	Custom      *ProviderConfigCustom      `json:"custom,omitempty"`
 	Antigravity *ProviderConfigAntigravity `json:"antigravity,omitempty"`
+
+	// 健康探测使用的模型（按 Client 类型），原样发送给上游；未配置时使用内置默认模型并经过模型映射
+	ProbeModels map[ClientType]string `json:"probeModels,omitempty"`
 }
 
 // Provider 供应商
@@ -73,6 +76,12 @@ type Provider struct {
 
 	// 支持的 Client
 	SupportedClientTypes []ClientType `json:"supportedClientTypes"`
+
+	// 订阅制（包月等），成本记为影子成本（ShadowCost），不计入实际成本和预算
+	IsSubscription bool `json:"isSubscription"`
+
+	// 计费覆盖（倍率、计费单位），nil 表示按官方价格计费
+	Pricing *ProviderPricing `json:"pricing,omitempty"`
 }
 
 type Project struct {
@@ -102,6 +111,78 @@ type Session struct {
 	RejectedAt *time.Time `json:"rejectedAt,omitempty"`
 }
 
+// 客户端 API Key（由 maxx 签发，用于访问代理端点）
+type APIKey struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// 展示的名称
+	Name string `json:"name"`
+
+	// Key 前缀，用于在列表中识别 Key（如 "maxx-1a2b3c4d"）
+	KeyPrefix string `json:"keyPrefix"`
+
+	// Key 的 SHA-256 哈希，明文不落库
+	KeyHash string `json:"-"`
+
+	// 明文 Key，仅在创建时返回一次
+	Key string `json:"key,omitempty"`
+
+	// 绑定的项目，0 表示不绑定（使用请求路径或会话中的项目）
+	ProjectID uint64 `json:"projectID"`
+
+	IsEnabled bool `json:"isEnabled"`
+
+	// 过期时间，nil 表示永不过期
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// 最近一次使用时间
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// 管理员角色
+type UserRole string
+
+var (
+	// 管理员：可读写全部管理接口
+	UserRoleAdmin UserRole = "admin"
+	// 只读用户：只能查看，不能修改
+	UserRoleViewer UserRole = "viewer"
+)
+
+// 管理后台用户
+type User struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Username string `json:"username"`
+
+	// 密码哈希（PBKDF2），不对外返回
+	PasswordHash string `json:"-"`
+
+	Role UserRole `json:"role"`
+
+	IsEnabled bool `json:"isEnabled"`
+
+	// 最近一次登录时间
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+}
+
+// 管理后台登录会话
+type AdminSession struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	UserID uint64 `json:"userID"`
+
+	// 会话 Token 的 SHA-256 哈希，明文只下发给客户端
+	TokenHash string `json:"-"`
+
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
 // 路由
 type Route struct {
 	ID        uint64    `json:"id"`
@@ -127,6 +208,43 @@ type Route struct {
 
 	// Model 映射: RequestModel → MappedModel，优先级高于 Provider
 	ModelMapping map[string]string `json:"modelMapping,omitempty"`
+
+	// 有序的模型映射规则，ModelMapping 精确匹配失败后按顺序尝试，第一条匹配的规则生效
+	ModelMappingRules []ModelMappingRule `json:"modelMappingRules,omitempty"`
+
+	// 备用模型，映射后的模型返回模型不存在或配额耗尽时，在同一 Provider 上依次尝试
+	FallbackModels []string `json:"fallbackModels,omitempty"`
+
+	// 匹配规则，满足任意一条即可使用该路由；为空表示匹配所有请求
+	MatchRules []RouteMatchRule `json:"matchRules,omitempty"`
+}
+
+// 路由匹配规则，所有已设置的条件都满足才算匹配
+type RouteMatchRule struct {
+	// 规则名称，用于 explain 输出
+	Name string `json:"name,omitempty"`
+
+	// 模型 glob，如 "claude-opus-*"、"*haiku*"（* 匹配任意字符，? 匹配单个字符）
+	Model string `json:"model,omitempty"`
+
+	// 模型正则，如 "^gemini-.*-image"
+	ModelRegex string `json:"modelRegex,omitempty"`
+
+	// nil 表示不限制；true 只匹配流式请求，false 只匹配非流式请求
+	Stream *bool `json:"stream,omitempty"`
+
+	// 输入 Token 估算上限，0 表示不限制
+	MaxInputTokens int `json:"maxInputTokens,omitempty"`
+}
+
+// 模型映射规则
+type ModelMappingRule struct {
+	// 匹配模式：默认为 glob（* 和 ? 作为捕获组），Regex 为 true 时为正则表达式
+	Pattern string `json:"pattern"`
+	Regex   bool   `json:"regex,omitempty"`
+
+	// 目标模型，支持捕获组替换，如 "${1}-latest"、"$name"
+	Target string `json:"target"`
 }
 
 type RequestInfo struct {
@@ -164,8 +282,9 @@ type ProxyRequest struct {
 	// 是否为 SSE 流式请求
 	IsStream bool `json:"isStream"`
 
-	// PENDING, IN_PROGRESS, COMPLETED, FAILED, REJECTED
+	// PENDING, IN_PROGRESS, COMPLETED, FAILED, REJECTED, UNAUTHORIZED
 	// REJECTED: 请求被拒绝（如：强制项目绑定超时）
+	// UNAUTHORIZED: 客户端 API Key 缺失或无效
 	Status string `json:"status"`
 
 	// HTTP 状态码（冗余存储，用于列表查询性能优化）
@@ -199,8 +318,11 @@ type ProxyRequest struct {
 	Cache5mWriteCount uint64 `json:"cache5mWriteCount"`
 	Cache1hWriteCount uint64 `json:"cache1hWriteCount"`
 
-	// 成本 (微美元，1 USD = 1,000,000)
+	// 成本 (微美元，1 USD = 1,000,000)，为所有 Attempt 成本之和（含失败、对冲落败和回退前的模型）
 	Cost uint64 `json:"cost"`
+
+	// 影子成本：订阅制 Provider 按标价计算的成本 (微美元)，不计入 Cost，同样为所有 Attempt 之和
+	ShadowCost uint64 `json:"shadowCost"`
 }
 
 type ProxyUpstreamAttempt struct {
@@ -213,6 +335,9 @@ type ProxyUpstreamAttempt struct {
 	EndTime   time.Time     `json:"endTime"`
 	Duration  time.Duration `json:"duration"`
 
+	// 首字节时间（从开始到第一次向客户端写出响应），0 表示未写出
+	TTFB time.Duration `json:"ttfb"`
+
 	// PENDING, IN_PROGRESS, COMPLETED, FAILED
 	Status string `json:"status"`
 
@@ -221,12 +346,18 @@ type ProxyUpstreamAttempt struct {
 	// 是否为 SSE 流式请求
 	IsStream bool `json:"isStream"`
 
+	// 是否参与了请求对冲（与另一个 Route 的 Attempt 并发执行）
+	Hedged bool `json:"hedged"`
+
 	RequestInfo  *RequestInfo  `json:"requestInfo"`
 	ResponseInfo *ResponseInfo `json:"responseInfo"`
 
 	RouteID    uint64 `json:"routeID"`
 	ProviderID uint64 `json:"providerID"`
 
+	// 发送给上游的模型（映射后），用于计算成本
+	MappedModel string `json:"mappedModel"`
+
 	// Token 使用情况
 	InputTokenCount  uint64 `json:"inputTokenCount"`
 	OutputTokenCount uint64 `json:"outputTokenCount"`
@@ -241,7 +372,8 @@ type ProxyUpstreamAttempt struct {
 	Cache5mWriteCount uint64 `json:"cache5mWriteCount"`
 	Cache1hWriteCount uint64 `json:"cache1hWriteCount"`
 
-	Cost uint64 `json:"cost"`
+	Cost       uint64 `json:"cost"`
+	ShadowCost uint64 `json:"shadowCost"` // 订阅制 Provider 的标价成本
 }
 
 // 重试配置
@@ -267,6 +399,28 @@ type RetryConfig struct {
 
 	// 最大间隔上限
 	MaxInterval time.Duration `json:"maxInterval"`
+
+	// 流式缓冲：在首个内容事件或达到字节阈值前暂存输出，
+	// 期间上游失败可透明切换到下一个路由
+	StreamBufferEnabled bool `json:"streamBufferEnabled"`
+
+	// 缓冲字节阈值，0 表示默认 16KB
+	StreamBufferBytes int `json:"streamBufferBytes"`
+
+	// 上游超时，0 表示不限制（或使用 Provider 适配器的默认值）
+	// 超时会作为可重试错误触发冷却并切换路由
+
+	// 建立连接超时
+	ConnectTimeout time.Duration `json:"connectTimeout"`
+
+	// 首字节超时：从请求开始到收到上游响应内容
+	FirstByteTimeout time.Duration `json:"firstByteTimeout"`
+
+	// 流式响应相邻两个数据块之间的最大间隔
+	StreamIdleTimeout time.Duration `json:"streamIdleTimeout"`
+
+	// 单次上游请求的总时长上限
+	TotalTimeout time.Duration `json:"totalTimeout"`
 }
 
 // 路由策略类型
@@ -277,12 +431,47 @@ var (
 	RoutingStrategyPriority RoutingStrategyType = "priority"
 	// 加权随机
 	RoutingStrategyWeightedRandom RoutingStrategyType = "weighted_random"
+	// 最低延迟（按 Provider 近期延迟的滑动平均排序）
+	RoutingStrategyLeastLatency RoutingStrategyType = "least_latency"
+	// 最低成本（按映射后模型在价格表中的单价排序）
+	RoutingStrategyLeastCost RoutingStrategyType = "least_cost"
+)
+
+// 延迟指标
+type LatencyMetric string
+
+var (
+	// 首字节时间（默认，适合流式请求）
+	LatencyMetricTTFB LatencyMetric = "ttfb"
+	// 完整请求耗时
+	LatencyMetricDuration LatencyMetric = "duration"
 )
 
 // 路由策略配置（策略特定参数）
 type RoutingStrategyConfig struct {
-	// 加权随机策略的权重配置等
-	// 根据具体策略扩展
+	// 加权随机：RouteID → 权重，未配置的 Route 权重为 DefaultWeight
+	// 权重为 0 的 Route 不参与随机，只作为兜底排在最后
+	Weights map[uint64]int `json:"weights,omitempty"`
+
+	// 加权随机：未配置权重的 Route 使用的权重，0 表示 1
+	DefaultWeight int `json:"defaultWeight,omitempty"`
+
+	// 最低延迟：使用的延迟指标，空值为 ttfb
+	LatencyMetric LatencyMetric `json:"latencyMetric,omitempty"`
+
+	// 会话粘滞：优先使用上次成功服务该 Session 的 Provider（保持 Prompt Cache 命中）
+	// Provider 冷却或不可用时按正常顺序回退
+	StickySession bool `json:"stickySession,omitempty"`
+
+	// 会话粘滞的有效期（每次成功请求后刷新），0 表示默认 1 小时
+	StickySessionTTL time.Duration `json:"stickySessionTTL,omitempty"`
+
+	// 请求对冲：非流式请求在 HedgeDelay 内未完成时，同时向下一个 Route 发送相同请求，
+	// 采用先成功的响应并取消另一个
+	Hedging bool `json:"hedging,omitempty"`
+
+	// 请求对冲的等待时间，0 表示默认 2 秒
+	HedgeDelay time.Duration `json:"hedgeDelay,omitempty"`
 }
 
 // 路由策略
@@ -311,7 +500,20 @@ type SystemSetting struct {
 
 // 系统设置 Key 常量
 const (
-	SettingKeyProxyPort = "proxy_port" // 代理服务器端口，默认 9880
+	SettingKeyProxyPort        = "proxy_port"         // 代理服务器端口，默认 9880
+	SettingKeyProxyAuthEnabled = "proxy_auth_enabled" // 代理端点是否要求客户端 API Key，默认要求，"false" 显式关闭
+
+	SettingKeySignatureCacheBackend    = "antigravity_signature_cache_backend"     // Antigravity 签名缓存后端：memory（默认）或 sqlite
+	SettingKeySignatureCacheMaxEntries = "antigravity_signature_cache_max_entries" // 签名缓存每层最多条目数，默认 1000
+	SettingKeySignatureCacheTTL        = "antigravity_signature_cache_ttl"         // 签名缓存有效期（秒），默认 7200
+
+	SettingKeyHealthCheckInterval = "health_check_interval" // Provider 主动健康检查间隔（秒），0 或未设置表示关闭
+
+	SettingKeyCircuitWindow           = "circuit_breaker_window"             // 熔断失败率统计窗口（秒），默认 60
+	SettingKeyCircuitMinRequests      = "circuit_breaker_min_requests"       // 窗口内至少多少请求才计算失败率，默认 10
+	SettingKeyCircuitFailureRate      = "circuit_breaker_failure_rate"       // 触发熔断的失败率（0-1），默认 0.5
+	SettingKeyCircuitOpenDuration     = "circuit_breaker_open_duration"      // 熔断打开时长（秒），默认 30
+	SettingKeyCircuitHalfOpenRequests = "circuit_breaker_half_open_requests" // 半开状态放行的试探请求数，默认 3
 )
 
 // Antigravity 模型配额
@@ -372,5 +574,16 @@ type ProviderStats struct {
 	TotalCacheWrite   uint64 `json:"totalCacheWrite"`
 
 	// 成本 (微美元)
-	TotalCost uint64 `json:"totalCost"`
+	TotalCost       uint64 `json:"totalCost"`
+	TotalShadowCost uint64 `json:"totalShadowCost"` // 订阅制 Provider 的标价成本
+
+	// 按 Provider 计费单位折算的成本（微单位），仅设置了计费单位的 Provider 有值
+	BillingCurrency string `json:"billingCurrency,omitempty"`
+	TotalBilledCost uint64 `json:"totalBilledCost,omitempty"`
+
+	// 健康检查（最近 24 小时的主动探测）
+	ProbeCount       uint64     `json:"probeCount"`
+	Uptime           float64    `json:"uptime"` // 探测成功率 0-100
+	LastProbeAt      *time.Time `json:"lastProbeAt,omitempty"`
+	LastProbeSuccess bool       `json:"lastProbeSuccess"`
 }
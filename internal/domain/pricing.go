@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// 自定义模型价格，覆盖或补充内置价格表
+// 价格单位：微美元/百万tokens (microUSD/M tokens)，与 pricing.ModelPricing 一致
+type ModelPrice struct {
+	// 0 表示内置价格（未保存到数据库）
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// 模型 ID 或前缀，如 "claude-sonnet-4-5"（也匹配 "claude-sonnet-4-5-20250929"）
+	ModelID string `json:"modelId"`
+
+	InputPriceMicro  uint64 `json:"inputPriceMicro"`
+	OutputPriceMicro uint64 `json:"outputPriceMicro"`
+
+	// 缓存价格，0 表示使用默认值（读取 input / 10，5分钟写入 input * 5/4，1小时写入 input * 2）
+	CacheReadPriceMicro    uint64 `json:"cacheReadPriceMicro"`
+	Cache5mWritePriceMicro uint64 `json:"cache5mWritePriceMicro"`
+	Cache1hWritePriceMicro uint64 `json:"cache1hWritePriceMicro"`
+
+	// 超过阈值部分的分层定价
+	Has1MContext       bool   `json:"has1mContext"`
+	Context1MThreshold uint64 `json:"context1mThreshold"`
+	InputPremiumNum    uint64 `json:"inputPremiumNum"`
+	InputPremiumDenom  uint64 `json:"inputPremiumDenom"`
+	OutputPremiumNum   uint64 `json:"outputPremiumNum"`
+	OutputPremiumDenom uint64 `json:"outputPremiumDenom"`
+}
+
+// Provider 计费覆盖，用于按官方价格折扣或以自有额度计费的中转站
+// 实际成本 = 官方价格成本 × 倍率 × USDPerUnit（Currency 为空时计费单位即美元）
+type ProviderPricing struct {
+	// 全局倍率，如 0.3 表示按官方价格的 30% 计费；0 表示 1
+	Multiplier float64 `json:"multiplier"`
+
+	// 按模型覆盖倍率，key 为模型 ID 或前缀（最长前缀优先），优先于 Multiplier
+	ModelMultipliers map[string]float64 `json:"modelMultipliers,omitempty"`
+
+	// 计费单位，如 "CNY"、"credits"；空表示美元
+	Currency string `json:"currency,omitempty"`
+
+	// 1 个计费单位折合的美元数，如 1 CNY = 0.14 USD；Currency 为空时忽略
+	USDPerUnit float64 `json:"usdPerUnit,omitempty"`
+}
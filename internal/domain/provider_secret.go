@@ -0,0 +1,52 @@
+package domain
+
+// MaskSecret 遮蔽密钥，仅保留首尾各 4 个字符（短密钥全部遮蔽）
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) <= 12 {
+		return "********"
+	}
+	return s[:4] + "********" + s[len(s)-4:]
+}
+
+// MaskedCopy 返回密钥字段已遮蔽的副本，用于管理接口响应
+func (p *Provider) MaskedCopy() *Provider {
+	out := *p
+	if p.Config == nil {
+		return &out
+	}
+	cfg := *p.Config
+	if cfg.Custom != nil {
+		custom := *cfg.Custom
+		custom.APIKey = MaskSecret(custom.APIKey)
+		cfg.Custom = &custom
+	}
+	if cfg.Antigravity != nil {
+		antigravity := *cfg.Antigravity
+		antigravity.RefreshToken = MaskSecret(antigravity.RefreshToken)
+		cfg.Antigravity = &antigravity
+	}
+	out.Config = &cfg
+	return &out
+}
+
+// RestoreMaskedSecrets 当更新请求回传的是遮蔽后的密钥时，保留原有密钥
+func (p *Provider) RestoreMaskedSecrets(existing *Provider) {
+	if p.Config == nil || existing == nil || existing.Config == nil {
+		return
+	}
+	if p.Config.Custom != nil && existing.Config.Custom != nil {
+		old := existing.Config.Custom.APIKey
+		if old != "" && p.Config.Custom.APIKey == MaskSecret(old) {
+			p.Config.Custom.APIKey = old
+		}
+	}
+	if p.Config.Antigravity != nil && existing.Config.Antigravity != nil {
+		old := existing.Config.Antigravity.RefreshToken
+		if old != "" && p.Config.Antigravity.RefreshToken == MaskSecret(old) {
+			p.Config.Antigravity.RefreshToken = old
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// 限流作用范围
+type RateLimitScope string
+
+var (
+	RateLimitScopeProvider RateLimitScope = "provider"
+	RateLimitScopeProject  RateLimitScope = "project"
+	RateLimitScopeAPIKey   RateLimitScope = "api_key"
+)
+
+// 限流配置（令牌桶），每个 Scope + ScopeID 最多一条
+type RateLimit struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Scope RateLimitScope `json:"scope"`
+
+	// ProviderID / ProjectID / APIKeyID，取决于 Scope
+	ScopeID uint64 `json:"scopeID"`
+
+	// 每分钟请求数，0 表示不限制
+	RPM int `json:"rpm"`
+
+	// 每分钟 Token 数（输入 + 输出），0 表示不限制
+	// 请求开始时按请求体估算扣除，完成后按实际用量校正
+	TPM int `json:"tpm"`
+
+	// 最大并发请求数，0 表示不限制
+	MaxConcurrency int `json:"maxConcurrency"`
+
+	// 超限时排队等待的最长时间，0 表示直接返回 429
+	QueueTimeout time.Duration `json:"queueTimeout"`
+
+	IsEnabled bool `json:"isEnabled"`
+}
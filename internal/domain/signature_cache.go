@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// Antigravity 签名缓存层
+const (
+	SignatureCacheLayerTool   = "tool"   // tool_use_id -> thought signature
+	SignatureCacheLayerFamily = "family" // thought signature -> 模型族
+)
+
+// Antigravity 签名缓存后端
+const (
+	SignatureCacheBackendMemory = "memory" // 进程内存（默认），重启丢失
+	SignatureCacheBackendSQLite = "sqlite" // maxx 数据库，重启保留，可在多实例间共享
+)
+
+// SignatureCacheEntry 是签名缓存的一条记录
+type SignatureCacheEntry struct {
+	Layer     string    `json:"layer"` // tool 或 family
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SignatureCacheStats 是签名缓存的实时状态（在 /admin/proxy-status 中展示）
+type SignatureCacheStats struct {
+	Backend       string `json:"backend"`
+	MaxEntries    int    `json:"maxEntries"`
+	TTLSeconds    int64  `json:"ttlSeconds"`
+	ToolEntries   int    `json:"toolEntries"`
+	FamilyEntries int    `json:"familyEntries"`
+	ToolHits      int64  `json:"toolHits"`
+	ToolMisses    int64  `json:"toolMisses"`
+	FamilyHits    int64  `json:"familyHits"`
+	FamilyMisses  int64  `json:"familyMisses"`
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// ThoughtSignature 是 Antigravity 按会话和模型族保存的最近一次 thought signature，
+// 客户端在工具循环中丢弃签名时用于回填
+type ThoughtSignature struct {
+	SessionID   string    `json:"sessionID"`   // 没有会话 ID 的请求不保存签名
+	ModelFamily string    `json:"modelFamily"` // 签名只在同一模型族内有效
+	Signature   string    `json:"signature"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,105 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/adapter/provider"
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/tokenizer"
+)
+
+// CountTokens answers Claude's /v1/messages/count_tokens
+// The request is routed like a normal Claude request: the first matched route counts natively
+// when its provider speaks Claude, otherwise (Antigravity, converted routes, upstream errors)
+// the count is estimated locally. Only a single ProxyRequest without attempts is recorded.
+func (e *Executor) CountTokens(ctx context.Context, w http.ResponseWriter, req *http.Request) error {
+	proxyReq := e.newProxyRequest(ctx, req)
+	// count_tokens bodies carry the whole conversation, don't store them again
+	proxyReq.RequestInfo.Body = ""
+
+	ctx, span := startRequestSpan(ctx, req, "maxx.count_tokens")
+	defer func() {
+		endRequestSpan(span, proxyReq, "")
+	}()
+
+	source := "estimate"
+	var tokens int
+	counted := false
+
+	routes, err := e.router.Match(&router.MatchContext{
+		ClientType:   ctxutil.GetClientType(ctx),
+		ProjectID:    ctxutil.GetProjectID(ctx),
+		RequestModel: ctxutil.GetRequestModel(ctx),
+		SessionID:    ctxutil.GetSessionID(ctx),
+		InputTokens:  tokenizer.EstimateRequest(ctxutil.GetRequestBody(ctx)),
+	})
+	if err == nil && len(routes) > 0 {
+		matchedRoute := routes[0]
+		proxyReq.RouteID = matchedRoute.Route.ID
+		proxyReq.ProviderID = matchedRoute.Provider.ID
+
+		if counter, ok := matchedRoute.ProviderAdapter.(provider.TokenCounter); ok {
+			countCtx := ctxutil.WithMappedModel(ctx, router.MapModel(proxyReq.RequestModel, matchedRoute.Route, matchedRoute.Provider))
+			n, err := counter.CountTokens(countCtx, matchedRoute.Provider)
+			switch {
+			case err == nil:
+				tokens, counted, source = n, true, "provider"
+				span.SetAttr("maxx.provider", matchedRoute.Provider.Name)
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case !errors.Is(err, domain.ErrUnsupportedFormat):
+				log.Printf("[Executor] count_tokens on provider %d failed, using local estimate: %v", matchedRoute.Provider.ID, err)
+			}
+		}
+	}
+
+	if !counted {
+		n, err := tokenizer.EstimateClaudeInput(ctxutil.GetRequestBody(ctx))
+		if err != nil {
+			e.recordCountTokens(proxyReq, "FAILED", http.StatusBadRequest, "", "invalid request body: "+err.Error())
+			return domain.NewProxyErrorWithMessage(domain.ErrInvalidInput, false, "invalid request body")
+		}
+		tokens = n
+	}
+
+	body, _ := json.Marshal(map[string]int{"input_tokens": tokens})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+
+	proxyReq.InputTokenCount = uint64(tokens)
+	span.SetAttr("maxx.token_count_source", source)
+	e.recordCountTokens(proxyReq, "COMPLETED", http.StatusOK, string(body), "")
+	log.Printf("[Executor] count_tokens: %d input tokens (%s)", tokens, source)
+	return nil
+}
+
+// recordCountTokens stores a count_tokens request in its final state
+func (e *Executor) recordCountTokens(proxyReq *domain.ProxyRequest, status string, statusCode int, responseBody, errMsg string) {
+	proxyReq.Status = status
+	proxyReq.StatusCode = statusCode
+	proxyReq.Error = errMsg
+	proxyReq.EndTime = time.Now()
+	proxyReq.Duration = proxyReq.EndTime.Sub(proxyReq.StartTime)
+	if responseBody != "" {
+		proxyReq.ResponseInfo = &domain.ResponseInfo{
+			Status:  statusCode,
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    responseBody,
+		}
+	}
+	if err := e.proxyRequestRepo.Create(proxyReq); err != nil {
+		log.Printf("[Executor] Failed to record count_tokens request: %v", err)
+		return
+	}
+	if e.broadcaster != nil {
+		e.broadcaster.BroadcastProxyRequest(proxyReq)
+	}
+}
@@ -2,16 +2,24 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
+	"github.com/awsl-project/maxx/internal/budget"
+	"github.com/awsl-project/maxx/internal/circuit"
 	"github.com/awsl-project/maxx/internal/cooldown"
 	ctxutil "github.com/awsl-project/maxx/internal/context"
 	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/event"
+	"github.com/awsl-project/maxx/internal/metrics"
+	"github.com/awsl-project/maxx/internal/ratelimit"
 	"github.com/awsl-project/maxx/internal/repository"
 	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/tokenizer"
 	"github.com/awsl-project/maxx/internal/usage"
 	"github.com/awsl-project/maxx/internal/waiter"
 )
@@ -62,28 +70,8 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 	log.Printf("[Executor] clientType=%s, projectID=%d, model=%s, isStream=%v", clientType, projectID, requestModel, isStream)
 
 	// Create proxy request record immediately (PENDING status)
-	proxyReq := &domain.ProxyRequest{
-		InstanceID:   e.instanceID,
-		RequestID:    generateRequestID(),
-		SessionID:    sessionID,
-		ClientType:   clientType,
-		ProjectID:    projectID,
-		RequestModel: requestModel,
-		StartTime:    time.Now(),
-		IsStream:     isStream,
-		Status:       "PENDING",
-	}
-
-	// Capture client's original request info
-	requestURI := ctxutil.GetRequestURI(ctx)
-	requestHeaders := ctxutil.GetRequestHeaders(ctx)
-	requestBody := ctxutil.GetRequestBody(ctx)
-	proxyReq.RequestInfo = &domain.RequestInfo{
-		Method:  req.Method,
-		URL:     requestURI,
-		Headers: flattenHeaders(requestHeaders),
-		Body:    string(requestBody),
-	}
+	proxyReq := e.newProxyRequest(ctx, req)
+	proxyReq.Status = "PENDING"
 
 	if err := e.proxyRequestRepo.Create(proxyReq); err != nil {
 		log.Printf("[Executor] Failed to create proxy request: %v", err)
@@ -96,6 +84,16 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 
 	ctx = ctxutil.WithProxyRequest(ctx, proxyReq)
 
+	// Telemetry: provider and TTFB of the attempt that served the request
+	var servedBy string
+	var servedTTFB time.Duration
+	ctx, requestSpan := startRequestSpan(ctx, req, "maxx.proxy_request")
+	defer metrics.RequestStarted(clientType)()
+	defer func() {
+		metrics.ObserveRequest(proxyReq, servedBy, servedTTFB)
+		endRequestSpan(requestSpan, proxyReq, servedBy)
+	}()
+
 	// Check for project binding if required
 	if projectID == 0 && e.projectWaiter != nil {
 		// Get session for project waiter
@@ -144,10 +142,38 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 		ctx = ctxutil.WithProjectID(ctx, projectID)
 	}
 
+	// Hard project budgets
+	if err := budget.Default().Check(domain.BudgetScopeProject, projectID); err != nil {
+		return e.rejectBudgetExceeded(proxyReq, err)
+	}
+
+	// Project and client key limits (queues up to the limit's QueueTimeout)
+	estimatedTokens := tokenizer.EstimateRequest(ctxutil.GetRequestBody(ctx))
+	limitKeys := []ratelimit.Key{{Scope: domain.RateLimitScopeProject, ScopeID: projectID}}
+	if apiKeyID := ctxutil.GetAPIKeyID(ctx); apiKeyID != 0 {
+		limitKeys = append(limitKeys, ratelimit.Key{Scope: domain.RateLimitScopeAPIKey, ScopeID: apiKeyID})
+	}
+	permit, err := ratelimit.Default().Acquire(ctx, limitKeys, estimatedTokens, true)
+	if err != nil {
+		return e.rejectRateLimited(proxyReq, err)
+	}
+	defer func() {
+		permit.Release(int(proxyReq.InputTokenCount + proxyReq.OutputTokenCount))
+	}()
+
 	// Match routes
-	routes, err := e.router.Match(clientType, projectID)
+	routes, err := e.router.Match(&router.MatchContext{
+		ClientType:   clientType,
+		ProjectID:    projectID,
+		RequestModel: requestModel,
+		SessionID:    sessionID,
+		IsStream:     isStream,
+		InputTokens:  estimatedTokens,
+	})
 	if err != nil {
 		log.Printf("[Executor] Route match error: %v", err)
+		// Nothing was sent upstream, give the capacity back
+		permit.Cancel()
 		proxyReq.Status = "FAILED"
 		proxyReq.Error = "no routes available"
 		proxyReq.EndTime = time.Now()
@@ -224,9 +250,22 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 		}
 	}()
 
+	// Hedging: a slow non-streaming request is raced against the next route (once per request)
+	var hedgeDelay time.Duration
+	if !isStream {
+		hedgeDelay = e.router.HedgeDelay(projectID)
+	}
+	var hedgeFailedRoute *router.MatchedRoute
+
 	// Try routes in order with retry logic
 	var lastErr error
+	var responseCommitted bool
+	var circuitRetryAfter time.Duration // shortest wait among routes skipped by an open breaker
 	for routeIdx, matchedRoute := range routes {
+		if matchedRoute == hedgeFailedRoute {
+			log.Printf("[Executor] Route %d already failed as a hedged attempt, skipping", routeIdx+1)
+			continue
+		}
 		log.Printf("[Executor] Trying route %d/%d: routeID=%d, providerID=%d, provider=%s",
 			routeIdx+1, len(routes), matchedRoute.Route.ID, matchedRoute.Provider.ID, matchedRoute.Provider.Name)
 
@@ -236,7 +275,26 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 			return ctx.Err()
 		}
 
+		// Skip providers whose hard budget is exhausted
+		if err := budget.Default().Check(domain.BudgetScopeProvider, matchedRoute.Provider.ID); err != nil {
+			log.Printf("[Executor] Route %d: %v, moving to next route", routeIdx+1, err)
+			lastErr = budgetError(err)
+			continue
+		}
+
+		// Skip providers whose circuit breaker is open (half-open only admits a few trial requests)
+		if !circuit.Default().Allow(matchedRoute.Provider.ID, string(clientType)) {
+			log.Printf("[Executor] Route %d: circuit open for provider %d, moving to next route", routeIdx+1, matchedRoute.Provider.ID)
+			wait := circuit.Default().RetryAfter(matchedRoute.Provider.ID, string(clientType))
+			if circuitRetryAfter == 0 || wait < circuitRetryAfter {
+				circuitRetryAfter = wait
+			}
+			lastErr = circuitOpenError(circuitRetryAfter)
+			continue
+		}
+
 		// Update proxyReq with current route/provider for real-time tracking
+		servedBy = matchedRoute.Provider.Name
 		proxyReq.RouteID = matchedRoute.Route.ID
 		proxyReq.ProviderID = matchedRoute.Provider.ID
 		_ = e.proxyRequestRepo.Update(proxyReq)
@@ -244,9 +302,10 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 			e.broadcaster.BroadcastProxyRequest(proxyReq)
 		}
 
-		// Determine model mapping
-		mappedModel := e.mapModel(requestModel, matchedRoute.Route, matchedRoute.Provider)
-		ctx = ctxutil.WithMappedModel(ctx, mappedModel)
+		// Determine model mapping (the route's fallback models follow the mapped model)
+		models := router.ModelChain(requestModel, matchedRoute.Route, matchedRoute.Provider)
+		modelIdx := 0
+		ctx = ctxutil.WithMappedModel(ctx, models[modelIdx])
 
 		// Get retry config
 		retryConfig := e.getRetryConfig(matchedRoute.RetryConfig)
@@ -258,12 +317,26 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 				return ctx.Err()
 			}
 
+			// Provider limits: skip to the next route when exhausted, only the last route queues
+			providerPermit, err := ratelimit.Default().Acquire(ctx, []ratelimit.Key{
+				{Scope: domain.RateLimitScopeProvider, ScopeID: matchedRoute.Provider.ID},
+			}, estimatedTokens, routeIdx == len(routes)-1)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				log.Printf("[Executor] Route %d: %v, moving to next route", routeIdx+1, err)
+				lastErr = rateLimitError(err)
+				break
+			}
+
 			// Create attempt record with start time
 			attemptStartTime := time.Now()
 			attemptRecord := &domain.ProxyUpstreamAttempt{
 				ProxyRequestID: proxyReq.ID,
 				RouteID:        matchedRoute.Route.ID,
 				ProviderID:     matchedRoute.Provider.ID,
+				MappedModel:    models[modelIdx],
 				IsStream:       isStream,
 				Status:         "IN_PROGRESS",
 				StartTime:      attemptStartTime,
@@ -292,19 +365,64 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 
 			// Put attempt into context so adapter can populate request/response info
 			attemptCtx := ctxutil.WithUpstreamAttempt(ctx, attemptRecord)
+			attemptCtx = ctxutil.WithRetryConfig(attemptCtx, retryConfig)
+			attemptCtx, attemptSpan := startAttemptSpan(attemptCtx, matchedRoute, attemptRecord, attempt)
 
 			// Wrap ResponseWriter to capture actual client response
-			responseCapture := NewResponseCapture(w)
+			// (behind a stream buffer when enabled, so early upstream failures can still fail over)
+			var streamBuffer *StreamBuffer
+			var clientWriter http.ResponseWriter = w
+			if isStream && retryConfig.StreamBufferEnabled {
+				streamBuffer = NewStreamBuffer(w, clientType, retryConfig.StreamBufferBytes)
+				clientWriter = streamBuffer
+			}
+			responseCapture := NewResponseCapture(clientWriter)
 
 			// Execute request
 			log.Printf("[Executor] Route %d, attempt %d: executing...", routeIdx+1, attempt+1)
-			err := matchedRoute.ProviderAdapter.Execute(attemptCtx, responseCapture, req, matchedRoute.Provider)
+			var hedgeWinner *hedgeLeg
+			if hedgeDelay > 0 && routeIdx+1 < len(routes) {
+				backup, delay := routes[routeIdx+1], hedgeDelay
+				hedgeDelay = 0
+				hedgeWinner = e.executeHedged(ctx, responseCapture, req, &hedgeLeg{
+					route:  matchedRoute,
+					record: attemptRecord,
+					ctx:    attemptCtx,
+					span:   attemptSpan,
+				}, backup, delay)
+				err = hedgeWinner.err
+				if err != nil && attemptRecord.Hedged {
+					hedgeFailedRoute = backup
+				}
+			} else {
+				err = matchedRoute.ProviderAdapter.Execute(attemptCtx, responseCapture, req, matchedRoute.Provider)
+				applySubscriptionCost(matchedRoute.Provider, attemptRecord)
+			}
+			providerPermit.Release(int(attemptRecord.InputTokenCount + attemptRecord.OutputTokenCount))
+			recordAttemptCost(proxyReq, projectID, matchedRoute.Provider, attemptRecord)
+			if hedgeWinner != nil && hedgeWinner.record != attemptRecord {
+				// The backup route won the race (the primary attempt was finalized as the loser)
+				matchedRoute, attemptRecord, attemptCtx, attemptSpan = hedgeWinner.route, hedgeWinner.record, hedgeWinner.ctx, hedgeWinner.span
+				currentAttempt = attemptRecord
+				servedBy = matchedRoute.Provider.Name
+				proxyReq.RouteID = matchedRoute.Route.ID
+				proxyReq.ProviderID = matchedRoute.Provider.ID
+			}
 			if err == nil {
+				// Send anything still held back by the stream buffer
+				if streamBuffer != nil {
+					_ = streamBuffer.Commit()
+				}
+
 				// Success - set end time and duration
 				attemptRecord.EndTime = time.Now()
 				attemptRecord.Duration = attemptRecord.EndTime.Sub(attemptRecord.StartTime)
+				attemptRecord.TTFB = responseCapture.TTFB(attemptRecord.StartTime)
 				log.Printf("[Executor] Route %d, attempt %d: SUCCESS", routeIdx+1, attempt+1)
 				attemptRecord.Status = "COMPLETED"
+				metrics.ObserveAttempt(clientType, matchedRoute.Provider.Name, attemptRecord)
+				endAttemptSpan(attemptSpan, attemptRecord, nil)
+				servedTTFB = attemptRecord.TTFB
 				_ = e.attemptRepo.Update(attemptRecord)
 				if e.broadcaster != nil {
 					e.broadcaster.BroadcastProxyUpstreamAttempt(attemptRecord)
@@ -314,6 +432,9 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 				// Reset failure counts on success
 				clientType := string(ctxutil.GetClientType(attemptCtx))
 				cooldown.Default().RecordSuccess(matchedRoute.Provider.ID, clientType)
+				circuit.Default().RecordSuccess(matchedRoute.Provider.ID, clientType)
+				e.router.RecordLatency(matchedRoute.Provider.ID, domain.ClientType(clientType), attemptRecord.TTFB, attemptRecord.Duration)
+				e.router.RecordSessionProvider(projectID, sessionID, matchedRoute.Provider.ID)
 
 				proxyReq.Status = "COMPLETED"
 				proxyReq.EndTime = time.Now()
@@ -339,7 +460,6 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 					proxyReq.Cache5mWriteCount = metrics.Cache5mCreationCount
 					proxyReq.Cache1hWriteCount = metrics.Cache1hCreationCount
 				}
-				proxyReq.Cost = attemptRecord.Cost
 
 				_ = e.proxyRequestRepo.Update(proxyReq)
 
@@ -354,6 +474,7 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 			// Handle error - set end time and duration
 			attemptRecord.EndTime = time.Now()
 			attemptRecord.Duration = attemptRecord.EndTime.Sub(attemptRecord.StartTime)
+			attemptRecord.TTFB = responseCapture.TTFB(attemptRecord.StartTime)
 			log.Printf("[Executor] Route %d, attempt %d: FAILED - %v", routeIdx+1, attempt+1, err)
 			lastErr = err
 
@@ -363,6 +484,8 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 			} else {
 				attemptRecord.Status = "FAILED"
 			}
+			metrics.ObserveAttempt(clientType, matchedRoute.Provider.Name, attemptRecord)
+			endAttemptSpan(attemptSpan, attemptRecord, err)
 			_ = e.attemptRepo.Update(attemptRecord)
 			if e.broadcaster != nil {
 				e.broadcaster.BroadcastProxyUpstreamAttempt(attemptRecord)
@@ -391,7 +514,6 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 					proxyReq.Cache1hWriteCount = metrics.Cache1hCreationCount
 				}
 			}
-			proxyReq.Cost = attemptRecord.Cost
 
 			_ = e.proxyRequestRepo.Update(proxyReq)
 			if e.broadcaster != nil {
@@ -414,6 +536,21 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 				return ctx.Err()
 			}
 
+			// Part of the response already reached the client: retrying would corrupt it
+			committed := responseCapture.Written()
+			if streamBuffer != nil {
+				committed = streamBuffer.Committed()
+			}
+			if committed {
+				log.Printf("[Executor] Route %d, attempt %d: response already sent to client, cannot fail over", routeIdx+1, attempt+1)
+				if proxyErr, ok := err.(*domain.ProxyError); ok {
+					e.handleCooldown(attemptCtx, proxyErr, matchedRoute.Provider)
+				}
+				lastErr = &domain.ProxyError{Err: err, Message: "response interrupted", ResponseCommitted: true}
+				responseCommitted = true
+				break
+			}
+
 			// Check if retryable
 			proxyErr, ok := err.(*domain.ProxyError)
 			if !ok {
@@ -421,9 +558,32 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 				break // Move to next route
 			}
 
+			canFallBack := hedgeWinner == nil && modelIdx+1 < len(models)
+
+			// The upstream rejected the mapped model itself: try the next fallback model on the
+			// same provider (the provider is fine, so this neither cools it down nor uses up a retry)
+			if canFallBack && isModelUnavailableError(proxyErr) {
+				modelIdx++
+				log.Printf("[Executor] Route %d: model %s unavailable (%v), falling back to %s",
+					routeIdx+1, models[modelIdx-1], proxyErr, models[modelIdx])
+				ctx = ctxutil.WithMappedModel(ctx, models[modelIdx])
+				attempt--
+				continue
+			}
+
 			// Handle cooldown (unified cooldown logic for all providers)
 			e.handleCooldown(attemptCtx, proxyErr, matchedRoute.Provider)
 
+			// The mapped model is out of quota: spend the next retry on the next fallback model
+			// instead of waiting for the quota to reset
+			if canFallBack && isModelQuotaError(proxyErr) && attempt < retryConfig.MaxRetries {
+				modelIdx++
+				log.Printf("[Executor] Route %d: model %s out of quota, retrying with %s",
+					routeIdx+1, models[modelIdx-1], models[modelIdx])
+				ctx = ctxutil.WithMappedModel(ctx, models[modelIdx])
+				continue
+			}
+
 			if !proxyErr.Retryable {
 				log.Printf("[Executor] Error is not retryable, moving to next route")
 				break // Move to next route
@@ -453,18 +613,31 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 				}
 			}
 		}
+		if responseCommitted {
+			break
+		}
+
 		// Inner loop ended, will try next route if available
 		log.Printf("[Executor] Route %d exhausted (retries: %d), moving to next route if available",
 			routeIdx+1, retryConfig.MaxRetries)
 	}
 
-	log.Printf("[Executor] All %d routes exhausted, request failed", len(routes))
+	if !responseCommitted {
+		log.Printf("[Executor] All %d routes exhausted, request failed", len(routes))
+	}
 	// All routes failed
 	proxyReq.Status = "FAILED"
 	proxyReq.EndTime = time.Now()
 	proxyReq.Duration = proxyReq.EndTime.Sub(proxyReq.StartTime)
 	if lastErr != nil {
 		proxyReq.Error = lastErr.Error()
+		if errors.Is(lastErr, domain.ErrRateLimited) {
+			proxyReq.StatusCode = http.StatusTooManyRequests
+		} else if errors.Is(lastErr, domain.ErrBudgetExceeded) {
+			proxyReq.StatusCode = http.StatusPaymentRequired
+		} else if errors.Is(lastErr, domain.ErrCircuitOpen) {
+			proxyReq.StatusCode = http.StatusServiceUnavailable
+		}
 	}
 	_ = e.proxyRequestRepo.Update(proxyReq)
 
@@ -479,30 +652,117 @@ func (e *Executor) Execute(ctx context.Context, w http.ResponseWriter, req *http
 	return domain.NewProxyErrorWithMessage(domain.ErrAllRoutesFailed, false, "all routes exhausted")
 }
 
-func (e *Executor) mapModel(requestModel string, route *domain.Route, provider *domain.Provider) string {
-	// Route mapping takes precedence
-	if route.ModelMapping != nil {
-		if mapped, ok := route.ModelMapping[requestModel]; ok {
-			return mapped
-		}
+// RecordRejected records a request that was refused before routing (e.g. invalid client API key)
+// The record is created in its final state and broadcast so it shows up in the request log
+func (e *Executor) RecordRejected(ctx context.Context, req *http.Request, status string, statusCode int, reason string) {
+	proxyReq := e.newProxyRequest(ctx, req)
+	proxyReq.Status = status
+	proxyReq.StatusCode = statusCode
+	proxyReq.Error = reason
+	proxyReq.EndTime = proxyReq.StartTime
+	if err := e.proxyRequestRepo.Create(proxyReq); err != nil {
+		log.Printf("[Executor] Failed to record rejected request: %v", err)
+		return
+	}
+	if e.broadcaster != nil {
+		e.broadcaster.BroadcastProxyRequest(proxyReq)
 	}
+}
 
-	// Provider mapping
-	if provider.Config != nil {
-		if provider.Config.Custom != nil && provider.Config.Custom.ModelMapping != nil {
-			if mapped, ok := provider.Config.Custom.ModelMapping[requestModel]; ok {
-				return mapped
-			}
-		}
-		if provider.Config.Antigravity != nil && provider.Config.Antigravity.ModelMapping != nil {
-			if mapped, ok := provider.Config.Antigravity.ModelMapping[requestModel]; ok {
-				return mapped
-			}
-		}
+// rejectRateLimited finalizes a request that was refused (or abandoned while queued) by a rate limit
+func (e *Executor) rejectRateLimited(proxyReq *domain.ProxyRequest, err error) error {
+	proxyReq.EndTime = time.Now()
+	proxyReq.Duration = proxyReq.EndTime.Sub(proxyReq.StartTime)
+	if errors.Is(err, domain.ErrRateLimited) {
+		proxyReq.Status = "REJECTED"
+		proxyReq.StatusCode = http.StatusTooManyRequests
+		err = rateLimitError(err)
+	} else {
+		proxyReq.Status = "CANCELLED"
+	}
+	proxyReq.Error = err.Error()
+	_ = e.proxyRequestRepo.Update(proxyReq)
+	if e.broadcaster != nil {
+		e.broadcaster.BroadcastProxyRequest(proxyReq)
+	}
+	log.Printf("[Executor] Request rejected: %v", err)
+	return err
+}
+
+// rateLimitError wraps a limiter error so handlers answer 429 with Retry-After
+func rateLimitError(err error) error {
+	var limitErr *ratelimit.LimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	return &domain.ProxyError{
+		Err:            limitErr,
+		Retryable:      true,
+		RetryAfter:     limitErr.RetryAfter,
+		HTTPStatusCode: http.StatusTooManyRequests,
+	}
+}
+
+// rejectBudgetExceeded finalizes a request refused because a hard budget is exhausted
+func (e *Executor) rejectBudgetExceeded(proxyReq *domain.ProxyRequest, err error) error {
+	proxyReq.EndTime = time.Now()
+	proxyReq.Duration = proxyReq.EndTime.Sub(proxyReq.StartTime)
+	proxyReq.Status = "REJECTED"
+	proxyReq.StatusCode = http.StatusPaymentRequired
+	err = budgetError(err)
+	proxyReq.Error = err.Error()
+	_ = e.proxyRequestRepo.Update(proxyReq)
+	if e.broadcaster != nil {
+		e.broadcaster.BroadcastProxyRequest(proxyReq)
+	}
+	log.Printf("[Executor] Request rejected: %v", err)
+	return err
+}
+
+// budgetError wraps a budget error so handlers answer 402 instead of 502
+func budgetError(err error) error {
+	return &domain.ProxyError{
+		Err:            err,
+		Retryable:      false,
+		HTTPStatusCode: http.StatusPaymentRequired,
+	}
+}
+
+// circuitOpenError is returned when every remaining route's circuit breaker is open;
+// retryAfter is when the first of them admits requests again
+func circuitOpenError(retryAfter time.Duration) error {
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return &domain.ProxyError{
+		Err:            domain.ErrCircuitOpen,
+		Retryable:      true,
+		HTTPStatusCode: http.StatusServiceUnavailable,
+		RetryAfter:     retryAfter,
+	}
+}
+
+// newProxyRequest builds a proxy request record from the request context
+func (e *Executor) newProxyRequest(ctx context.Context, req *http.Request) *domain.ProxyRequest {
+	proxyReq := &domain.ProxyRequest{
+		InstanceID:   e.instanceID,
+		RequestID:    generateRequestID(),
+		SessionID:    ctxutil.GetSessionID(ctx),
+		ClientType:   ctxutil.GetClientType(ctx),
+		ProjectID:    ctxutil.GetProjectID(ctx),
+		RequestModel: ctxutil.GetRequestModel(ctx),
+		StartTime:    time.Now(),
+		IsStream:     ctxutil.GetIsStream(ctx),
 	}
 
-	// No mapping, use original
-	return requestModel
+	// Capture client's original request info
+	proxyReq.RequestInfo = &domain.RequestInfo{
+		Method:  req.Method,
+		URL:     ctxutil.GetRequestURI(ctx),
+		Headers: redactAuthHeaders(flattenHeaders(ctxutil.GetRequestHeaders(ctx))),
+		Body:    string(ctxutil.GetRequestBody(ctx)),
+	}
+	return proxyReq
 }
 
 func (e *Executor) getRetryConfig(config *domain.RetryConfig) *domain.RetryConfig {
@@ -557,6 +817,25 @@ func flattenHeaders(h http.Header) map[string]string {
 	return result
 }
 
+// redactAuthHeaders masks client credentials so API keys are not persisted in request logs
+func redactAuthHeaders(headers map[string]string) map[string]string {
+	for key, value := range headers {
+		switch strings.ToLower(key) {
+		case "authorization", "x-api-key", "x-goog-api-key":
+			headers[key] = maskSecret(value)
+		}
+	}
+	return headers
+}
+
+// maskSecret keeps a short prefix of a secret for identification
+func maskSecret(s string) string {
+	if len(s) <= 12 {
+		return "***"
+	}
+	return s[:12] + "***"
+}
+
 // handleCooldown processes cooldown information from ProxyError and sets provider cooldown
 // Priority: 1) Explicit time from API, 2) Policy-based calculation based on failure reason
 func (e *Executor) handleCooldown(ctx context.Context, proxyErr *domain.ProxyError, provider *domain.Provider) {
@@ -609,6 +888,10 @@ func (e *Executor) handleCooldown(ctx context.Context, proxyErr *domain.ProxyErr
 	// If explicitUntil is not nil, it will be used directly
 	// Otherwise, cooldown duration is calculated based on policy and failure count
 	until := cooldown.Default().RecordFailure(provider.ID, clientType, reason, explicitUntil)
+	// The breaker only opens once the failure rate over its window crosses the threshold.
+	// It is keyed by the request's client type, like Allow and RecordSuccess.
+	circuit.Default().RecordFailure(provider.ID, string(ctxutil.GetClientType(ctx)))
+	metrics.ProviderFailure(provider.ID, provider.Name, clientType, string(reason))
 
 	// If there's an async update channel, listen for updates
 	if proxyErr.CooldownUpdateChan != nil {
@@ -629,6 +912,67 @@ func (e *Executor) handleCooldown(ctx context.Context, proxyErr *domain.ProxyErr
 		provider.ID, provider.Name, until.Format("2006-01-02 15:04:05"), clientTypeDesc, reason, explicitStr)
 }
 
+// recordAttemptCost books a finished attempt against the budget and adds it to the
+// request's cost, so the request totals every billed attempt: failed ones, hedge
+// losers and earlier fallback models included
+func recordAttemptCost(proxyReq *domain.ProxyRequest, projectID uint64, provider *domain.Provider, attempt *domain.ProxyUpstreamAttempt) {
+	budget.Default().Record(projectID, provider.ID, attempt.Cost)
+	if proxyReq != nil {
+		proxyReq.Cost += attempt.Cost
+		proxyReq.ShadowCost += attempt.ShadowCost
+	}
+}
+
+// applySubscriptionCost books the cost of subscription providers as shadow cost:
+// it shows what the traffic would have cost at list price without counting as spend or against budgets
+func applySubscriptionCost(provider *domain.Provider, attempt *domain.ProxyUpstreamAttempt) {
+	if provider.IsSubscription && attempt.Cost > 0 {
+		attempt.ShadowCost += attempt.Cost
+		attempt.Cost = 0
+	}
+}
+
+// modelErrorMarkers are upstream error fragments meaning the requested model can't be served.
+// Only errors that clearly name the model count: generic "not supported" or quota messages
+// are ordinary provider errors and go through cooldown and retries.
+var modelErrorMarkers = []string{
+	"model_not_found",
+	"model not found",
+	"no such model",
+	"unknown model",
+	"invalid model",
+	"unsupported model",
+	"model_not_supported",
+	"model not supported",
+	"model is not supported",
+}
+
+// modelDoesNotExist matches OpenAI-style "The model `x` does not exist" messages
+var modelDoesNotExist = regexp.MustCompile(`\bmodel\b[^.]*\bdoes not exist`)
+
+// isModelUnavailableError reports whether the upstream rejected the mapped model itself
+// (unknown or unsupported), so another model may still work on the same provider
+func isModelUnavailableError(proxyErr *domain.ProxyError) bool {
+	switch proxyErr.HTTPStatusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound:
+	default:
+		return false
+	}
+	msg := strings.ToLower(proxyErr.Error())
+	for _, marker := range modelErrorMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return modelDoesNotExist.MatchString(msg)
+}
+
+// isModelQuotaError reports whether the adapter classified the error as exhausted quota,
+// which providers such as Antigravity track per model
+func isModelQuotaError(proxyErr *domain.ProxyError) bool {
+	return proxyErr.RateLimitInfo != nil && proxyErr.RateLimitInfo.Type == "quota_exhausted"
+}
+
 // mapRateLimitTypeToReason maps RateLimitInfo.Type to CooldownReason
 func mapRateLimitTypeToReason(rateLimitType string) cooldown.CooldownReason {
 	switch rateLimitType {
@@ -0,0 +1,168 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/budget"
+	"github.com/awsl-project/maxx/internal/circuit"
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/cooldown"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+	"github.com/awsl-project/maxx/internal/router"
+)
+
+func TestIsModelUnavailableError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   bool
+	}{
+		{"model_not_found code", http.StatusNotFound, `{"error":{"code":"model_not_found"}}`, true},
+		{"openai does not exist", http.StatusNotFound, "The model `gpt-9` does not exist or you do not have access to it.", true},
+		{"unsupported model", http.StatusBadRequest, `{"error":"Unsupported model: claude-x"}`, true},
+		{"model not supported", http.StatusForbidden, "this model is not supported on your plan", true},
+		{"bare 404", http.StatusNotFound, "404 page not found", false},
+		{"other resource does not exist", http.StatusNotFound, "the requested path does not exist", false},
+		{"generic not supported", http.StatusBadRequest, "parameter top_k is not supported", false},
+		{"rate limit", http.StatusTooManyRequests, "RESOURCE_EXHAUSTED: quota exceeded", false},
+		{"429 naming the model", http.StatusTooManyRequests, "model not found", false},
+		{"server error", http.StatusInternalServerError, "unknown model", false},
+	}
+	for _, tt := range tests {
+		proxyErr := domain.NewProxyError(errors.New(tt.body), false)
+		proxyErr.HTTPStatusCode = tt.status
+		if got := isModelUnavailableError(proxyErr); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsModelQuotaError(t *testing.T) {
+	proxyErr := domain.NewProxyError(errors.New("quota exceeded"), true)
+	proxyErr.HTTPStatusCode = http.StatusTooManyRequests
+	if isModelQuotaError(proxyErr) {
+		t.Error("an unclassified 429 is a provider rate limit, not exhausted model quota")
+	}
+	proxyErr.RateLimitInfo = &domain.RateLimitInfo{Type: "rate_limit_exceeded"}
+	if isModelQuotaError(proxyErr) {
+		t.Error("rate_limit_exceeded is not exhausted quota")
+	}
+	proxyErr.RateLimitInfo.Type = "quota_exhausted"
+	if !isModelQuotaError(proxyErr) {
+		t.Error("quota_exhausted should fall back to the next model")
+	}
+}
+
+func TestHandleCooldown_BreakerUsesFailureRate(t *testing.T) {
+	const providerID = 9201
+	provider := &domain.Provider{ID: providerID, Name: "breaker-test"}
+	ctx := ctxutil.WithClientType(context.Background(), domain.ClientTypeClaude)
+	t.Cleanup(func() {
+		cooldown.Default().ClearCooldown(providerID, "")
+		cooldown.Default().ClearCooldown(providerID, string(domain.ClientTypeClaude))
+		circuit.Default().Reset(providerID)
+	})
+
+	e := &Executor{}
+	serverError := func() *domain.ProxyError {
+		proxyErr := domain.NewProxyError(errors.New("upstream 500"), true)
+		proxyErr.IsServerError = true
+		return proxyErr
+	}
+
+	// A single failure puts the provider in cooldown but leaves the breaker closed
+	e.handleCooldown(ctx, serverError(), provider)
+	if got := circuit.Default().State(providerID, string(domain.ClientTypeClaude)); got != circuit.StateClosed {
+		t.Fatalf("breaker after one failure = %s, want closed", got)
+	}
+
+	// A provider-wide cooldown still counts against the request's own breaker
+	providerWide := serverError()
+	providerWide.RateLimitInfo = &domain.RateLimitInfo{ClientType: ""}
+	e.handleCooldown(ctx, providerWide, provider)
+	for _, s := range circuit.Default().Status() {
+		if s.ProviderID == providerID && s.ClientType != string(domain.ClientTypeClaude) {
+			t.Errorf("breaker created under client type %q", s.ClientType)
+		}
+	}
+
+	// Enough failures in the window open it
+	for i := 2; i < circuit.DefaultConfig().MinRequests; i++ {
+		e.handleCooldown(ctx, serverError(), provider)
+	}
+	if got := circuit.Default().State(providerID, string(domain.ClientTypeClaude)); got != circuit.StateOpen {
+		t.Fatalf("breaker after %d failures = %s, want open", circuit.DefaultConfig().MinRequests, got)
+	}
+}
+
+func TestCircuitOpenError(t *testing.T) {
+	err := circuitOpenError(0)
+	var proxyErr *domain.ProxyError
+	if !errors.As(err, &proxyErr) || !errors.Is(err, domain.ErrCircuitOpen) {
+		t.Fatalf("circuitOpenError = %v", err)
+	}
+	if proxyErr.HTTPStatusCode != http.StatusServiceUnavailable || proxyErr.RetryAfter < time.Second {
+		t.Errorf("status = %d, RetryAfter = %v", proxyErr.HTTPStatusCode, proxyErr.RetryAfter)
+	}
+	if err := circuitOpenError(20 * time.Second).(*domain.ProxyError); err.RetryAfter != 20*time.Second {
+		t.Errorf("RetryAfter = %v, want 20s", err.RetryAfter)
+	}
+}
+
+// memoryBudgets serves one budget per scope with no prior spend
+type memoryBudgets struct {
+	repository.BudgetRepository
+	budgets []*domain.Budget
+}
+
+func (r *memoryBudgets) List() ([]*domain.Budget, error) { return r.budgets, nil }
+
+func (r *memoryBudgets) GetSpend(domain.BudgetScope, uint64, time.Time) (uint64, error) {
+	return 0, nil
+}
+
+func TestRecordAttemptCost_SumsAllAttempts(t *testing.T) {
+	const projectID, providerID, subscriptionID = 9301, 9302, 9303
+	budgets := &memoryBudgets{budgets: []*domain.Budget{
+		{ID: 1, Scope: domain.BudgetScopeProject, ScopeID: projectID, Period: domain.BudgetPeriodMonthly, Limit: 1_000_000, IsEnabled: true},
+	}}
+	budget.Default().SetRepository(budgets)
+	if err := budget.Default().Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		budgets.budgets = nil
+		_ = budget.Default().Load()
+		budget.Default().SetRepository(nil)
+	})
+
+	provider := &domain.Provider{ID: providerID}
+	subscription := &domain.Provider{ID: subscriptionID, IsSubscription: true}
+	proxyReq := &domain.ProxyRequest{ID: 1}
+	ctx := ctxutil.WithProxyRequest(ctxutil.WithProjectID(context.Background(), projectID), proxyReq)
+
+	// A failed attempt that was still billed, a fallback model that succeeded
+	recordAttemptCost(proxyReq, projectID, provider, &domain.ProxyUpstreamAttempt{Status: "FAILED", Cost: 300})
+	recordAttemptCost(proxyReq, projectID, provider, &domain.ProxyUpstreamAttempt{Status: "COMPLETED", Cost: 500})
+	// A hedge loser on a subscription provider only adds shadow cost
+	loser := &domain.ProxyUpstreamAttempt{Status: "CANCELLED", Cost: 200}
+	applySubscriptionCost(subscription, loser)
+	(&hedgeLeg{route: &router.MatchedRoute{Provider: subscription}, record: loser}).release(ctx)
+	// A billed hedge loser on a regular provider
+	(&hedgeLeg{route: &router.MatchedRoute{Provider: provider}, record: &domain.ProxyUpstreamAttempt{Status: "CANCELLED", Cost: 50}}).release(ctx)
+
+	if proxyReq.Cost != 850 || proxyReq.ShadowCost != 200 {
+		t.Errorf("request cost = %d (shadow %d), want 850 (shadow 200)", proxyReq.Cost, proxyReq.ShadowCost)
+	}
+	for _, s := range budget.Default().Status() {
+		if s.Budget.ScopeID == projectID && s.Spent != proxyReq.Cost {
+			t.Errorf("budget spent = %d, want the request cost %d", s.Spent, proxyReq.Cost)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/budget"
+	"github.com/awsl-project/maxx/internal/circuit"
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/metrics"
+	"github.com/awsl-project/maxx/internal/ratelimit"
+	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/tokenizer"
+	"github.com/awsl-project/maxx/internal/tracing"
+)
+
+// hedgeLeg is one of the two attempts raced by a hedged request
+type hedgeLeg struct {
+	route    *router.MatchedRoute
+	record   *domain.ProxyUpstreamAttempt
+	ctx      context.Context
+	span     *tracing.Span
+	cancel   context.CancelFunc
+	recorder *responseRecorder
+	permit   *ratelimit.Permit // only set for the backup leg, the primary's permit is the caller's
+	err      error
+}
+
+// start runs the adapter in the background and sends the leg to done when it returns
+func (l *hedgeLeg) start(req *http.Request, done chan<- *hedgeLeg) {
+	go func() {
+		l.err = l.route.ProviderAdapter.Execute(l.ctx, l.recorder, req, l.route.Provider)
+		applySubscriptionCost(l.route.Provider, l.record)
+		done <- l
+	}()
+}
+
+// executeHedged runs the primary attempt and, if it hasn't finished within delay,
+// the same request on the backup route. The first successful response is written
+// to w and the other attempt is cancelled.
+// Returns the leg the caller finishes as the request's attempt: the winner, or the
+// primary when both failed. The other leg is finalized here.
+func (e *Executor) executeHedged(ctx context.Context, w http.ResponseWriter, req *http.Request, primary *hedgeLeg, backup *router.MatchedRoute, delay time.Duration) *hedgeLeg {
+	primary.ctx, primary.cancel = context.WithCancel(primary.ctx)
+	defer primary.cancel()
+	primary.recorder = newResponseRecorder()
+
+	done := make(chan *hedgeLeg, 2)
+	primary.start(req, done)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case leg := <-done:
+		// Finished before the hedge delay
+		leg.replay(w)
+		return leg
+	case <-timer.C:
+	}
+
+	backupLeg := e.startBackupLeg(ctx, req, backup, done)
+	if backupLeg == nil {
+		leg := <-done
+		leg.replay(w)
+		return leg
+	}
+	defer backupLeg.cancel()
+	defer backupLeg.release(ctx)
+	log.Printf("[Executor] No response from route %d within %v, hedging on route %d",
+		primary.route.Route.ID, delay, backup.Route.ID)
+	primary.record.Hedged = true
+
+	first := <-done
+	other := backupLeg
+	if first == backupLeg {
+		other = primary
+	}
+
+	if first.err == nil {
+		// Winner found: stop the slower attempt
+		other.cancel()
+		<-done
+		e.finishHedgeLoser(ctx, other, "CANCELLED")
+		first.replay(w)
+		return first
+	}
+
+	second := <-done
+	if second.err == nil {
+		e.finishHedgeLoser(ctx, first, "FAILED")
+		second.replay(w)
+		return second
+	}
+
+	// Both failed: the caller handles the primary like any failed attempt
+	e.finishHedgeLoser(ctx, backupLeg, "FAILED")
+	return primary
+}
+
+// startBackupLeg creates the backup attempt and starts it, or returns nil
+// when the backup provider is over budget or out of rate limit capacity
+func (e *Executor) startBackupLeg(ctx context.Context, req *http.Request, backup *router.MatchedRoute, done chan<- *hedgeLeg) *hedgeLeg {
+	if err := budget.Default().Check(domain.BudgetScopeProvider, backup.Provider.ID); err != nil {
+		log.Printf("[Executor] Not hedging on route %d: %v", backup.Route.ID, err)
+		return nil
+	}
+	if !circuit.Default().Allow(backup.Provider.ID, string(ctxutil.GetClientType(ctx))) {
+		log.Printf("[Executor] Not hedging on route %d: circuit open", backup.Route.ID)
+		return nil
+	}
+	estimatedTokens := tokenizer.EstimateRequest(ctxutil.GetRequestBody(ctx))
+	permit, err := ratelimit.Default().Acquire(ctx, []ratelimit.Key{
+		{Scope: domain.RateLimitScopeProvider, ScopeID: backup.Provider.ID},
+	}, estimatedTokens, false)
+	if err != nil {
+		log.Printf("[Executor] Not hedging on route %d: %v", backup.Route.ID, err)
+		return nil
+	}
+
+	proxyReq := ctxutil.GetProxyRequest(ctx)
+	mappedModel := router.MapModel(ctxutil.GetRequestModel(ctx), backup.Route, backup.Provider)
+	record := &domain.ProxyUpstreamAttempt{
+		ProxyRequestID: proxyReq.ID,
+		RouteID:        backup.Route.ID,
+		ProviderID:     backup.Provider.ID,
+		MappedModel:    mappedModel,
+		IsStream:       false,
+		Hedged:         true,
+		Status:         "IN_PROGRESS",
+		StartTime:      time.Now(),
+	}
+	if err := e.attemptRepo.Create(record); err != nil {
+		log.Printf("[Executor] Failed to create hedge attempt record: %v", err)
+	}
+	proxyReq.ProxyUpstreamAttemptCount++
+	if e.broadcaster != nil {
+		e.broadcaster.BroadcastProxyRequest(proxyReq)
+		e.broadcaster.BroadcastProxyUpstreamAttempt(record)
+	}
+
+	legCtx := ctxutil.WithMappedModel(ctx, mappedModel)
+	legCtx = ctxutil.WithUpstreamAttempt(legCtx, record)
+	legCtx = ctxutil.WithRetryConfig(legCtx, e.getRetryConfig(backup.RetryConfig))
+	legCtx, span := startAttemptSpan(legCtx, backup, record, 0)
+	legCtx, cancel := context.WithCancel(legCtx)
+
+	leg := &hedgeLeg{
+		route:    backup,
+		record:   record,
+		ctx:      legCtx,
+		span:     span,
+		cancel:   cancel,
+		recorder: newResponseRecorder(),
+		permit:   permit,
+	}
+	leg.start(req, done)
+	return leg
+}
+
+// finishHedgeLoser finalizes the attempt whose response is not used
+func (e *Executor) finishHedgeLoser(ctx context.Context, leg *hedgeLeg, status string) {
+	if ctx.Err() != nil {
+		status = "CANCELLED"
+	}
+
+	leg.record.EndTime = time.Now()
+	leg.record.Duration = leg.record.EndTime.Sub(leg.record.StartTime)
+	leg.record.Status = status
+	metrics.ObserveAttempt(ctxutil.GetClientType(ctx), leg.route.Provider.Name, leg.record)
+	endAttemptSpan(leg.span, leg.record, leg.err)
+	_ = e.attemptRepo.Update(leg.record)
+	if e.broadcaster != nil {
+		e.broadcaster.BroadcastProxyUpstreamAttempt(leg.record)
+	}
+
+	if status == "FAILED" {
+		log.Printf("[Executor] Hedged attempt on route %d failed: %v", leg.route.Route.ID, leg.err)
+		if proxyErr, ok := leg.err.(*domain.ProxyError); ok {
+			e.handleCooldown(leg.ctx, proxyErr, leg.route.Provider)
+		}
+	}
+}
+
+// release returns the backup leg's rate limit permit and records its cost
+func (l *hedgeLeg) release(ctx context.Context) {
+	l.permit.Release(int(l.record.InputTokenCount + l.record.OutputTokenCount))
+	recordAttemptCost(ctxutil.GetProxyRequest(ctx), ctxutil.GetProjectID(ctx), l.route.Provider, l.record)
+}
+
+// replay sends a successful leg's recorded response to the client
+// (failed legs send nothing, so the request can still fail over)
+func (l *hedgeLeg) replay(w http.ResponseWriter) {
+	if l.err != nil {
+		return
+	}
+	l.recorder.replayTo(w)
+}
+
+// responseRecorder buffers a complete non-streaming response in memory
+type responseRecorder struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header)}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.statusCode == 0 {
+		r.statusCode = code
+	}
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	return r.body.Write(p)
+}
+
+// Flush is a no-op, the response is only sent once the race is decided
+func (r *responseRecorder) Flush() {}
+
+// replayTo sends the recorded headers, status and body to w
+func (r *responseRecorder) replayTo(w http.ResponseWriter) {
+	for key, values := range r.header {
+		w.Header()[key] = values
+	}
+	status := r.statusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	if r.body.Len() > 0 {
+		_, _ = w.Write(r.body.Bytes())
+	}
+}
@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/adapter/provider/custom"
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/router"
+)
+
+type memoryAttempts struct {
+	mu       sync.Mutex
+	attempts []*domain.ProxyUpstreamAttempt
+}
+
+func (m *memoryAttempts) Create(a *domain.ProxyUpstreamAttempt) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a.ID = uint64(len(m.attempts) + 1)
+	m.attempts = append(m.attempts, a)
+	return nil
+}
+
+func (m *memoryAttempts) Update(*domain.ProxyUpstreamAttempt) error { return nil }
+
+func (m *memoryAttempts) ListByProxyRequestID(uint64) ([]*domain.ProxyUpstreamAttempt, error) {
+	return nil, nil
+}
+
+func (m *memoryAttempts) GetProviderStats(string, uint64) (map[uint64]*domain.ProviderStats, error) {
+	return nil, nil
+}
+
+func (m *memoryAttempts) RecalculateCosts(time.Time, time.Time, func(*domain.ProxyUpstreamAttempt) (uint64, uint64)) (int64, int64, error) {
+	return 0, 0, nil
+}
+
+// newCustomRoute serves a Claude custom provider from upstream with its own API key
+func newCustomRoute(t *testing.T, id uint64, apiKey string, upstream http.HandlerFunc) *router.MatchedRoute {
+	t.Helper()
+	srv := httptest.NewServer(upstream)
+	t.Cleanup(srv.Close)
+
+	p := &domain.Provider{
+		ID:                   id,
+		Type:                 "custom",
+		Name:                 apiKey,
+		SupportedClientTypes: []domain.ClientType{domain.ClientTypeClaude},
+		Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			BaseURL: srv.URL,
+			APIKey:  apiKey,
+		}},
+	}
+	adapter, err := custom.NewAdapter(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &router.MatchedRoute{
+		Route:           &domain.Route{ID: id, ProviderID: id},
+		Provider:        p,
+		ProviderAdapter: adapter,
+		RetryConfig:     &domain.RetryConfig{},
+	}
+}
+
+// keyRecorder answers with a Claude message after delay and remembers the API key it saw
+type keyRecorder struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (k *keyRecorder) seen() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]string(nil), k.keys...)
+}
+
+func (k *keyRecorder) handler(delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Read the body so the server notices when the client cancels
+		_, _ = io.Copy(io.Discard, r.Body)
+		k.mu.Lock()
+		k.keys = append(k.keys, r.Header.Get("x-api-key"))
+		k.mu.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"hi"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}
+}
+
+func TestExecuteHedged_BackupWinsWithItsOwnKey(t *testing.T) {
+	var slow, fast keyRecorder
+	primaryRoute := newCustomRoute(t, 1, "sk-primary", slow.handler(2*time.Second))
+	backupRoute := newCustomRoute(t, 2, "sk-backup", fast.handler(0))
+
+	headers := http.Header{}
+	headers.Set("x-api-key", "sk-client")
+	headers.Set("anthropic-version", "2023-06-01")
+	body := []byte(`{"model":"claude-haiku-4-5","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`)
+
+	ctx := context.Background()
+	ctx = ctxutil.WithClientType(ctx, domain.ClientTypeClaude)
+	ctx = ctxutil.WithRequestModel(ctx, "claude-haiku-4-5")
+	ctx = ctxutil.WithRequestBody(ctx, body)
+	ctx = ctxutil.WithRequestHeaders(ctx, headers)
+	ctx = ctxutil.WithRequestURI(ctx, "/v1/messages")
+	ctx = ctxutil.WithProxyRequest(ctx, &domain.ProxyRequest{ID: 1})
+
+	attempts := &memoryAttempts{}
+	e := &Executor{attemptRepo: attempts}
+
+	primaryRecord := &domain.ProxyUpstreamAttempt{RouteID: 1, ProviderID: 1}
+	primaryCtx := ctxutil.WithMappedModel(ctx, "claude-haiku-4-5")
+	primaryCtx = ctxutil.WithUpstreamAttempt(primaryCtx, primaryRecord)
+	primary := &hedgeLeg{route: primaryRoute, record: primaryRecord, ctx: primaryCtx}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	w := httptest.NewRecorder()
+	winner := e.executeHedged(ctx, w, req, primary, backupRoute, 50*time.Millisecond)
+
+	if winner.err != nil || winner.route != backupRoute {
+		t.Fatalf("expected the backup leg to win, got route %d err=%v", winner.route.Route.ID, winner.err)
+	}
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("expected the backup response to be replayed, got %d %q", w.Code, w.Body.String())
+	}
+	if !primaryRecord.Hedged || primaryRecord.Status != "CANCELLED" {
+		t.Fatalf("primary should be marked hedged and cancelled, got hedged=%v status=%s", primaryRecord.Hedged, primaryRecord.Status)
+	}
+	if len(attempts.attempts) != 1 || attempts.attempts[0].ProviderID != 2 {
+		t.Fatalf("expected one backup attempt record, got %+v", attempts.attempts)
+	}
+
+	// Each provider gets its own key and the client's headers stay untouched
+	if keys := slow.seen(); len(keys) != 1 || keys[0] != "sk-primary" {
+		t.Errorf("primary upstream saw keys %v", keys)
+	}
+	if keys := fast.seen(); len(keys) != 1 || keys[0] != "sk-backup" {
+		t.Errorf("backup upstream saw keys %v", keys)
+	}
+	if got := headers.Get("x-api-key"); got != "sk-client" {
+		t.Errorf("client headers were modified: x-api-key=%q", got)
+	}
+}
@@ -3,6 +3,7 @@ package executor
 import (
 	"bytes"
 	"net/http"
+	"time"
 )
 
 // ResponseCapture wraps http.ResponseWriter to capture the response
@@ -12,6 +13,9 @@ type ResponseCapture struct {
 	statusCode int
 	body       bytes.Buffer
 	headers    http.Header
+
+	// firstWriteAt is when the first header or body byte reached the client
+	firstWriteAt time.Time
 }
 
 // NewResponseCapture creates a new ResponseCapture wrapper
@@ -25,12 +29,14 @@ func NewResponseCapture(w http.ResponseWriter) *ResponseCapture {
 
 // WriteHeader captures the status code and forwards to underlying writer
 func (rc *ResponseCapture) WriteHeader(code int) {
+	rc.markFirstWrite()
 	rc.statusCode = code
 	rc.ResponseWriter.WriteHeader(code)
 }
 
 // Write captures the body and forwards to underlying writer
 func (rc *ResponseCapture) Write(b []byte) (int, error) {
+	rc.markFirstWrite()
 	rc.body.Write(b)
 	return rc.ResponseWriter.Write(b)
 }
@@ -67,3 +73,22 @@ func (rc *ResponseCapture) CapturedHeaders() map[string]string {
 	}
 	return result
 }
+
+// TTFB returns the time from start until the first write, or 0 if nothing was written
+func (rc *ResponseCapture) TTFB(start time.Time) time.Duration {
+	if rc.firstWriteAt.IsZero() {
+		return 0
+	}
+	return rc.firstWriteAt.Sub(start)
+}
+
+// Written reports whether anything was written through the capture
+func (rc *ResponseCapture) Written() bool {
+	return !rc.firstWriteAt.IsZero()
+}
+
+func (rc *ResponseCapture) markFirstWrite() {
+	if rc.firstWriteAt.IsZero() {
+		rc.firstWriteAt = time.Now()
+	}
+}
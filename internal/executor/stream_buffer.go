@@ -0,0 +1,147 @@
+package executor
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// defaultStreamBufferBytes is used when RetryConfig.StreamBufferBytes is 0
+const defaultStreamBufferBytes = 16 * 1024
+
+// StreamBuffer holds back a streaming response until the first content event
+// or a byte threshold, so an attempt that fails before then can be discarded
+// and the request transparently retried on another route.
+// Once committed, everything is passed straight through to the client.
+type StreamBuffer struct {
+	w          http.ResponseWriter
+	clientType domain.ClientType
+	threshold  int
+
+	header     http.Header
+	statusCode int
+	buf        bytes.Buffer
+	committed  bool
+}
+
+// NewStreamBuffer creates a buffer in front of the client writer
+func NewStreamBuffer(w http.ResponseWriter, clientType domain.ClientType, threshold int) *StreamBuffer {
+	if threshold <= 0 {
+		threshold = defaultStreamBufferBytes
+	}
+	return &StreamBuffer{
+		w:          w,
+		clientType: clientType,
+		threshold:  threshold,
+		header:     make(http.Header),
+	}
+}
+
+// Header returns the held-back headers until the response is committed
+func (b *StreamBuffer) Header() http.Header {
+	if b.committed {
+		return b.w.Header()
+	}
+	return b.header
+}
+
+// WriteHeader records the status code until the response is committed
+func (b *StreamBuffer) WriteHeader(code int) {
+	if b.committed {
+		b.w.WriteHeader(code)
+		return
+	}
+	if b.statusCode == 0 {
+		b.statusCode = code
+	}
+}
+
+// Write buffers output and commits on the first content event or when the threshold is reached
+func (b *StreamBuffer) Write(p []byte) (int, error) {
+	if b.committed {
+		return b.w.Write(p)
+	}
+	b.buf.Write(p)
+	if b.buf.Len() >= b.threshold || isContentEvent(b.clientType, p) {
+		if err := b.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush is a no-op until the response is committed
+func (b *StreamBuffer) Flush() {
+	if !b.committed {
+		return
+	}
+	if f, ok := b.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Commit sends the held-back headers and output to the client
+func (b *StreamBuffer) Commit() error {
+	if b.committed {
+		return nil
+	}
+	b.committed = true
+
+	for key, values := range b.header {
+		b.w.Header()[key] = values
+	}
+	status := b.statusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	b.w.WriteHeader(status)
+
+	if b.buf.Len() > 0 {
+		if _, err := b.w.Write(b.buf.Bytes()); err != nil {
+			return err
+		}
+		b.buf.Reset()
+	}
+	if f, ok := b.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// Committed reports whether anything reached the client
+func (b *StreamBuffer) Committed() bool {
+	return b.committed
+}
+
+// isContentEvent reports whether an SSE chunk in the client's format carries model output
+// (events before it, like message_start or role-only chunks, are safe to discard)
+func isContentEvent(clientType domain.ClientType, chunk []byte) bool {
+	switch clientType {
+	case domain.ClientTypeClaude:
+		return bytes.Contains(chunk, []byte("content_block_delta"))
+	case domain.ClientTypeCodex:
+		return bytes.Contains(chunk, []byte(`.delta"`))
+	case domain.ClientTypeOpenAI:
+		return bytes.Contains(chunk, []byte(`"tool_calls"`)) ||
+			hasNonEmptyString(chunk, []byte(`"content":"`)) ||
+			hasNonEmptyString(chunk, []byte(`"reasoning_content":"`))
+	case domain.ClientTypeGemini:
+		return bytes.Contains(chunk, []byte(`"parts"`))
+	}
+	return false
+}
+
+// hasNonEmptyString reports whether key (a JSON `"name":"` prefix) is followed by a non-empty string
+func hasNonEmptyString(chunk, key []byte) bool {
+	for {
+		idx := bytes.Index(chunk, key)
+		if idx < 0 {
+			return false
+		}
+		chunk = chunk[idx+len(key):]
+		if len(chunk) > 0 && chunk[0] != '"' {
+			return true
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestIsContentEvent(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientType domain.ClientType
+		chunk      string
+		want       bool
+	}{
+		{"claude message_start", domain.ClientTypeClaude, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n", false},
+		{"claude ping", domain.ClientTypeClaude, "event: ping\ndata: {\"type\": \"ping\"}\n\n", false},
+		{"claude block start", domain.ClientTypeClaude, "event: content_block_start\ndata: {\"type\":\"content_block_start\"}\n\n", false},
+		{"claude delta", domain.ClientTypeClaude, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"Hi\"}}\n\n", true},
+		{"codex created", domain.ClientTypeCodex, "event: response.created\ndata: {\"type\":\"response.created\"}\n\n", false},
+		{"codex text delta", domain.ClientTypeCodex, "data: {\"type\":\"response.output_text.delta\",\"delta\":\"Hi\"}\n\n", true},
+		{"openai role chunk", domain.ClientTypeOpenAI, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"\"}}]}\n\n", false},
+		{"openai content", domain.ClientTypeOpenAI, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n", true},
+		{"openai empty then content", domain.ClientTypeOpenAI, "data: {\"content\":\"\"}\n\ndata: {\"content\":\"Hi\"}\n\n", true},
+		{"openai reasoning", domain.ClientTypeOpenAI, "data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"Hmm\"}}]}\n\n", true},
+		{"openai tool call", domain.ClientTypeOpenAI, "data: {\"choices\":[{\"delta\":{\"tool_calls\":[{\"index\":0}]}}]}\n\n", true},
+		{"openai done", domain.ClientTypeOpenAI, "data: [DONE]\n\n", false},
+		{"gemini candidate", domain.ClientTypeGemini, "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hi\"}]}}]}\n\n", true},
+		{"gemini metadata", domain.ClientTypeGemini, "data: {\"usageMetadata\":{\"totalTokenCount\":3}}\n\n", false},
+		{"unknown client", domain.ClientType("other"), "content_block_delta", false},
+	}
+	for _, tt := range tests {
+		if got := isContentEvent(tt.clientType, []byte(tt.chunk)); got != tt.want {
+			t.Errorf("%s: isContentEvent = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStreamBufferHoldsUntilContent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	b := NewStreamBuffer(rec, domain.ClientTypeClaude, 0)
+
+	b.Header().Set("Content-Type", "text/event-stream")
+	b.WriteHeader(http.StatusCreated)
+	b.Write([]byte("event: message_start\ndata: {}\n\n"))
+	b.Flush()
+	if b.Committed() || rec.Body.Len() > 0 || rec.Header().Get("Content-Type") != "" {
+		t.Fatal("preamble reached the client before any content")
+	}
+
+	b.Write([]byte("event: content_block_delta\ndata: {}\n\n"))
+	if !b.Committed() {
+		t.Fatal("not committed after a content event")
+	}
+	if rec.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", got)
+	}
+	want := "event: message_start\ndata: {}\n\nevent: content_block_delta\ndata: {}\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+	if !rec.Flushed {
+		t.Error("commit did not flush")
+	}
+
+	// After the commit, writes pass straight through
+	b.Write([]byte("event: message_stop\n\n"))
+	if got := rec.Body.String(); got != want+"event: message_stop\n\n" {
+		t.Errorf("body after commit = %q", got)
+	}
+}
+
+func TestStreamBufferThreshold(t *testing.T) {
+	rec := httptest.NewRecorder()
+	b := NewStreamBuffer(rec, domain.ClientTypeClaude, 10)
+
+	b.Write([]byte("12345"))
+	if b.Committed() {
+		t.Fatal("committed below the threshold")
+	}
+	b.Write([]byte("67890"))
+	if !b.Committed() {
+		t.Fatal("not committed at the threshold")
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != "1234567890" {
+		t.Errorf("got %d %q, want 200 %q", rec.Code, rec.Body.String(), "1234567890")
+	}
+}
+
+func TestStreamBufferDiscardedAttempt(t *testing.T) {
+	rec := httptest.NewRecorder()
+	b := NewStreamBuffer(rec, domain.ClientTypeOpenAI, 0)
+
+	// An attempt that fails before any content leaves the client untouched
+	b.Header().Set("X-Upstream", "first")
+	b.WriteHeader(http.StatusOK)
+	b.Write([]byte("data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"\"}}]}\n\n"))
+	if b.Committed() {
+		t.Fatal("committed on a role-only chunk")
+	}
+	if rec.Body.Len() > 0 || len(rec.Header()) > 0 {
+		t.Errorf("client received output from a discarded attempt: %q %v", rec.Body.String(), rec.Header())
+	}
+
+	// Commit without output still sends the recorded status and headers
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Header().Get("X-Upstream") != "first" {
+		t.Error("headers not sent on commit")
+	}
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/tracing"
+)
+
+// startRequestSpan starts the server span of a proxy request, continuing the client's trace
+// when it sent a traceparent header
+func startRequestSpan(ctx context.Context, req *http.Request, name string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(tracing.Extract(ctx, req.Header), name, tracing.KindServer)
+	span.SetAttr("maxx.client_type", string(ctxutil.GetClientType(ctx)))
+	span.SetAttr("maxx.request_model", ctxutil.GetRequestModel(ctx))
+	span.SetAttr("maxx.project_id", ctxutil.GetProjectID(ctx))
+	span.SetAttr("maxx.stream", ctxutil.GetIsStream(ctx))
+	if sessionID := ctxutil.GetSessionID(ctx); sessionID != "" {
+		span.SetAttr("maxx.session_id", sessionID)
+	}
+	return ctx, span
+}
+
+// endRequestSpan records the final state of a proxy request and ends its span
+func endRequestSpan(span *tracing.Span, proxyReq *domain.ProxyRequest, providerName string) {
+	if span == nil {
+		return
+	}
+	span.SetAttr("maxx.request_id", proxyReq.ID)
+	span.SetAttr("maxx.project_id", proxyReq.ProjectID)
+	span.SetAttr("maxx.status", proxyReq.Status)
+	span.SetAttr("maxx.attempts", proxyReq.ProxyUpstreamAttemptCount)
+	if providerName != "" {
+		span.SetAttr("maxx.provider", providerName)
+	}
+	if proxyReq.StatusCode != 0 {
+		span.SetAttr("http.response.status_code", proxyReq.StatusCode)
+	}
+	span.SetAttr("maxx.tokens.input", proxyReq.InputTokenCount)
+	span.SetAttr("maxx.tokens.output", proxyReq.OutputTokenCount)
+	span.SetAttr("maxx.cost_micro_usd", proxyReq.Cost)
+	if proxyReq.Status == "COMPLETED" {
+		span.SetOK()
+	} else {
+		msg := proxyReq.Error
+		if msg == "" {
+			msg = proxyReq.Status
+		}
+		span.SetError(errors.New(msg))
+	}
+	span.End()
+}
+
+// startAttemptSpan starts the span of one upstream attempt; adapter spans nest under it
+func startAttemptSpan(ctx context.Context, matchedRoute *router.MatchedRoute, attemptRecord *domain.ProxyUpstreamAttempt, retry int) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, "maxx.upstream_attempt", tracing.KindInternal)
+	span.SetAttr("maxx.attempt_id", attemptRecord.ID)
+	span.SetAttr("maxx.retry", retry)
+	span.SetAttr("maxx.route_id", matchedRoute.Route.ID)
+	span.SetAttr("maxx.provider_id", matchedRoute.Provider.ID)
+	span.SetAttr("maxx.provider", matchedRoute.Provider.Name)
+	span.SetAttr("maxx.provider_type", matchedRoute.Provider.Type)
+	span.SetAttr("maxx.mapped_model", ctxutil.GetMappedModel(ctx))
+	return ctx, span
+}
+
+// endAttemptSpan records the outcome of an upstream attempt and ends its span
+func endAttemptSpan(span *tracing.Span, attemptRecord *domain.ProxyUpstreamAttempt, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttr("maxx.status", attemptRecord.Status)
+	span.SetAttr("maxx.ttfb_ms", attemptRecord.TTFB)
+	span.SetAttr("maxx.tokens.input", attemptRecord.InputTokenCount)
+	span.SetAttr("maxx.tokens.output", attemptRecord.OutputTokenCount)
+	span.SetAttr("maxx.cost_micro_usd", attemptRecord.Cost)
+	var proxyErr *domain.ProxyError
+	if errors.As(err, &proxyErr) {
+		span.SetAttr("maxx.retryable", proxyErr.Retryable)
+		if proxyErr.HTTPStatusCode != 0 {
+			span.SetAttr("http.response.status_code", proxyErr.HTTPStatusCode)
+		}
+	}
+	if err != nil {
+		span.SetError(err)
+	} else {
+		span.SetOK()
+	}
+	span.End()
+}
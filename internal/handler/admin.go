@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/awsl-project/maxx/internal/circuit"
 	"github.com/awsl-project/maxx/internal/cooldown"
 	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/service"
@@ -52,6 +55,12 @@ func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleProjects(w, r, id, parts)
 	case "sessions":
 		h.handleSessions(w, r, parts)
+	case "api-keys":
+		h.handleAPIKeys(w, r, id)
+	case "rate-limits":
+		h.handleRateLimits(w, r, id)
+	case "budgets":
+		h.handleBudgets(w, r, id, parts)
 	case "retry-configs":
 		h.handleRetryConfigs(w, r, id)
 	case "routing-strategies":
@@ -65,7 +74,9 @@ func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case "provider-stats":
 		h.handleProviderStats(w, r)
 	case "cooldowns":
-		h.handleCooldowns(w, r, id)
+		h.handleCooldowns(w, r, id, parts)
+	case "pricing":
+		h.handlePricing(w, r, id, parts)
 	case "logs":
 		h.handleLogs(w, r)
 	default:
@@ -94,6 +105,9 @@ func (h *AdminHandler) handleProviders(w http.ResponseWriter, r *http.Request, i
 				writeJSON(w, http.StatusNotFound, map[string]string{"error": "provider not found"})
 				return
 			}
+			if !revealSecrets(r) {
+				provider = provider.MaskedCopy()
+			}
 			writeJSON(w, http.StatusOK, provider)
 		} else {
 			providers, err := h.svc.GetProviders()
@@ -101,6 +115,13 @@ func (h *AdminHandler) handleProviders(w http.ResponseWriter, r *http.Request, i
 				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 				return
 			}
+			if !revealSecrets(r) {
+				masked := make([]*domain.Provider, len(providers))
+				for i, p := range providers {
+					masked[i] = p.MaskedCopy()
+				}
+				providers = masked
+			}
 			writeJSON(w, http.StatusOK, providers)
 		}
 	case http.MethodPost:
@@ -110,10 +131,10 @@ func (h *AdminHandler) handleProviders(w http.ResponseWriter, r *http.Request, i
 			return
 		}
 		if err := h.svc.CreateProvider(&provider); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusCreated, provider)
+		writeJSON(w, http.StatusCreated, provider.MaskedCopy())
 	case http.MethodPut:
 		if id == 0 {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
@@ -136,10 +157,10 @@ func (h *AdminHandler) handleProviders(w http.ResponseWriter, r *http.Request, i
 		provider.ID = existing.ID
 		provider.CreatedAt = existing.CreatedAt
 		if err := h.svc.UpdateProvider(&provider); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, provider)
+		writeJSON(w, http.StatusOK, provider.MaskedCopy())
 	case http.MethodDelete:
 		if id == 0 {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
@@ -155,6 +176,11 @@ func (h *AdminHandler) handleProviders(w http.ResponseWriter, r *http.Request, i
 	}
 }
 
+// revealSecrets reports whether the caller asked for unmasked provider secrets (?reveal=true)
+func revealSecrets(r *http.Request) bool {
+	return r.URL.Query().Get("reveal") == "true"
+}
+
 // handleProvidersExport exports all providers as JSON
 func (h *AdminHandler) handleProvidersExport(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -198,6 +224,17 @@ func (h *AdminHandler) handleProvidersImport(w http.ResponseWriter, r *http.Requ
 
 // Route handlers
 func (h *AdminHandler) handleRoutes(w http.ResponseWriter, r *http.Request, id uint64) {
+	// Check for special endpoints
+	path := r.URL.Path
+	if strings.HasSuffix(path, "/explain") {
+		h.handleRoutesExplain(w, r)
+		return
+	}
+	if strings.HasSuffix(path, "/validate") {
+		h.handleRoutesValidate(w, r)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		if id > 0 {
@@ -222,7 +259,7 @@ func (h *AdminHandler) handleRoutes(w http.ResponseWriter, r *http.Request, id u
 			return
 		}
 		if err := h.svc.CreateRoute(&route); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
 		writeJSON(w, http.StatusCreated, route)
@@ -291,8 +328,29 @@ func (h *AdminHandler) handleRoutes(w http.ResponseWriter, r *http.Request, id u
 				}
 			}
 		}
+		if v, ok := updates["matchRules"]; ok {
+			existing.MatchRules = nil
+			if err := remarshal(v, &existing.MatchRules); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid matchRules: " + err.Error()})
+				return
+			}
+		}
+		if v, ok := updates["modelMappingRules"]; ok {
+			existing.ModelMappingRules = nil
+			if err := remarshal(v, &existing.ModelMappingRules); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid modelMappingRules: " + err.Error()})
+				return
+			}
+		}
+		if v, ok := updates["fallbackModels"]; ok {
+			existing.FallbackModels = nil
+			if err := remarshal(v, &existing.FallbackModels); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid fallbackModels: " + err.Error()})
+				return
+			}
+		}
 		if err := h.svc.UpdateRoute(existing); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
 		writeJSON(w, http.StatusOK, existing)
@@ -311,6 +369,47 @@ func (h *AdminHandler) handleRoutes(w http.ResponseWriter, r *http.Request, id u
 	}
 }
 
+// handleRoutesExplain shows which routes and match rules a hypothetical request would use
+// POST /admin/routes/explain {"clientType","projectID","model","stream","inputTokens"}
+func (h *AdminHandler) handleRoutesExplain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var req service.RouteExplainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := h.svc.ExplainRouteMatch(req)
+	if err != nil {
+		writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleRoutesValidate checks match rules without saving them
+// POST /admin/routes/validate {"matchRules":[...]}
+func (h *AdminHandler) handleRoutesValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body struct {
+		MatchRules []domain.RouteMatchRule `json:"matchRules"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := h.svc.ValidateRouteMatchRules(body.MatchRules); err != nil {
+		writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"valid": true})
+}
+
 // Project handlers
 func (h *AdminHandler) handleProjects(w http.ResponseWriter, r *http.Request, id uint64, parts []string) {
 	// Check for by-slug endpoint: /admin/projects/by-slug/{slug}
@@ -484,6 +583,239 @@ func (h *AdminHandler) handleSessionReject(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, http.StatusOK, session)
 }
 
+// APIKey handlers
+func (h *AdminHandler) handleAPIKeys(w http.ResponseWriter, r *http.Request, id uint64) {
+	switch r.Method {
+	case http.MethodGet:
+		if id > 0 {
+			key, err := h.svc.GetAPIKey(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "api key not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, key)
+		} else {
+			keys, err := h.svc.GetAPIKeys()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, keys)
+		}
+	case http.MethodPost:
+		var key domain.APIKey
+		if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		// The plaintext key is only included in this response
+		created, err := h.svc.CreateAPIKey(&key)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	case http.MethodPut:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		existing, err := h.svc.GetAPIKey(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "api key not found"})
+			return
+		}
+		var key domain.APIKey
+		if err := json.NewDecoder(r.Body).Decode(&key); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		key.ID = existing.ID
+		key.CreatedAt = existing.CreatedAt
+		if err := h.svc.UpdateAPIKey(&key); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, key)
+	case http.MethodDelete:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		if err := h.svc.DeleteAPIKey(id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// RateLimit handlers
+func (h *AdminHandler) handleRateLimits(w http.ResponseWriter, r *http.Request, id uint64) {
+	switch r.Method {
+	case http.MethodGet:
+		if id > 0 {
+			limit, err := h.svc.GetRateLimit(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "rate limit not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, limit)
+		} else {
+			limits, err := h.svc.GetRateLimits()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, limits)
+		}
+	case http.MethodPost:
+		var limit domain.RateLimit
+		if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := h.svc.CreateRateLimit(&limit); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, limit)
+	case http.MethodPut:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		existing, err := h.svc.GetRateLimit(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "rate limit not found"})
+			return
+		}
+		var limit domain.RateLimit
+		if err := json.NewDecoder(r.Body).Decode(&limit); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		limit.ID = existing.ID
+		limit.CreatedAt = existing.CreatedAt
+		if err := h.svc.UpdateRateLimit(&limit); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, limit)
+	case http.MethodDelete:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		if err := h.svc.DeleteRateLimit(id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// Budget handlers
+func (h *AdminHandler) handleBudgets(w http.ResponseWriter, r *http.Request, id uint64, parts []string) {
+	// GET /admin/budgets/status
+	if len(parts) > 2 && parts[2] == "status" {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		writeJSON(w, http.StatusOK, h.svc.GetBudgetStatus())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if id > 0 {
+			b, err := h.svc.GetBudget(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, b)
+		} else {
+			budgets, err := h.svc.GetBudgets()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, budgets)
+		}
+	case http.MethodPost:
+		var b domain.Budget
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := h.svc.CreateBudget(&b); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, b)
+	case http.MethodPut:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		existing, err := h.svc.GetBudget(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "budget not found"})
+			return
+		}
+		var b domain.Budget
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		b.ID = existing.ID
+		b.CreatedAt = existing.CreatedAt
+		if err := h.svc.UpdateBudget(&b); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, b)
+	case http.MethodDelete:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		if err := h.svc.DeleteBudget(id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// validationErrorStatus maps service validation errors to 4xx statuses
+func validationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrAlreadyExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// remarshal decodes a value taken from a partial-update map into its typed form
+func remarshal(v interface{}, dst interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
 // RetryConfig handlers
 func (h *AdminHandler) handleRetryConfigs(w http.ResponseWriter, r *http.Request, id uint64) {
 	switch r.Method {
@@ -814,7 +1146,17 @@ func (h *AdminHandler) handleLogs(w http.ResponseWriter, r *http.Request) {
 // Cooldowns handler
 // GET /admin/cooldowns - list all active cooldowns
 // DELETE /admin/cooldowns/{id} - clear cooldown for a provider
-func (h *AdminHandler) handleCooldowns(w http.ResponseWriter, r *http.Request, providerID uint64) {
+// /admin/cooldowns/policies[/{id}] - manage cooldown policy overrides
+func (h *AdminHandler) handleCooldowns(w http.ResponseWriter, r *http.Request, providerID uint64, parts []string) {
+	if len(parts) > 2 && parts[2] == "policies" {
+		var id uint64
+		if len(parts) > 3 && parts[3] != "" {
+			id, _ = strconv.ParseUint(parts[3], 10, 64)
+		}
+		h.handleCooldownPolicies(w, r, id)
+		return
+	}
+
 	cm := cooldown.Default()
 
 	switch r.Method {
@@ -847,6 +1189,7 @@ func (h *AdminHandler) handleCooldowns(w http.ResponseWriter, r *http.Request, p
 		}
 		// Clear all cooldowns for this provider (both global and client-type-specific)
 		cm.ClearCooldown(providerID, "")
+		circuit.Default().Reset(providerID)
 		writeJSON(w, http.StatusOK, map[string]string{"message": "cooldown cleared"})
 
 	default:
@@ -854,6 +1197,229 @@ func (h *AdminHandler) handleCooldowns(w http.ResponseWriter, r *http.Request, p
 	}
 }
 
+// Cooldown policy handlers
+func (h *AdminHandler) handleCooldownPolicies(w http.ResponseWriter, r *http.Request, id uint64) {
+	switch r.Method {
+	case http.MethodGet:
+		if id > 0 {
+			p, err := h.svc.GetCooldownPolicy(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "cooldown policy not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, p)
+		} else {
+			policies, err := h.svc.GetCooldownPolicies()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, policies)
+		}
+	case http.MethodPost:
+		var p domain.CooldownPolicyConfig
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := h.svc.CreateCooldownPolicy(&p); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, p)
+	case http.MethodPut:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		existing, err := h.svc.GetCooldownPolicy(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "cooldown policy not found"})
+			return
+		}
+		var p domain.CooldownPolicyConfig
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		p.ID = existing.ID
+		p.CreatedAt = existing.CreatedAt
+		if err := h.svc.UpdateCooldownPolicy(&p); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+	case http.MethodDelete:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		if err := h.svc.DeleteCooldownPolicy(id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// Pricing handlers
+func (h *AdminHandler) handlePricing(w http.ResponseWriter, r *http.Request, id uint64, parts []string) {
+	if len(parts) > 2 {
+		switch parts[2] {
+		case "import":
+			h.handlePricingImport(w, r)
+			return
+		case "export":
+			h.handlePricingExport(w, r)
+			return
+		case "recalculate":
+			h.handlePricingRecalculate(w, r)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if id > 0 {
+			p, err := h.svc.GetModelPrice(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "model price not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, p)
+		} else {
+			prices, err := h.svc.GetModelPrices()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, prices)
+		}
+	case http.MethodPost:
+		var p domain.ModelPrice
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := h.svc.CreateModelPrice(&p); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, p)
+	case http.MethodPut:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		existing, err := h.svc.GetModelPrice(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "model price not found"})
+			return
+		}
+		var p domain.ModelPrice
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		p.ID = existing.ID
+		p.CreatedAt = existing.CreatedAt
+		if err := h.svc.UpdateModelPrice(&p); err != nil {
+			writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+	case http.MethodDelete:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		if err := h.svc.DeleteModelPrice(id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handlePricingImport imports a LiteLLM price file sent inline as data
+// Server-side paths are not accepted, so callers can't make maxx read arbitrary files
+func (h *AdminHandler) handlePricingImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	var body struct {
+		Path string          `json:"path"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	if body.Path != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "path is not supported, send the file contents as data"})
+		return
+	}
+	if len(body.Data) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "data required"})
+		return
+	}
+
+	result, err := h.svc.ImportLiteLLMPrices(body.Data)
+	if err != nil {
+		writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handlePricingExport downloads the effective price table in LiteLLM format
+func (h *AdminHandler) handlePricingExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	data, err := h.svc.ExportLiteLLMPrices()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=model_prices_and_context_window.json")
+	w.Write(data)
+}
+
+// handlePricingRecalculate re-prices historical attempts after a price correction
+func (h *AdminHandler) handlePricingRecalculate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	var body struct {
+		Since time.Time `json:"since"`
+		Until time.Time `json:"until"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+
+	result, err := h.svc.RecalculateCosts(body.Since, body.Until)
+	if err != nil {
+		writeJSON(w, validationErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
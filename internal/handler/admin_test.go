@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlePricingImport_RejectsServerPaths(t *testing.T) {
+	h := NewAdminHandler(nil, "")
+	for _, body := range []string{
+		`{"path":"/etc/passwd"}`,
+		`{"path":"../maxx.db","data":{}}`,
+		`{}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/pricing/import", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.handlePricingImport(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "root:") {
+			t.Errorf("%s: response leaks file contents: %s", body, w.Body.String())
+		}
+	}
+}
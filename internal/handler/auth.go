@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/service"
+)
+
+// SessionCookieName is the cookie carrying the admin session token
+const SessionCookieName = "maxx_session"
+
+// AuthMiddleware protects the admin API, Antigravity API and WebSocket hub
+// Viewers may only issue read requests; everything else requires the admin role
+type AuthMiddleware struct {
+	svc *service.AuthService
+}
+
+// NewAuthMiddleware creates a new auth middleware
+func NewAuthMiddleware(svc *service.AuthService) *AuthMiddleware {
+	return &AuthMiddleware{svc: svc}
+}
+
+// Wrap returns a handler that authenticates requests before calling next
+func (m *AuthMiddleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := m.svc.Authenticate(requestToken(r))
+		if err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+			return
+		}
+		if user.Role != domain.UserRoleAdmin && requiresAdmin(r) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": domain.ErrForbidden.Error()})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctxutil.WithUser(r.Context(), user)))
+	})
+}
+
+// WrapFunc is Wrap for handler functions
+func (m *AuthMiddleware) WrapFunc(next http.HandlerFunc) http.Handler {
+	return m.Wrap(next)
+}
+
+// isPublicPath lists endpoints reachable without a session
+func isPublicPath(path string) bool {
+	switch strings.TrimSuffix(path, "/") {
+	case "/admin/auth/login", "/admin/auth/setup", "/admin/auth/status", "/antigravity/oauth/callback":
+		return true
+	}
+	return false
+}
+
+// requiresAdmin reports whether the request mutates state or exposes secrets
+func requiresAdmin(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		// Logging out is allowed for everyone
+		return strings.TrimSuffix(r.URL.Path, "/") != "/admin/auth/logout"
+	}
+	// Read-only endpoints that return credentials, users or the full usage and spend metrics
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if strings.HasPrefix(path, "/admin/providers") && revealSecrets(r) {
+		return true
+	}
+	return strings.HasSuffix(path, "/providers/export") || strings.HasPrefix(path, "/admin/users") || path == "/metrics"
+}
+
+// requestToken reads the session token from the Authorization header or the session cookie
+// The ?token= query parameter is only accepted on the /ws upgrade (browsers can't set
+// headers there), so tokens don't end up in access logs and history elsewhere
+func requestToken(r *http.Request) string {
+	if token := bearerToken(r); token != "" {
+		return token
+	}
+	if cookie, err := r.Cookie(SessionCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if r.URL.Path == "/ws" {
+		return r.URL.Query().Get("token")
+	}
+	return ""
+}
+
+// AuthHandler handles login/logout and user management
+// Routes:
+//
+//	GET  /admin/auth/status - 是否需要初始化、当前用户
+//	POST /admin/auth/setup  - 使用启动时打印的一次性 setup token 创建首个管理员（仅在没有用户时可用）
+//	POST /admin/auth/login  - 登录
+//	POST /admin/auth/logout - 登出
+//	GET  /admin/auth/me     - 当前用户
+//	/admin/users[/{id}]     - 用户管理（仅管理员）
+type AuthHandler struct {
+	svc *service.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(svc *service.AuthService) *AuthHandler {
+	return &AuthHandler{svc: svc}
+}
+
+// ServeHTTP routes auth requests
+func (h *AuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/admin")
+	path = strings.TrimSuffix(path, "/")
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	if parts[1] == "users" {
+		var id uint64
+		if len(parts) > 2 && parts[2] != "" {
+			id, _ = strconv.ParseUint(parts[2], 10, 64)
+		}
+		h.handleUsers(w, r, id)
+		return
+	}
+
+	var action string
+	if len(parts) > 2 {
+		action = parts[2]
+	}
+	switch action {
+	case "status":
+		h.handleStatus(w, r)
+	case "setup":
+		h.handleSetup(w, r)
+	case "login":
+		h.handleLogin(w, r)
+	case "logout":
+		h.handleLogout(w, r)
+	case "me":
+		h.handleMe(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+type credentials struct {
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	SetupToken string `json:"setupToken,omitempty"`
+}
+
+func (h *AuthHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	result := map[string]interface{}{
+		"setupRequired": h.svc.SetupRequired(),
+	}
+	if user, err := h.svc.Authenticate(requestToken(r)); err == nil {
+		result["user"] = user
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *AuthHandler) handleSetup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body credentials
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := h.svc.Setup(body.SetupToken, body.Username, body.Password)
+	if err != nil {
+		writeJSON(w, authErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	setSessionCookie(w, r, result)
+	writeJSON(w, http.StatusCreated, result)
+}
+
+func (h *AuthHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body credentials
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	result, err := h.svc.Login(body.Username, body.Password)
+	if err != nil {
+		writeJSON(w, authErrorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	setSessionCookie(w, r, result)
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *AuthHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	_ = h.svc.Logout(requestToken(r))
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeJSON(w, http.StatusOK, map[string]string{"message": "logged out"})
+}
+
+func (h *AuthHandler) handleMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	user := ctxutil.GetUser(r.Context())
+	if user == nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": domain.ErrUnauthorized.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// userRequest is the body for creating/updating users
+// Password is optional on update (empty keeps the current password)
+type userRequest struct {
+	Username  string          `json:"username"`
+	Password  string          `json:"password"`
+	Role      domain.UserRole `json:"role"`
+	IsEnabled *bool           `json:"isEnabled"`
+}
+
+func (h *AuthHandler) handleUsers(w http.ResponseWriter, r *http.Request, id uint64) {
+	switch r.Method {
+	case http.MethodGet:
+		if id > 0 {
+			user, err := h.svc.GetUser(id)
+			if err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+				return
+			}
+			writeJSON(w, http.StatusOK, user)
+		} else {
+			users, err := h.svc.GetUsers()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusOK, users)
+		}
+	case http.MethodPost:
+		var body userRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		user := &domain.User{Username: body.Username, Role: body.Role, IsEnabled: true}
+		if body.IsEnabled != nil {
+			user.IsEnabled = *body.IsEnabled
+		}
+		created, err := h.svc.CreateUser(user, body.Password)
+		if err != nil {
+			writeJSON(w, authErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	case http.MethodPut:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		existing, err := h.svc.GetUser(id)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+			return
+		}
+		var body userRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		user := *existing
+		if body.Username != "" {
+			user.Username = body.Username
+		}
+		if body.Role != "" {
+			user.Role = body.Role
+		}
+		if body.IsEnabled != nil {
+			user.IsEnabled = *body.IsEnabled
+		}
+		if err := h.svc.UpdateUser(&user, body.Password); err != nil {
+			writeJSON(w, authErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, user)
+	case http.MethodDelete:
+		if id == 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id required"})
+			return
+		}
+		if err := h.svc.DeleteUser(id); err != nil {
+			writeJSON(w, authErrorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusNoContent, nil)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+func setSessionCookie(w http.ResponseWriter, r *http.Request, result *service.LoginResult) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    result.Token,
+		Path:     "/",
+		Expires:  result.ExpiresAt,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func authErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredentials), errors.Is(err, domain.ErrUnauthorized), errors.Is(err, domain.ErrInvalidSetupToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAlreadyExists), errors.Is(err, domain.ErrLastAdmin):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidInput):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository/sqlite"
+	"github.com/awsl-project/maxx/internal/service"
+)
+
+func newTestAuth(t *testing.T) *service.AuthService {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "maxx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return service.NewAuthService(sqlite.NewUserRepository(db), sqlite.NewAdminSessionRepository(db))
+}
+
+// loginToken creates a user with the given role and returns a session token for it
+func loginToken(t *testing.T, svc *service.AuthService, username string, role domain.UserRole) string {
+	t.Helper()
+	if _, err := svc.CreateUser(&domain.User{Username: username, Role: role, IsEnabled: true}, "password123"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := svc.Login(username, "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Token
+}
+
+// protected records whether the request reached the wrapped handler
+func protected(reached *bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*reached = true
+		if ctxutil.GetUser(r.Context()) == nil && !isPublicPath(r.URL.Path) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestAuthMiddleware_NoUsersFailsClosed(t *testing.T) {
+	m := NewAuthMiddleware(newTestAuth(t))
+	for _, path := range []string{"/admin/providers", "/admin/providers/export", "/ws", "/antigravity/providers/1/quota"} {
+		var reached bool
+		w := httptest.NewRecorder()
+		m.Wrap(protected(&reached)).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusUnauthorized || reached {
+			t.Errorf("%s without users: status = %d, reached = %v; want 401", path, w.Code, reached)
+		}
+	}
+}
+
+func TestAuthMiddleware_Roles(t *testing.T) {
+	svc := newTestAuth(t)
+	if err := svc.Bootstrap("admin", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	result, err := svc.Login("admin", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminToken := result.Token
+	viewerToken := loginToken(t, svc, "viewer", domain.UserRoleViewer)
+	m := NewAuthMiddleware(svc)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"public status", http.MethodGet, "/admin/auth/status", "", http.StatusOK},
+		{"public login", http.MethodPost, "/admin/auth/login", "", http.StatusOK},
+		{"public setup", http.MethodPost, "/admin/auth/setup", "", http.StatusOK},
+		{"no token", http.MethodGet, "/admin/providers", "", http.StatusUnauthorized},
+		{"bad token", http.MethodGet, "/admin/providers", "nope", http.StatusUnauthorized},
+		{"viewer read", http.MethodGet, "/admin/providers", viewerToken, http.StatusOK},
+		{"viewer write", http.MethodPost, "/admin/providers", viewerToken, http.StatusForbidden},
+		{"viewer delete", http.MethodDelete, "/admin/routes/1", viewerToken, http.StatusForbidden},
+		{"viewer export", http.MethodGet, "/admin/providers/export", viewerToken, http.StatusForbidden},
+		{"viewer reveal", http.MethodGet, "/admin/providers?reveal=true", viewerToken, http.StatusForbidden},
+		{"viewer users", http.MethodGet, "/admin/users", viewerToken, http.StatusForbidden},
+		{"viewer logout", http.MethodPost, "/admin/auth/logout", viewerToken, http.StatusOK},
+		{"viewer ws", http.MethodGet, "/ws", viewerToken, http.StatusOK},
+		{"viewer metrics", http.MethodGet, "/metrics", viewerToken, http.StatusForbidden},
+		{"query token on metrics", http.MethodGet, "/metrics?token=" + adminToken, "", http.StatusUnauthorized},
+		{"admin metrics", http.MethodGet, "/metrics", adminToken, http.StatusOK},
+		{"admin write", http.MethodPost, "/admin/providers", adminToken, http.StatusOK},
+		{"admin export", http.MethodGet, "/admin/providers/export", adminToken, http.StatusOK},
+		{"admin users", http.MethodGet, "/admin/users", adminToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		var reached bool
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		m.Wrap(protected(&reached)).ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+		if reached != (tt.want == http.StatusOK) {
+			t.Errorf("%s: reached handler = %v", tt.name, reached)
+		}
+	}
+}
+
+func TestRequestToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		cookie string
+		want   string
+	}{
+		{"bearer", "/admin/providers", "Bearer header-token", "", "header-token"},
+		{"cookie", "/admin/providers", "", "cookie-token", "cookie-token"},
+		{"bearer wins", "/admin/providers", "Bearer header-token", "cookie-token", "header-token"},
+		{"query ignored", "/admin/providers?token=query-token", "", "", ""},
+		{"query ignored on export", "/admin/providers/export?token=query-token", "", "", ""},
+		{"query on ws", "/ws?token=query-token", "", "", "query-token"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.cookie})
+		}
+		if got := requestToken(req); got != tt.want {
+			t.Errorf("%s: requestToken = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAuthHandler_SetupAndLogin(t *testing.T) {
+	svc := newTestAuth(t)
+	setupToken, err := svc.NewSetupToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewAuthMiddleware(svc).Wrap(NewAuthHandler(svc))
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/admin/auth/status", "", nil)
+	var status struct {
+		SetupRequired bool         `json:"setupRequired"`
+		User          *domain.User `json:"user"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.SetupRequired || status.User != nil {
+		t.Fatalf("status before setup = %+v", status)
+	}
+
+	if w := do(http.MethodPost, "/admin/auth/setup", `{"username":"admin","password":"password123"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("setup without token: status = %d, want 401", w.Code)
+	}
+	w = do(http.MethodPost, "/admin/auth/setup", `{"setupToken":"`+setupToken+`","username":"admin","password":"password123"}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("setup: status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/admin/auth/setup", `{"setupToken":"`+setupToken+`","username":"other","password":"password123"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("second setup: status = %d, want 401", w.Code)
+	}
+
+	if w := do(http.MethodPost, "/admin/auth/login", `{"username":"admin","password":"wrong-password"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong password: status = %d, want 401", w.Code)
+	}
+	w = do(http.MethodPost, "/admin/auth/login", `{"username":"admin","password":"password123"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status = %d", w.Code)
+	}
+	var session *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == SessionCookieName {
+			session = c
+		}
+	}
+	if session == nil || !session.HttpOnly {
+		t.Fatalf("login did not set an HttpOnly session cookie: %+v", session)
+	}
+
+	if w := do(http.MethodGet, "/admin/auth/me", "", session); w.Code != http.StatusOK {
+		t.Errorf("me with cookie: status = %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/admin/auth/logout", "", session); w.Code != http.StatusOK {
+		t.Errorf("logout: status = %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/admin/auth/me", "", session); w.Code != http.StatusUnauthorized {
+		t.Errorf("me after logout: status = %d, want 401", w.Code)
+	}
+}
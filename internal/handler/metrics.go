@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/awsl-project/maxx/internal/metrics"
+)
+
+// MetricsHandler serves Prometheus metrics to admin sessions (viewers are refused).
+// When a token is configured, scrapers may send it as a bearer token instead.
+type MetricsHandler struct {
+	token string
+	auth  *AuthMiddleware
+}
+
+// NewMetricsHandler creates a metrics handler protected by auth; token is an optional scrape token
+func NewMetricsHandler(token string, auth *AuthMiddleware) *MetricsHandler {
+	return &MetricsHandler{token: token, auth: auth}
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if h.token != "" {
+		got := bearerToken(r)
+		if got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) == 1 {
+			h.writeMetrics(w)
+			return
+		}
+	}
+	h.auth.WrapFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.writeMetrics(w)
+	}).ServeHTTP(w, r)
+}
+
+func (h *MetricsHandler) writeMetrics(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	_ = metrics.Default().WriteText(w)
+}
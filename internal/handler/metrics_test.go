@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+	"github.com/awsl-project/maxx/internal/service"
+)
+
+// memoryUsers holds a single user (or none, when auth is not set up)
+type memoryUsers struct {
+	repository.UserRepository
+	admin *domain.User
+}
+
+func (r *memoryUsers) Count() (int64, error) {
+	if r.admin == nil {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+func (r *memoryUsers) GetByID(id uint64) (*domain.User, error) {
+	if r.admin != nil && r.admin.ID == id {
+		return r.admin, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+// memorySessions knows one session token
+type memorySessions struct {
+	repository.AdminSessionRepository
+	token string
+}
+
+func (r *memorySessions) GetByTokenHash(tokenHash string) (*domain.AdminSession, error) {
+	sum := sha256.Sum256([]byte(r.token))
+	if tokenHash != hex.EncodeToString(sum[:]) {
+		return nil, domain.ErrNotFound
+	}
+	return &domain.AdminSession{UserID: 1, TokenHash: tokenHash, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func TestMetricsHandlerAuth(t *testing.T) {
+	admin := &domain.User{ID: 1, Username: "admin", Role: domain.UserRoleAdmin, IsEnabled: true}
+	viewer := &domain.User{ID: 1, Username: "viewer", Role: domain.UserRoleViewer, IsEnabled: true}
+	tests := []struct {
+		name   string
+		users  *memoryUsers
+		token  string // scrape token, empty when not configured
+		header string // Authorization header sent
+		want   int
+	}{
+		{"no session", &memoryUsers{admin: admin}, "", "", http.StatusUnauthorized},
+		{"admin session", &memoryUsers{admin: admin}, "", "Bearer session-token", http.StatusOK},
+		{"scrape token", &memoryUsers{admin: admin}, "scrape-token", "Bearer scrape-token", http.StatusOK},
+		{"wrong scrape token", &memoryUsers{admin: admin}, "scrape-token", "Bearer guess", http.StatusUnauthorized},
+		{"session with scrape token configured", &memoryUsers{admin: admin}, "scrape-token", "Bearer session-token", http.StatusOK},
+		{"auth not set up", &memoryUsers{}, "", "", http.StatusUnauthorized},
+		{"auth not set up with a token", &memoryUsers{}, "", "Bearer session-token", http.StatusUnauthorized},
+		{"viewer session", &memoryUsers{admin: viewer}, "", "Bearer session-token", http.StatusForbidden},
+		{"viewer session with scrape token configured", &memoryUsers{admin: viewer}, "scrape-token", "Bearer session-token", http.StatusForbidden},
+		{"scrape token for a viewer deployment", &memoryUsers{admin: viewer}, "scrape-token", "Bearer scrape-token", http.StatusOK},
+		{"empty bearer with scrape token configured", &memoryUsers{admin: admin}, "scrape-token", "Bearer ", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		auth := NewAuthMiddleware(service.NewAuthService(tt.users, &memorySessions{token: "session-token"}))
+		h := NewMetricsHandler(tt.token, auth)
+
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.want)
+		}
+	}
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/awsl-project/maxx/internal/adapter/client"
 	ctxutil "github.com/awsl-project/maxx/internal/context"
@@ -19,6 +22,8 @@ type ProxyHandler struct {
 	clientAdapter *client.Adapter
 	executor      *executor.Executor
 	sessionRepo   repository.SessionRepository
+	apiKeyRepo    repository.APIKeyRepository
+	settingRepo   repository.SystemSettingRepository
 }
 
 // NewProxyHandler creates a new proxy handler
@@ -26,11 +31,15 @@ func NewProxyHandler(
 	clientAdapter *client.Adapter,
 	exec *executor.Executor,
 	sessionRepo repository.SessionRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	settingRepo repository.SystemSettingRepository,
 ) *ProxyHandler {
 	return &ProxyHandler{
 		clientAdapter: clientAdapter,
 		executor:      exec,
 		sessionRepo:   sessionRepo,
+		apiKeyRepo:    apiKeyRepo,
+		settingRepo:   settingRepo,
 	}
 }
 
@@ -43,20 +52,6 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Claude Desktop / Anthropic compatibility: count_tokens placeholder
-	if r.URL.Path == "/v1/messages/count_tokens" {
-		_, _ = io.Copy(io.Discard, r.Body)
-		_ = r.Body.Close()
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"input_tokens":  0,
-			"output_tokens": 0,
-		})
-		return
-	}
-
 	// Read body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -97,7 +92,33 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Get or create session to get project ID (if not already set from header)
+	// Authenticate client API key (unless proxy auth is explicitly disabled)
+	apiKey, err := h.authenticate(r, clientType)
+	if err == nil && apiKey != nil && apiKey.ProjectID != 0 {
+		if projectID != 0 && projectID != apiKey.ProjectID {
+			err = domain.ErrAPIKeyProject
+		} else {
+			projectID = apiKey.ProjectID
+		}
+	}
+	if err != nil {
+		status := http.StatusUnauthorized
+		if err == domain.ErrAPIKeyProject {
+			status = http.StatusForbidden
+		}
+		log.Printf("[Proxy] Rejected unauthorized request: %v", err)
+		h.executor.RecordRejected(ctxutil.WithProjectID(ctx, projectID), r, "UNAUTHORIZED", status, err.Error())
+		writeClientError(w, clientType, status, err.Error())
+		return
+	}
+	if apiKey != nil {
+		ctx = ctxutil.WithAPIKeyID(ctx, apiKey.ID)
+		if stripClientCredentials(r, clientType) {
+			ctx = ctxutil.WithRequestURI(ctx, r.URL.RequestURI())
+		}
+	}
+
+	// Get or create session to get project ID (if not already set from header or key)
 	session, _ := h.sessionRepo.GetBySessionID(sessionID)
 	if session != nil {
 		if projectID == 0 {
@@ -116,14 +137,23 @@ func (h *ProxyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx = ctxutil.WithProjectID(ctx, projectID)
 
 	// Execute request (executor handles request recording, project binding, routing, etc.)
-	err = h.executor.Execute(ctx, w, r)
+	if r.URL.Path == "/v1/messages/count_tokens" {
+		err = h.executor.CountTokens(ctx, w, r)
+	} else {
+		err = h.executor.Execute(ctx, w, r)
+	}
 	if err != nil {
 		proxyErr, ok := err.(*domain.ProxyError)
 		if ok {
-			if stream {
-				writeStreamError(w, proxyErr)
+			if proxyErr.ResponseCommitted {
+				// Headers and part of the body were already sent: end the stream with an error event
+				if stream {
+					writeStreamErrorEvent(w, clientType, proxyErr)
+				}
+			} else if stream {
+				writeStreamError(w, clientType, proxyErr)
 			} else {
-				writeProxyError(w, proxyErr)
+				writeProxyError(w, clientType, proxyErr)
 			}
 		} else {
 			writeError(w, http.StatusInternalServerError, err.Error())
@@ -144,15 +174,43 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	})
 }
 
-func writeProxyError(w http.ResponseWriter, err *domain.ProxyError) {
-	w.Header().Set("Content-Type", "application/json")
-	if err.RetryAfter > 0 {
-		sec := int64(err.RetryAfter.Seconds())
+// proxyErrorStatus returns the status to send for errors raised by maxx itself;
+// upstream failures are reported as 502
+func proxyErrorStatus(err *domain.ProxyError) int {
+	if errors.Is(err, domain.ErrRateLimited) {
+		return http.StatusTooManyRequests
+	}
+	if errors.Is(err, domain.ErrBudgetExceeded) {
+		return http.StatusPaymentRequired
+	}
+	if errors.Is(err, domain.ErrInvalidInput) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, domain.ErrCircuitOpen) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		sec := int64(math.Ceil(retryAfter.Seconds()))
 		if sec <= 0 {
 			sec = 1
 		}
 		w.Header().Set("Retry-After", strconv.FormatInt(sec, 10))
 	}
+}
+
+func writeProxyError(w http.ResponseWriter, clientType domain.ClientType, err *domain.ProxyError) {
+	if status := proxyErrorStatus(err); status != http.StatusBadGateway {
+		setRetryAfter(w, err.RetryAfter)
+		writeClientError(w, clientType, status, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setRetryAfter(w, err.RetryAfter)
 	w.WriteHeader(http.StatusBadGateway)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"error": map[string]interface{}{
@@ -163,27 +221,65 @@ func writeProxyError(w http.ResponseWriter, err *domain.ProxyError) {
 	})
 }
 
-func writeStreamError(w http.ResponseWriter, err *domain.ProxyError) {
+func writeStreamError(w http.ResponseWriter, clientType domain.ClientType, err *domain.ProxyError) {
+	// Errors raised before anything was streamed get a real status code so clients can back off
+	if status := proxyErrorStatus(err); status != http.StatusBadGateway {
+		setRetryAfter(w, err.RetryAfter)
+		writeClientError(w, clientType, status, err.Error())
+		return
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
-	if err.RetryAfter > 0 {
-		sec := int64(err.RetryAfter.Seconds())
-		if sec <= 0 {
-			sec = 1
+	setRetryAfter(w, err.RetryAfter)
+	w.WriteHeader(http.StatusOK)
+
+	writeStreamErrorEvent(w, clientType, err)
+}
+
+// writeStreamErrorEvent writes an upstream error as an SSE event in the client's native stream format
+func writeStreamErrorEvent(w http.ResponseWriter, clientType domain.ClientType, err *domain.ProxyError) {
+	var event string
+	var payload interface{}
+	switch clientType {
+	case domain.ClientTypeClaude:
+		event = "error"
+		payload = map[string]interface{}{
+			"type": "error",
+			"error": map[string]interface{}{
+				"type":    "api_error",
+				"message": err.Error(),
+			},
+		}
+	case domain.ClientTypeCodex:
+		event = "error"
+		payload = map[string]interface{}{
+			"type":    "error",
+			"code":    "upstream_error",
+			"message": err.Error(),
+		}
+	case domain.ClientTypeGemini:
+		payload = map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    http.StatusBadGateway,
+				"message": err.Error(),
+				"status":  "UNAVAILABLE",
+			},
+		}
+	default:
+		payload = map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": err.Error(),
+				"type":    "upstream_error",
+				"code":    nil,
+			},
 		}
-		w.Header().Set("Retry-After", strconv.FormatInt(sec, 10))
 	}
-	w.WriteHeader(http.StatusOK)
 
-	errorEvent := map[string]interface{}{
-		"type": "error",
-		"error": map[string]interface{}{
-			"message":   err.Error(),
-			"type":      "upstream_error",
-			"retryable": err.Retryable,
-		},
+	data, _ := json.Marshal(payload)
+	if event != "" {
+		w.Write([]byte("event: " + event + "\n"))
 	}
-	data, _ := json.Marshal(errorEvent)
 	w.Write([]byte("data: "))
 	w.Write(data)
 	w.Write([]byte("\n\n"))
@@ -192,3 +288,75 @@ func writeStreamError(w http.ResponseWriter, err *domain.ProxyError) {
 		f.Flush()
 	}
 }
+
+// writeClientError writes an error in the client's native error format
+func writeClientError(w http.ResponseWriter, clientType domain.ClientType, status int, message string) {
+	var body interface{}
+	switch clientType {
+	case domain.ClientTypeClaude:
+		errType := "api_error"
+		switch status {
+		case http.StatusBadRequest:
+			errType = "invalid_request_error"
+		case http.StatusUnauthorized:
+			errType = "authentication_error"
+		case http.StatusForbidden:
+			errType = "permission_error"
+		case http.StatusPaymentRequired:
+			errType = "billing_error"
+		case http.StatusTooManyRequests:
+			errType = "rate_limit_error"
+		}
+		body = map[string]interface{}{
+			"type": "error",
+			"error": map[string]interface{}{
+				"type":    errType,
+				"message": message,
+			},
+		}
+	case domain.ClientTypeGemini:
+		grpcStatus := "UNKNOWN"
+		switch status {
+		case http.StatusBadRequest:
+			grpcStatus = "INVALID_ARGUMENT"
+		case http.StatusUnauthorized:
+			grpcStatus = "UNAUTHENTICATED"
+		case http.StatusForbidden:
+			grpcStatus = "PERMISSION_DENIED"
+		case http.StatusPaymentRequired:
+			grpcStatus = "FAILED_PRECONDITION"
+		case http.StatusTooManyRequests:
+			grpcStatus = "RESOURCE_EXHAUSTED"
+		}
+		body = map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    status,
+				"message": message,
+				"status":  grpcStatus,
+			},
+		}
+	default:
+		errType, code := "api_error", ""
+		switch status {
+		case http.StatusBadRequest:
+			errType = "invalid_request_error"
+		case http.StatusUnauthorized, http.StatusForbidden:
+			errType, code = "invalid_request_error", "invalid_api_key"
+		case http.StatusTooManyRequests:
+			errType, code = "rate_limit_exceeded", "rate_limit_exceeded"
+		case http.StatusPaymentRequired:
+			errType, code = "insufficient_quota", "insufficient_quota"
+		}
+		body = map[string]interface{}{
+			"error": map[string]interface{}{
+				"message": message,
+				"type":    errType,
+				"code":    code,
+			},
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// lastUsedUpdateInterval limits how often APIKey.LastUsedAt is written back
+const lastUsedUpdateInterval = time.Minute
+
+// extractClientAPIKey reads the client API key from the header the client type normally uses
+// Claude: x-api-key (Claude Code also sends Authorization: Bearer when ANTHROPIC_AUTH_TOKEN is set)
+// OpenAI / Codex: Authorization: Bearer
+// Gemini: x-goog-api-key or the ?key= query parameter
+func extractClientAPIKey(r *http.Request, clientType domain.ClientType) string {
+	switch clientType {
+	case domain.ClientTypeClaude:
+		if key := r.Header.Get("x-api-key"); key != "" {
+			return key
+		}
+		return bearerToken(r)
+	case domain.ClientTypeOpenAI, domain.ClientTypeCodex:
+		return bearerToken(r)
+	case domain.ClientTypeGemini:
+		if key := r.Header.Get("x-goog-api-key"); key != "" {
+			return key
+		}
+		return r.URL.Query().Get("key")
+	}
+	return ""
+}
+
+func bearerToken(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// authRequired reports whether proxy endpoints require a client API key
+// Keys are enforced by default; only an explicit proxy_auth_enabled = "false" opts out.
+// If the setting can't be read the proxy fails closed.
+func (h *ProxyHandler) authRequired() bool {
+	if h.settingRepo == nil {
+		return true
+	}
+	value, err := h.settingRepo.Get(domain.SettingKeyProxyAuthEnabled)
+	if err != nil {
+		return true
+	}
+	return value != "false"
+}
+
+// authenticate validates the client API key unless proxy authentication is switched off
+// Returns the matched key (nil when authentication is disabled)
+func (h *ProxyHandler) authenticate(r *http.Request, clientType domain.ClientType) (*domain.APIKey, error) {
+	if !h.authRequired() {
+		return nil, nil
+	}
+
+	plain := extractClientAPIKey(r, clientType)
+	if plain == "" {
+		return nil, domain.ErrMissingAPIKey
+	}
+
+	if h.apiKeyRepo == nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	key, err := h.apiKeyRepo.GetByHash(domain.HashAPIKey(plain))
+	if err != nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if !key.IsEnabled {
+		return nil, domain.ErrAPIKeyDisabled
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return nil, domain.ErrAPIKeyExpired
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedUpdateInterval {
+		updated := *key
+		updated.LastUsedAt = &now
+		_ = h.apiKeyRepo.Update(&updated)
+	}
+	return key, nil
+}
+
+// clientAuthHeaders are the headers a client may carry its maxx key in
+var clientAuthHeaders = []string{"Authorization", "x-api-key", "x-goog-api-key"}
+
+// stripClientCredentials removes the maxx key from the request once it has been
+// authenticated, so it is never forwarded to a provider; adapters add the
+// provider's own credentials. Reports whether the Gemini ?key= parameter was removed.
+func stripClientCredentials(r *http.Request, clientType domain.ClientType) bool {
+	for _, name := range clientAuthHeaders {
+		r.Header.Del(name)
+	}
+	if clientType != domain.ClientTypeGemini {
+		return false
+	}
+	query := r.URL.Query()
+	if !query.Has("key") {
+		return false
+	}
+	query.Del("key")
+	r.URL.RawQuery = query.Encode()
+	return true
+}
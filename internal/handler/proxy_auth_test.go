@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// memoryAPIKeys looks keys up by hash and records LastUsedAt updates
+type memoryAPIKeys struct {
+	repository.APIKeyRepository
+	keys    map[string]*domain.APIKey
+	updated int
+}
+
+func (r *memoryAPIKeys) GetByHash(keyHash string) (*domain.APIKey, error) {
+	if key, ok := r.keys[keyHash]; ok {
+		return key, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryAPIKeys) Update(key *domain.APIKey) error {
+	r.updated++
+	return nil
+}
+
+// memorySettings holds system settings; err is returned by every Get
+type memorySettings struct {
+	repository.SystemSettingRepository
+	values map[string]string
+	err    error
+}
+
+func (r *memorySettings) Get(key string) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	return r.values[key], nil
+}
+
+func TestProxyAuthenticate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	keys := &memoryAPIKeys{keys: map[string]*domain.APIKey{
+		domain.HashAPIKey("maxx-valid"):    {ID: 1, IsEnabled: true},
+		domain.HashAPIKey("maxx-disabled"): {ID: 2, IsEnabled: false},
+		domain.HashAPIKey("maxx-expired"):  {ID: 3, IsEnabled: true, ExpiresAt: &past},
+		domain.HashAPIKey("maxx-future"):   {ID: 4, IsEnabled: true, ExpiresAt: &future},
+	}}
+
+	tests := []struct {
+		name    string
+		setting string
+		readErr error
+		key     string
+		wantID  uint64
+		wantErr error
+	}{
+		{name: "enforced by default", key: "", wantErr: domain.ErrMissingAPIKey},
+		{name: "enforced when enabled", setting: "true", key: "", wantErr: domain.ErrMissingAPIKey},
+		{name: "fails closed on read error", readErr: errors.New("db closed"), key: "", wantErr: domain.ErrMissingAPIKey},
+		{name: "unknown setting value enforces", setting: "yes", key: "", wantErr: domain.ErrMissingAPIKey},
+		{name: "explicit opt-out", setting: "false", key: ""},
+		{name: "invalid key", key: "maxx-guess", wantErr: domain.ErrInvalidAPIKey},
+		{name: "disabled key", key: "maxx-disabled", wantErr: domain.ErrAPIKeyDisabled},
+		{name: "expired key", key: "maxx-expired", wantErr: domain.ErrAPIKeyExpired},
+		{name: "valid key", key: "maxx-valid", wantID: 1},
+		{name: "key expiring later", key: "maxx-future", wantID: 4},
+	}
+	for _, tt := range tests {
+		h := &ProxyHandler{
+			apiKeyRepo:  keys,
+			settingRepo: &memorySettings{values: map[string]string{domain.SettingKeyProxyAuthEnabled: tt.setting}, err: tt.readErr},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+		if tt.key != "" {
+			req.Header.Set("x-api-key", tt.key)
+		}
+		key, err := h.authenticate(req, domain.ClientTypeClaude)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var gotID uint64
+		if key != nil {
+			gotID = key.ID
+		}
+		if gotID != tt.wantID {
+			t.Errorf("%s: key ID = %d, want %d", tt.name, gotID, tt.wantID)
+		}
+	}
+}
+
+func TestProxyAuthenticate_LastUsedThrottled(t *testing.T) {
+	keys := &memoryAPIKeys{keys: map[string]*domain.APIKey{
+		domain.HashAPIKey("maxx-valid"): {ID: 1, IsEnabled: true},
+	}}
+	h := &ProxyHandler{apiKeyRepo: keys, settingRepo: &memorySettings{}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	req.Header.Set("x-api-key", "maxx-valid")
+
+	if _, err := h.authenticate(req, domain.ClientTypeClaude); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	keys.keys[domain.HashAPIKey("maxx-valid")].LastUsedAt = &now
+	if _, err := h.authenticate(req, domain.ClientTypeClaude); err != nil {
+		t.Fatal(err)
+	}
+	if keys.updated != 1 {
+		t.Errorf("LastUsedAt written %d times, want 1", keys.updated)
+	}
+}
+
+func TestExtractClientAPIKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		clientType domain.ClientType
+		url        string
+		headers    map[string]string
+		want       string
+	}{
+		{"claude x-api-key", domain.ClientTypeClaude, "/v1/messages", map[string]string{"x-api-key": "k1"}, "k1"},
+		{"claude bearer", domain.ClientTypeClaude, "/v1/messages", map[string]string{"Authorization": "Bearer k2"}, "k2"},
+		{"claude x-api-key wins", domain.ClientTypeClaude, "/v1/messages", map[string]string{"x-api-key": "k1", "Authorization": "Bearer k2"}, "k1"},
+		{"claude ignores query", domain.ClientTypeClaude, "/v1/messages?key=k3", nil, ""},
+		{"openai bearer", domain.ClientTypeOpenAI, "/v1/chat/completions", map[string]string{"Authorization": "Bearer k4"}, "k4"},
+		{"openai lowercase scheme", domain.ClientTypeOpenAI, "/v1/chat/completions", map[string]string{"Authorization": "bearer k4"}, "k4"},
+		{"openai ignores x-api-key", domain.ClientTypeOpenAI, "/v1/chat/completions", map[string]string{"x-api-key": "k1"}, ""},
+		{"openai basic auth", domain.ClientTypeOpenAI, "/v1/chat/completions", map[string]string{"Authorization": "Basic abc"}, ""},
+		{"codex bearer", domain.ClientTypeCodex, "/responses", map[string]string{"Authorization": "Bearer k5"}, "k5"},
+		{"gemini header", domain.ClientTypeGemini, "/v1beta/models/gemini:generateContent", map[string]string{"x-goog-api-key": "k6"}, "k6"},
+		{"gemini query", domain.ClientTypeGemini, "/v1beta/models/gemini:generateContent?key=k7", nil, "k7"},
+		{"gemini header wins", domain.ClientTypeGemini, "/v1beta/models/gemini:generateContent?key=k7", map[string]string{"x-goog-api-key": "k6"}, "k6"},
+		{"gemini ignores bearer", domain.ClientTypeGemini, "/v1beta/models/gemini:generateContent", map[string]string{"Authorization": "Bearer k2"}, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		if got := extractClientAPIKey(req, tt.clientType); got != tt.want {
+			t.Errorf("%s: extractClientAPIKey = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStripClientCredentials(t *testing.T) {
+	tests := []struct {
+		name        string
+		clientType  domain.ClientType
+		url         string
+		headers     map[string]string
+		wantQuery   string
+		wantRemoved bool
+	}{
+		{"claude x-api-key", domain.ClientTypeClaude, "/v1/messages?beta=true", map[string]string{"x-api-key": "maxx-key", "anthropic-version": "2023-06-01"}, "beta=true", false},
+		{"claude bearer", domain.ClientTypeClaude, "/v1/messages", map[string]string{"Authorization": "Bearer maxx-key"}, "", false},
+		{"openai bearer", domain.ClientTypeOpenAI, "/v1/chat/completions", map[string]string{"Authorization": "Bearer maxx-key"}, "", false},
+		{"codex bearer", domain.ClientTypeCodex, "/responses", map[string]string{"Authorization": "Bearer maxx-key"}, "", false},
+		{"gemini header", domain.ClientTypeGemini, "/v1beta/models/gemini:generateContent", map[string]string{"x-goog-api-key": "maxx-key"}, "", false},
+		{"gemini query", domain.ClientTypeGemini, "/v1beta/models/gemini:streamGenerateContent?alt=sse&key=maxx-key", nil, "alt=sse", true},
+		{"gemini header and query", domain.ClientTypeGemini, "/v1beta/models/gemini:generateContent?key=maxx-key", map[string]string{"x-goog-api-key": "maxx-key"}, "", true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		if got := stripClientCredentials(req, tt.clientType); got != tt.wantRemoved {
+			t.Errorf("%s: stripClientCredentials = %v, want %v", tt.name, got, tt.wantRemoved)
+		}
+		for _, name := range clientAuthHeaders {
+			if v := req.Header.Get(name); v != "" {
+				t.Errorf("%s: %s = %q was not removed", tt.name, name, v)
+			}
+		}
+		if got := req.URL.RawQuery; got != tt.wantQuery {
+			t.Errorf("%s: query = %q, want %q", tt.name, got, tt.wantQuery)
+		}
+		if tt.headers["anthropic-version"] != "" && req.Header.Get("anthropic-version") == "" {
+			t.Errorf("%s: non-credential headers were removed", tt.name)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestSetRetryAfter(t *testing.T) {
+	tests := []struct {
+		retryAfter time.Duration
+		want       string
+	}{
+		{0, ""},
+		{-time.Second, ""},
+		{time.Nanosecond, "1"},
+		{999 * time.Millisecond, "1"},
+		{time.Second, "1"},
+		{1500 * time.Millisecond, "2"}, // rounded up so clients never retry too early
+		{30 * time.Second, "30"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		setRetryAfter(w, tt.retryAfter)
+		if got := w.Header().Get("Retry-After"); got != tt.want {
+			t.Errorf("%v: Retry-After = %q, want %q", tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestWriteProxyError_CircuitOpen(t *testing.T) {
+	err := domain.NewProxyError(domain.ErrCircuitOpen, true)
+	err.HTTPStatusCode = http.StatusServiceUnavailable
+	err.RetryAfter = 12 * time.Second
+
+	w := httptest.NewRecorder()
+	writeProxyError(w, domain.ClientTypeClaude, err)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "12" {
+		t.Errorf("Retry-After = %q, want 12", got)
+	}
+
+	w = httptest.NewRecorder()
+	writeStreamError(w, domain.ClientTypeClaude, err)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "12" {
+		t.Errorf("stream: status = %d, Retry-After = %q", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,355 @@
+// Package health actively probes providers with minimal requests, so cooldowns
+// start before user traffic fails and end as soon as a provider recovers.
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/adapter/provider"
+	"github.com/awsl-project/maxx/internal/circuit"
+	ctxutil "github.com/awsl-project/maxx/internal/context"
+	"github.com/awsl-project/maxx/internal/cooldown"
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+	"github.com/awsl-project/maxx/internal/router"
+)
+
+const (
+	// DefaultTimeout bounds a single probe
+	DefaultTimeout = 30 * time.Second
+
+	// HistoryRetention is how long probe results are kept
+	HistoryRetention = 7 * 24 * time.Hour
+
+	// disabledRecheck is how often Run re-reads the interval while probing is off
+	disabledRecheck = time.Minute
+
+	// probeSessionID marks probe requests in adapters that key state by session
+	probeSessionID = "maxx-health-probe"
+)
+
+// probeModels are the default request models per client type (before model mapping);
+// a provider's ProviderConfig.ProbeModels replaces them
+var probeModels = map[domain.ClientType]string{
+	domain.ClientTypeClaude: "claude-haiku-4-5",
+	domain.ClientTypeOpenAI: "gpt-4o-mini",
+	domain.ClientTypeCodex:  "gpt-5-codex",
+	domain.ClientTypeGemini: "gemini-2.5-flash",
+}
+
+// ProviderSource lists the providers to probe
+type ProviderSource interface {
+	GetAll() map[uint64]*domain.Provider
+}
+
+// AdapterSource returns the adapter serving a provider
+type AdapterSource interface {
+	Adapter(providerID uint64) (provider.ProviderAdapter, bool)
+}
+
+// Prober sends a minimal request to every provider and supported client type,
+// records the result and updates cooldowns accordingly
+type Prober struct {
+	providers ProviderSource
+	adapters  AdapterSource
+	results   repository.ProbeResultRepository
+	settings  repository.SystemSettingRepository
+	cooldowns *cooldown.Manager
+	breakers  *circuit.Manager
+	timeout   time.Duration
+}
+
+// NewProber creates a prober that updates the default cooldown and circuit breaker managers
+func NewProber(
+	providers ProviderSource,
+	adapters AdapterSource,
+	results repository.ProbeResultRepository,
+	settings repository.SystemSettingRepository,
+) *Prober {
+	return &Prober{
+		providers: providers,
+		adapters:  adapters,
+		results:   results,
+		settings:  settings,
+		cooldowns: cooldown.Default(),
+		breakers:  circuit.Default(),
+		timeout:   DefaultTimeout,
+	}
+}
+
+// Interval returns the configured probe interval, 0 when probing is disabled
+func (p *Prober) Interval() time.Duration {
+	if p.settings == nil {
+		return 0
+	}
+	value, err := p.settings.Get(domain.SettingKeyHealthCheckInterval)
+	if err != nil || value == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// Run probes all providers on the configured interval until ctx is done
+func (p *Prober) Run(ctx context.Context) {
+	for {
+		wait := p.Interval()
+		if wait > 0 {
+			p.ProbeAll(ctx)
+		} else {
+			wait = disabledRecheck
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ProbeAll probes every provider for each of its supported client types in parallel
+func (p *Prober) ProbeAll(ctx context.Context) []*domain.ProbeResult {
+	providers := p.providers.GetAll()
+	ids := make([]uint64, 0, len(providers))
+	for id := range providers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make([]*domain.ProbeResult, 0)
+	for _, id := range ids {
+		prov := providers[id]
+		if prov.DeletedAt != nil {
+			continue
+		}
+		adapter, ok := p.adapters.Adapter(id)
+		if !ok {
+			continue
+		}
+		for _, clientType := range prov.SupportedClientTypes {
+			if _, _, ok := probeModel(prov, clientType); !ok {
+				continue
+			}
+			wg.Add(1)
+			go func(clientType domain.ClientType) {
+				defer wg.Done()
+				result := p.Probe(ctx, prov, adapter, clientType)
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+			}(clientType)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].ProviderID != results[j].ProviderID {
+			return results[i].ProviderID < results[j].ProviderID
+		}
+		return results[i].ClientType < results[j].ClientType
+	})
+	return results
+}
+
+// Probe sends one minimal request, stores the result and updates the provider's cooldown
+func (p *Prober) Probe(ctx context.Context, prov *domain.Provider, adapter provider.ProviderAdapter, clientType domain.ClientType) *domain.ProbeResult {
+	requestModel, mappedModel, _ := probeModel(prov, clientType)
+	uri, headers, body := probeRequest(clientType, mappedModel)
+
+	attempt := &domain.ProxyUpstreamAttempt{}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	ctx = ctxutil.WithClientType(ctx, clientType)
+	ctx = ctxutil.WithSessionID(ctx, probeSessionID)
+	ctx = ctxutil.WithRequestModel(ctx, requestModel)
+	ctx = ctxutil.WithMappedModel(ctx, mappedModel)
+	ctx = ctxutil.WithRequestBody(ctx, body)
+	ctx = ctxutil.WithRequestHeaders(ctx, headers)
+	ctx = ctxutil.WithRequestURI(ctx, uri)
+	ctx = ctxutil.WithIsStream(ctx, false)
+	ctx = ctxutil.WithUpstreamAttempt(ctx, attempt)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	req.Header = headers.Clone()
+
+	w := newDiscardWriter()
+	start := time.Now()
+	err := adapter.Execute(ctx, w, req, prov)
+
+	result := &domain.ProbeResult{
+		CreatedAt:  start,
+		ProviderID: prov.ID,
+		ClientType: clientType,
+		Success:    err == nil,
+		StatusCode: w.statusCode,
+		Latency:    time.Since(start),
+	}
+	if attempt.ResponseInfo != nil {
+		result.StatusCode = attempt.ResponseInfo.Status
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Rejected = isRejection(err)
+		log.Printf("[Health] Probe of provider %d (%s) failed: %v", prov.ID, clientType, err)
+	}
+
+	if p.results != nil {
+		if err := p.results.Create(result); err != nil {
+			log.Printf("[Health] Failed to store probe result: %v", err)
+		}
+	}
+	p.applyCooldown(result, err)
+	return result
+}
+
+// probeModel returns the request model and the model sent upstream for a probe:
+// the provider's configured probe model as is, otherwise the default mapped through
+// the provider's model mapping. ok is false for client types probes can't be built for.
+func probeModel(prov *domain.Provider, clientType domain.ClientType) (requestModel, mappedModel string, ok bool) {
+	requestModel, ok = probeModels[clientType]
+	if !ok {
+		return "", "", false
+	}
+	if prov.Config != nil {
+		if model := prov.Config.ProbeModels[clientType]; model != "" {
+			return model, model, true
+		}
+	}
+	return requestModel, router.MapModel(requestModel, &domain.Route{}, prov), true
+}
+
+// isRejection reports whether the upstream answered the probe with a non-retryable 4xx,
+// e.g. an unknown probe model: the provider is reachable, so this is not downtime
+func isRejection(err error) bool {
+	proxyErr, ok := err.(*domain.ProxyError)
+	return ok && !proxyErr.Retryable && proxyErr.HTTPStatusCode >= 400 && proxyErr.HTTPStatusCode < 500
+}
+
+// applyCooldown clears the cooldown after a successful probe and starts one when
+// the provider looks down. Non-retryable failures (e.g. the provider rejecting the
+// probe model) say nothing about availability and are only recorded.
+// A successful probe moves an open circuit breaker to half-open rather than closing it,
+// so real traffic returns gradually.
+func (p *Prober) applyCooldown(result *domain.ProbeResult, err error) {
+	clientType := string(result.ClientType)
+	if result.Success {
+		if p.cooldowns.IsInCooldown(result.ProviderID, clientType) {
+			p.cooldowns.RecordSuccess(result.ProviderID, clientType)
+		}
+		if p.breakers.State(result.ProviderID, clientType) == circuit.StateOpen {
+			p.breakers.Recover(result.ProviderID, clientType)
+		}
+		return
+	}
+
+	proxyErr, ok := err.(*domain.ProxyError)
+	if !ok || !proxyErr.Retryable {
+		return
+	}
+
+	var explicitUntil *time.Time
+	reason := cooldown.ReasonUnknown
+	switch {
+	case proxyErr.CooldownUntil != nil:
+		explicitUntil = proxyErr.CooldownUntil
+		reason = cooldown.ReasonQuotaExhausted
+	case proxyErr.RateLimitInfo != nil && !proxyErr.RateLimitInfo.QuotaResetTime.IsZero():
+		explicitUntil = &proxyErr.RateLimitInfo.QuotaResetTime
+		reason = cooldown.ReasonQuotaExhausted
+	case proxyErr.RetryAfter > 0:
+		until := time.Now().Add(proxyErr.RetryAfter)
+		explicitUntil = &until
+		reason = cooldown.ReasonRateLimit
+	case proxyErr.IsServerError:
+		reason = cooldown.ReasonServerError
+	case proxyErr.IsNetworkError:
+		reason = cooldown.ReasonNetworkError
+	}
+	until := p.cooldowns.RecordFailure(result.ProviderID, clientType, reason, explicitUntil)
+	p.breakers.Trip(result.ProviderID, clientType, until)
+}
+
+// probeRequest builds the smallest request each client format accepts
+func probeRequest(clientType domain.ClientType, model string) (string, http.Header, []byte) {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+
+	var uri string
+	var body map[string]interface{}
+	switch clientType {
+	case domain.ClientTypeClaude:
+		uri = "/v1/messages"
+		headers.Set("anthropic-version", "2023-06-01")
+		body = map[string]interface{}{
+			"model":      model,
+			"max_tokens": 1,
+			"messages":   []map[string]interface{}{{"role": "user", "content": "ping"}},
+		}
+	case domain.ClientTypeOpenAI:
+		uri = "/v1/chat/completions"
+		body = map[string]interface{}{
+			"model":      model,
+			"max_tokens": 1,
+			"messages":   []map[string]interface{}{{"role": "user", "content": "ping"}},
+		}
+	case domain.ClientTypeCodex:
+		uri = "/v1/responses"
+		body = map[string]interface{}{
+			"model":             model,
+			"input":             "ping",
+			"max_output_tokens": 16,
+		}
+	case domain.ClientTypeGemini:
+		uri = "/v1beta/models/" + model + ":generateContent"
+		body = map[string]interface{}{
+			"contents":         []map[string]interface{}{{"role": "user", "parts": []map[string]string{{"text": "ping"}}}},
+			"generationConfig": map[string]interface{}{"maxOutputTokens": 1},
+		}
+	}
+
+	data, _ := json.Marshal(body)
+	return uri, headers, data
+}
+
+// discardWriter drops the probe response, keeping only the status code
+type discardWriter struct {
+	header     http.Header
+	statusCode int
+}
+
+func newDiscardWriter() *discardWriter {
+	return &discardWriter{header: make(http.Header)}
+}
+
+func (w *discardWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *discardWriter) WriteHeader(code int) {
+	if w.statusCode == 0 {
+		w.statusCode = code
+	}
+}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	return len(p), nil
+}
+
+func (w *discardWriter) Flush() {}
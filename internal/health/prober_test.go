@@ -0,0 +1,246 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/adapter/provider"
+	"github.com/awsl-project/maxx/internal/adapter/provider/custom"
+	"github.com/awsl-project/maxx/internal/circuit"
+	"github.com/awsl-project/maxx/internal/cooldown"
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+type staticProviders map[uint64]*domain.Provider
+
+func (s staticProviders) GetAll() map[uint64]*domain.Provider { return s }
+
+type staticAdapters map[uint64]provider.ProviderAdapter
+
+func (s staticAdapters) Adapter(id uint64) (provider.ProviderAdapter, bool) {
+	a, ok := s[id]
+	return a, ok
+}
+
+type memoryResults struct {
+	mu      sync.Mutex
+	results []*domain.ProbeResult
+}
+
+func (m *memoryResults) Create(r *domain.ProbeResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = append(m.results, r)
+	return nil
+}
+
+func (m *memoryResults) ListByProvider(uint64, int) ([]*domain.ProbeResult, error) { return nil, nil }
+
+func (m *memoryResults) Summarize(time.Time) (map[uint64]*domain.ProbeSummary, error) {
+	return nil, nil
+}
+
+func (m *memoryResults) DeleteBefore(time.Time) error { return nil }
+
+// newTestProber probes one custom provider (ID 1, Claude) served by upstream
+func newTestProber(t *testing.T, upstream http.HandlerFunc) (*Prober, *memoryResults) {
+	t.Helper()
+	srv := httptest.NewServer(upstream)
+	t.Cleanup(srv.Close)
+
+	p := &domain.Provider{
+		ID:                   1,
+		Type:                 "custom",
+		Name:                 "relay",
+		SupportedClientTypes: []domain.ClientType{domain.ClientTypeClaude},
+		Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			BaseURL:      srv.URL,
+			APIKey:       "sk-test",
+			ModelMapping: map[string]string{"claude-haiku-4-5": "relay-haiku"},
+		}},
+	}
+	adapter, err := custom.NewAdapter(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := &memoryResults{}
+	prober := NewProber(staticProviders{1: p}, staticAdapters{1: adapter}, results, nil)
+	prober.cooldowns = cooldown.NewManager()
+	prober.breakers = circuit.NewManager()
+	prober.timeout = 5 * time.Second
+	return prober, results
+}
+
+func TestProbe_SuccessClearsCooldown(t *testing.T) {
+	var gotModel, gotKey string
+	prober, results := newTestProber(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		gotModel, gotKey = body.Model, r.Header.Get("x-api-key")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"p"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	})
+	prober.cooldowns.SetCooldownDuration(1, string(domain.ClientTypeClaude), time.Hour)
+
+	got := prober.ProbeAll(context.Background())
+	if len(got) != 1 || !got[0].Success || got[0].StatusCode != http.StatusOK {
+		t.Fatalf("expected one successful probe, got %+v", got)
+	}
+	if gotModel != "relay-haiku" || gotKey != "sk-test" {
+		t.Fatalf("probe should use the provider's mapping and key, got model=%q key=%q", gotModel, gotKey)
+	}
+	if prober.cooldowns.IsInCooldown(1, string(domain.ClientTypeClaude)) {
+		t.Fatal("successful probe should clear the cooldown")
+	}
+	if len(results.results) != 1 {
+		t.Fatalf("expected the probe to be stored, got %d results", len(results.results))
+	}
+}
+
+func TestProbe_ServerErrorStartsCooldown(t *testing.T) {
+	prober, results := newTestProber(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"overloaded"}`, http.StatusServiceUnavailable)
+	})
+
+	got := prober.ProbeAll(context.Background())
+	if len(got) != 1 || got[0].Success || got[0].StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected one failed probe with 503, got %+v", got)
+	}
+	if !prober.cooldowns.IsInCooldown(1, string(domain.ClientTypeClaude)) {
+		t.Fatal("failed probe should put the provider in cooldown")
+	}
+	if len(results.results) != 1 || results.results[0].Error == "" {
+		t.Fatalf("expected the failure to be stored, got %+v", results.results)
+	}
+}
+
+func TestProbe_ClientErrorDoesNotCooldown(t *testing.T) {
+	prober, _ := newTestProber(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"unknown model"}`, http.StatusBadRequest)
+	})
+
+	got := prober.ProbeAll(context.Background())
+	if len(got) != 1 || got[0].Success || !got[0].Rejected {
+		t.Fatalf("expected one rejected probe, got %+v", got)
+	}
+	if prober.cooldowns.IsInCooldown(1, string(domain.ClientTypeClaude)) {
+		t.Fatal("a rejected probe request says nothing about availability")
+	}
+}
+
+func TestProbe_RejectedModel(t *testing.T) {
+	var gotModels []string
+	var mu sync.Mutex
+	prober, results := newTestProber(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		gotModels = append(gotModels, body.Model)
+		mu.Unlock()
+		if body.Model != "relay-sonnet" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"not_found_error","message":"model not found"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"p"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	})
+
+	// The default probe model is rejected: recorded, but neither downtime nor a cooldown
+	got := prober.ProbeAll(context.Background())
+	if len(got) != 1 || got[0].Success || !got[0].Rejected || got[0].StatusCode != http.StatusNotFound {
+		t.Fatalf("expected one rejected probe with 404, got %+v", got)
+	}
+	if prober.cooldowns.IsInCooldown(1, string(domain.ClientTypeClaude)) {
+		t.Fatal("a rejected probe model should not put the provider in cooldown")
+	}
+	if len(results.results) != 1 || !results.results[0].Rejected {
+		t.Fatalf("expected the rejection to be stored, got %+v", results.results)
+	}
+
+	// A configured probe model is sent as is
+	prov := prober.providers.GetAll()[1]
+	prov.Config.ProbeModels = map[domain.ClientType]string{domain.ClientTypeClaude: "relay-sonnet"}
+	got = prober.ProbeAll(context.Background())
+	if len(got) != 1 || !got[0].Success || got[0].Rejected {
+		t.Fatalf("expected the configured probe model to succeed, got %+v", got)
+	}
+	if want := []string{"relay-haiku", "relay-sonnet"}; len(gotModels) != 2 || gotModels[0] != want[0] || gotModels[1] != want[1] {
+		t.Fatalf("upstream models = %v, want %v", gotModels, want)
+	}
+}
+
+func TestProbeModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      *domain.ProviderConfig
+		clientType  domain.ClientType
+		wantRequest string
+		wantMapped  string
+		wantOK      bool
+	}{
+		{"default", nil, domain.ClientTypeOpenAI, "gpt-4o-mini", "gpt-4o-mini", true},
+		{
+			name:        "provider mapping",
+			config:      &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{ModelMapping: map[string]string{"gpt-4o-mini": "relay-mini"}}},
+			clientType:  domain.ClientTypeOpenAI,
+			wantRequest: "gpt-4o-mini",
+			wantMapped:  "relay-mini",
+			wantOK:      true,
+		},
+		{
+			name: "configured model skips mapping",
+			config: &domain.ProviderConfig{
+				Custom:      &domain.ProviderConfigCustom{ModelMapping: map[string]string{"relay-large": "relay-other"}},
+				ProbeModels: map[domain.ClientType]string{domain.ClientTypeOpenAI: "relay-large"},
+			},
+			clientType:  domain.ClientTypeOpenAI,
+			wantRequest: "relay-large",
+			wantMapped:  "relay-large",
+			wantOK:      true,
+		},
+		{"unknown client type", nil, "unknown", "", "", false},
+		{
+			name:       "configured model for an unknown client type",
+			config:     &domain.ProviderConfig{ProbeModels: map[domain.ClientType]string{"unknown": "m"}},
+			clientType: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		request, mapped, ok := probeModel(&domain.Provider{Config: tt.config}, tt.clientType)
+		if request != tt.wantRequest || mapped != tt.wantMapped || ok != tt.wantOK {
+			t.Errorf("%s: probeModel = %q, %q, %v, want %q, %q, %v", tt.name, request, mapped, ok, tt.wantRequest, tt.wantMapped, tt.wantOK)
+		}
+	}
+}
+
+func TestProbe_Timeout(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	prober, _ := newTestProber(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	})
+	// Cleanups run last-in first-out: unblock the handler before the server closes
+	t.Cleanup(func() { close(release) })
+	prober.timeout = 100 * time.Millisecond
+
+	got := prober.ProbeAll(context.Background())
+	if len(got) != 1 || got[0].Success || calls.Load() != 1 {
+		t.Fatalf("expected one timed out probe, got %+v", got)
+	}
+	if !prober.cooldowns.IsInCooldown(1, string(domain.ClientTypeClaude)) {
+		t.Fatal("an unreachable provider should be put in cooldown")
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/cooldown"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+var (
+	providerRepoMu sync.RWMutex
+	providerRepo   repository.ProviderRepository
+
+	providerFailuresTotal = defaultRegistry.NewCounter("maxx_provider_failures_total",
+		"Upstream failures that counted towards a provider cooldown, by reason.", "provider_id", "provider", "client_type", "reason")
+)
+
+func init() {
+	cooldownLabels := []string{"provider_id", "provider", "client_type", "reason"}
+	defaultRegistry.NewGaugeFunc("maxx_provider_cooldown",
+		"1 while a provider is cooling down (client_type is empty when all client types are affected).",
+		cooldownLabels, func() []Sample { return cooldownSamples(false) })
+	defaultRegistry.NewGaugeFunc("maxx_provider_cooldown_remaining_seconds",
+		"Seconds until a provider's cooldown ends.",
+		cooldownLabels, func() []Sample { return cooldownSamples(true) })
+	defaultRegistry.NewGaugeFunc("maxx_provider_consecutive_failures",
+		"Consecutive failures per provider and reason since the last success.",
+		cooldownLabels, failureSamples)
+}
+
+// SetProviderRepository sets where provider names for metric labels are looked up
+func SetProviderRepository(repo repository.ProviderRepository) {
+	providerRepoMu.Lock()
+	providerRepo = repo
+	providerRepoMu.Unlock()
+}
+
+// ProviderFailure counts a failure recorded against a provider's cooldown
+func ProviderFailure(providerID uint64, providerName, clientType, reason string) {
+	providerFailuresTotal.Inc(strconv.FormatUint(providerID, 10), providerName, clientType, reason)
+}
+
+func providerName(id uint64) string {
+	providerRepoMu.RLock()
+	repo := providerRepo
+	providerRepoMu.RUnlock()
+	if repo == nil {
+		return ""
+	}
+	if p, err := repo.GetByID(id); err == nil {
+		return p.Name
+	}
+	return ""
+}
+
+func cooldownSamples(remaining bool) []Sample {
+	var samples []Sample
+	now := time.Now()
+	for key, until := range cooldown.Default().GetAllCooldowns() {
+		reason := string(cooldown.ReasonUnknown)
+		if info := cooldown.Default().GetCooldownInfo(key.ProviderID, key.ClientType, ""); info != nil {
+			reason = string(info.Reason)
+		}
+		value := 1.0
+		if remaining {
+			value = until.Sub(now).Seconds()
+		}
+		samples = append(samples, Sample{
+			LabelValues: []string{strconv.FormatUint(key.ProviderID, 10), providerName(key.ProviderID), key.ClientType, reason},
+			Value:       value,
+		})
+	}
+	return samples
+}
+
+func failureSamples() []Sample {
+	var samples []Sample
+	for key, count := range cooldown.Default().GetFailureCounts() {
+		if count == 0 {
+			continue
+		}
+		samples = append(samples, Sample{
+			LabelValues: []string{strconv.FormatUint(key.ProviderID, 10), providerName(key.ProviderID), key.ClientType, string(key.Reason)},
+			Value:       float64(count),
+		})
+	}
+	return samples
+}
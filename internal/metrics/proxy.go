@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// Default registry served at /metrics
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry served at /metrics
+func Default() *Registry {
+	return defaultRegistry
+}
+
+var (
+	latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+	ttfbBuckets    = []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30}
+
+	requestLabels = []string{"client_type", "provider", "project", "model", "status"}
+
+	requestsTotal = defaultRegistry.NewCounter("maxx_requests_total",
+		"Proxy requests by final status.", requestLabels...)
+	requestDuration = defaultRegistry.NewHistogram("maxx_request_duration_seconds",
+		"End-to-end proxy request duration, including retries and failover.", latencyBuckets, requestLabels...)
+	requestTTFB = defaultRegistry.NewHistogram("maxx_request_ttfb_seconds",
+		"Time to first byte of the successful upstream attempt.", ttfbBuckets, "client_type", "provider", "project", "model")
+	tokensTotal = defaultRegistry.NewCounter("maxx_tokens_total",
+		"Tokens used by proxy requests, by token type.", "client_type", "provider", "project", "model", "type")
+	costTotal = defaultRegistry.NewCounter("maxx_cost_usd_total",
+		"Cost of proxy requests in USD.", "client_type", "provider", "project", "model")
+	requestsInFlight = defaultRegistry.NewGauge("maxx_requests_in_flight",
+		"Proxy requests currently being processed.", "client_type")
+
+	attemptsTotal = defaultRegistry.NewCounter("maxx_upstream_attempts_total",
+		"Upstream attempts by provider and outcome.", "client_type", "provider", "status")
+	attemptDuration = defaultRegistry.NewHistogram("maxx_upstream_attempt_duration_seconds",
+		"Upstream attempt duration.", latencyBuckets, "client_type", "provider")
+	attemptTTFB = defaultRegistry.NewHistogram("maxx_upstream_ttfb_seconds",
+		"Upstream attempt time to first byte.", ttfbBuckets, "client_type", "provider")
+)
+
+// RequestStarted counts a request as in flight; call the returned func when it ends
+func RequestStarted(clientType domain.ClientType) func() {
+	requestsInFlight.Add(1, string(clientType))
+	return func() {
+		requestsInFlight.Add(-1, string(clientType))
+	}
+}
+
+// ObserveRequest records a finished proxy request
+// ttfb is the time to first byte of the attempt that served it (0 if none)
+func ObserveRequest(req *domain.ProxyRequest, providerName string, ttfb time.Duration) {
+	clientType := string(req.ClientType)
+	project := strconv.FormatUint(req.ProjectID, 10)
+	model := req.RequestModel
+
+	requestsTotal.Inc(clientType, providerName, project, model, req.Status)
+	requestDuration.Observe(req.Duration.Seconds(), clientType, providerName, project, model, req.Status)
+	if ttfb > 0 {
+		requestTTFB.Observe(ttfb.Seconds(), clientType, providerName, project, model)
+	}
+
+	tokens := []struct {
+		kind  string
+		count uint64
+	}{
+		{"input", req.InputTokenCount},
+		{"output", req.OutputTokenCount},
+		{"cache_read", req.CacheReadCount},
+		{"cache_write", req.CacheWriteCount},
+	}
+	for _, t := range tokens {
+		if t.count > 0 {
+			tokensTotal.Add(float64(t.count), clientType, providerName, project, model, t.kind)
+		}
+	}
+	if req.Cost > 0 {
+		// Cost is stored in micro-USD
+		costTotal.Add(float64(req.Cost)/1_000_000, clientType, providerName, project, model)
+	}
+}
+
+// ObserveAttempt records a finished upstream attempt
+func ObserveAttempt(clientType domain.ClientType, providerName string, attempt *domain.ProxyUpstreamAttempt) {
+	attemptsTotal.Inc(string(clientType), providerName, attempt.Status)
+	attemptDuration.Observe(attempt.Duration.Seconds(), string(clientType), providerName)
+	if attempt.TTFB > 0 {
+		attemptTTFB.Observe(attempt.TTFB.Seconds(), string(clientType), providerName)
+	}
+}
@@ -0,0 +1,335 @@
+// Package metrics exposes proxy telemetry in the Prometheus text exposition format.
+// It implements the small subset of the client library maxx needs (counters, gauges,
+// histograms and scrape-time gauges) to avoid pulling in another dependency.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Sample is one labeled value of a scrape-time gauge
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families in registration order
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	r.families = append(r.families, f)
+	r.mu.Unlock()
+}
+
+// WriteText writes all metrics in the Prometheus text format (version 0.0.4)
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// ContentType is the content type of WriteText's output
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type desc struct {
+	name       string
+	help       string
+	metricType string
+	labels     []string
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.metricType)
+}
+
+// series key joins label values with a separator that can't appear in valid UTF-8
+func seriesKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func (d *desc) checkLabels(values []string) {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+}
+
+// ===== Counter / Gauge =====
+
+type valueSeries struct {
+	labelValues []string
+	value       float64
+}
+
+type valueVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*valueSeries
+}
+
+func (v *valueVec) get(values []string) *valueSeries {
+	v.checkLabels(values)
+	key := seriesKey(values)
+	s, ok := v.series[key]
+	if !ok {
+		s = &valueSeries{labelValues: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *valueVec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	series := make([]*valueSeries, 0, len(v.series))
+	for _, s := range v.series {
+		series = append(series, &valueSeries{labelValues: s.labelValues, value: s.value})
+	}
+	v.mu.Unlock()
+
+	sortSeries(series)
+	v.writeHeader(w)
+	for _, s := range series {
+		writeSample(w, v.name, v.labels, s.labelValues, "", "", s.value)
+	}
+}
+
+// CounterVec is a monotonically increasing value per label set
+type CounterVec struct {
+	valueVec
+}
+
+// NewCounter registers a counter
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{valueVec{desc: desc{name, help, "counter", labels}, series: make(map[string]*valueSeries)}}
+	r.register(c)
+	return c
+}
+
+// Add increases the counter; negative values are ignored
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += delta
+	c.mu.Unlock()
+}
+
+// Inc increases the counter by one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// GaugeVec is a value per label set that can go up and down
+type GaugeVec struct {
+	valueVec
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{valueVec{desc: desc{name, help, "gauge", labels}, series: make(map[string]*valueSeries)}}
+	r.register(g)
+	return g
+}
+
+// Add changes the gauge by delta
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += delta
+	g.mu.Unlock()
+}
+
+// Set sets the gauge
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = value
+	g.mu.Unlock()
+}
+
+// ===== Scrape-time gauge =====
+
+type gaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc registers a gauge whose samples are computed on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&gaugeFunc{desc: desc{name, help, "gauge", labels}, collect: collect})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	samples := g.collect()
+	series := make([]*valueSeries, 0, len(samples))
+	for _, s := range samples {
+		g.checkLabels(s.LabelValues)
+		series = append(series, &valueSeries{labelValues: s.LabelValues, value: s.Value})
+	}
+	sortSeries(series)
+	g.writeHeader(w)
+	for _, s := range series {
+		writeSample(w, g.name, g.labels, s.labelValues, "", "", s.value)
+	}
+}
+
+// ===== Histogram =====
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// HistogramVec counts observations into buckets per label set
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// NewHistogram registers a histogram with the given upper bounds (ascending, +Inf is implicit)
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records a value
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+	key := seriesKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	series := make([]*histogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		series = append(series, &histogramSeries{
+			labelValues: s.labelValues,
+			counts:      append([]uint64(nil), s.counts...),
+			count:       s.count,
+			sum:         s.sum,
+		})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(series, func(i, j int) bool {
+		return seriesKey(series[i].labelValues) < seriesKey(series[j].labelValues)
+	})
+	h.writeHeader(w)
+	for _, s := range series {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// ===== Text format helpers =====
+
+func sortSeries(series []*valueSeries) {
+	sort.Slice(series, func(i, j int) bool {
+		return seriesKey(series[i].labelValues) < seriesKey(series[j].labelValues)
+	})
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(values[i]))
+			w.WriteByte('"')
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraLabel)
+			w.WriteString(`="`)
+			w.WriteString(extraValue)
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(v string) string {
+	return helpEscaper.Replace(v)
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_requests_total", "Requests.", "provider", "status")
+	c.Inc("a", "COMPLETED")
+	c.Add(2, "a", "COMPLETED")
+	c.Inc(`b"\`, "FAILED")
+	c.Add(-1, "a", "COMPLETED") // ignored
+
+	g := r.NewGauge("test_in_flight", "In flight.")
+	g.Add(2)
+	g.Add(-1)
+
+	h := r.NewHistogram("test_duration_seconds", "Duration.", []float64{0.5, 1}, "provider")
+	h.Observe(0.2, "a")
+	h.Observe(0.5, "a")
+	h.Observe(3, "a")
+
+	r.NewGaugeFunc("test_cooldown", "Cooldown.", []string{"provider"}, func() []Sample {
+		return []Sample{{LabelValues: []string{"x"}, Value: 1}}
+	})
+
+	var sb strings.Builder
+	if err := r.WriteText(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{provider="a",status="COMPLETED"} 3
+test_requests_total{provider="b\"\\",status="FAILED"} 1
+# HELP test_in_flight In flight.
+# TYPE test_in_flight gauge
+test_in_flight 1
+# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{provider="a",le="0.5"} 2
+test_duration_seconds_bucket{provider="a",le="1"} 2
+test_duration_seconds_bucket{provider="a",le="+Inf"} 3
+test_duration_seconds_sum{provider="a"} 3.7
+test_duration_seconds_count{provider="a"} 3
+# HELP test_cooldown Cooldown.
+# TYPE test_cooldown gauge
+test_cooldown{provider="x"} 1
+`
+	if got := sb.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"sync"
 
+	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/usage"
 )
 
@@ -37,8 +38,9 @@ func NewCalculator(pt *PriceTable) *Calculator {
 // Calculate 计算成本，返回微美元 (1 USD = 1,000,000 microUSD)
 // model: 模型名称
 // metrics: token使用指标
+// providerPricing: Provider 的计费覆盖（倍率、计费单位），nil 表示按官方价格
 // 如果模型未找到，返回0并记录警告日志
-func (c *Calculator) Calculate(model string, metrics *usage.Metrics) uint64 {
+func (c *Calculator) Calculate(model string, metrics *usage.Metrics, providerPricing *domain.ProviderPricing) uint64 {
 	if metrics == nil {
 		return 0
 	}
@@ -52,7 +54,7 @@ func (c *Calculator) Calculate(model string, metrics *usage.Metrics) uint64 {
 		return 0
 	}
 
-	return c.CalculateWithPricing(pricing, metrics)
+	return ApplyProviderPricing(c.CalculateWithPricing(pricing, metrics), model, providerPricing)
 }
 
 // CalculateWithPricing 使用指定价格计算成本（纯整数运算）
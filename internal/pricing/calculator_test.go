@@ -3,6 +3,7 @@ package pricing
 import (
 	"testing"
 
+	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/usage"
 )
 
@@ -134,7 +135,7 @@ func TestCalculator_Calculate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := calc.Calculate(tt.model, tt.metrics)
+			got := calc.Calculate(tt.model, tt.metrics, nil)
 			if tt.wantZero && got != 0 {
 				t.Errorf("Calculate() = %d, want 0", got)
 			}
@@ -159,7 +160,7 @@ func TestCalculator_Calculate_WithCache(t *testing.T) {
 		Cache1hCreationCount: 10_000,  // 10K × $3.75/M = $0.0375 = 37,500 microUSD
 	}
 
-	cost := calc.Calculate("claude-sonnet-4-5", metrics)
+	cost := calc.Calculate("claude-sonnet-4-5", metrics, nil)
 	if cost == 0 {
 		t.Fatal("Calculate() = 0, want non-zero")
 	}
@@ -181,7 +182,7 @@ func TestCalculator_Calculate_1MContext(t *testing.T) {
 		OutputTokens: 50_000,  // 全部低于 200K: 50K×$15/M = $0.75 = 750,000 microUSD
 	}
 
-	cost := calc.Calculate("claude-sonnet-4-5", metrics)
+	cost := calc.Calculate("claude-sonnet-4-5", metrics, nil)
 	expectedMicroUSD := uint64(1_200_000 + 750_000)
 	if cost != expectedMicroUSD {
 		t.Errorf("Calculate() = %d microUSD, want %d microUSD", cost, expectedMicroUSD)
@@ -207,6 +208,9 @@ func TestPriceTable_Get_PrefixMatch(t *testing.T) {
 		{"gemini-2.5-pro", true},
 		{"gemini-2.5-flash", true},
 		{"gemini-3-pro-preview", true},
+		{"gemini-3-pro-high", true},         // Antigravity
+		{"gemini-2.5-flash-thinking", true}, // prefix match
+		{"claude-opus-4-5-thinking", true},  // prefix match
 		{"unknown-model", false},
 	}
 
@@ -270,3 +274,48 @@ func TestDefaultCachePrices(t *testing.T) {
 		t.Errorf("GetEffectiveCache1hWritePriceMicro() = %d, want 2000000", got)
 	}
 }
+
+func TestAntigravityModelPrices(t *testing.T) {
+	pt := DefaultPriceTable()
+
+	// flash-lite has its own price instead of matching the gemini-2.5-flash prefix
+	if got := pt.Get("gemini-2.5-flash-lite").InputPriceMicro; got != 100_000 {
+		t.Errorf("gemini-2.5-flash-lite input = %d, want 100000", got)
+	}
+	if got := pt.Get("gemini-3-pro-low").OutputPriceMicro; got != 12_000_000 {
+		t.Errorf("gemini-3-pro-low output = %d, want 12000000", got)
+	}
+	if got := pt.Get("claude-sonnet-4-5-thinking").ModelID; got != "claude-sonnet-4-5" {
+		t.Errorf("claude-sonnet-4-5-thinking priced as %s, want claude-sonnet-4-5", got)
+	}
+}
+
+func TestCalculateWithProviderPricing(t *testing.T) {
+	calc := NewCalculator(DefaultPriceTable())
+	// 100K input tokens of claude-sonnet-4-5 = $0.30 at official price
+	metrics := &usage.Metrics{InputTokens: 100_000}
+
+	relay := &domain.ProviderPricing{
+		Multiplier:       0.3,
+		ModelMultipliers: map[string]float64{"claude-sonnet-4-5": 0.5, "claude-haiku": 0},
+	}
+	if got := calc.Calculate("claude-opus-4-5", metrics, relay); got != calc.Calculate("claude-opus-4-5", metrics, nil)*3/10 {
+		t.Errorf("global multiplier not applied: %d", got)
+	}
+	if got := calc.Calculate("claude-sonnet-4-5-20250929", metrics, relay); got != 150_000 {
+		t.Errorf("model multiplier (prefix) = %d, want 150000", got)
+	}
+	if got := calc.Calculate("claude-haiku-4-5", metrics, relay); got != 0 {
+		t.Errorf("zero model multiplier = %d, want 0", got)
+	}
+
+	// 0.3 credits per official USD, 1 credit = $0.14: $0.30 -> 0.09 credits -> $0.0126
+	credits := &domain.ProviderPricing{Multiplier: 0.3, Currency: "credits", USDPerUnit: 0.14}
+	cost := calc.Calculate("claude-sonnet-4-5", metrics, credits)
+	if cost != 12_600 {
+		t.Errorf("currency conversion = %d, want 12600", cost)
+	}
+	if got := BilledAmount(cost, credits); got != 90_000 {
+		t.Errorf("BilledAmount = %d, want 90000", got)
+	}
+}
@@ -126,5 +126,42 @@ func initDefaultPrices() *PriceTable {
 		CacheReadPriceMicro: 100_000,   // $0.10/M
 	})
 
+	// gemini-2.5-flash-lite: input=$0.10, cache_read=$0.01, output=$0.40
+	// (单独定价，否则会前缀匹配到 gemini-2.5-flash)
+	pt.Set(&ModelPricing{
+		ModelID:             "gemini-2.5-flash-lite",
+		InputPriceMicro:     100_000, // $0.10/M
+		OutputPriceMicro:    400_000, // $0.40/M
+		CacheReadPriceMicro: 10_000,  // $0.01/M
+	})
+
+	// ========== Antigravity 模型 ==========
+	// Antigravity 使用自己的模型名，按对应的 Vertex 标价计费
+	// gemini-2.5-flash-thinking、claude-*-thinking 通过前缀匹配到基础模型
+
+	// gemini-3-pro-high / gemini-3-pro-low: 同 gemini-3-pro-preview
+	pt.Set(&ModelPricing{
+		ModelID:             "gemini-3-pro",
+		InputPriceMicro:     2_000_000,  // $2.00/M
+		OutputPriceMicro:    12_000_000, // $12.00/M
+		CacheReadPriceMicro: 200_000,    // $0.20/M
+	})
+
+	// gemini-3-pro-image: input=$2, output=$120（图片输出 token 价格）
+	pt.Set(&ModelPricing{
+		ModelID:             "gemini-3-pro-image",
+		InputPriceMicro:     2_000_000,   // $2.00/M
+		OutputPriceMicro:    120_000_000, // $120.00/M
+		CacheReadPriceMicro: 200_000,     // $0.20/M
+	})
+
+	// gemini-3-flash: 同 gemini-3-flash-preview
+	pt.Set(&ModelPricing{
+		ModelID:             "gemini-3-flash",
+		InputPriceMicro:     500_000,   // $0.50/M
+		OutputPriceMicro:    3_000_000, // $3.00/M
+		CacheReadPriceMicro: 50_000,    // $0.05/M
+	})
+
 	return pt
 }
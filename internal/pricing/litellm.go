@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// LiteLLM 价格文件（model_prices_and_context_window.json）中的单个模型
+// 价格单位：美元/token
+type liteLLMModel struct {
+	Mode                          string   `json:"mode,omitempty"`
+	InputCostPerToken             *float64 `json:"input_cost_per_token,omitempty"`
+	OutputCostPerToken            *float64 `json:"output_cost_per_token,omitempty"`
+	CacheReadInputTokenCost       *float64 `json:"cache_read_input_token_cost,omitempty"`
+	CacheCreationInputTokenCost   *float64 `json:"cache_creation_input_token_cost,omitempty"`
+	CacheCreationInputTokenCost1h *float64 `json:"cache_creation_input_token_cost_above_1hr,omitempty"`
+	InputCostPerTokenAbove200k    *float64 `json:"input_cost_per_token_above_200k_tokens,omitempty"`
+	OutputCostPerTokenAbove200k   *float64 `json:"output_cost_per_token_above_200k_tokens,omitempty"`
+}
+
+// liteLLMThreshold LiteLLM 分层定价的固定阈值
+const liteLLMThreshold = 200_000
+
+// ParseLiteLLM 解析 LiteLLM 格式的价格 JSON，按模型 ID 排序返回
+// 跳过 sample_spec、带 provider 前缀的条目（如 "bedrock/..."）以及非对话模型
+func ParseLiteLLM(data []byte) ([]*ModelPricing, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid LiteLLM price file: %w", err)
+	}
+
+	prices := make([]*ModelPricing, 0, len(raw))
+	for modelID, entry := range raw {
+		if modelID == "sample_spec" || strings.Contains(modelID, "/") {
+			continue
+		}
+		var m liteLLMModel
+		if err := json.Unmarshal(entry, &m); err != nil {
+			continue
+		}
+		switch m.Mode {
+		case "", "chat", "completion", "responses":
+		default:
+			continue
+		}
+		if m.InputCostPerToken == nil && m.OutputCostPerToken == nil {
+			continue
+		}
+
+		p := &ModelPricing{
+			ModelID:                modelID,
+			InputPriceMicro:        perTokenToMicro(m.InputCostPerToken),
+			OutputPriceMicro:       perTokenToMicro(m.OutputCostPerToken),
+			CacheReadPriceMicro:    perTokenToMicro(m.CacheReadInputTokenCost),
+			Cache5mWritePriceMicro: perTokenToMicro(m.CacheCreationInputTokenCost),
+			Cache1hWritePriceMicro: perTokenToMicro(m.CacheCreationInputTokenCost1h),
+		}
+		if m.InputCostPerTokenAbove200k != nil || m.OutputCostPerTokenAbove200k != nil {
+			p.Has1MContext = true
+			p.Context1MThreshold = liteLLMThreshold
+			p.InputPremiumNum, p.InputPremiumDenom = premiumFraction(p.InputPriceMicro, perTokenToMicro(m.InputCostPerTokenAbove200k))
+			p.OutputPremiumNum, p.OutputPremiumDenom = premiumFraction(p.OutputPriceMicro, perTokenToMicro(m.OutputCostPerTokenAbove200k))
+		}
+		prices = append(prices, p)
+	}
+
+	sort.Slice(prices, func(i, j int) bool { return prices[i].ModelID < prices[j].ModelID })
+	return prices, nil
+}
+
+// ExportLiteLLM 导出为 LiteLLM 格式的价格 JSON，缓存价格导出实际生效的值
+func ExportLiteLLM(prices []*ModelPricing) ([]byte, error) {
+	out := make(map[string]*liteLLMModel, len(prices))
+	for _, p := range prices {
+		m := &liteLLMModel{
+			Mode:                          "chat",
+			InputCostPerToken:             microToPerToken(p.InputPriceMicro),
+			OutputCostPerToken:            microToPerToken(p.OutputPriceMicro),
+			CacheReadInputTokenCost:       microToPerToken(p.GetEffectiveCacheReadPriceMicro()),
+			CacheCreationInputTokenCost:   microToPerToken(p.GetEffectiveCache5mWritePriceMicro()),
+			CacheCreationInputTokenCost1h: microToPerToken(p.GetEffectiveCache1hWritePriceMicro()),
+		}
+		// LiteLLM 只能表示 200k 阈值的分层定价
+		if p.Has1MContext && p.GetContext1MThreshold() == liteLLMThreshold {
+			inNum, inDenom := p.GetInputPremiumFraction()
+			outNum, outDenom := p.GetOutputPremiumFraction()
+			m.InputCostPerTokenAbove200k = microToPerToken(p.InputPriceMicro * inNum / inDenom)
+			m.OutputCostPerTokenAbove200k = microToPerToken(p.OutputPriceMicro * outNum / outDenom)
+		}
+		out[p.ModelID] = m
+	}
+	return json.MarshalIndent(out, "", "    ")
+}
+
+// perTokenToMicro 美元/token 转换为 microUSD/M tokens（即乘以 1e12）
+func perTokenToMicro(v *float64) uint64 {
+	if v == nil || *v <= 0 {
+		return 0
+	}
+	return uint64(math.Round(*v * MicroUSDPerUSD * TokensPerMillion))
+}
+
+// microToPerToken microUSD/M tokens 转换为美元/token
+func microToPerToken(v uint64) *float64 {
+	f := float64(v) / MicroUSDPerUSD / TokensPerMillion
+	return &f
+}
+
+// premiumFraction 将超阈值价格表示为基础价格的倍率分数（约分后）
+func premiumFraction(base, above uint64) (num, denom uint64) {
+	if base == 0 || above == 0 {
+		return 0, 0
+	}
+	g := gcd(above, base)
+	return above / g, base / g
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,55 @@
+package pricing
+
+import "testing"
+
+func TestParseLiteLLM(t *testing.T) {
+	data := []byte(`{
+		"sample_spec": {"input_cost_per_token": 0.0},
+		"claude-sonnet-4-5": {
+			"mode": "chat",
+			"input_cost_per_token": 3e-06,
+			"output_cost_per_token": 1.5e-05,
+			"cache_read_input_token_cost": 3e-07,
+			"cache_creation_input_token_cost": 3.75e-06,
+			"input_cost_per_token_above_200k_tokens": 6e-06,
+			"output_cost_per_token_above_200k_tokens": 2.25e-05
+		},
+		"bedrock/claude-sonnet-4-5": {"mode": "chat", "input_cost_per_token": 3e-06},
+		"text-embedding-3-small": {"mode": "embedding", "input_cost_per_token": 2e-08},
+		"gpt-4o-mini": {"mode": "chat", "input_cost_per_token": 1.5e-07, "output_cost_per_token": 6e-07}
+	}`)
+
+	prices, err := ParseLiteLLM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prices) != 2 || prices[0].ModelID != "claude-sonnet-4-5" || prices[1].ModelID != "gpt-4o-mini" {
+		t.Fatalf("expected claude-sonnet-4-5 and gpt-4o-mini, got %+v", prices)
+	}
+
+	sonnet := prices[0]
+	if sonnet.InputPriceMicro != 3_000_000 || sonnet.OutputPriceMicro != 15_000_000 ||
+		sonnet.CacheReadPriceMicro != 300_000 || sonnet.Cache5mWritePriceMicro != 3_750_000 {
+		t.Errorf("unexpected sonnet prices: %+v", sonnet)
+	}
+	if !sonnet.Has1MContext || sonnet.InputPremiumNum != 2 || sonnet.InputPremiumDenom != 1 ||
+		sonnet.OutputPremiumNum != 3 || sonnet.OutputPremiumDenom != 2 {
+		t.Errorf("unexpected sonnet tiers: %+v", sonnet)
+	}
+	if prices[1].InputPriceMicro != 150_000 || prices[1].OutputPriceMicro != 600_000 {
+		t.Errorf("unexpected gpt-4o-mini prices: %+v", prices[1])
+	}
+
+	// Exported prices parse back to the same values
+	exported, err := ExportLiteLLM(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseLiteLLM(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 2 || again[0].OutputPremiumNum != 3 || again[1].OutputPriceMicro != 600_000 {
+		t.Errorf("round trip changed prices: %+v", again)
+	}
+}
@@ -0,0 +1,55 @@
+package pricing
+
+import (
+	"math"
+	"strings"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// ProviderMultiplier 返回模型适用的倍率
+// 优先最长前缀匹配的模型倍率（可以为 0，表示免费），其次全局倍率，默认 1
+func ProviderMultiplier(model string, pp *domain.ProviderPricing) float64 {
+	if pp == nil {
+		return 1
+	}
+	if m, ok := pp.ModelMultipliers[model]; ok {
+		return m
+	}
+	bestLen := 0
+	multiplier, found := 0.0, false
+	for key, m := range pp.ModelMultipliers {
+		if strings.HasPrefix(model, key) && len(key) > bestLen {
+			multiplier, found = m, true
+			bestLen = len(key)
+		}
+	}
+	if found {
+		return multiplier
+	}
+	if pp.Multiplier > 0 {
+		return pp.Multiplier
+	}
+	return 1
+}
+
+// ApplyProviderPricing 将官方价格成本（微美元）换算为 Provider 的实际成本（微美元）
+func ApplyProviderPricing(cost uint64, model string, pp *domain.ProviderPricing) uint64 {
+	if pp == nil || cost == 0 {
+		return cost
+	}
+	factor := ProviderMultiplier(model, pp)
+	if pp.Currency != "" {
+		factor *= pp.USDPerUnit
+	}
+	return uint64(math.Round(float64(cost) * factor))
+}
+
+// BilledAmount 将实际成本（微美元）折算为 Provider 计费单位的微单位
+// 未设置 Currency 时返回原值
+func BilledAmount(cost uint64, pp *domain.ProviderPricing) uint64 {
+	if pp == nil || pp.Currency == "" || pp.USDPerUnit <= 0 {
+		return cost
+	}
+	return uint64(math.Round(float64(cost) / pp.USDPerUnit))
+}
@@ -0,0 +1,68 @@
+package pricing
+
+import (
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// FromModelPrice 将数据库中的自定义价格转换为 ModelPricing
+func FromModelPrice(p *domain.ModelPrice) *ModelPricing {
+	return &ModelPricing{
+		ModelID:                p.ModelID,
+		InputPriceMicro:        p.InputPriceMicro,
+		OutputPriceMicro:       p.OutputPriceMicro,
+		CacheReadPriceMicro:    p.CacheReadPriceMicro,
+		Cache5mWritePriceMicro: p.Cache5mWritePriceMicro,
+		Cache1hWritePriceMicro: p.Cache1hWritePriceMicro,
+		Has1MContext:           p.Has1MContext,
+		Context1MThreshold:     p.Context1MThreshold,
+		InputPremiumNum:        p.InputPremiumNum,
+		InputPremiumDenom:      p.InputPremiumDenom,
+		OutputPremiumNum:       p.OutputPremiumNum,
+		OutputPremiumDenom:     p.OutputPremiumDenom,
+	}
+}
+
+// ToModelPrice 将 ModelPricing 转换为 domain.ModelPrice（ID 为 0）
+func ToModelPrice(p *ModelPricing) *domain.ModelPrice {
+	return &domain.ModelPrice{
+		ModelID:                p.ModelID,
+		InputPriceMicro:        p.InputPriceMicro,
+		OutputPriceMicro:       p.OutputPriceMicro,
+		CacheReadPriceMicro:    p.CacheReadPriceMicro,
+		Cache5mWritePriceMicro: p.Cache5mWritePriceMicro,
+		Cache1hWritePriceMicro: p.Cache1hWritePriceMicro,
+		Has1MContext:           p.Has1MContext,
+		Context1MThreshold:     p.Context1MThreshold,
+		InputPremiumNum:        p.InputPremiumNum,
+		InputPremiumDenom:      p.InputPremiumDenom,
+		OutputPremiumNum:       p.OutputPremiumNum,
+		OutputPremiumDenom:     p.OutputPremiumDenom,
+	}
+}
+
+// BuildPriceTable 以默认价格表为基础，叠加自定义价格（同名模型以自定义为准）
+func BuildPriceTable(custom []*domain.ModelPrice) *PriceTable {
+	defaults := DefaultPriceTable()
+	pt := NewPriceTable(defaults.Version)
+	for _, p := range defaults.Models {
+		pt.Set(p)
+	}
+	for _, p := range custom {
+		pt.Set(FromModelPrice(p))
+	}
+	if len(custom) > 0 {
+		pt.Version = defaults.Version + "+custom"
+	}
+	return pt
+}
+
+// LoadPrices 从数据库加载自定义价格并更新全局计算器的价格表
+func LoadPrices(repo repository.ModelPriceRepository) error {
+	custom, err := repo.List()
+	if err != nil {
+		return err
+	}
+	GlobalCalculator().SetPriceTable(BuildPriceTable(custom))
+	return nil
+}
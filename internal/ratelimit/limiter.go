@@ -0,0 +1,231 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// concurrencyRetryAfter is the Retry-After hint when only the concurrency cap is hit
+const concurrencyRetryAfter = time.Second
+
+// LimitError is returned when a request exceeds a limit
+type LimitError struct {
+	Scope      domain.RateLimitScope
+	ScopeID    uint64
+	Reason     string // rpm, tpm or concurrency
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded: %s %d (%s)", e.Scope, e.ScopeID, e.Reason)
+}
+
+func (e *LimitError) Unwrap() error {
+	return domain.ErrRateLimited
+}
+
+// Limiter enforces one RateLimit config with token buckets for requests and tokens
+// plus an in-flight counter. Buckets refill continuously and start full.
+type Limiter struct {
+	mu       sync.Mutex
+	config   domain.RateLimit
+	requests float64 // available requests
+	tokens   float64 // available tokens, negative when actual usage exceeded the estimate
+	last     time.Time
+	inFlight int
+	waiting  int
+	rejected uint64
+	changed  chan struct{} // closed and replaced whenever capacity is released
+	now      func() time.Time
+}
+
+func newLimiter(config domain.RateLimit, now func() time.Time) *Limiter {
+	return &Limiter{
+		config:   config,
+		requests: float64(config.RPM),
+		tokens:   float64(config.TPM),
+		last:     now(),
+		changed:  make(chan struct{}),
+		now:      now,
+	}
+}
+
+// setConfig updates the limits, clamping the buckets to the new capacity.
+// A limit that was previously off (0) starts with a full bucket, like a new limiter.
+func (l *Limiter) setConfig(config domain.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.config.RPM <= 0 {
+		l.requests = float64(config.RPM)
+	}
+	if l.config.TPM <= 0 {
+		l.tokens = float64(config.TPM)
+	}
+	l.config = config
+	l.requests = math.Min(l.requests, float64(config.RPM))
+	l.tokens = math.Min(l.tokens, float64(config.TPM))
+	l.notify()
+}
+
+func (l *Limiter) refill() {
+	now := l.now()
+	elapsed := now.Sub(l.last).Minutes()
+	l.last = now
+	if elapsed <= 0 {
+		return
+	}
+	if rpm := float64(l.config.RPM); rpm > 0 {
+		l.requests = math.Min(rpm, l.requests+elapsed*rpm)
+	}
+	if tpm := float64(l.config.TPM); tpm > 0 {
+		l.tokens = math.Min(tpm, l.tokens+elapsed*tpm)
+	}
+}
+
+// tokenCost caps the estimate at the bucket size so a single large request can still pass
+func (l *Limiter) tokenCost(estimated int) float64 {
+	if l.config.TPM <= 0 {
+		return 0
+	}
+	return math.Min(float64(estimated), float64(l.config.TPM))
+}
+
+// tryAcquire takes capacity if available, otherwise reports what is exhausted and
+// how long until it likely frees up
+func (l *Limiter) tryAcquire(estimated int) (reason string, wait time.Duration) {
+	l.refill()
+	if l.config.MaxConcurrency > 0 && l.inFlight >= l.config.MaxConcurrency {
+		return "concurrency", concurrencyRetryAfter
+	}
+	if l.config.RPM > 0 && l.requests < 1 {
+		reason = "rpm"
+		wait = minutes((1 - l.requests) / float64(l.config.RPM))
+	}
+	if cost := l.tokenCost(estimated); l.config.TPM > 0 && l.tokens < cost {
+		if w := minutes((cost - l.tokens) / float64(l.config.TPM)); w > wait {
+			reason, wait = "tpm", w
+		}
+	}
+	if reason != "" {
+		return reason, wait
+	}
+
+	if l.config.RPM > 0 {
+		l.requests--
+	}
+	l.tokens -= l.tokenCost(estimated)
+	l.inFlight++
+	return "", 0
+}
+
+// acquire waits up to timeout for capacity
+func (l *Limiter) acquire(ctx context.Context, estimated int, timeout time.Duration) error {
+	deadline := l.now().Add(timeout)
+	l.mu.Lock()
+	for {
+		reason, wait := l.tryAcquire(estimated)
+		if reason == "" {
+			l.mu.Unlock()
+			return nil
+		}
+		remaining := deadline.Sub(l.now())
+		if remaining <= 0 {
+			l.rejected++
+			l.mu.Unlock()
+			if wait <= 0 {
+				wait = concurrencyRetryAfter
+			}
+			return &LimitError{Scope: l.config.Scope, ScopeID: l.config.ScopeID, Reason: reason, RetryAfter: wait}
+		}
+		if wait <= 0 || wait > remaining {
+			wait = remaining
+		}
+
+		changed := l.changed
+		l.waiting++
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			l.mu.Lock()
+			l.waiting--
+			l.mu.Unlock()
+			return ctx.Err()
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+
+		l.mu.Lock()
+		l.waiting--
+	}
+}
+
+// release ends a request, correcting the token bucket with the actual usage
+func (l *Limiter) release(estimated, actual int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.inFlight > 0 {
+		l.inFlight--
+	}
+	if l.config.TPM > 0 && actual >= 0 {
+		l.tokens -= float64(actual) - l.tokenCost(estimated)
+		// Allow at most one minute of debt
+		l.tokens = math.Max(l.tokens, -float64(l.config.TPM))
+		l.tokens = math.Min(l.tokens, float64(l.config.TPM))
+	}
+	l.notify()
+}
+
+// cancel refunds a request that was admitted but never sent
+func (l *Limiter) cancel(estimated int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.inFlight > 0 {
+		l.inFlight--
+	}
+	if l.config.RPM > 0 {
+		l.requests = math.Min(float64(l.config.RPM), l.requests+1)
+	}
+	l.tokens = math.Min(float64(l.config.TPM), l.tokens+l.tokenCost(estimated))
+	l.notify()
+}
+
+func (l *Limiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *Limiter) status() *LimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	return &LimiterStatus{
+		ID:                 l.config.ID,
+		Scope:              l.config.Scope,
+		ScopeID:            l.config.ScopeID,
+		RPM:                l.config.RPM,
+		AvailableRequests:  math.Floor(l.requests),
+		TPM:                l.config.TPM,
+		AvailableTokens:    math.Floor(l.tokens),
+		MaxConcurrency:     l.config.MaxConcurrency,
+		InFlight:           l.inFlight,
+		Waiting:            l.waiting,
+		Rejected:           l.rejected,
+		QueueTimeoutMillis: l.config.QueueTimeout.Milliseconds(),
+	}
+}
+
+func minutes(m float64) time.Duration {
+	return time.Duration(m * float64(time.Minute))
+}
@@ -0,0 +1,301 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+var (
+	projectKey = Key{Scope: domain.RateLimitScopeProject, ScopeID: 1}
+	apiKeyKey  = Key{Scope: domain.RateLimitScopeAPIKey, ScopeID: 2}
+)
+
+func newTestManager(now *time.Time, configs ...*domain.RateLimit) *Manager {
+	m := NewManager()
+	m.now = func() time.Time { return *now }
+	for _, c := range configs {
+		c.IsEnabled = true
+	}
+	m.SetConfigs(configs)
+	return m
+}
+
+func statusOf(m *Manager, key Key) *LimiterStatus {
+	for _, s := range m.Status() {
+		if s.Scope == key.Scope && s.ScopeID == key.ScopeID {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestRefillRate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(&now, &domain.RateLimit{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, RPM: 60})
+	ctx := context.Background()
+
+	for i := 0; i < 60; i++ {
+		if _, err := m.Acquire(ctx, []Key{projectKey}, 0, false); err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+	}
+	if _, err := m.Acquire(ctx, []Key{projectKey}, 0, false); !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("61st request: got %v, want rate limited", err)
+	}
+
+	// 60 RPM refills one request per second
+	now = now.Add(time.Second)
+	if _, err := m.Acquire(ctx, []Key{projectKey}, 0, false); err != nil {
+		t.Fatalf("after 1s: %v", err)
+	}
+	if _, err := m.Acquire(ctx, []Key{projectKey}, 0, false); err == nil {
+		t.Fatal("after 1s: second request admitted, want only one")
+	}
+
+	// The bucket never refills above its capacity
+	now = now.Add(time.Hour)
+	if got := statusOf(m, projectKey).AvailableRequests; got != 60 {
+		t.Errorf("after 1h: available = %v, want 60", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     domain.RateLimit
+		admit      int // requests admitted before the rejected one
+		estimated  int
+		wantReason string
+		wantRetry  time.Duration
+	}{
+		{
+			name:       "rpm",
+			config:     domain.RateLimit{RPM: 2},
+			admit:      2,
+			wantReason: "rpm",
+			wantRetry:  30 * time.Second,
+		},
+		{
+			name:       "tpm",
+			config:     domain.RateLimit{TPM: 1000},
+			admit:      2,
+			estimated:  400,
+			wantReason: "tpm",
+			wantRetry:  12 * time.Second, // 200 tokens left, 200 more needed at 1000/min
+		},
+		{
+			name:       "longest wait wins",
+			config:     domain.RateLimit{RPM: 2, TPM: 1000},
+			admit:      2,
+			estimated:  450,
+			wantReason: "rpm",
+			wantRetry:  30 * time.Second, // tpm only needs 21s for 350 more tokens
+		},
+		{
+			name:       "concurrency",
+			config:     domain.RateLimit{RPM: 100, MaxConcurrency: 1},
+			admit:      1,
+			wantReason: "concurrency",
+			wantRetry:  concurrencyRetryAfter,
+		},
+	}
+
+	for _, tt := range tests {
+		now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		tt.config.Scope, tt.config.ScopeID = projectKey.Scope, projectKey.ScopeID
+		m := newTestManager(&now, &tt.config)
+
+		for i := 0; i < tt.admit; i++ {
+			if _, err := m.Acquire(context.Background(), []Key{projectKey}, tt.estimated, false); err != nil {
+				t.Fatalf("%s: request %d: %v", tt.name, i, err)
+			}
+		}
+		_, err := m.Acquire(context.Background(), []Key{projectKey}, tt.estimated, false)
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("%s: got %v, want LimitError", tt.name, err)
+		}
+		if limitErr.Reason != tt.wantReason {
+			t.Errorf("%s: reason = %q, want %q", tt.name, limitErr.Reason, tt.wantReason)
+		}
+		if d := limitErr.RetryAfter - tt.wantRetry; d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("%s: RetryAfter = %v, want %v", tt.name, limitErr.RetryAfter, tt.wantRetry)
+		}
+	}
+}
+
+func TestQueueTimeout(t *testing.T) {
+	m := NewManager()
+	m.SetConfigs([]*domain.RateLimit{{
+		Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, RPM: 1,
+		QueueTimeout: 50 * time.Millisecond, IsEnabled: true,
+	}})
+	ctx := context.Background()
+
+	if _, err := m.Acquire(ctx, []Key{projectKey}, 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	_, err := m.Acquire(ctx, []Key{projectKey}, 0, true)
+	elapsed := time.Since(start)
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("got %v, want LimitError", err)
+	}
+	if elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("rejected after %v, want ~50ms", elapsed)
+	}
+	// The hint still points at the next refill, not the queue timeout
+	if limitErr.RetryAfter < 59*time.Second {
+		t.Errorf("RetryAfter = %v, want ~60s", limitErr.RetryAfter)
+	}
+	if got := statusOf(m, projectKey); got.Rejected != 1 || got.Waiting != 0 {
+		t.Errorf("rejected = %d, waiting = %d, want 1 and 0", got.Rejected, got.Waiting)
+	}
+}
+
+func TestQueuedRequestAdmittedOnRelease(t *testing.T) {
+	m := NewManager()
+	m.SetConfigs([]*domain.RateLimit{{
+		Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, MaxConcurrency: 1,
+		QueueTimeout: 5 * time.Second, IsEnabled: true,
+	}})
+	ctx := context.Background()
+
+	first, err := m.Acquire(ctx, []Key{projectKey}, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		first.Release(-1)
+	}()
+
+	start := time.Now()
+	if _, err := m.Acquire(ctx, []Key{projectKey}, 0, true); err != nil {
+		t.Fatalf("queued request: %v", err)
+	}
+	// Woken by the release, not by the concurrency retry interval
+	if elapsed := time.Since(start); elapsed >= concurrencyRetryAfter {
+		t.Errorf("admitted after %v, want right after the release", elapsed)
+	}
+}
+
+func TestQueueRespectsContext(t *testing.T) {
+	m := NewManager()
+	m.SetConfigs([]*domain.RateLimit{{
+		Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, RPM: 1,
+		QueueTimeout: time.Minute, IsEnabled: true,
+	}})
+	if _, err := m.Acquire(context.Background(), []Key{projectKey}, 0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := m.Acquire(ctx, []Key{projectKey}, 0, true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context deadline exceeded", err)
+	}
+}
+
+func TestRefundOnFailure(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(&now,
+		&domain.RateLimit{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, RPM: 10, TPM: 1000},
+		&domain.RateLimit{Scope: apiKeyKey.Scope, ScopeID: apiKeyKey.ScopeID, MaxConcurrency: 1},
+	)
+	ctx := context.Background()
+	keys := []Key{projectKey, apiKeyKey}
+
+	held, err := m.Acquire(ctx, keys, 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The project limit admits the second request, then the API key rejects it:
+	// the project's request and tokens must be given back
+	if _, err := m.Acquire(ctx, keys, 100, false); !errors.Is(err, domain.ErrRateLimited) {
+		t.Fatalf("got %v, want rate limited", err)
+	}
+	project := statusOf(m, projectKey)
+	if project.AvailableRequests != 9 || project.AvailableTokens != 900 || project.InFlight != 1 {
+		t.Errorf("project after refund: requests=%v tokens=%v inFlight=%d, want 9, 900, 1",
+			project.AvailableRequests, project.AvailableTokens, project.InFlight)
+	}
+
+	// Cancel refunds everything; a second Cancel or Release is a no-op
+	held.Cancel()
+	held.Cancel()
+	held.Release(500)
+	project = statusOf(m, projectKey)
+	if project.AvailableRequests != 10 || project.AvailableTokens != 1000 || project.InFlight != 0 {
+		t.Errorf("project after cancel: requests=%v tokens=%v inFlight=%d, want 10, 1000, 0",
+			project.AvailableRequests, project.AvailableTokens, project.InFlight)
+	}
+	if got := statusOf(m, apiKeyKey).InFlight; got != 0 {
+		t.Errorf("api key inFlight = %d, want 0", got)
+	}
+}
+
+func TestReleaseCorrectsTokens(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(&now, &domain.RateLimit{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, TPM: 1000})
+	ctx := context.Background()
+
+	tests := []struct {
+		estimated, actual int
+		wantTokens        float64
+	}{
+		{estimated: 100, actual: 300, wantTokens: 700},
+		{estimated: 300, actual: 100, wantTokens: 600},
+		{estimated: 100, actual: -1, wantTokens: 500},   // unknown usage keeps the estimate
+		{estimated: 0, actual: 5000, wantTokens: -1000}, // at most one minute of debt
+	}
+	for _, tt := range tests {
+		p, err := m.Acquire(ctx, []Key{projectKey}, tt.estimated, false)
+		if err != nil {
+			t.Fatalf("estimate %d: %v", tt.estimated, err)
+		}
+		p.Release(tt.actual)
+		if got := statusOf(m, projectKey).AvailableTokens; got != tt.wantTokens {
+			t.Errorf("estimate %d, actual %d: tokens = %v, want %v", tt.estimated, tt.actual, got, tt.wantTokens)
+		}
+	}
+}
+
+func TestSetConfigEnablesLimitWithFullBucket(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(&now, &domain.RateLimit{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, MaxConcurrency: 5})
+	ctx := context.Background()
+
+	// Adding RPM and TPM to a concurrency-only limit starts both buckets full
+	m.SetConfigs([]*domain.RateLimit{{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, IsEnabled: true, MaxConcurrency: 5, RPM: 3, TPM: 1000}})
+	status := statusOf(m, projectKey)
+	if status.AvailableRequests != 3 || status.AvailableTokens != 1000 {
+		t.Fatalf("after enabling: available = %v requests, %v tokens, want 3 and 1000", status.AvailableRequests, status.AvailableTokens)
+	}
+	for i := 0; i < 3; i++ {
+		permit, err := m.Acquire(ctx, []Key{projectKey}, 100, false)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		permit.Release(100)
+	}
+
+	// Raising an existing limit keeps the drained bucket instead of refilling it
+	m.SetConfigs([]*domain.RateLimit{{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, IsEnabled: true, MaxConcurrency: 5, RPM: 6, TPM: 1000}})
+	if got := statusOf(m, projectKey).AvailableRequests; got != 0 {
+		t.Errorf("after raising RPM: available = %v, want 0", got)
+	}
+
+	// Lowering a limit clamps the bucket to the new capacity
+	m.SetConfigs([]*domain.RateLimit{{Scope: projectKey.Scope, ScopeID: projectKey.ScopeID, IsEnabled: true, MaxConcurrency: 5, RPM: 6, TPM: 500}})
+	if got := statusOf(m, projectKey).AvailableTokens; got != 500 {
+		t.Errorf("after lowering TPM: available tokens = %v, want 500", got)
+	}
+}
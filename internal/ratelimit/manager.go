@@ -0,0 +1,189 @@
+// Package ratelimit enforces per-provider, per-project and per-API-key limits on
+// requests per minute, tokens per minute and concurrent requests.
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+// Key identifies the subject of a limit
+type Key struct {
+	Scope   domain.RateLimitScope
+	ScopeID uint64
+}
+
+// LimiterStatus is the live state of a limiter (shown in /admin/proxy-status)
+type LimiterStatus struct {
+	ID                 uint64                `json:"id"`
+	Scope              domain.RateLimitScope `json:"scope"`
+	ScopeID            uint64                `json:"scopeID"`
+	RPM                int                   `json:"rpm"`
+	AvailableRequests  float64               `json:"availableRequests"`
+	TPM                int                   `json:"tpm"`
+	AvailableTokens    float64               `json:"availableTokens"`
+	MaxConcurrency     int                   `json:"maxConcurrency"`
+	InFlight           int                   `json:"inFlight"`
+	Waiting            int                   `json:"waiting"`
+	Rejected           uint64                `json:"rejected"`
+	QueueTimeoutMillis int64                 `json:"queueTimeoutMs"`
+}
+
+// Manager holds one limiter per enabled RateLimit config
+type Manager struct {
+	mu       sync.RWMutex
+	limiters map[Key]*Limiter
+	repo     repository.RateLimitRepository
+	now      func() time.Time
+}
+
+// NewManager creates an empty manager
+func NewManager() *Manager {
+	return &Manager{
+		limiters: make(map[Key]*Limiter),
+		now:      time.Now,
+	}
+}
+
+// Default global manager
+var defaultManager = NewManager()
+
+// Default returns the default global rate limit manager
+func Default() *Manager {
+	return defaultManager
+}
+
+// SetRepository sets the repository limits are loaded from
+func (m *Manager) SetRepository(repo repository.RateLimitRepository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.repo = repo
+}
+
+// Load (re)builds limiters from the repository
+// Existing limiters keep their bucket state when their config changes
+func (m *Manager) Load() error {
+	m.mu.RLock()
+	repo := m.repo
+	m.mu.RUnlock()
+	if repo == nil {
+		return nil
+	}
+	configs, err := repo.List()
+	if err != nil {
+		return err
+	}
+	m.SetConfigs(configs)
+	return nil
+}
+
+// SetConfigs replaces the active limits
+func (m *Manager) SetConfigs(configs []*domain.RateLimit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := make(map[Key]*Limiter, len(configs))
+	for _, c := range configs {
+		if !c.IsEnabled || (c.RPM <= 0 && c.TPM <= 0 && c.MaxConcurrency <= 0) {
+			continue
+		}
+		key := Key{Scope: c.Scope, ScopeID: c.ScopeID}
+		if l, ok := m.limiters[key]; ok {
+			l.setConfig(*c)
+			next[key] = l
+		} else {
+			next[key] = newLimiter(*c, m.now)
+		}
+	}
+	m.limiters = next
+	log.Printf("[RateLimit] Loaded %d limits", len(next))
+}
+
+// Acquire admits a request against every configured limit in keys.
+// When wait is true, the request queues up to each limit's QueueTimeout; otherwise it
+// fails immediately. On failure, limits already taken are refunded.
+// estimatedTokens is the expected token usage used for TPM admission.
+func (m *Manager) Acquire(ctx context.Context, keys []Key, estimatedTokens int, wait bool) (*Permit, error) {
+	m.mu.RLock()
+	var limiters []*Limiter
+	for _, k := range keys {
+		if l, ok := m.limiters[k]; ok {
+			limiters = append(limiters, l)
+		}
+	}
+	m.mu.RUnlock()
+
+	permit := &Permit{estimated: estimatedTokens}
+	for _, l := range limiters {
+		var timeout time.Duration
+		if wait {
+			l.mu.Lock()
+			timeout = l.config.QueueTimeout
+			l.mu.Unlock()
+		}
+		if err := l.acquire(ctx, estimatedTokens, timeout); err != nil {
+			permit.Cancel()
+			return nil, err
+		}
+		permit.limiters = append(permit.limiters, l)
+	}
+	return permit, nil
+}
+
+// Status returns the state of all limiters ordered by scope and scope ID
+func (m *Manager) Status() []*LimiterStatus {
+	m.mu.RLock()
+	limiters := make([]*Limiter, 0, len(m.limiters))
+	for _, l := range m.limiters {
+		limiters = append(limiters, l)
+	}
+	m.mu.RUnlock()
+
+	result := make([]*LimiterStatus, 0, len(limiters))
+	for _, l := range limiters {
+		result = append(result, l.status())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Scope != result[j].Scope {
+			return result[i].Scope < result[j].Scope
+		}
+		return result[i].ScopeID < result[j].ScopeID
+	})
+	return result
+}
+
+// Permit is the capacity held by an admitted request. A nil permit is valid and does nothing.
+type Permit struct {
+	limiters  []*Limiter
+	estimated int
+	done      bool
+}
+
+// Release returns concurrency and corrects TPM with the actual token usage
+// (pass a negative value when usage is unknown to keep the estimate)
+func (p *Permit) Release(actualTokens int) {
+	if p == nil || p.done {
+		return
+	}
+	p.done = true
+	for _, l := range p.limiters {
+		l.release(p.estimated, actualTokens)
+	}
+}
+
+// Cancel refunds the permit for a request that was never sent upstream
+func (p *Permit) Cancel() {
+	if p == nil || p.done {
+		return
+	}
+	p.done = true
+	for _, l := range p.limiters {
+		l.cancel(p.estimated)
+	}
+}
@@ -0,0 +1,112 @@
+package cached
+
+import (
+	"sync"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+type APIKeyRepository struct {
+	repo      repository.APIKeyRepository
+	cache     map[uint64]*domain.APIKey
+	hashCache map[string]*domain.APIKey
+	mu        sync.RWMutex
+}
+
+func NewAPIKeyRepository(repo repository.APIKeyRepository) *APIKeyRepository {
+	return &APIKeyRepository{
+		repo:      repo,
+		cache:     make(map[uint64]*domain.APIKey),
+		hashCache: make(map[string]*domain.APIKey),
+	}
+}
+
+func (r *APIKeyRepository) Load() error {
+	list, err := r.repo.List()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range list {
+		r.cache[k.ID] = k
+		r.hashCache[k.KeyHash] = k
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) Create(k *domain.APIKey) error {
+	if err := r.repo.Create(k); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cache[k.ID] = k
+	r.hashCache[k.KeyHash] = k
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *APIKeyRepository) Update(k *domain.APIKey) error {
+	if err := r.repo.Update(k); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cache[k.ID] = k
+	r.hashCache[k.KeyHash] = k
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *APIKeyRepository) Delete(id uint64) error {
+	if err := r.repo.Delete(id); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if k, ok := r.cache[id]; ok {
+		delete(r.hashCache, k.KeyHash)
+	}
+	delete(r.cache, id)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *APIKeyRepository) GetByID(id uint64) (*domain.APIKey, error) {
+	r.mu.RLock()
+	if k, ok := r.cache[id]; ok {
+		r.mu.RUnlock()
+		return k, nil
+	}
+	r.mu.RUnlock()
+	return r.repo.GetByID(id)
+}
+
+func (r *APIKeyRepository) GetByHash(keyHash string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	if k, ok := r.hashCache[keyHash]; ok {
+		r.mu.RUnlock()
+		return k, nil
+	}
+	r.mu.RUnlock()
+
+	k, err := r.repo.GetByHash(keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache[k.ID] = k
+	r.hashCache[k.KeyHash] = k
+	r.mu.Unlock()
+	return k, nil
+}
+
+func (r *APIKeyRepository) List() ([]*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*domain.APIKey, 0, len(r.cache))
+	for _, k := range r.cache {
+		list = append(list, k)
+	}
+	return list, nil
+}
@@ -29,6 +29,15 @@ type CooldownRepository interface {
 	Get(providerID uint64, clientType string) (*domain.Cooldown, error)
 }
 
+// CooldownPolicyRepository stores cooldown policy overrides
+type CooldownPolicyRepository interface {
+	Create(policy *domain.CooldownPolicyConfig) error
+	Update(policy *domain.CooldownPolicyConfig) error
+	Delete(id uint64) error
+	GetByID(id uint64) (*domain.CooldownPolicyConfig, error)
+	List() ([]*domain.CooldownPolicyConfig, error)
+}
+
 // CooldownInfo is a helper structure for returning cooldown information
 type CooldownInfo struct {
 	ProviderID   uint64    `json:"providerID"`
@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/awsl-project/maxx/internal/domain"
+import (
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
 
 type ProviderRepository interface {
 	Create(provider *domain.Provider) error
@@ -53,6 +57,53 @@ type SessionRepository interface {
 	List() ([]*domain.Session, error)
 }
 
+type APIKeyRepository interface {
+	Create(key *domain.APIKey) error
+	Update(key *domain.APIKey) error
+	Delete(id uint64) error
+	GetByID(id uint64) (*domain.APIKey, error)
+	// GetByHash 根据 Key 的 SHA-256 哈希查找
+	GetByHash(keyHash string) (*domain.APIKey, error)
+	List() ([]*domain.APIKey, error)
+}
+
+type RateLimitRepository interface {
+	Create(limit *domain.RateLimit) error
+	Update(limit *domain.RateLimit) error
+	Delete(id uint64) error
+	GetByID(id uint64) (*domain.RateLimit, error)
+	List() ([]*domain.RateLimit, error)
+}
+
+type BudgetRepository interface {
+	Create(budget *domain.Budget) error
+	Update(budget *domain.Budget) error
+	Delete(id uint64) error
+	GetByID(id uint64) (*domain.Budget, error)
+	List() ([]*domain.Budget, error)
+	// GetSpend returns the total attempt cost for the scope since the given time
+	GetSpend(scope domain.BudgetScope, scopeID uint64, since time.Time) (uint64, error)
+}
+
+type UserRepository interface {
+	Create(user *domain.User) error
+	Update(user *domain.User) error
+	Delete(id uint64) error
+	GetByID(id uint64) (*domain.User, error)
+	GetByUsername(username string) (*domain.User, error)
+	List() ([]*domain.User, error)
+	Count() (int64, error)
+}
+
+type AdminSessionRepository interface {
+	Create(session *domain.AdminSession) error
+	GetByTokenHash(tokenHash string) (*domain.AdminSession, error)
+	Delete(tokenHash string) error
+	DeleteByUserID(userID uint64) error
+	// DeleteExpired 删除已过期的会话，返回删除数量
+	DeleteExpired() (int64, error)
+}
+
 type ProxyRequestRepository interface {
 	Create(req *domain.ProxyRequest) error
 	Update(req *domain.ProxyRequest) error
@@ -75,6 +126,9 @@ type ProxyUpstreamAttemptRepository interface {
 	Update(attempt *domain.ProxyUpstreamAttempt) error
 	ListByProxyRequestID(proxyRequestID uint64) ([]*domain.ProxyUpstreamAttempt, error)
 	GetProviderStats(clientType string, projectID uint64) (map[uint64]*domain.ProviderStats, error)
+	// RecalculateCosts 按 calc 重新计算 [since, until) 内所有 attempt 的成本（until 为零值表示不限），
+	// 并同步更新对应请求的成本，返回更新的 attempt 数和请求数
+	RecalculateCosts(since, until time.Time, calc func(attempt *domain.ProxyUpstreamAttempt) (cost, shadowCost uint64)) (attempts, requests int64, err error)
 }
 
 type SystemSettingRepository interface {
@@ -94,3 +148,51 @@ type AntigravityQuotaRepository interface {
 	// Delete 删除配额
 	Delete(email string) error
 }
+
+type ThoughtSignatureRepository interface {
+	// Upsert 按 (会话, 模型族) 写入最新签名
+	Upsert(sig *domain.ThoughtSignature) error
+	// ListSince 获取 since 之后更新过的签名
+	ListSince(since time.Time) ([]*domain.ThoughtSignature, error)
+	// Delete 删除某个会话和模型族的签名（LRU 淘汰）
+	Delete(sessionID, modelFamily string) error
+	// DeleteBefore 删除 before 之前更新的签名（TTL 过期）
+	DeleteBefore(before time.Time) error
+	// DeleteAll 清空所有签名
+	DeleteAll() error
+}
+
+type SignatureCacheRepository interface {
+	// Get 获取缓存条目，不存在时返回 nil
+	Get(layer, key string) (*domain.SignatureCacheEntry, error)
+	Upsert(entry *domain.SignatureCacheEntry) error
+	Delete(layer, key string) error
+	// Count 统计某一层的条目数
+	Count(layer string) (int, error)
+	// DeleteBefore 删除 before 之前更新的条目（TTL 过期）
+	DeleteBefore(before time.Time) error
+	// Trim 只保留某一层最近更新的 keep 条
+	Trim(layer string, keep int) error
+	// DeleteAll 清空所有条目
+	DeleteAll() error
+}
+
+type ProbeResultRepository interface {
+	Create(result *domain.ProbeResult) error
+	// ListByProvider 获取 Provider 最近的 limit 条探测结果（新的在前）
+	ListByProvider(providerID uint64, limit int) ([]*domain.ProbeResult, error)
+	// Summarize 汇总 since 之后每个 Provider 的探测结果
+	Summarize(since time.Time) (map[uint64]*domain.ProbeSummary, error)
+	// DeleteBefore 删除 before 之前的探测历史
+	DeleteBefore(before time.Time) error
+}
+
+type ModelPriceRepository interface {
+	Create(price *domain.ModelPrice) error
+	Update(price *domain.ModelPrice) error
+	Delete(id uint64) error
+	GetByID(id uint64) (*domain.ModelPrice, error)
+	// GetByModelID 根据模型 ID 查找自定义价格
+	GetByModelID(modelID string) (*domain.ModelPrice, error)
+	List() ([]*domain.ModelPrice, error)
+}
@@ -0,0 +1,68 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+type AdminSessionRepository struct {
+	db *DB
+}
+
+func NewAdminSessionRepository(db *DB) *AdminSessionRepository {
+	return &AdminSessionRepository{db: db}
+}
+
+func (r *AdminSessionRepository) Create(s *domain.AdminSession) error {
+	s.CreatedAt = time.Now()
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO admin_sessions (created_at, user_id, token_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		s.CreatedAt, s.UserID, s.TokenHash, formatTime(s.ExpiresAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	s.ID = uint64(id)
+	return nil
+}
+
+func (r *AdminSessionRepository) GetByTokenHash(tokenHash string) (*domain.AdminSession, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, user_id, token_hash, expires_at FROM admin_sessions WHERE token_hash = ?`, tokenHash)
+	var s domain.AdminSession
+	var expiresAt string
+	err := row.Scan(&s.ID, &s.CreatedAt, &s.UserID, &s.TokenHash, &expiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	s.ExpiresAt, _ = parseTimeString(expiresAt)
+	return &s, nil
+}
+
+func (r *AdminSessionRepository) Delete(tokenHash string) error {
+	_, err := r.db.db.Exec(`DELETE FROM admin_sessions WHERE token_hash = ?`, tokenHash)
+	return err
+}
+
+func (r *AdminSessionRepository) DeleteByUserID(userID uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM admin_sessions WHERE user_id = ?`, userID)
+	return err
+}
+
+func (r *AdminSessionRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.db.Exec(`DELETE FROM admin_sessions WHERE expires_at <= ?`, formatTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
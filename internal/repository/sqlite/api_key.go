@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+type APIKeyRepository struct {
+	db *DB
+}
+
+func NewAPIKeyRepository(db *DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (r *APIKeyRepository) Create(k *domain.APIKey) error {
+	now := time.Now()
+	k.CreatedAt = now
+	k.UpdatedAt = now
+
+	isEnabled := 0
+	if k.IsEnabled {
+		isEnabled = 1
+	}
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO api_keys (created_at, updated_at, name, key_prefix, key_hash, project_id, is_enabled, expires_at, last_used_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		k.CreatedAt, k.UpdatedAt, k.Name, k.KeyPrefix, k.KeyHash, k.ProjectID, isEnabled, formatTimePtr(k.ExpiresAt), formatTimePtr(k.LastUsedAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	k.ID = uint64(id)
+	return nil
+}
+
+func (r *APIKeyRepository) Update(k *domain.APIKey) error {
+	k.UpdatedAt = time.Now()
+	isEnabled := 0
+	if k.IsEnabled {
+		isEnabled = 1
+	}
+	_, err := r.db.db.Exec(
+		`UPDATE api_keys SET updated_at = ?, name = ?, project_id = ?, is_enabled = ?, expires_at = ?, last_used_at = ? WHERE id = ?`,
+		k.UpdatedAt, k.Name, k.ProjectID, isEnabled, formatTimePtr(k.ExpiresAt), formatTimePtr(k.LastUsedAt), k.ID,
+	)
+	return err
+}
+
+func (r *APIKeyRepository) Delete(id uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM api_keys WHERE id = ?`, id)
+	return err
+}
+
+func (r *APIKeyRepository) GetByID(id uint64) (*domain.APIKey, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, name, key_prefix, key_hash, project_id, is_enabled, expires_at, last_used_at FROM api_keys WHERE id = ?`, id)
+	return r.scanAPIKey(row)
+}
+
+func (r *APIKeyRepository) GetByHash(keyHash string) (*domain.APIKey, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, name, key_prefix, key_hash, project_id, is_enabled, expires_at, last_used_at FROM api_keys WHERE key_hash = ?`, keyHash)
+	return r.scanAPIKey(row)
+}
+
+func (r *APIKeyRepository) List() ([]*domain.APIKey, error) {
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, name, key_prefix, key_hash, project_id, is_enabled, expires_at, last_used_at FROM api_keys ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*domain.APIKey, 0)
+	for rows.Next() {
+		k, err := r.scanAPIKeyRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepository) scanAPIKey(row *sql.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var isEnabled int
+	var expiresAt, lastUsedAt sql.NullString
+	err := row.Scan(&k.ID, &k.CreatedAt, &k.UpdatedAt, &k.Name, &k.KeyPrefix, &k.KeyHash, &k.ProjectID, &isEnabled, &expiresAt, &lastUsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	k.IsEnabled = isEnabled == 1
+	k.ExpiresAt = parseNullTimeString(expiresAt)
+	k.LastUsedAt = parseNullTimeString(lastUsedAt)
+	return &k, nil
+}
+
+func (r *APIKeyRepository) scanAPIKeyRows(rows *sql.Rows) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var isEnabled int
+	var expiresAt, lastUsedAt sql.NullString
+	err := rows.Scan(&k.ID, &k.CreatedAt, &k.UpdatedAt, &k.Name, &k.KeyPrefix, &k.KeyHash, &k.ProjectID, &isEnabled, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	k.IsEnabled = isEnabled == 1
+	k.ExpiresAt = parseNullTimeString(expiresAt)
+	k.LastUsedAt = parseNullTimeString(lastUsedAt)
+	return &k, nil
+}
+
+// parseNullTimeString parses a nullable timestamp column into *time.Time
+func parseNullTimeString(s sql.NullString) *time.Time {
+	if !s.Valid || s.String == "" {
+		return nil
+	}
+	t, err := parseTimeString(s.String)
+	if err != nil || t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+type BudgetRepository struct {
+	db *DB
+}
+
+func NewBudgetRepository(db *DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+func (r *BudgetRepository) Create(b *domain.Budget) error {
+	now := time.Now()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+
+	hardLimit, isEnabled := 0, 0
+	if b.HardLimit {
+		hardLimit = 1
+	}
+	if b.IsEnabled {
+		isEnabled = 1
+	}
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO budgets (created_at, updated_at, scope, scope_id, period, limit_micro, warn_percent, hard_limit, timezone, reset_hour, reset_day, is_enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		b.CreatedAt, b.UpdatedAt, b.Scope, b.ScopeID, b.Period, b.Limit, b.WarnPercent, hardLimit, b.Timezone, b.ResetHour, b.ResetDay, isEnabled,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	b.ID = uint64(id)
+	return nil
+}
+
+func (r *BudgetRepository) Update(b *domain.Budget) error {
+	b.UpdatedAt = time.Now()
+	hardLimit, isEnabled := 0, 0
+	if b.HardLimit {
+		hardLimit = 1
+	}
+	if b.IsEnabled {
+		isEnabled = 1
+	}
+	_, err := r.db.db.Exec(
+		`UPDATE budgets SET updated_at = ?, scope = ?, scope_id = ?, period = ?, limit_micro = ?, warn_percent = ?, hard_limit = ?, timezone = ?, reset_hour = ?, reset_day = ?, is_enabled = ? WHERE id = ?`,
+		b.UpdatedAt, b.Scope, b.ScopeID, b.Period, b.Limit, b.WarnPercent, hardLimit, b.Timezone, b.ResetHour, b.ResetDay, isEnabled, b.ID,
+	)
+	return err
+}
+
+func (r *BudgetRepository) Delete(id uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM budgets WHERE id = ?`, id)
+	return err
+}
+
+func (r *BudgetRepository) GetByID(id uint64) (*domain.Budget, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, scope, scope_id, period, limit_micro, warn_percent, hard_limit, timezone, reset_hour, reset_day, is_enabled FROM budgets WHERE id = ?`, id)
+	return r.scanBudget(row)
+}
+
+func (r *BudgetRepository) List() ([]*domain.Budget, error) {
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, scope, scope_id, period, limit_micro, warn_percent, hard_limit, timezone, reset_hour, reset_day, is_enabled FROM budgets ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := make([]*domain.Budget, 0)
+	for rows.Next() {
+		b, err := r.scanBudgetRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// GetSpend sums attempt costs, so failed attempts that were billed upstream count too
+// Times are compared with julianday() because stored timestamps carry their zone offset
+func (r *BudgetRepository) GetSpend(scope domain.BudgetScope, scopeID uint64, since time.Time) (uint64, error) {
+	var query string
+	switch scope {
+	case domain.BudgetScopeProvider:
+		query = `SELECT COALESCE(SUM(cost), 0) FROM proxy_upstream_attempts
+			WHERE provider_id = ? AND julianday(created_at) >= julianday(?)`
+	case domain.BudgetScopeProject:
+		query = `SELECT COALESCE(SUM(a.cost), 0) FROM proxy_upstream_attempts a
+			JOIN proxy_requests r ON r.id = a.proxy_request_id
+			WHERE r.project_id = ? AND julianday(a.created_at) >= julianday(?)`
+	default:
+		return 0, domain.ErrInvalidInput
+	}
+
+	var spent int64
+	if err := r.db.db.QueryRow(query, scopeID, since.UTC().Format("2006-01-02 15:04:05")).Scan(&spent); err != nil {
+		return 0, err
+	}
+	return uint64(spent), nil
+}
+
+func (r *BudgetRepository) scanBudget(row *sql.Row) (*domain.Budget, error) {
+	var b domain.Budget
+	var hardLimit, isEnabled int
+	err := row.Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt, &b.Scope, &b.ScopeID, &b.Period, &b.Limit, &b.WarnPercent, &hardLimit, &b.Timezone, &b.ResetHour, &b.ResetDay, &isEnabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	b.HardLimit = hardLimit == 1
+	b.IsEnabled = isEnabled == 1
+	return &b, nil
+}
+
+func (r *BudgetRepository) scanBudgetRows(rows *sql.Rows) (*domain.Budget, error) {
+	var b domain.Budget
+	var hardLimit, isEnabled int
+	err := rows.Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt, &b.Scope, &b.ScopeID, &b.Period, &b.Limit, &b.WarnPercent, &hardLimit, &b.Timezone, &b.ResetHour, &b.ResetDay, &isEnabled)
+	if err != nil {
+		return nil, err
+	}
+	b.HardLimit = hardLimit == 1
+	b.IsEnabled = isEnabled == 1
+	return &b, nil
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+type CooldownPolicyRepository struct {
+	db *DB
+}
+
+func NewCooldownPolicyRepository(db *DB) repository.CooldownPolicyRepository {
+	return &CooldownPolicyRepository{db: db}
+}
+
+func (r *CooldownPolicyRepository) Create(p *domain.CooldownPolicyConfig) error {
+	now := time.Now()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	isEnabled := 0
+	if p.IsEnabled {
+		isEnabled = 1
+	}
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO cooldown_policies (created_at, updated_at, provider_id, reason, type, base_ms, max_ms, jitter, is_enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.CreatedAt, p.UpdatedAt, p.ProviderID, p.Reason, p.Type, p.Base.Milliseconds(), p.Max.Milliseconds(), p.Jitter, isEnabled,
+	)
+	if err != nil {
+		return duplicatePolicyError(p, err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = uint64(id)
+	return nil
+}
+
+func (r *CooldownPolicyRepository) Update(p *domain.CooldownPolicyConfig) error {
+	p.UpdatedAt = time.Now()
+	isEnabled := 0
+	if p.IsEnabled {
+		isEnabled = 1
+	}
+	_, err := r.db.db.Exec(
+		`UPDATE cooldown_policies SET updated_at = ?, provider_id = ?, reason = ?, type = ?, base_ms = ?, max_ms = ?, jitter = ?, is_enabled = ? WHERE id = ?`,
+		p.UpdatedAt, p.ProviderID, p.Reason, p.Type, p.Base.Milliseconds(), p.Max.Milliseconds(), p.Jitter, isEnabled, p.ID,
+	)
+	return duplicatePolicyError(p, err)
+}
+
+// duplicatePolicyError maps the provider + reason unique index violation to ErrAlreadyExists
+func duplicatePolicyError(p *domain.CooldownPolicyConfig, err error) error {
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: cooldown policy for provider %d and reason %q", domain.ErrAlreadyExists, p.ProviderID, p.Reason)
+	}
+	return err
+}
+
+func (r *CooldownPolicyRepository) Delete(id uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM cooldown_policies WHERE id = ?`, id)
+	return err
+}
+
+func (r *CooldownPolicyRepository) GetByID(id uint64) (*domain.CooldownPolicyConfig, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, provider_id, reason, type, base_ms, max_ms, jitter, is_enabled FROM cooldown_policies WHERE id = ?`, id)
+	return r.scanPolicy(row)
+}
+
+func (r *CooldownPolicyRepository) List() ([]*domain.CooldownPolicyConfig, error) {
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, provider_id, reason, type, base_ms, max_ms, jitter, is_enabled FROM cooldown_policies ORDER BY provider_id, reason`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := make([]*domain.CooldownPolicyConfig, 0)
+	for rows.Next() {
+		p, err := r.scanPolicyRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *CooldownPolicyRepository) scanPolicy(row *sql.Row) (*domain.CooldownPolicyConfig, error) {
+	var p domain.CooldownPolicyConfig
+	var baseMs, maxMs int64
+	var isEnabled int
+	err := row.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.ProviderID, &p.Reason, &p.Type, &baseMs, &maxMs, &p.Jitter, &isEnabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	p.Base = time.Duration(baseMs) * time.Millisecond
+	p.Max = time.Duration(maxMs) * time.Millisecond
+	p.IsEnabled = isEnabled == 1
+	return &p, nil
+}
+
+func (r *CooldownPolicyRepository) scanPolicyRows(rows *sql.Rows) (*domain.CooldownPolicyConfig, error) {
+	var p domain.CooldownPolicyConfig
+	var baseMs, maxMs int64
+	var isEnabled int
+	err := rows.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.ProviderID, &p.Reason, &p.Type, &baseMs, &maxMs, &p.Jitter, &isEnabled)
+	if err != nil {
+		return nil, err
+	}
+	p.Base = time.Duration(baseMs) * time.Millisecond
+	p.Max = time.Duration(maxMs) * time.Millisecond
+	p.IsEnabled = isEnabled == 1
+	return &p, nil
+}
@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestCooldownPolicyRepository_Duplicate(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "maxx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewCooldownPolicyRepository(db)
+
+	newPolicy := func(providerID uint64, reason domain.CooldownReason) *domain.CooldownPolicyConfig {
+		return &domain.CooldownPolicyConfig{ProviderID: providerID, Reason: reason, Type: domain.CooldownPolicyFixed, Base: time.Minute, IsEnabled: true}
+	}
+	first := newPolicy(1, domain.CooldownReasonServerError)
+	if err := repo.Create(first); err != nil {
+		t.Fatal(err)
+	}
+	other := newPolicy(1, domain.CooldownReasonNetworkError)
+	if err := repo.Create(other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Create(newPolicy(1, domain.CooldownReasonServerError)); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("Create duplicate: err = %v, want ErrAlreadyExists", err)
+	}
+	other.Reason = domain.CooldownReasonServerError
+	if err := repo.Update(other); !errors.Is(err, domain.ErrAlreadyExists) {
+		t.Errorf("Update to duplicate: err = %v, want ErrAlreadyExists", err)
+	}
+	if err := repo.Create(newPolicy(2, domain.CooldownReasonServerError)); err != nil {
+		t.Errorf("Create for another provider: %v", err)
+	}
+}
@@ -3,13 +3,18 @@ package sqlite
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/awsl-project/maxx/internal/secret"
+	"github.com/mattn/go-sqlite3"
 )
 
 type DB struct {
 	db *sql.DB
+
+	// keyring encrypts provider secrets; nil stores them as plaintext
+	keyring *secret.Keyring
 }
 
 func NewDB(path string) (*DB, error) {
@@ -30,6 +35,11 @@ func NewDB(path string) (*DB, error) {
 	return d, nil
 }
 
+// SetKeyring enables encryption of provider secrets
+func (d *DB) SetKeyring(kr *secret.Keyring) {
+	d.keyring = kr
+}
+
 func (d *DB) Close() error {
 	return d.db.Close()
 }
@@ -44,7 +54,9 @@ func (d *DB) migrate() error {
 		name TEXT NOT NULL,
 		config TEXT,
 		supported_client_types TEXT,
-		deleted_at DATETIME
+		deleted_at DATETIME,
+		is_subscription INTEGER DEFAULT 0,
+		pricing TEXT
 	);
 
 	CREATE TABLE IF NOT EXISTS projects (
@@ -75,7 +87,10 @@ func (d *DB) migrate() error {
 		provider_id INTEGER NOT NULL,
 		position INTEGER DEFAULT 0,
 		retry_config_id INTEGER DEFAULT 0,
-		model_mapping TEXT
+		model_mapping TEXT,
+		match_rules TEXT,
+		model_mapping_rules TEXT,
+		fallback_models TEXT
 	);
 
 	CREATE TABLE IF NOT EXISTS retry_configs (
@@ -87,7 +102,13 @@ func (d *DB) migrate() error {
 		max_retries INTEGER DEFAULT 3,
 		initial_interval_ms INTEGER DEFAULT 1000,
 		backoff_rate REAL DEFAULT 2.0,
-		max_interval_ms INTEGER DEFAULT 30000
+		max_interval_ms INTEGER DEFAULT 30000,
+		stream_buffer_enabled INTEGER DEFAULT 0,
+		stream_buffer_bytes INTEGER DEFAULT 0,
+		first_byte_timeout_ms INTEGER DEFAULT 0,
+		connect_timeout_ms INTEGER DEFAULT 0,
+		stream_idle_timeout_ms INTEGER DEFAULT 0,
+		total_timeout_ms INTEGER DEFAULT 0
 	);
 
 	CREATE TABLE IF NOT EXISTS routing_strategies (
@@ -125,6 +146,7 @@ func (d *DB) migrate() error {
 		cache_5m_write_count INTEGER DEFAULT 0,
 		cache_1h_write_count INTEGER DEFAULT 0,
 		cost INTEGER DEFAULT 0,
+		shadow_cost INTEGER DEFAULT 0,
 		route_id INTEGER DEFAULT 0,
 		provider_id INTEGER DEFAULT 0,
 		is_stream INTEGER DEFAULT 0
@@ -147,7 +169,10 @@ func (d *DB) migrate() error {
 		cache_5m_write_count INTEGER DEFAULT 0,
 		cache_1h_write_count INTEGER DEFAULT 0,
 		cost INTEGER DEFAULT 0,
-		is_stream INTEGER DEFAULT 0
+		shadow_cost INTEGER DEFAULT 0,
+		is_stream INTEGER DEFAULT 0,
+		hedged INTEGER DEFAULT 0,
+		mapped_model TEXT DEFAULT ''
 	);
 
 	CREATE TABLE IF NOT EXISTS system_settings (
@@ -173,6 +198,20 @@ func (d *DB) migrate() error {
 	CREATE UNIQUE INDEX IF NOT EXISTS idx_cooldowns_provider_client ON cooldowns(provider_id, client_type);
 	CREATE INDEX IF NOT EXISTS idx_cooldowns_until ON cooldowns(until_time);
 
+	CREATE TABLE IF NOT EXISTS cooldown_policies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		provider_id INTEGER NOT NULL DEFAULT 0,
+		reason TEXT NOT NULL,
+		type TEXT NOT NULL,
+		base_ms INTEGER NOT NULL DEFAULT 0,
+		max_ms INTEGER NOT NULL DEFAULT 0,
+		jitter REAL NOT NULL DEFAULT 0,
+		is_enabled INTEGER DEFAULT 1
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_cooldown_policies_provider_reason ON cooldown_policies(provider_id, reason);
+
 	CREATE TABLE IF NOT EXISTS failure_counts (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -200,6 +239,122 @@ func (d *DB) migrate() error {
 		project_id TEXT DEFAULT ''
 	);
 	CREATE UNIQUE INDEX IF NOT EXISTS idx_antigravity_quotas_email ON antigravity_quotas(email);
+
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		name TEXT NOT NULL,
+		key_prefix TEXT NOT NULL DEFAULT '',
+		key_hash TEXT NOT NULL,
+		project_id INTEGER DEFAULT 0,
+		is_enabled INTEGER DEFAULT 1,
+		expires_at DATETIME,
+		last_used_at DATETIME
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
+
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		role TEXT NOT NULL DEFAULT 'viewer',
+		is_enabled INTEGER DEFAULT 1,
+		last_login_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS admin_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		user_id INTEGER NOT NULL,
+		token_hash TEXT NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_sessions_token_hash ON admin_sessions(token_hash);
+	CREATE INDEX IF NOT EXISTS idx_admin_sessions_user ON admin_sessions(user_id);
+
+	CREATE TABLE IF NOT EXISTS rate_limits (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		scope TEXT NOT NULL,
+		scope_id INTEGER NOT NULL DEFAULT 0,
+		rpm INTEGER NOT NULL DEFAULT 0,
+		tpm INTEGER NOT NULL DEFAULT 0,
+		max_concurrency INTEGER NOT NULL DEFAULT 0,
+		queue_timeout_ms INTEGER NOT NULL DEFAULT 0,
+		is_enabled INTEGER DEFAULT 1
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_rate_limits_scope ON rate_limits(scope, scope_id);
+
+	CREATE TABLE IF NOT EXISTS budgets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		scope TEXT NOT NULL,
+		scope_id INTEGER NOT NULL DEFAULT 0,
+		period TEXT NOT NULL,
+		limit_micro INTEGER NOT NULL DEFAULT 0,
+		warn_percent INTEGER NOT NULL DEFAULT 0,
+		hard_limit INTEGER DEFAULT 0,
+		timezone TEXT NOT NULL DEFAULT '',
+		reset_hour INTEGER NOT NULL DEFAULT 0,
+		reset_day INTEGER NOT NULL DEFAULT 0,
+		is_enabled INTEGER DEFAULT 1
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_budgets_scope_period ON budgets(scope, scope_id, period);
+
+	CREATE TABLE IF NOT EXISTS thought_signatures (
+		session_id TEXT NOT NULL,
+		model_family TEXT NOT NULL,
+		signature TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (session_id, model_family)
+	);
+	CREATE INDEX IF NOT EXISTS idx_thought_signatures_updated ON thought_signatures(updated_at);
+
+	CREATE TABLE IF NOT EXISTS signature_cache (
+		layer TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (layer, key)
+	);
+	CREATE INDEX IF NOT EXISTS idx_signature_cache_updated ON signature_cache(layer, updated_at);
+
+	CREATE TABLE IF NOT EXISTS probe_results (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME NOT NULL,
+		provider_id INTEGER NOT NULL,
+		client_type TEXT NOT NULL,
+		success INTEGER NOT NULL DEFAULT 0,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		rejected INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_probe_results_provider ON probe_results(provider_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS model_prices (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		model_id TEXT NOT NULL,
+		input_price_micro INTEGER NOT NULL DEFAULT 0,
+		output_price_micro INTEGER NOT NULL DEFAULT 0,
+		cache_read_price_micro INTEGER NOT NULL DEFAULT 0,
+		cache_5m_write_price_micro INTEGER NOT NULL DEFAULT 0,
+		cache_1h_write_price_micro INTEGER NOT NULL DEFAULT 0,
+		has_1m_context INTEGER NOT NULL DEFAULT 0,
+		context_1m_threshold INTEGER NOT NULL DEFAULT 0,
+		input_premium_num INTEGER NOT NULL DEFAULT 0,
+		input_premium_denom INTEGER NOT NULL DEFAULT 0,
+		output_premium_num INTEGER NOT NULL DEFAULT 0,
+		output_premium_denom INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_model_prices_model ON model_prices(model_id);
 	`
 
 	_, err := d.db.Exec(schema)
@@ -298,6 +453,77 @@ func (d *DB) migrate() error {
 		}
 	}
 
+	// Migration: Add ttfb_ms column to proxy_upstream_attempts if it doesn't exist
+	var hasTTFB bool
+	row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('proxy_upstream_attempts') WHERE name='ttfb_ms'`)
+	row.Scan(&hasTTFB)
+
+	if !hasTTFB {
+		_, err = d.db.Exec(`ALTER TABLE proxy_upstream_attempts ADD COLUMN ttfb_ms INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Migration: Add hedged column to proxy_upstream_attempts if it doesn't exist
+	var hasHedged bool
+	row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('proxy_upstream_attempts') WHERE name='hedged'`)
+	row.Scan(&hasHedged)
+
+	if !hasHedged {
+		_, err = d.db.Exec(`ALTER TABLE proxy_upstream_attempts ADD COLUMN hedged INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Migration: Add match_rules column to routes if it doesn't exist
+	var hasMatchRules bool
+	row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('routes') WHERE name='match_rules'`)
+	row.Scan(&hasMatchRules)
+
+	if !hasMatchRules {
+		_, err = d.db.Exec(`ALTER TABLE routes ADD COLUMN match_rules TEXT`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Migration: Add model_mapping_rules and fallback_models columns to routes if they don't exist
+	for _, column := range []string{"model_mapping_rules", "fallback_models"} {
+		var hasColumn bool
+		row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('routes') WHERE name=?`, column)
+		row.Scan(&hasColumn)
+
+		if !hasColumn {
+			_, err = d.db.Exec(`ALTER TABLE routes ADD COLUMN ` + column + ` TEXT`)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Migration: Add subscription flag, shadow cost, mapped model, provider pricing and probe rejection columns if they don't exist
+	for _, m := range []struct{ table, column, def string }{
+		{"providers", "is_subscription", "INTEGER DEFAULT 0"},
+		{"proxy_requests", "shadow_cost", "INTEGER DEFAULT 0"},
+		{"proxy_upstream_attempts", "shadow_cost", "INTEGER DEFAULT 0"},
+		{"proxy_upstream_attempts", "mapped_model", "TEXT DEFAULT ''"},
+		{"providers", "pricing", "TEXT"},
+		{"probe_results", "rejected", "INTEGER NOT NULL DEFAULT 0"},
+	} {
+		var hasColumn bool
+		row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('`+m.table+`') WHERE name=?`, m.column)
+		row.Scan(&hasColumn)
+
+		if !hasColumn {
+			_, err = d.db.Exec(`ALTER TABLE ` + m.table + ` ADD COLUMN ` + m.column + ` ` + m.def)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Migration: Add status_code column to proxy_requests if it doesn't exist
 	var hasStatusCode bool
 	row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('proxy_requests') WHERE name='status_code'`)
@@ -333,6 +559,46 @@ func (d *DB) migrate() error {
 		}
 	}
 
+	// Migration: Add stream buffering and first byte timeout columns to retry_configs
+	var hasStreamBuffer bool
+	row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('retry_configs') WHERE name='stream_buffer_enabled'`)
+	row.Scan(&hasStreamBuffer)
+
+	if !hasStreamBuffer {
+		_, err = d.db.Exec(`ALTER TABLE retry_configs ADD COLUMN stream_buffer_enabled INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+		_, err = d.db.Exec(`ALTER TABLE retry_configs ADD COLUMN stream_buffer_bytes INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+		_, err = d.db.Exec(`ALTER TABLE retry_configs ADD COLUMN first_byte_timeout_ms INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Migration: Add connect, stream idle and total timeout columns to retry_configs
+	var hasConnectTimeout bool
+	row = d.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('retry_configs') WHERE name='connect_timeout_ms'`)
+	row.Scan(&hasConnectTimeout)
+
+	if !hasConnectTimeout {
+		_, err = d.db.Exec(`ALTER TABLE retry_configs ADD COLUMN connect_timeout_ms INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+		_, err = d.db.Exec(`ALTER TABLE retry_configs ADD COLUMN stream_idle_timeout_ms INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+		_, err = d.db.Exec(`ALTER TABLE retry_configs ADD COLUMN total_timeout_ms INTEGER DEFAULT 0`)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -448,6 +714,12 @@ func itoa(i int) string {
 }
 
 // Helper functions for JSON serialization
+// isUniqueViolation reports whether err was raised by a UNIQUE constraint or index
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
 func toJSON(v interface{}) string {
 	if v == nil {
 		return ""
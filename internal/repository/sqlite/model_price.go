@@ -0,0 +1,103 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+const modelPriceColumns = `id, created_at, updated_at, model_id, input_price_micro, output_price_micro, cache_read_price_micro, cache_5m_write_price_micro, cache_1h_write_price_micro, has_1m_context, context_1m_threshold, input_premium_num, input_premium_denom, output_premium_num, output_premium_denom`
+
+type ModelPriceRepository struct {
+	db *DB
+}
+
+func NewModelPriceRepository(db *DB) repository.ModelPriceRepository {
+	return &ModelPriceRepository{db: db}
+}
+
+func (r *ModelPriceRepository) Create(p *domain.ModelPrice) error {
+	now := time.Now()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO model_prices (created_at, updated_at, model_id, input_price_micro, output_price_micro, cache_read_price_micro, cache_5m_write_price_micro, cache_1h_write_price_micro, has_1m_context, context_1m_threshold, input_premium_num, input_premium_denom, output_premium_num, output_premium_denom) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.CreatedAt, p.UpdatedAt, p.ModelID, p.InputPriceMicro, p.OutputPriceMicro, p.CacheReadPriceMicro, p.Cache5mWritePriceMicro, p.Cache1hWritePriceMicro, p.Has1MContext, p.Context1MThreshold, p.InputPremiumNum, p.InputPremiumDenom, p.OutputPremiumNum, p.OutputPremiumDenom,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = uint64(id)
+	return nil
+}
+
+func (r *ModelPriceRepository) Update(p *domain.ModelPrice) error {
+	p.UpdatedAt = time.Now()
+	_, err := r.db.db.Exec(
+		`UPDATE model_prices SET updated_at = ?, model_id = ?, input_price_micro = ?, output_price_micro = ?, cache_read_price_micro = ?, cache_5m_write_price_micro = ?, cache_1h_write_price_micro = ?, has_1m_context = ?, context_1m_threshold = ?, input_premium_num = ?, input_premium_denom = ?, output_premium_num = ?, output_premium_denom = ? WHERE id = ?`,
+		p.UpdatedAt, p.ModelID, p.InputPriceMicro, p.OutputPriceMicro, p.CacheReadPriceMicro, p.Cache5mWritePriceMicro, p.Cache1hWritePriceMicro, p.Has1MContext, p.Context1MThreshold, p.InputPremiumNum, p.InputPremiumDenom, p.OutputPremiumNum, p.OutputPremiumDenom, p.ID,
+	)
+	return err
+}
+
+func (r *ModelPriceRepository) Delete(id uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM model_prices WHERE id = ?`, id)
+	return err
+}
+
+func (r *ModelPriceRepository) GetByID(id uint64) (*domain.ModelPrice, error) {
+	row := r.db.db.QueryRow(`SELECT `+modelPriceColumns+` FROM model_prices WHERE id = ?`, id)
+	return r.scanPrice(row)
+}
+
+func (r *ModelPriceRepository) GetByModelID(modelID string) (*domain.ModelPrice, error) {
+	row := r.db.db.QueryRow(`SELECT `+modelPriceColumns+` FROM model_prices WHERE model_id = ?`, modelID)
+	return r.scanPrice(row)
+}
+
+func (r *ModelPriceRepository) List() ([]*domain.ModelPrice, error) {
+	rows, err := r.db.db.Query(`SELECT ` + modelPriceColumns + ` FROM model_prices ORDER BY model_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := make([]*domain.ModelPrice, 0)
+	for rows.Next() {
+		p, err := r.scanPriceRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+func (r *ModelPriceRepository) scanPrice(row *sql.Row) (*domain.ModelPrice, error) {
+	var p domain.ModelPrice
+	err := row.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.ModelID, &p.InputPriceMicro, &p.OutputPriceMicro, &p.CacheReadPriceMicro, &p.Cache5mWritePriceMicro, &p.Cache1hWritePriceMicro, &p.Has1MContext, &p.Context1MThreshold, &p.InputPremiumNum, &p.InputPremiumDenom, &p.OutputPremiumNum, &p.OutputPremiumDenom)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ModelPriceRepository) scanPriceRows(rows *sql.Rows) (*domain.ModelPrice, error) {
+	var p domain.ModelPrice
+	err := rows.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &p.ModelID, &p.InputPriceMicro, &p.OutputPriceMicro, &p.CacheReadPriceMicro, &p.Cache5mWritePriceMicro, &p.Cache1hWritePriceMicro, &p.Has1MContext, &p.Context1MThreshold, &p.InputPremiumNum, &p.InputPremiumDenom, &p.OutputPremiumNum, &p.OutputPremiumDenom)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
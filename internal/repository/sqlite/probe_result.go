@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+type ProbeResultRepository struct {
+	db *DB
+}
+
+func NewProbeResultRepository(db *DB) repository.ProbeResultRepository {
+	return &ProbeResultRepository{db: db}
+}
+
+func (r *ProbeResultRepository) Create(p *domain.ProbeResult) error {
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO probe_results (created_at, provider_id, client_type, success, status_code, latency_ms, error, rejected) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		formatTime(p.CreatedAt), p.ProviderID, p.ClientType, p.Success, p.StatusCode, p.Latency.Milliseconds(), p.Error, p.Rejected,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	p.ID = uint64(id)
+	return nil
+}
+
+func (r *ProbeResultRepository) ListByProvider(providerID uint64, limit int) ([]*domain.ProbeResult, error) {
+	rows, err := r.db.db.Query(
+		`SELECT id, created_at, provider_id, client_type, success, status_code, latency_ms, error, rejected
+		 FROM probe_results WHERE provider_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		providerID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]*domain.ProbeResult, 0)
+	for rows.Next() {
+		p := &domain.ProbeResult{}
+		var createdAt string
+		var latencyMs int64
+		if err := rows.Scan(&p.ID, &createdAt, &p.ProviderID, &p.ClientType, &p.Success, &p.StatusCode, &latencyMs, &p.Error, &p.Rejected); err != nil {
+			return nil, err
+		}
+		p.CreatedAt, _ = parseTimeString(createdAt)
+		p.Latency = time.Duration(latencyMs) * time.Millisecond
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+func (r *ProbeResultRepository) Summarize(since time.Time) (map[uint64]*domain.ProbeSummary, error) {
+	rows, err := r.db.db.Query(
+		`SELECT p.provider_id, COUNT(*) - SUM(p.rejected), SUM(p.success), MAX(p.created_at),
+		        COALESCE((SELECT l.success FROM probe_results l WHERE l.provider_id = p.provider_id AND l.rejected = 0 ORDER BY l.created_at DESC, l.id DESC LIMIT 1), 0)
+		 FROM probe_results p WHERE p.created_at >= ? GROUP BY p.provider_id`,
+		formatTime(since),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summaries := make(map[uint64]*domain.ProbeSummary)
+	for rows.Next() {
+		s := &domain.ProbeSummary{}
+		var lastProbeAt string
+		if err := rows.Scan(&s.ProviderID, &s.Total, &s.Succeeded, &lastProbeAt, &s.LastSuccess); err != nil {
+			return nil, err
+		}
+		s.LastProbeAt, _ = parseTimeString(lastProbeAt)
+		summaries[s.ProviderID] = s
+	}
+	return summaries, rows.Err()
+}
+
+func (r *ProbeResultRepository) DeleteBefore(before time.Time) error {
+	_, err := r.db.db.Exec(`DELETE FROM probe_results WHERE created_at < ?`, formatTime(before))
+	return err
+}
@@ -0,0 +1,51 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestProbeResultRepository_SummarizeSkipsRejected(t *testing.T) {
+	db, err := NewDB(filepath.Join(t.TempDir(), "maxx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo := NewProbeResultRepository(db)
+
+	start := time.Now().Add(-time.Hour)
+	for i, r := range []domain.ProbeResult{
+		{ProviderID: 1, Success: true},
+		{ProviderID: 1, Success: false},
+		{ProviderID: 1, Success: false, Rejected: true, StatusCode: 404},
+		{ProviderID: 2, Success: false, Rejected: true, StatusCode: 400},
+	} {
+		r.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		r.ClientType = domain.ClientTypeClaude
+		if err := repo.Create(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	summaries, err := repo.Summarize(start.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := summaries[1]; s == nil || s.Total != 2 || s.Succeeded != 1 || s.LastSuccess {
+		t.Errorf("provider 1 summary = %+v, want 2 probes, 1 success, last failed", s)
+	}
+	if s := summaries[2]; s == nil || s.Total != 0 || s.Succeeded != 0 {
+		t.Errorf("provider 2 summary = %+v, want no counted probes", s)
+	}
+
+	results, err := repo.ListByProvider(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 || !results[0].Rejected || results[1].Rejected {
+		t.Errorf("listed results = %+v, want the newest rejected", results)
+	}
+}
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/awsl-project/maxx/internal/domain"
@@ -20,9 +21,14 @@ func (r *ProviderRepository) Create(p *domain.Provider) error {
 	p.CreatedAt = now
 	p.UpdatedAt = now
 
+	config, err := r.db.encryptProviderConfig(p.Config)
+	if err != nil {
+		return err
+	}
+
 	result, err := r.db.db.Exec(
-		`INSERT INTO providers (created_at, updated_at, type, name, config, supported_client_types) VALUES (?, ?, ?, ?, ?, ?)`,
-		p.CreatedAt, p.UpdatedAt, p.Type, p.Name, toJSON(p.Config), toJSON(p.SupportedClientTypes),
+		`INSERT INTO providers (created_at, updated_at, type, name, config, supported_client_types, is_subscription, pricing) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.CreatedAt, p.UpdatedAt, p.Type, p.Name, toJSON(config), toJSON(p.SupportedClientTypes), p.IsSubscription, toJSON(p.Pricing),
 	)
 	if err != nil {
 		return err
@@ -38,9 +44,13 @@ func (r *ProviderRepository) Create(p *domain.Provider) error {
 
 func (r *ProviderRepository) Update(p *domain.Provider) error {
 	p.UpdatedAt = time.Now()
-	_, err := r.db.db.Exec(
-		`UPDATE providers SET updated_at = ?, type = ?, name = ?, config = ?, supported_client_types = ? WHERE id = ?`,
-		p.UpdatedAt, p.Type, p.Name, toJSON(p.Config), toJSON(p.SupportedClientTypes), p.ID,
+	config, err := r.db.encryptProviderConfig(p.Config)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.db.Exec(
+		`UPDATE providers SET updated_at = ?, type = ?, name = ?, config = ?, supported_client_types = ?, is_subscription = ?, pricing = ? WHERE id = ?`,
+		p.UpdatedAt, p.Type, p.Name, toJSON(config), toJSON(p.SupportedClientTypes), p.IsSubscription, toJSON(p.Pricing), p.ID,
 	)
 	return err
 }
@@ -52,12 +62,12 @@ func (r *ProviderRepository) Delete(id uint64) error {
 }
 
 func (r *ProviderRepository) GetByID(id uint64) (*domain.Provider, error) {
-	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, deleted_at, type, name, config, supported_client_types FROM providers WHERE id = ?`, id)
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, deleted_at, type, name, config, supported_client_types, COALESCE(is_subscription, 0), COALESCE(pricing, '') FROM providers WHERE id = ?`, id)
 	return r.scanProvider(row)
 }
 
 func (r *ProviderRepository) List() ([]*domain.Provider, error) {
-	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, deleted_at, type, name, config, supported_client_types FROM providers WHERE deleted_at IS NULL ORDER BY id`)
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, deleted_at, type, name, config, supported_client_types, COALESCE(is_subscription, 0), COALESCE(pricing, '') FROM providers WHERE deleted_at IS NULL ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
@@ -76,9 +86,9 @@ func (r *ProviderRepository) List() ([]*domain.Provider, error) {
 
 func (r *ProviderRepository) scanProvider(row *sql.Row) (*domain.Provider, error) {
 	var p domain.Provider
-	var configJSON, typesJSON string
+	var configJSON, typesJSON, pricingJSON string
 	var deletedAt sql.NullTime
-	err := row.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &deletedAt, &p.Type, &p.Name, &configJSON, &typesJSON)
+	err := row.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &deletedAt, &p.Type, &p.Name, &configJSON, &typesJSON, &p.IsSubscription, &pricingJSON)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrNotFound
@@ -89,15 +99,19 @@ func (r *ProviderRepository) scanProvider(row *sql.Row) (*domain.Provider, error
 		p.DeletedAt = &deletedAt.Time
 	}
 	p.Config = fromJSON[*domain.ProviderConfig](configJSON)
+	if err := r.db.decryptProviderConfig(p.Config); err != nil {
+		return nil, fmt.Errorf("provider %d: %w", p.ID, err)
+	}
 	p.SupportedClientTypes = fromJSON[[]domain.ClientType](typesJSON)
+	p.Pricing = fromJSON[*domain.ProviderPricing](pricingJSON)
 	return &p, nil
 }
 
 func (r *ProviderRepository) scanProviderRows(rows *sql.Rows) (*domain.Provider, error) {
 	var p domain.Provider
-	var configJSON, typesJSON string
+	var configJSON, typesJSON, pricingJSON string
 	var deletedAt sql.NullTime
-	err := rows.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &deletedAt, &p.Type, &p.Name, &configJSON, &typesJSON)
+	err := rows.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt, &deletedAt, &p.Type, &p.Name, &configJSON, &typesJSON, &p.IsSubscription, &pricingJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +119,10 @@ func (r *ProviderRepository) scanProviderRows(rows *sql.Rows) (*domain.Provider,
 		p.DeletedAt = &deletedAt.Time
 	}
 	p.Config = fromJSON[*domain.ProviderConfig](configJSON)
+	if err := r.db.decryptProviderConfig(p.Config); err != nil {
+		return nil, fmt.Errorf("provider %d: %w", p.ID, err)
+	}
 	p.SupportedClientTypes = fromJSON[[]domain.ClientType](typesJSON)
+	p.Pricing = fromJSON[*domain.ProviderPricing](pricingJSON)
 	return &p, nil
 }
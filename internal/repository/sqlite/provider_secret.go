@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/secret"
+)
+
+// providerSecrets returns pointers to the secret fields of a provider config
+func providerSecrets(cfg *domain.ProviderConfig) []*string {
+	if cfg == nil {
+		return nil
+	}
+	var fields []*string
+	if cfg.Custom != nil {
+		fields = append(fields, &cfg.Custom.APIKey)
+	}
+	if cfg.Antigravity != nil {
+		fields = append(fields, &cfg.Antigravity.RefreshToken)
+	}
+	return fields
+}
+
+// copyProviderConfig returns a copy whose secret fields can be modified
+// without touching the caller's (possibly cached) config
+func copyProviderConfig(cfg *domain.ProviderConfig) *domain.ProviderConfig {
+	if cfg == nil {
+		return nil
+	}
+	out := &domain.ProviderConfig{}
+	if cfg.Custom != nil {
+		custom := *cfg.Custom
+		out.Custom = &custom
+	}
+	if cfg.Antigravity != nil {
+		antigravity := *cfg.Antigravity
+		out.Antigravity = &antigravity
+	}
+	return out
+}
+
+// encryptProviderConfig returns a copy of cfg with secret fields encrypted
+func (d *DB) encryptProviderConfig(cfg *domain.ProviderConfig) (*domain.ProviderConfig, error) {
+	if d.keyring == nil || cfg == nil {
+		return cfg, nil
+	}
+	out := copyProviderConfig(cfg)
+	for _, field := range providerSecrets(out) {
+		enc, err := d.keyring.Encrypt(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = enc
+	}
+	return out, nil
+}
+
+// decryptProviderConfig decrypts secret fields in place
+func (d *DB) decryptProviderConfig(cfg *domain.ProviderConfig) error {
+	for _, field := range providerSecrets(cfg) {
+		if !secret.IsEncrypted(*field) {
+			continue
+		}
+		if d.keyring == nil {
+			return fmt.Errorf("provider secrets are encrypted but no master key is configured")
+		}
+		dec, err := d.keyring.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+		*field = dec
+	}
+	return nil
+}
+
+// EncryptProviderSecrets encrypts plaintext secrets left over from before encryption was enabled
+// Returns the number of rows rewritten
+func (d *DB) EncryptProviderSecrets() (int, error) {
+	if d.keyring == nil {
+		return 0, nil
+	}
+	return d.rewriteProviderConfigs(func(cfg *domain.ProviderConfig) (bool, error) {
+		changed := false
+		for _, field := range providerSecrets(cfg) {
+			if *field == "" || secret.IsEncrypted(*field) {
+				continue
+			}
+			enc, err := d.keyring.Encrypt(*field)
+			if err != nil {
+				return false, err
+			}
+			*field = enc
+			changed = true
+		}
+		return changed, nil
+	})
+}
+
+// RotateProviderSecrets rewraps every provider secret with next's master key
+// Plaintext secrets are encrypted with next. On success the DB switches to next.
+func (d *DB) RotateProviderSecrets(next *secret.Keyring) (int, error) {
+	current := d.keyring
+	count, err := d.rewriteProviderConfigs(func(cfg *domain.ProviderConfig) (bool, error) {
+		changed := false
+		for _, field := range providerSecrets(cfg) {
+			if *field == "" {
+				continue
+			}
+			var value string
+			var err error
+			if secret.IsEncrypted(*field) {
+				if current == nil {
+					return false, fmt.Errorf("provider secrets are encrypted but no master key is configured")
+				}
+				value, err = current.Rewrap(*field, next)
+			} else {
+				value, err = next.Encrypt(*field)
+			}
+			if err != nil {
+				return false, err
+			}
+			*field = value
+			changed = true
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	d.keyring = next
+	return count, nil
+}
+
+// rewriteProviderConfigs applies fn to the stored config of every provider (including
+// soft-deleted ones) inside a single transaction, writing back rows fn reports as changed
+func (d *DB) rewriteProviderConfigs(fn func(cfg *domain.ProviderConfig) (bool, error)) (int, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, config FROM providers`)
+	if err != nil {
+		return 0, err
+	}
+	configs := make(map[uint64]string)
+	for rows.Next() {
+		var id uint64
+		var configJSON string
+		if err := rows.Scan(&id, &configJSON); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		configs[id] = configJSON
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for id, configJSON := range configs {
+		cfg := fromJSON[*domain.ProviderConfig](configJSON)
+		changed, err := fn(cfg)
+		if err != nil {
+			return 0, fmt.Errorf("provider %d: %w", id, err)
+		}
+		if !changed {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE providers SET config = ? WHERE id = ?`, toJSON(cfg), id); err != nil {
+			return 0, err
+		}
+		count++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -22,8 +22,8 @@ func (r *ProxyUpstreamAttemptRepository) Create(a *domain.ProxyUpstreamAttempt)
 	a.UpdatedAt = now
 
 	result, err := r.db.db.Exec(
-		`INSERT INTO proxy_upstream_attempts (created_at, updated_at, start_time, end_time, duration_ms, status, proxy_request_id, is_stream, request_info, response_info, route_id, provider_id, input_token_count, output_token_count, cache_read_count, cache_write_count, cache_5m_write_count, cache_1h_write_count, cost) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		a.CreatedAt, a.UpdatedAt, a.StartTime, a.EndTime, a.Duration.Milliseconds(), a.Status, a.ProxyRequestID, a.IsStream, toJSON(a.RequestInfo), toJSON(a.ResponseInfo), a.RouteID, a.ProviderID, a.InputTokenCount, a.OutputTokenCount, a.CacheReadCount, a.CacheWriteCount, a.Cache5mWriteCount, a.Cache1hWriteCount, a.Cost,
+		`INSERT INTO proxy_upstream_attempts (created_at, updated_at, start_time, end_time, duration_ms, ttfb_ms, status, proxy_request_id, is_stream, hedged, request_info, response_info, route_id, provider_id, mapped_model, input_token_count, output_token_count, cache_read_count, cache_write_count, cache_5m_write_count, cache_1h_write_count, cost, shadow_cost) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		a.CreatedAt, a.UpdatedAt, a.StartTime, a.EndTime, a.Duration.Milliseconds(), a.TTFB.Milliseconds(), a.Status, a.ProxyRequestID, a.IsStream, a.Hedged, toJSON(a.RequestInfo), toJSON(a.ResponseInfo), a.RouteID, a.ProviderID, a.MappedModel, a.InputTokenCount, a.OutputTokenCount, a.CacheReadCount, a.CacheWriteCount, a.Cache5mWriteCount, a.Cache1hWriteCount, a.Cost, a.ShadowCost,
 	)
 	if err != nil {
 		return err
@@ -40,14 +40,14 @@ func (r *ProxyUpstreamAttemptRepository) Create(a *domain.ProxyUpstreamAttempt)
 func (r *ProxyUpstreamAttemptRepository) Update(a *domain.ProxyUpstreamAttempt) error {
 	a.UpdatedAt = time.Now()
 	_, err := r.db.db.Exec(
-		`UPDATE proxy_upstream_attempts SET updated_at = ?, start_time = ?, end_time = ?, duration_ms = ?, status = ?, is_stream = ?, request_info = ?, response_info = ?, route_id = ?, provider_id = ?, input_token_count = ?, output_token_count = ?, cache_read_count = ?, cache_write_count = ?, cache_5m_write_count = ?, cache_1h_write_count = ?, cost = ? WHERE id = ?`,
-		a.UpdatedAt, a.StartTime, a.EndTime, a.Duration.Milliseconds(), a.Status, a.IsStream, toJSON(a.RequestInfo), toJSON(a.ResponseInfo), a.RouteID, a.ProviderID, a.InputTokenCount, a.OutputTokenCount, a.CacheReadCount, a.CacheWriteCount, a.Cache5mWriteCount, a.Cache1hWriteCount, a.Cost, a.ID,
+		`UPDATE proxy_upstream_attempts SET updated_at = ?, start_time = ?, end_time = ?, duration_ms = ?, ttfb_ms = ?, status = ?, is_stream = ?, hedged = ?, request_info = ?, response_info = ?, route_id = ?, provider_id = ?, mapped_model = ?, input_token_count = ?, output_token_count = ?, cache_read_count = ?, cache_write_count = ?, cache_5m_write_count = ?, cache_1h_write_count = ?, cost = ?, shadow_cost = ? WHERE id = ?`,
+		a.UpdatedAt, a.StartTime, a.EndTime, a.Duration.Milliseconds(), a.TTFB.Milliseconds(), a.Status, a.IsStream, a.Hedged, toJSON(a.RequestInfo), toJSON(a.ResponseInfo), a.RouteID, a.ProviderID, a.MappedModel, a.InputTokenCount, a.OutputTokenCount, a.CacheReadCount, a.CacheWriteCount, a.Cache5mWriteCount, a.Cache1hWriteCount, a.Cost, a.ShadowCost, a.ID,
 	)
 	return err
 }
 
 func (r *ProxyUpstreamAttemptRepository) ListByProxyRequestID(proxyRequestID uint64) ([]*domain.ProxyUpstreamAttempt, error) {
-	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, start_time, end_time, duration_ms, status, proxy_request_id, is_stream, request_info, response_info, route_id, provider_id, input_token_count, output_token_count, cache_read_count, cache_write_count, cache_5m_write_count, cache_1h_write_count, cost FROM proxy_upstream_attempts WHERE proxy_request_id = ? ORDER BY id`, proxyRequestID)
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, start_time, end_time, duration_ms, ttfb_ms, status, proxy_request_id, is_stream, hedged, request_info, response_info, route_id, provider_id, COALESCE(mapped_model, ''), input_token_count, output_token_count, cache_read_count, cache_write_count, cache_5m_write_count, cache_1h_write_count, cost, shadow_cost FROM proxy_upstream_attempts WHERE proxy_request_id = ? ORDER BY id`, proxyRequestID)
 	if err != nil {
 		return nil, err
 	}
@@ -58,8 +58,8 @@ func (r *ProxyUpstreamAttemptRepository) ListByProxyRequestID(proxyRequestID uin
 		var a domain.ProxyUpstreamAttempt
 		var reqInfoJSON, respInfoJSON string
 		var startTime, endTime sql.NullTime
-		var durationMs int64
-		err := rows.Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt, &startTime, &endTime, &durationMs, &a.Status, &a.ProxyRequestID, &a.IsStream, &reqInfoJSON, &respInfoJSON, &a.RouteID, &a.ProviderID, &a.InputTokenCount, &a.OutputTokenCount, &a.CacheReadCount, &a.CacheWriteCount, &a.Cache5mWriteCount, &a.Cache1hWriteCount, &a.Cost)
+		var durationMs, ttfbMs int64
+		err := rows.Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt, &startTime, &endTime, &durationMs, &ttfbMs, &a.Status, &a.ProxyRequestID, &a.IsStream, &a.Hedged, &reqInfoJSON, &respInfoJSON, &a.RouteID, &a.ProviderID, &a.MappedModel, &a.InputTokenCount, &a.OutputTokenCount, &a.CacheReadCount, &a.CacheWriteCount, &a.Cache5mWriteCount, &a.Cache1hWriteCount, &a.Cost, &a.ShadowCost)
 		if err != nil {
 			return nil, err
 		}
@@ -70,6 +70,7 @@ func (r *ProxyUpstreamAttemptRepository) ListByProxyRequestID(proxyRequestID uin
 			a.EndTime = endTime.Time
 		}
 		a.Duration = time.Duration(durationMs) * time.Millisecond
+		a.TTFB = time.Duration(ttfbMs) * time.Millisecond
 		a.RequestInfo = fromJSON[*domain.RequestInfo](reqInfoJSON)
 		a.ResponseInfo = fromJSON[*domain.ResponseInfo](respInfoJSON)
 		attempts = append(attempts, &a)
@@ -77,6 +78,83 @@ func (r *ProxyUpstreamAttemptRepository) ListByProxyRequestID(proxyRequestID uin
 	return attempts, rows.Err()
 }
 
+// RecalculateCosts re-prices every attempt created in [since, until) inside a single transaction.
+// Attempts recorded before mapped_model existed fall back to the request's model.
+// Requests with a changed attempt get their cost re-summed over all their attempts.
+func (r *ProxyUpstreamAttemptRepository) RecalculateCosts(since, until time.Time, calc func(a *domain.ProxyUpstreamAttempt) (uint64, uint64)) (int64, int64, error) {
+	query := `SELECT a.id, a.proxy_request_id, a.provider_id, COALESCE(NULLIF(a.mapped_model, ''), r.request_model, ''), a.input_token_count, a.output_token_count, a.cache_read_count, a.cache_write_count, a.cache_5m_write_count, a.cache_1h_write_count, a.cost, a.shadow_cost
+		FROM proxy_upstream_attempts a
+		LEFT JOIN proxy_requests r ON r.id = a.proxy_request_id
+		WHERE julianday(a.created_at) >= julianday(?)`
+	args := []interface{}{since.UTC().Format("2006-01-02 15:04:05")}
+	if !until.IsZero() {
+		query += ` AND julianday(a.created_at) < julianday(?)`
+		args = append(args, until.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	tx, err := r.db.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	attempts := make([]*domain.ProxyUpstreamAttempt, 0)
+	for rows.Next() {
+		var a domain.ProxyUpstreamAttempt
+		if err := rows.Scan(&a.ID, &a.ProxyRequestID, &a.ProviderID, &a.MappedModel, &a.InputTokenCount, &a.OutputTokenCount, &a.CacheReadCount, &a.CacheWriteCount, &a.Cache5mWriteCount, &a.Cache1hWriteCount, &a.Cost, &a.ShadowCost); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		attempts = append(attempts, &a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	var updatedAttempts, updatedRequests int64
+	changedRequests := make([]uint64, 0)
+	seen := make(map[uint64]bool)
+	for _, a := range attempts {
+		cost, shadowCost := calc(a)
+		if cost == a.Cost && shadowCost == a.ShadowCost {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE proxy_upstream_attempts SET cost = ?, shadow_cost = ? WHERE id = ?`, cost, shadowCost, a.ID); err != nil {
+			return 0, 0, err
+		}
+		updatedAttempts++
+		if !seen[a.ProxyRequestID] {
+			seen[a.ProxyRequestID] = true
+			changedRequests = append(changedRequests, a.ProxyRequestID)
+		}
+	}
+
+	// A request costs the sum of all its attempts
+	for _, id := range changedRequests {
+		result, err := tx.Exec(
+			`UPDATE proxy_requests SET
+				cost = (SELECT COALESCE(SUM(cost), 0) FROM proxy_upstream_attempts WHERE proxy_request_id = ?),
+				shadow_cost = (SELECT COALESCE(SUM(shadow_cost), 0) FROM proxy_upstream_attempts WHERE proxy_request_id = ?)
+			WHERE id = ?`,
+			id, id, id,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+		n, _ := result.RowsAffected()
+		updatedRequests += n
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return updatedAttempts, updatedRequests, nil
+}
+
 // GetProviderStats returns aggregated statistics per provider, optionally filtered by client type and project ID
 func (r *ProxyUpstreamAttemptRepository) GetProviderStats(clientType string, projectID uint64) (map[uint64]*domain.ProviderStats, error) {
 	var query string
@@ -109,7 +187,8 @@ func (r *ProxyUpstreamAttemptRepository) GetProviderStats(clientType string, pro
 				COALESCE(SUM(a.output_token_count), 0) as total_output_tokens,
 				COALESCE(SUM(a.cache_read_count), 0) as total_cache_read,
 				COALESCE(SUM(a.cache_write_count), 0) as total_cache_write,
-				COALESCE(SUM(a.cost), 0) as total_cost
+				COALESCE(SUM(a.cost), 0) as total_cost,
+				COALESCE(SUM(a.shadow_cost), 0) as total_shadow_cost
 			FROM proxy_upstream_attempts a
 			INNER JOIN proxy_requests r ON a.proxy_request_id = r.id
 			WHERE ` + joinConditions(conditions) + `
@@ -127,7 +206,8 @@ func (r *ProxyUpstreamAttemptRepository) GetProviderStats(clientType string, pro
 				COALESCE(SUM(output_token_count), 0) as total_output_tokens,
 				COALESCE(SUM(cache_read_count), 0) as total_cache_read,
 				COALESCE(SUM(cache_write_count), 0) as total_cache_write,
-				COALESCE(SUM(cost), 0) as total_cost
+				COALESCE(SUM(cost), 0) as total_cost,
+				COALESCE(SUM(shadow_cost), 0) as total_shadow_cost
 			FROM proxy_upstream_attempts
 			WHERE provider_id > 0
 			GROUP BY provider_id
@@ -154,6 +234,7 @@ func (r *ProxyUpstreamAttemptRepository) GetProviderStats(clientType string, pro
 			&s.TotalCacheRead,
 			&s.TotalCacheWrite,
 			&s.TotalCost,
+			&s.TotalShadowCost,
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+type RateLimitRepository struct {
+	db *DB
+}
+
+func NewRateLimitRepository(db *DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+func (r *RateLimitRepository) Create(l *domain.RateLimit) error {
+	now := time.Now()
+	l.CreatedAt = now
+	l.UpdatedAt = now
+
+	isEnabled := 0
+	if l.IsEnabled {
+		isEnabled = 1
+	}
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO rate_limits (created_at, updated_at, scope, scope_id, rpm, tpm, max_concurrency, queue_timeout_ms, is_enabled) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		l.CreatedAt, l.UpdatedAt, l.Scope, l.ScopeID, l.RPM, l.TPM, l.MaxConcurrency, l.QueueTimeout.Milliseconds(), isEnabled,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	l.ID = uint64(id)
+	return nil
+}
+
+func (r *RateLimitRepository) Update(l *domain.RateLimit) error {
+	l.UpdatedAt = time.Now()
+	isEnabled := 0
+	if l.IsEnabled {
+		isEnabled = 1
+	}
+	_, err := r.db.db.Exec(
+		`UPDATE rate_limits SET updated_at = ?, scope = ?, scope_id = ?, rpm = ?, tpm = ?, max_concurrency = ?, queue_timeout_ms = ?, is_enabled = ? WHERE id = ?`,
+		l.UpdatedAt, l.Scope, l.ScopeID, l.RPM, l.TPM, l.MaxConcurrency, l.QueueTimeout.Milliseconds(), isEnabled, l.ID,
+	)
+	return err
+}
+
+func (r *RateLimitRepository) Delete(id uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM rate_limits WHERE id = ?`, id)
+	return err
+}
+
+func (r *RateLimitRepository) GetByID(id uint64) (*domain.RateLimit, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, scope, scope_id, rpm, tpm, max_concurrency, queue_timeout_ms, is_enabled FROM rate_limits WHERE id = ?`, id)
+	return r.scanRateLimit(row)
+}
+
+func (r *RateLimitRepository) List() ([]*domain.RateLimit, error) {
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, scope, scope_id, rpm, tpm, max_concurrency, queue_timeout_ms, is_enabled FROM rate_limits ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	limits := make([]*domain.RateLimit, 0)
+	for rows.Next() {
+		l, err := r.scanRateLimitRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
+
+func (r *RateLimitRepository) scanRateLimit(row *sql.Row) (*domain.RateLimit, error) {
+	var l domain.RateLimit
+	var queueTimeoutMs int64
+	var isEnabled int
+	err := row.Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt, &l.Scope, &l.ScopeID, &l.RPM, &l.TPM, &l.MaxConcurrency, &queueTimeoutMs, &isEnabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	l.QueueTimeout = time.Duration(queueTimeoutMs) * time.Millisecond
+	l.IsEnabled = isEnabled == 1
+	return &l, nil
+}
+
+func (r *RateLimitRepository) scanRateLimitRows(rows *sql.Rows) (*domain.RateLimit, error) {
+	var l domain.RateLimit
+	var queueTimeoutMs int64
+	var isEnabled int
+	err := rows.Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt, &l.Scope, &l.ScopeID, &l.RPM, &l.TPM, &l.MaxConcurrency, &queueTimeoutMs, &isEnabled)
+	if err != nil {
+		return nil, err
+	}
+	l.QueueTimeout = time.Duration(queueTimeoutMs) * time.Millisecond
+	l.IsEnabled = isEnabled == 1
+	return &l, nil
+}
@@ -24,10 +24,14 @@ func (r *RetryConfigRepository) Create(c *domain.RetryConfig) error {
 	if c.IsDefault {
 		isDefault = 1
 	}
+	streamBuffer := 0
+	if c.StreamBufferEnabled {
+		streamBuffer = 1
+	}
 
 	result, err := r.db.db.Exec(
-		`INSERT INTO retry_configs (created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		c.CreatedAt, c.UpdatedAt, c.Name, isDefault, c.MaxRetries, c.InitialInterval.Milliseconds(), c.BackoffRate, c.MaxInterval.Milliseconds(),
+		`INSERT INTO retry_configs (created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms, stream_buffer_enabled, stream_buffer_bytes, first_byte_timeout_ms, connect_timeout_ms, stream_idle_timeout_ms, total_timeout_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.CreatedAt, c.UpdatedAt, c.Name, isDefault, c.MaxRetries, c.InitialInterval.Milliseconds(), c.BackoffRate, c.MaxInterval.Milliseconds(), streamBuffer, c.StreamBufferBytes, c.FirstByteTimeout.Milliseconds(), c.ConnectTimeout.Milliseconds(), c.StreamIdleTimeout.Milliseconds(), c.TotalTimeout.Milliseconds(),
 	)
 	if err != nil {
 		return err
@@ -47,9 +51,13 @@ func (r *RetryConfigRepository) Update(c *domain.RetryConfig) error {
 	if c.IsDefault {
 		isDefault = 1
 	}
+	streamBuffer := 0
+	if c.StreamBufferEnabled {
+		streamBuffer = 1
+	}
 	_, err := r.db.db.Exec(
-		`UPDATE retry_configs SET updated_at = ?, name = ?, is_default = ?, max_retries = ?, initial_interval_ms = ?, backoff_rate = ?, max_interval_ms = ? WHERE id = ?`,
-		c.UpdatedAt, c.Name, isDefault, c.MaxRetries, c.InitialInterval.Milliseconds(), c.BackoffRate, c.MaxInterval.Milliseconds(), c.ID,
+		`UPDATE retry_configs SET updated_at = ?, name = ?, is_default = ?, max_retries = ?, initial_interval_ms = ?, backoff_rate = ?, max_interval_ms = ?, stream_buffer_enabled = ?, stream_buffer_bytes = ?, first_byte_timeout_ms = ?, connect_timeout_ms = ?, stream_idle_timeout_ms = ?, total_timeout_ms = ? WHERE id = ?`,
+		c.UpdatedAt, c.Name, isDefault, c.MaxRetries, c.InitialInterval.Milliseconds(), c.BackoffRate, c.MaxInterval.Milliseconds(), streamBuffer, c.StreamBufferBytes, c.FirstByteTimeout.Milliseconds(), c.ConnectTimeout.Milliseconds(), c.StreamIdleTimeout.Milliseconds(), c.TotalTimeout.Milliseconds(), c.ID,
 	)
 	return err
 }
@@ -60,17 +68,17 @@ func (r *RetryConfigRepository) Delete(id uint64) error {
 }
 
 func (r *RetryConfigRepository) GetByID(id uint64) (*domain.RetryConfig, error) {
-	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms FROM retry_configs WHERE id = ?`, id)
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms, stream_buffer_enabled, stream_buffer_bytes, first_byte_timeout_ms, connect_timeout_ms, stream_idle_timeout_ms, total_timeout_ms FROM retry_configs WHERE id = ?`, id)
 	return r.scanConfig(row)
 }
 
 func (r *RetryConfigRepository) GetDefault() (*domain.RetryConfig, error) {
-	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms FROM retry_configs WHERE is_default = 1 LIMIT 1`)
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms, stream_buffer_enabled, stream_buffer_bytes, first_byte_timeout_ms, connect_timeout_ms, stream_idle_timeout_ms, total_timeout_ms FROM retry_configs WHERE is_default = 1 LIMIT 1`)
 	return r.scanConfig(row)
 }
 
 func (r *RetryConfigRepository) List() ([]*domain.RetryConfig, error) {
-	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms FROM retry_configs ORDER BY id`)
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, name, is_default, max_retries, initial_interval_ms, backoff_rate, max_interval_ms, stream_buffer_enabled, stream_buffer_bytes, first_byte_timeout_ms, connect_timeout_ms, stream_idle_timeout_ms, total_timeout_ms FROM retry_configs ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
@@ -89,9 +97,9 @@ func (r *RetryConfigRepository) List() ([]*domain.RetryConfig, error) {
 
 func (r *RetryConfigRepository) scanConfig(row *sql.Row) (*domain.RetryConfig, error) {
 	var c domain.RetryConfig
-	var isDefault int
-	var initialMs, maxMs int64
-	err := row.Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.Name, &isDefault, &c.MaxRetries, &initialMs, &c.BackoffRate, &maxMs)
+	var isDefault, streamBuffer int
+	var initialMs, maxMs, firstByteMs, connectMs, idleMs, totalMs int64
+	err := row.Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.Name, &isDefault, &c.MaxRetries, &initialMs, &c.BackoffRate, &maxMs, &streamBuffer, &c.StreamBufferBytes, &firstByteMs, &connectMs, &idleMs, &totalMs)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrNotFound
@@ -101,19 +109,29 @@ func (r *RetryConfigRepository) scanConfig(row *sql.Row) (*domain.RetryConfig, e
 	c.IsDefault = isDefault == 1
 	c.InitialInterval = time.Duration(initialMs) * time.Millisecond
 	c.MaxInterval = time.Duration(maxMs) * time.Millisecond
+	c.StreamBufferEnabled = streamBuffer == 1
+	c.FirstByteTimeout = time.Duration(firstByteMs) * time.Millisecond
+	c.ConnectTimeout = time.Duration(connectMs) * time.Millisecond
+	c.StreamIdleTimeout = time.Duration(idleMs) * time.Millisecond
+	c.TotalTimeout = time.Duration(totalMs) * time.Millisecond
 	return &c, nil
 }
 
 func (r *RetryConfigRepository) scanConfigRows(rows *sql.Rows) (*domain.RetryConfig, error) {
 	var c domain.RetryConfig
-	var isDefault int
-	var initialMs, maxMs int64
-	err := rows.Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.Name, &isDefault, &c.MaxRetries, &initialMs, &c.BackoffRate, &maxMs)
+	var isDefault, streamBuffer int
+	var initialMs, maxMs, firstByteMs, connectMs, idleMs, totalMs int64
+	err := rows.Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt, &c.Name, &isDefault, &c.MaxRetries, &initialMs, &c.BackoffRate, &maxMs, &streamBuffer, &c.StreamBufferBytes, &firstByteMs, &connectMs, &idleMs, &totalMs)
 	if err != nil {
 		return nil, err
 	}
 	c.IsDefault = isDefault == 1
 	c.InitialInterval = time.Duration(initialMs) * time.Millisecond
 	c.MaxInterval = time.Duration(maxMs) * time.Millisecond
+	c.StreamBufferEnabled = streamBuffer == 1
+	c.FirstByteTimeout = time.Duration(firstByteMs) * time.Millisecond
+	c.ConnectTimeout = time.Duration(connectMs) * time.Millisecond
+	c.StreamIdleTimeout = time.Duration(idleMs) * time.Millisecond
+	c.TotalTimeout = time.Duration(totalMs) * time.Millisecond
 	return &c, nil
 }
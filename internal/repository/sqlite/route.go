@@ -30,8 +30,8 @@ func (r *RouteRepository) Create(route *domain.Route) error {
 	}
 
 	result, err := r.db.db.Exec(
-		`INSERT INTO routes (created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		route.CreatedAt, route.UpdatedAt, isEnabled, isNative, route.ProjectID, route.ClientType, route.ProviderID, route.Position, route.RetryConfigID, toJSON(route.ModelMapping),
+		`INSERT INTO routes (created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping, match_rules, model_mapping_rules, fallback_models) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		route.CreatedAt, route.UpdatedAt, isEnabled, isNative, route.ProjectID, route.ClientType, route.ProviderID, route.Position, route.RetryConfigID, toJSON(route.ModelMapping), toJSON(route.MatchRules), toJSON(route.ModelMappingRules), toJSON(route.FallbackModels),
 	)
 	if err != nil {
 		return err
@@ -56,8 +56,8 @@ func (r *RouteRepository) Update(route *domain.Route) error {
 		isNative = 1
 	}
 	_, err := r.db.db.Exec(
-		`UPDATE routes SET updated_at = ?, is_enabled = ?, is_native = ?, project_id = ?, client_type = ?, provider_id = ?, position = ?, retry_config_id = ?, model_mapping = ? WHERE id = ?`,
-		route.UpdatedAt, isEnabled, isNative, route.ProjectID, route.ClientType, route.ProviderID, route.Position, route.RetryConfigID, toJSON(route.ModelMapping), route.ID,
+		`UPDATE routes SET updated_at = ?, is_enabled = ?, is_native = ?, project_id = ?, client_type = ?, provider_id = ?, position = ?, retry_config_id = ?, model_mapping = ?, match_rules = ?, model_mapping_rules = ?, fallback_models = ? WHERE id = ?`,
+		route.UpdatedAt, isEnabled, isNative, route.ProjectID, route.ClientType, route.ProviderID, route.Position, route.RetryConfigID, toJSON(route.ModelMapping), toJSON(route.MatchRules), toJSON(route.ModelMappingRules), toJSON(route.FallbackModels), route.ID,
 	)
 	return err
 }
@@ -68,17 +68,17 @@ func (r *RouteRepository) Delete(id uint64) error {
 }
 
 func (r *RouteRepository) GetByID(id uint64) (*domain.Route, error) {
-	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping FROM routes WHERE id = ?`, id)
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping, COALESCE(match_rules, ''), COALESCE(model_mapping_rules, ''), COALESCE(fallback_models, '') FROM routes WHERE id = ?`, id)
 	return r.scanRoute(row)
 }
 
 func (r *RouteRepository) FindByKey(projectID, providerID uint64, clientType domain.ClientType) (*domain.Route, error) {
-	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping FROM routes WHERE project_id = ? AND provider_id = ? AND client_type = ?`, projectID, providerID, clientType)
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping, COALESCE(match_rules, ''), COALESCE(model_mapping_rules, ''), COALESCE(fallback_models, '') FROM routes WHERE project_id = ? AND provider_id = ? AND client_type = ?`, projectID, providerID, clientType)
 	return r.scanRoute(row)
 }
 
 func (r *RouteRepository) List() ([]*domain.Route, error) {
-	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping FROM routes ORDER BY position`)
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, is_enabled, is_native, project_id, client_type, provider_id, position, retry_config_id, model_mapping, COALESCE(match_rules, ''), COALESCE(model_mapping_rules, ''), COALESCE(fallback_models, '') FROM routes ORDER BY position`)
 	if err != nil {
 		return nil, err
 	}
@@ -98,8 +98,8 @@ func (r *RouteRepository) List() ([]*domain.Route, error) {
 func (r *RouteRepository) scanRoute(row *sql.Row) (*domain.Route, error) {
 	var route domain.Route
 	var isEnabled, isNative int
-	var mappingJSON string
-	err := row.Scan(&route.ID, &route.CreatedAt, &route.UpdatedAt, &isEnabled, &isNative, &route.ProjectID, &route.ClientType, &route.ProviderID, &route.Position, &route.RetryConfigID, &mappingJSON)
+	var mappingJSON, rulesJSON, mappingRulesJSON, fallbackJSON string
+	err := row.Scan(&route.ID, &route.CreatedAt, &route.UpdatedAt, &isEnabled, &isNative, &route.ProjectID, &route.ClientType, &route.ProviderID, &route.Position, &route.RetryConfigID, &mappingJSON, &rulesJSON, &mappingRulesJSON, &fallbackJSON)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrNotFound
@@ -109,19 +109,25 @@ func (r *RouteRepository) scanRoute(row *sql.Row) (*domain.Route, error) {
 	route.IsEnabled = isEnabled == 1
 	route.IsNative = isNative == 1
 	route.ModelMapping = fromJSON[map[string]string](mappingJSON)
+	route.MatchRules = fromJSON[[]domain.RouteMatchRule](rulesJSON)
+	route.ModelMappingRules = fromJSON[[]domain.ModelMappingRule](mappingRulesJSON)
+	route.FallbackModels = fromJSON[[]string](fallbackJSON)
 	return &route, nil
 }
 
 func (r *RouteRepository) scanRouteRows(rows *sql.Rows) (*domain.Route, error) {
 	var route domain.Route
 	var isEnabled, isNative int
-	var mappingJSON string
-	err := rows.Scan(&route.ID, &route.CreatedAt, &route.UpdatedAt, &isEnabled, &isNative, &route.ProjectID, &route.ClientType, &route.ProviderID, &route.Position, &route.RetryConfigID, &mappingJSON)
+	var mappingJSON, rulesJSON, mappingRulesJSON, fallbackJSON string
+	err := rows.Scan(&route.ID, &route.CreatedAt, &route.UpdatedAt, &isEnabled, &isNative, &route.ProjectID, &route.ClientType, &route.ProviderID, &route.Position, &route.RetryConfigID, &mappingJSON, &rulesJSON, &mappingRulesJSON, &fallbackJSON)
 	if err != nil {
 		return nil, err
 	}
 	route.IsEnabled = isEnabled == 1
 	route.IsNative = isNative == 1
 	route.ModelMapping = fromJSON[map[string]string](mappingJSON)
+	route.MatchRules = fromJSON[[]domain.RouteMatchRule](rulesJSON)
+	route.ModelMappingRules = fromJSON[[]domain.ModelMappingRule](mappingRulesJSON)
+	route.FallbackModels = fromJSON[[]string](fallbackJSON)
 	return &route, nil
 }
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+type SignatureCacheRepository struct {
+	db *DB
+}
+
+func NewSignatureCacheRepository(db *DB) repository.SignatureCacheRepository {
+	return &SignatureCacheRepository{db: db}
+}
+
+func (r *SignatureCacheRepository) Get(layer, key string) (*domain.SignatureCacheEntry, error) {
+	entry := &domain.SignatureCacheEntry{Layer: layer, Key: key}
+	var updatedAt string
+	err := r.db.db.QueryRow(
+		`SELECT value, updated_at FROM signature_cache WHERE layer = ? AND key = ?`,
+		layer, key,
+	).Scan(&entry.Value, &updatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entry.UpdatedAt, _ = parseTimeString(updatedAt)
+	return entry, nil
+}
+
+func (r *SignatureCacheRepository) Upsert(entry *domain.SignatureCacheEntry) error {
+	query := `INSERT INTO signature_cache (layer, key, value, updated_at)
+	          VALUES (?, ?, ?, ?)
+	          ON CONFLICT(layer, key) DO UPDATE SET
+	            value = excluded.value,
+	            updated_at = excluded.updated_at`
+
+	_, err := r.db.db.Exec(query, entry.Layer, entry.Key, entry.Value, formatTime(entry.UpdatedAt))
+	return err
+}
+
+func (r *SignatureCacheRepository) Delete(layer, key string) error {
+	_, err := r.db.db.Exec(`DELETE FROM signature_cache WHERE layer = ? AND key = ?`, layer, key)
+	return err
+}
+
+func (r *SignatureCacheRepository) Count(layer string) (int, error) {
+	var count int
+	err := r.db.db.QueryRow(`SELECT COUNT(*) FROM signature_cache WHERE layer = ?`, layer).Scan(&count)
+	return count, err
+}
+
+func (r *SignatureCacheRepository) DeleteBefore(before time.Time) error {
+	_, err := r.db.db.Exec(`DELETE FROM signature_cache WHERE updated_at < ?`, formatTime(before))
+	return err
+}
+
+func (r *SignatureCacheRepository) Trim(layer string, keep int) error {
+	query := `DELETE FROM signature_cache
+	          WHERE layer = ? AND key NOT IN (
+	            SELECT key FROM signature_cache WHERE layer = ? ORDER BY updated_at DESC, rowid DESC LIMIT ?
+	          )`
+
+	_, err := r.db.db.Exec(query, layer, layer, keep)
+	return err
+}
+
+func (r *SignatureCacheRepository) DeleteAll() error {
+	_, err := r.db.db.Exec(`DELETE FROM signature_cache`)
+	return err
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+type ThoughtSignatureRepository struct {
+	db *DB
+}
+
+func NewThoughtSignatureRepository(db *DB) repository.ThoughtSignatureRepository {
+	return &ThoughtSignatureRepository{db: db}
+}
+
+func (r *ThoughtSignatureRepository) Upsert(sig *domain.ThoughtSignature) error {
+	query := `INSERT INTO thought_signatures (session_id, model_family, signature, updated_at)
+	          VALUES (?, ?, ?, ?)
+	          ON CONFLICT(session_id, model_family) DO UPDATE SET
+	            signature = excluded.signature,
+	            updated_at = excluded.updated_at`
+
+	_, err := r.db.db.Exec(query, sig.SessionID, sig.ModelFamily, sig.Signature, formatTime(sig.UpdatedAt))
+	return err
+}
+
+func (r *ThoughtSignatureRepository) ListSince(since time.Time) ([]*domain.ThoughtSignature, error) {
+	query := `SELECT session_id, model_family, signature, updated_at
+	          FROM thought_signatures
+	          WHERE updated_at >= ?`
+
+	rows, err := r.db.db.Query(query, formatTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sigs := make([]*domain.ThoughtSignature, 0)
+	for rows.Next() {
+		sig := &domain.ThoughtSignature{}
+		var updatedAt string
+		if err := rows.Scan(&sig.SessionID, &sig.ModelFamily, &sig.Signature, &updatedAt); err != nil {
+			return nil, err
+		}
+		sig.UpdatedAt, _ = parseTimeString(updatedAt)
+		sigs = append(sigs, sig)
+	}
+
+	return sigs, rows.Err()
+}
+
+func (r *ThoughtSignatureRepository) Delete(sessionID, modelFamily string) error {
+	_, err := r.db.db.Exec(`DELETE FROM thought_signatures WHERE session_id = ? AND model_family = ?`, sessionID, modelFamily)
+	return err
+}
+
+func (r *ThoughtSignatureRepository) DeleteBefore(before time.Time) error {
+	_, err := r.db.db.Exec(`DELETE FROM thought_signatures WHERE updated_at < ?`, formatTime(before))
+	return err
+}
+
+func (r *ThoughtSignatureRepository) DeleteAll() error {
+	_, err := r.db.db.Exec(`DELETE FROM thought_signatures`)
+	return err
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+type UserRepository struct {
+	db *DB
+}
+
+func NewUserRepository(db *DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) Create(u *domain.User) error {
+	now := time.Now()
+	u.CreatedAt = now
+	u.UpdatedAt = now
+
+	isEnabled := 0
+	if u.IsEnabled {
+		isEnabled = 1
+	}
+
+	result, err := r.db.db.Exec(
+		`INSERT INTO users (created_at, updated_at, username, password_hash, role, is_enabled, last_login_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		u.CreatedAt, u.UpdatedAt, u.Username, u.PasswordHash, u.Role, isEnabled, formatTimePtr(u.LastLoginAt),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID = uint64(id)
+	return nil
+}
+
+func (r *UserRepository) Update(u *domain.User) error {
+	u.UpdatedAt = time.Now()
+	isEnabled := 0
+	if u.IsEnabled {
+		isEnabled = 1
+	}
+	_, err := r.db.db.Exec(
+		`UPDATE users SET updated_at = ?, username = ?, password_hash = ?, role = ?, is_enabled = ?, last_login_at = ? WHERE id = ?`,
+		u.UpdatedAt, u.Username, u.PasswordHash, u.Role, isEnabled, formatTimePtr(u.LastLoginAt), u.ID,
+	)
+	return err
+}
+
+func (r *UserRepository) Delete(id uint64) error {
+	_, err := r.db.db.Exec(`DELETE FROM users WHERE id = ?`, id)
+	return err
+}
+
+func (r *UserRepository) GetByID(id uint64) (*domain.User, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, username, password_hash, role, is_enabled, last_login_at FROM users WHERE id = ?`, id)
+	return r.scanUser(row)
+}
+
+func (r *UserRepository) GetByUsername(username string) (*domain.User, error) {
+	row := r.db.db.QueryRow(`SELECT id, created_at, updated_at, username, password_hash, role, is_enabled, last_login_at FROM users WHERE username = ?`, username)
+	return r.scanUser(row)
+}
+
+func (r *UserRepository) List() ([]*domain.User, error) {
+	rows, err := r.db.db.Query(`SELECT id, created_at, updated_at, username, password_hash, role, is_enabled, last_login_at FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0)
+	for rows.Next() {
+		var u domain.User
+		var isEnabled int
+		var lastLoginAt sql.NullString
+		if err := rows.Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt, &u.Username, &u.PasswordHash, &u.Role, &isEnabled, &lastLoginAt); err != nil {
+			return nil, err
+		}
+		u.IsEnabled = isEnabled == 1
+		u.LastLoginAt = parseNullTimeString(lastLoginAt)
+		users = append(users, &u)
+	}
+	return users, rows.Err()
+}
+
+func (r *UserRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+	return count, err
+}
+
+func (r *UserRepository) scanUser(row *sql.Row) (*domain.User, error) {
+	var u domain.User
+	var isEnabled int
+	var lastLoginAt sql.NullString
+	err := row.Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt, &u.Username, &u.PasswordHash, &u.Role, &isEnabled, &lastLoginAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	u.IsEnabled = isEnabled == 1
+	u.LastLoginAt = parseNullTimeString(lastLoginAt)
+	return &u, nil
+}
@@ -0,0 +1,83 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// latencyAlpha is the smoothing factor of the exponential moving average
+// Higher values react faster to recent attempts
+const latencyAlpha = 0.2
+
+type latencyKey struct {
+	providerID uint64
+	clientType domain.ClientType
+}
+
+type movingAverage struct {
+	value   float64
+	samples int
+}
+
+func (m *movingAverage) add(d time.Duration) {
+	if m.samples == 0 {
+		m.value = float64(d)
+	} else {
+		m.value = latencyAlpha*float64(d) + (1-latencyAlpha)*m.value
+	}
+	m.samples++
+}
+
+type latencyStats struct {
+	ttfb     movingAverage
+	duration movingAverage
+}
+
+// LatencyTracker keeps a moving average of successful attempt latency per provider and client type
+type LatencyTracker struct {
+	mu    sync.RWMutex
+	stats map[latencyKey]*latencyStats
+}
+
+// NewLatencyTracker creates an empty tracker
+func NewLatencyTracker() *LatencyTracker {
+	return &LatencyTracker{stats: make(map[latencyKey]*latencyStats)}
+}
+
+// Record adds a successful attempt. A zero ttfb is ignored (nothing was written).
+func (t *LatencyTracker) Record(providerID uint64, clientType domain.ClientType, ttfb, duration time.Duration) {
+	key := latencyKey{providerID, clientType}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.stats[key]
+	if !ok {
+		s = &latencyStats{}
+		t.stats[key] = s
+	}
+	if ttfb > 0 {
+		s.ttfb.add(ttfb)
+	}
+	if duration > 0 {
+		s.duration.add(duration)
+	}
+}
+
+// Get returns the average latency for the metric, falling back to duration when
+// no TTFB samples exist. ok is false when the provider has no samples yet.
+func (t *LatencyTracker) Get(providerID uint64, clientType domain.ClientType, metric domain.LatencyMetric) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	s, ok := t.stats[latencyKey{providerID, clientType}]
+	if !ok {
+		return 0, false
+	}
+	if metric != domain.LatencyMetricDuration && s.ttfb.samples > 0 {
+		return time.Duration(s.ttfb.value), true
+	}
+	if s.duration.samples > 0 {
+		return time.Duration(s.duration.value), true
+	}
+	return 0, false
+}
@@ -0,0 +1,64 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// ValidateModelMapping checks a route's mapping rules and fallback models
+func ValidateModelMapping(rules []domain.ModelMappingRule, fallbackModels []string) error {
+	for i, rule := range rules {
+		if rule.Pattern == "" || rule.Target == "" {
+			return fmt.Errorf("%w: mapping rule %d: pattern and target are required", domain.ErrInvalidInput, i)
+		}
+		if _, err := compilePattern(mappingPatternKey(rule)); err != nil {
+			return fmt.Errorf("%w: mapping rule %d: invalid pattern %q: %v", domain.ErrInvalidInput, i, rule.Pattern, err)
+		}
+	}
+	for i, model := range fallbackModels {
+		if strings.TrimSpace(model) == "" {
+			return fmt.Errorf("%w: fallback model %d is empty", domain.ErrInvalidInput, i)
+		}
+	}
+	return nil
+}
+
+// ModelChain returns the models to try on a route in order:
+// the mapped model followed by the route's fallback models
+func ModelChain(requestModel string, route *domain.Route, provider *domain.Provider) []string {
+	mapped := MapModel(requestModel, route, provider)
+	chain := make([]string, 0, 1+len(route.FallbackModels))
+	chain = append(chain, mapped)
+	for _, model := range route.FallbackModels {
+		if model != mapped {
+			chain = append(chain, model)
+		}
+	}
+	return chain
+}
+
+// applyMappingRules returns the target of the first rule matching the model,
+// with capture groups substituted
+func applyMappingRules(requestModel string, rules []domain.ModelMappingRule) (string, bool) {
+	for _, rule := range rules {
+		re, err := compilePattern(mappingPatternKey(rule))
+		if err != nil {
+			continue
+		}
+		match := re.FindStringSubmatchIndex(requestModel)
+		if match == nil {
+			continue
+		}
+		return string(re.ExpandString(nil, rule.Target, requestModel, match)), true
+	}
+	return "", false
+}
+
+func mappingPatternKey(rule domain.ModelMappingRule) string {
+	if rule.Regex {
+		return "re:" + rule.Pattern
+	}
+	return "capture:" + rule.Pattern
+}
@@ -0,0 +1,142 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+// RouteExplanation tells whether a route's match rules accept a request
+type RouteExplanation struct {
+	RouteID    uint64 `json:"routeID"`
+	ProviderID uint64 `json:"providerID"`
+	ProjectID  uint64 `json:"projectID"`
+	Position   int    `json:"position"`
+	Matched    bool   `json:"matched"`
+	RuleIndex  int    `json:"ruleIndex"` // Index of the matching rule, -1 when none matched or the route has no rules
+	RuleName   string `json:"ruleName,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// MatchExplanation is the result of Router.Explain
+type MatchExplanation struct {
+	// Every enabled route of the client type in the scope Match uses (project or global)
+	Routes []*RouteExplanation `json:"routes"`
+	// Route IDs in the order Match would try them (after cooldowns and the routing strategy)
+	Selected []uint64 `json:"selected"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// patternCache holds compiled model globs and regexes
+var patternCache sync.Map // string -> *regexp.Regexp
+
+// ValidateMatchRules checks that every rule's model patterns compile
+func ValidateMatchRules(rules []domain.RouteMatchRule) error {
+	for i, rule := range rules {
+		if rule.Model != "" {
+			if _, err := compilePattern("glob:" + rule.Model); err != nil {
+				return fmt.Errorf("%w: rule %d: invalid model glob %q: %v", domain.ErrInvalidInput, i, rule.Model, err)
+			}
+		}
+		if rule.ModelRegex != "" {
+			if _, err := compilePattern("re:" + rule.ModelRegex); err != nil {
+				return fmt.Errorf("%w: rule %d: invalid model regex %q: %v", domain.ErrInvalidInput, i, rule.ModelRegex, err)
+			}
+		}
+		if rule.MaxInputTokens < 0 {
+			return fmt.Errorf("%w: rule %d: maxInputTokens must not be negative", domain.ErrInvalidInput, i)
+		}
+	}
+	return nil
+}
+
+// matchRoute reports whether a route accepts the request and which rule matched.
+// Routes without rules match every request (ruleIndex -1).
+func matchRoute(route *domain.Route, mc *MatchContext) (matched bool, ruleIndex int, reason string) {
+	if len(route.MatchRules) == 0 {
+		return true, -1, "no match rules"
+	}
+	reasons := make([]string, 0, len(route.MatchRules))
+	for i := range route.MatchRules {
+		ok, why := matchRule(&route.MatchRules[i], mc)
+		if ok {
+			return true, i, why
+		}
+		reasons = append(reasons, fmt.Sprintf("rule %d: %s", i, why))
+	}
+	return false, -1, strings.Join(reasons, "; ")
+}
+
+// matchRule checks every condition set on the rule
+func matchRule(rule *domain.RouteMatchRule, mc *MatchContext) (bool, string) {
+	if rule.Model != "" {
+		re, err := compilePattern("glob:" + rule.Model)
+		if err != nil || !re.MatchString(mc.RequestModel) {
+			return false, fmt.Sprintf("model %q does not match %q", mc.RequestModel, rule.Model)
+		}
+	}
+	if rule.ModelRegex != "" {
+		re, err := compilePattern("re:" + rule.ModelRegex)
+		if err != nil || !re.MatchString(mc.RequestModel) {
+			return false, fmt.Sprintf("model %q does not match /%s/", mc.RequestModel, rule.ModelRegex)
+		}
+	}
+	if rule.Stream != nil && *rule.Stream != mc.IsStream {
+		if *rule.Stream {
+			return false, "rule only matches streaming requests"
+		}
+		return false, "rule only matches non-streaming requests"
+	}
+	if rule.MaxInputTokens > 0 && mc.InputTokens > rule.MaxInputTokens {
+		return false, fmt.Sprintf("estimated %d input tokens exceed %d", mc.InputTokens, rule.MaxInputTokens)
+	}
+	return true, "all conditions matched"
+}
+
+// compilePattern compiles "glob:<glob>", "capture:<glob>" or "re:<regex>" once
+func compilePattern(key string) (*regexp.Regexp, error) {
+	if cached, ok := patternCache.Load(key); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	var expr string
+	if glob, ok := strings.CutPrefix(key, "glob:"); ok {
+		expr = globToRegex(glob, false)
+	} else if glob, ok := strings.CutPrefix(key, "capture:"); ok {
+		expr = globToRegex(glob, true)
+	} else {
+		expr = strings.TrimPrefix(key, "re:")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(key, re)
+	return re, nil
+}
+
+// globToRegex converts a model glob (* and ?) into an anchored regular expression.
+// With capture set every wildcard becomes a numbered capture group ($1, $2, ...).
+func globToRegex(glob string, capture bool) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch {
+		case r == '*' && capture:
+			b.WriteString("(.*)")
+		case r == '?' && capture:
+			b.WriteString("(.)")
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestMatchRoute(t *testing.T) {
+	stream := true
+	route := &domain.Route{MatchRules: []domain.RouteMatchRule{
+		{Name: "opus", Model: "claude-opus-*"},
+		{Name: "small-stream", ModelRegex: `haiku|mini`, Stream: &stream, MaxInputTokens: 1000},
+	}}
+
+	tests := []struct {
+		name      string
+		mc        MatchContext
+		matched   bool
+		ruleIndex int
+	}{
+		{"glob", MatchContext{RequestModel: "claude-opus-4-1"}, true, 0},
+		{"glob is anchored", MatchContext{RequestModel: "x-claude-opus-4"}, false, -1},
+		{"regex and stream", MatchContext{RequestModel: "claude-haiku-4-5", IsStream: true, InputTokens: 500}, true, 1},
+		{"non-streaming", MatchContext{RequestModel: "claude-haiku-4-5", InputTokens: 500}, false, -1},
+		{"too many tokens", MatchContext{RequestModel: "gpt-4o-mini", IsStream: true, InputTokens: 5000}, false, -1},
+	}
+	for _, tt := range tests {
+		matched, ruleIndex, reason := matchRoute(route, &tt.mc)
+		if matched != tt.matched || ruleIndex != tt.ruleIndex {
+			t.Errorf("%s: got matched=%v rule=%d (%s), want matched=%v rule=%d",
+				tt.name, matched, ruleIndex, reason, tt.matched, tt.ruleIndex)
+		}
+	}
+
+	// Routes without rules match everything
+	if matched, ruleIndex, _ := matchRoute(&domain.Route{}, &MatchContext{RequestModel: "any"}); !matched || ruleIndex != -1 {
+		t.Errorf("route without rules should match, got matched=%v rule=%d", matched, ruleIndex)
+	}
+}
+
+func TestValidateMatchRules(t *testing.T) {
+	if err := ValidateMatchRules([]domain.RouteMatchRule{{Model: "gpt-4?-*", ModelRegex: `^o\d`}}); err != nil {
+		t.Fatalf("valid rules rejected: %v", err)
+	}
+	if err := ValidateMatchRules([]domain.RouteMatchRule{{ModelRegex: "(unclosed"}}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput for a bad regex, got %v", err)
+	}
+	if err := ValidateMatchRules([]domain.RouteMatchRule{{MaxInputTokens: -1}}); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Fatalf("expected ErrInvalidInput for negative maxInputTokens, got %v", err)
+	}
+}
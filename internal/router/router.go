@@ -5,8 +5,10 @@ import (
 	"math/rand"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/awsl-project/maxx/internal/adapter/provider"
+	"github.com/awsl-project/maxx/internal/circuit"
 	"github.com/awsl-project/maxx/internal/cooldown"
 	"github.com/awsl-project/maxx/internal/domain"
 	"github.com/awsl-project/maxx/internal/repository/cached"
@@ -20,6 +22,16 @@ type MatchedRoute struct {
 	RetryConfig     *domain.RetryConfig
 }
 
+// MatchContext describes the request being routed
+type MatchContext struct {
+	ClientType   domain.ClientType
+	ProjectID    uint64
+	RequestModel string
+	SessionID    string
+	IsStream     bool
+	InputTokens  int // Estimated input tokens, checked against MaxInputTokens match rules
+}
+
 // Router handles route matching and selection
 type Router struct {
 	routeRepo           *cached.RouteRepository
@@ -34,6 +46,15 @@ type Router struct {
 
 	// Cooldown manager
 	cooldownManager *cooldown.Manager
+
+	// Latency averages for the least_latency strategy
+	latency *LatencyTracker
+
+	// randFloat returns a number in [0, 1) for weighted selection
+	randFloat func() float64
+
+	// Session → provider bindings for sticky sessions
+	sticky *StickyStore
 }
 
 // NewRouter creates a new router
@@ -52,6 +73,9 @@ func NewRouter(
 		projectRepo:         projectRepo,
 		adapters:            make(map[uint64]provider.ProviderAdapter),
 		cooldownManager:     cooldown.Default(),
+		latency:             NewLatencyTracker(),
+		randFloat:           rand.Float64,
+		sticky:              NewStickyStore(),
 	}
 }
 
@@ -92,6 +116,14 @@ func (r *Router) RefreshAdapter(p *domain.Provider) error {
 	return nil
 }
 
+// Adapter returns the adapter of a provider (used by the health prober)
+func (r *Router) Adapter(providerID uint64) (provider.ProviderAdapter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.adapters[providerID]
+	return a, ok
+}
+
 // RemoveAdapter removes the adapter for a provider
 func (r *Router) RemoveAdapter(providerID uint64) {
 	r.mu.Lock()
@@ -99,19 +131,46 @@ func (r *Router) RemoveAdapter(providerID uint64) {
 	r.mu.Unlock()
 }
 
-// Match returns matched routes for a client type and project
-func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*MatchedRoute, error) {
-	routes := r.routeRepo.GetAll()
+// RecordLatency feeds a successful attempt into the least_latency averages
+func (r *Router) RecordLatency(providerID uint64, clientType domain.ClientType, ttfb, duration time.Duration) {
+	r.latency.Record(providerID, clientType, ttfb, duration)
+}
 
-	log.Printf("[Router] Match called: clientType=%s, projectID=%d, total routes in cache=%d", clientType, projectID, len(routes))
+// RecordSessionProvider remembers the provider that served a session when the
+// project's routing strategy has sticky sessions enabled
+func (r *Router) RecordSessionProvider(projectID uint64, sessionID string, providerID uint64) {
+	if sessionID == "" {
+		return
+	}
+	strategy := r.getRoutingStrategy(projectID)
+	if strategy.Config == nil || !strategy.Config.StickySession {
+		return
+	}
+	ttl := strategy.Config.StickySessionTTL
+	if ttl <= 0 {
+		ttl = DefaultStickySessionTTL
+	}
+	r.sticky.Set(sessionID, providerID, ttl)
+}
 
-	// Debug: print all routes in cache
-	for _, rt := range routes {
-		log.Printf("[Router] Route in cache: id=%d, clientType=%s, projectID=%d, providerID=%d, isEnabled=%v",
-			rt.ID, rt.ClientType, rt.ProjectID, rt.ProviderID, rt.IsEnabled)
+// DefaultHedgeDelay is used when hedging is enabled without a delay
+const DefaultHedgeDelay = 2 * time.Second
+
+// HedgeDelay returns how long a non-streaming request may run before it is
+// hedged on the next route, or 0 when the project's strategy doesn't hedge
+func (r *Router) HedgeDelay(projectID uint64) time.Duration {
+	strategy := r.getRoutingStrategy(projectID)
+	if strategy.Config == nil || !strategy.Config.Hedging {
+		return 0
+	}
+	if strategy.Config.HedgeDelay > 0 {
+		return strategy.Config.HedgeDelay
 	}
+	return DefaultHedgeDelay
+}
 
-	// Check if ClientType has custom routes enabled for this project
+// useProjectRoutes reports whether the project has custom routes enabled for the client type
+func (r *Router) useProjectRoutes(clientType domain.ClientType, projectID uint64) bool {
 	useProjectRoutes := false
 	if projectID != 0 {
 		project, err := r.projectRepo.GetByID(projectID)
@@ -141,6 +200,23 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 	} else {
 		log.Printf("[Router] projectID is 0, using global routes")
 	}
+	return useProjectRoutes
+}
+
+// Match returns matched routes for a request
+func (r *Router) Match(mc *MatchContext) ([]*MatchedRoute, error) {
+	clientType, projectID := mc.ClientType, mc.ProjectID
+	routes := r.routeRepo.GetAll()
+
+	log.Printf("[Router] Match called: clientType=%s, projectID=%d, total routes in cache=%d", clientType, projectID, len(routes))
+
+	// Debug: print all routes in cache
+	for _, rt := range routes {
+		log.Printf("[Router] Route in cache: id=%d, clientType=%s, projectID=%d, providerID=%d, isEnabled=%v",
+			rt.ID, rt.ClientType, rt.ProjectID, rt.ProviderID, rt.IsEnabled)
+	}
+
+	useProjectRoutes := r.useProjectRoutes(clientType, projectID)
 
 	// Filter routes
 	var filtered []*domain.Route
@@ -160,6 +236,10 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 				continue
 			}
 			if route.ProjectID == projectID && projectID != 0 {
+				if matched, _, reason := matchRoute(route, mc); !matched {
+					log.Printf("[Router] Project route id=%d does not match request: %s", route.ID, reason)
+					continue
+				}
 				log.Printf("[Router] Found matching project route: id=%d, providerID=%d", route.ID, route.ProviderID)
 				filtered = append(filtered, route)
 				hasProjectRoutes = true
@@ -180,6 +260,10 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 				continue
 			}
 			if route.ProjectID == 0 {
+				if matched, _, reason := matchRoute(route, mc); !matched {
+					log.Printf("[Router] Global route id=%d does not match request: %s", route.ID, reason)
+					continue
+				}
 				log.Printf("[Router] Found global route: id=%d, providerID=%d", route.ID, route.ProviderID)
 				filtered = append(filtered, route)
 			}
@@ -195,8 +279,10 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 	// Get routing strategy
 	strategy := r.getRoutingStrategy(projectID)
 
+	providers := r.providerRepo.GetAll()
+
 	// Sort routes by strategy
-	r.sortRoutes(filtered, strategy)
+	r.sortRoutes(filtered, strategy, providers, mc)
 
 	// Get default retry config
 	defaultRetry, err := r.retryConfigRepo.GetDefault()
@@ -213,7 +299,6 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 	defer r.mu.RUnlock()
 
 	var matched []*MatchedRoute
-	providers := r.providerRepo.GetAll()
 
 	log.Printf("[Router] Providers in cache: %d, Adapters: %d", len(providers), len(r.adapters))
 
@@ -254,6 +339,13 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 		})
 	}
 
+	// Sticky session: move the provider that last served this session to the front
+	if strategy.Config != nil && strategy.Config.StickySession && mc.SessionID != "" {
+		if providerID, ok := r.sticky.Get(mc.SessionID); ok {
+			preferSticky(matched, providerID)
+		}
+	}
+
 	log.Printf("[Router] Final matched routes: %d", len(matched))
 
 	if len(matched) == 0 {
@@ -263,6 +355,58 @@ func (r *Router) Match(clientType domain.ClientType, projectID uint64) ([]*Match
 	return matched, nil
 }
 
+// preferSticky moves the routes of providerID to the front, keeping the rest in order
+// Cooling-down providers were already filtered out, so a missing provider falls back to normal order
+func preferSticky(matched []*MatchedRoute, providerID uint64) {
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Provider.ID == providerID && matched[j].Provider.ID != providerID
+	})
+}
+
+// Explain reports how each route's match rules treat a request and which routes Match would select
+func (r *Router) Explain(mc *MatchContext) *MatchExplanation {
+	result := &MatchExplanation{
+		Routes:   []*RouteExplanation{},
+		Selected: []uint64{},
+	}
+
+	// Routes are cached in position order
+	routes := r.routeRepo.GetAll()
+	useProjectRoutes := r.useProjectRoutes(mc.ClientType, mc.ProjectID)
+	for _, route := range routes {
+		if !route.IsEnabled || route.ClientType != mc.ClientType {
+			continue
+		}
+		if route.ProjectID != 0 && (!useProjectRoutes || route.ProjectID != mc.ProjectID) {
+			continue
+		}
+		matched, ruleIndex, reason := matchRoute(route, mc)
+		explanation := &RouteExplanation{
+			RouteID:    route.ID,
+			ProviderID: route.ProviderID,
+			ProjectID:  route.ProjectID,
+			Position:   route.Position,
+			Matched:    matched,
+			RuleIndex:  ruleIndex,
+			Reason:     reason,
+		}
+		if ruleIndex >= 0 {
+			explanation.RuleName = route.MatchRules[ruleIndex].Name
+		}
+		result.Routes = append(result.Routes, explanation)
+	}
+
+	matched, err := r.Match(mc)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for _, m := range matched {
+		result.Selected = append(result.Selected, m.Route.ID)
+	}
+	return result
+}
+
 func (r *Router) getRoutingStrategy(projectID uint64) *domain.RoutingStrategy {
 	// Try project-specific strategy first
 	if projectID != 0 {
@@ -278,20 +422,6 @@ func (r *Router) getRoutingStrategy(projectID uint64) *domain.RoutingStrategy {
 	return &domain.RoutingStrategy{Type: domain.RoutingStrategyPriority}
 }
 
-func (r *Router) sortRoutes(routes []*domain.Route, strategy *domain.RoutingStrategy) {
-	switch strategy.Type {
-	case domain.RoutingStrategyWeightedRandom:
-		// Shuffle with weights (simplified - just shuffle for now)
-		rand.Shuffle(len(routes), func(i, j int) {
-			routes[i], routes[j] = routes[j], routes[i]
-		})
-	default: // priority
-		sort.Slice(routes, func(i, j int) bool {
-			return routes[i].Position < routes[j].Position
-		})
-	}
-}
-
 // GetCooldowns returns all active cooldowns
 func (r *Router) GetCooldowns() ([]*domain.Cooldown, error) {
 	return r.cooldownManager.GetAllCooldownsFromDB()
@@ -301,6 +431,7 @@ func (r *Router) GetCooldowns() ([]*domain.Cooldown, error) {
 // Clears all cooldowns (global + per-client-type) for the provider
 func (r *Router) ClearCooldown(providerID uint64) error {
 	r.cooldownManager.ClearCooldown(providerID, "")
+	circuit.Default().Reset(providerID)
 	return nil
 }
 
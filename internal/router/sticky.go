@@ -0,0 +1,81 @@
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultStickySessionTTL is used when sticky sessions are enabled without a TTL
+// Matches Claude's 1h prompt cache so long-lived caches keep hitting the same account
+const DefaultStickySessionTTL = time.Hour
+
+// stickyPruneInterval limits how often expired bindings are swept
+const stickyPruneInterval = time.Minute
+
+type stickyBinding struct {
+	providerID uint64
+	expiresAt  time.Time
+}
+
+// StickyStore remembers which provider last served a session successfully
+type StickyStore struct {
+	mu         sync.Mutex
+	bindings   map[string]stickyBinding
+	lastPruned time.Time
+	now        func() time.Time
+}
+
+// NewStickyStore creates an empty store
+func NewStickyStore() *StickyStore {
+	return &StickyStore{
+		bindings: make(map[string]stickyBinding),
+		now:      time.Now,
+	}
+}
+
+// Get returns the provider bound to the session, if the binding hasn't expired
+func (s *StickyStore) Get(sessionID string) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bindings[sessionID]
+	if !ok {
+		return 0, false
+	}
+	if s.now().After(b.expiresAt) {
+		delete(s.bindings, sessionID)
+		return 0, false
+	}
+	return b.providerID, true
+}
+
+// Set binds the session to the provider for ttl (refreshing any existing binding)
+func (s *StickyStore) Set(sessionID string, providerID uint64, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.bindings[sessionID] = stickyBinding{providerID: providerID, expiresAt: now.Add(ttl)}
+
+	if now.Sub(s.lastPruned) < stickyPruneInterval {
+		return
+	}
+	s.lastPruned = now
+	for id, b := range s.bindings {
+		if now.After(b.expiresAt) {
+			delete(s.bindings, id)
+		}
+	}
+}
+
+// Delete removes the session's binding
+func (s *StickyStore) Delete(sessionID string) {
+	s.mu.Lock()
+	delete(s.bindings, sessionID)
+	s.mu.Unlock()
+}
+
+// Len returns the number of bindings (including expired ones not yet pruned)
+func (s *StickyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.bindings)
+}
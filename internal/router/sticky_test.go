@@ -0,0 +1,50 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func TestStickyStore_TTL(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	s := NewStickyStore()
+	s.now = func() time.Time { return now }
+
+	s.Set("sess", 7, time.Minute)
+	if id, ok := s.Get("sess"); !ok || id != 7 {
+		t.Fatalf("expected binding to provider 7, got %d, %v", id, ok)
+	}
+
+	// Refreshing extends the binding
+	now = now.Add(50 * time.Second)
+	s.Set("sess", 7, time.Minute)
+	now = now.Add(50 * time.Second)
+	if _, ok := s.Get("sess"); !ok {
+		t.Fatal("binding should have been refreshed")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := s.Get("sess"); ok {
+		t.Fatal("binding should have expired")
+	}
+}
+
+func TestPreferSticky(t *testing.T) {
+	matched := []*MatchedRoute{
+		{Provider: &domain.Provider{ID: 1}},
+		{Provider: &domain.Provider{ID: 2}},
+		{Provider: &domain.Provider{ID: 3}},
+	}
+	preferSticky(matched, 3)
+	if matched[0].Provider.ID != 3 || matched[1].Provider.ID != 1 || matched[2].Provider.ID != 2 {
+		t.Errorf("unexpected order %d,%d,%d", matched[0].Provider.ID, matched[1].Provider.ID, matched[2].Provider.ID)
+	}
+
+	// Unknown provider (e.g. cooling down and filtered out) keeps the normal order
+	preferSticky(matched, 9)
+	if matched[0].Provider.ID != 3 || matched[1].Provider.ID != 1 {
+		t.Errorf("order should be unchanged, got %d,%d", matched[0].Provider.ID, matched[1].Provider.ID)
+	}
+}
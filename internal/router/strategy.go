@@ -0,0 +1,136 @@
+package router
+
+import (
+	"math"
+	"sort"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/pricing"
+)
+
+// MapModel resolves the upstream model for a route
+// Route mapping (exact entries, then ordered rules) takes precedence over provider mapping
+func MapModel(requestModel string, route *domain.Route, provider *domain.Provider) string {
+	if route.ModelMapping != nil {
+		if mapped, ok := route.ModelMapping[requestModel]; ok {
+			return mapped
+		}
+	}
+	if mapped, ok := applyMappingRules(requestModel, route.ModelMappingRules); ok {
+		return mapped
+	}
+
+	if provider != nil && provider.Config != nil {
+		if provider.Config.Custom != nil && provider.Config.Custom.ModelMapping != nil {
+			if mapped, ok := provider.Config.Custom.ModelMapping[requestModel]; ok {
+				return mapped
+			}
+		}
+		if provider.Config.Antigravity != nil && provider.Config.Antigravity.ModelMapping != nil {
+			if mapped, ok := provider.Config.Antigravity.ModelMapping[requestModel]; ok {
+				return mapped
+			}
+		}
+	}
+
+	return requestModel
+}
+
+func (r *Router) sortRoutes(routes []*domain.Route, strategy *domain.RoutingStrategy, providers map[uint64]*domain.Provider, mc *MatchContext) {
+	// Priority order is the base for every strategy and breaks ties
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].Position < routes[j].Position
+	})
+
+	config := strategy.Config
+	if config == nil {
+		config = &domain.RoutingStrategyConfig{}
+	}
+
+	switch strategy.Type {
+	case domain.RoutingStrategyWeightedRandom:
+		r.sortWeighted(routes, config)
+	case domain.RoutingStrategyLeastLatency:
+		r.sortByLatency(routes, config, mc.ClientType)
+	case domain.RoutingStrategyLeastCost:
+		sortByCost(routes, providers, mc.RequestModel)
+	}
+}
+
+// sortWeighted orders routes by weighted random sampling without replacement
+// (exponential race: each route draws -ln(u)/weight, smallest wins), so the first route
+// is picked with probability weight/sum and the remaining order stays weighted for failover
+func (r *Router) sortWeighted(routes []*domain.Route, config *domain.RoutingStrategyConfig) {
+	defaultWeight := config.DefaultWeight
+	if defaultWeight <= 0 {
+		defaultWeight = 1
+	}
+
+	keys := make(map[uint64]float64, len(routes))
+	for _, route := range routes {
+		weight := defaultWeight
+		if w, ok := config.Weights[route.ID]; ok {
+			weight = w
+		}
+		if weight <= 0 {
+			keys[route.ID] = math.Inf(1)
+			continue
+		}
+		u := r.randFloat()
+		for u == 0 {
+			u = r.randFloat()
+		}
+		keys[route.ID] = -math.Log(u) / float64(weight)
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return keys[routes[i].ID] < keys[routes[j].ID]
+	})
+}
+
+// sortByLatency puts routes without samples first (so new providers get measured),
+// then orders by ascending average latency
+func (r *Router) sortByLatency(routes []*domain.Route, config *domain.RoutingStrategyConfig, clientType domain.ClientType) {
+	metric := config.LatencyMetric
+	if metric == "" {
+		metric = domain.LatencyMetricTTFB
+	}
+
+	latency := make(map[uint64]float64, len(routes))
+	for _, route := range routes {
+		if d, ok := r.latency.Get(route.ProviderID, clientType, metric); ok {
+			latency[route.ID] = float64(d)
+		} else {
+			latency[route.ID] = -1
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return latency[routes[i].ID] < latency[routes[j].ID]
+	})
+}
+
+// sortByCost orders routes by what the mapped model costs on each provider
+// (input + output per M tokens, after the provider's multiplier and currency, in USD)
+// Routes whose model has no price go last
+func sortByCost(routes []*domain.Route, providers map[uint64]*domain.Provider, requestModel string) {
+	calc := pricing.GlobalCalculator()
+	cost := make(map[uint64]float64, len(routes))
+	for _, route := range routes {
+		provider := providers[route.ProviderID]
+		model := MapModel(requestModel, route, provider)
+		if p := calc.GetPricing(model); p != nil {
+			var providerPricing *domain.ProviderPricing
+			if provider != nil {
+				providerPricing = provider.Pricing
+			}
+			cost[route.ID] = float64(pricing.ApplyProviderPricing(p.InputPriceMicro+p.OutputPriceMicro, model, providerPricing))
+		} else {
+			cost[route.ID] = math.Inf(1)
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return cost[routes[i].ID] < cost[routes[j].ID]
+	})
+}
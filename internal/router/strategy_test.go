@@ -0,0 +1,263 @@
+package router
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+)
+
+func newTestRouter(seed int64) *Router {
+	return &Router{
+		latency:   NewLatencyTracker(),
+		randFloat: rand.New(rand.NewSource(seed)).Float64,
+	}
+}
+
+func testRoutes(n int) []*domain.Route {
+	routes := make([]*domain.Route, n)
+	for i := range routes {
+		routes[i] = &domain.Route{ID: uint64(i + 1), ProviderID: uint64(i + 1), Position: i}
+	}
+	return routes
+}
+
+// firstPicks sorts the routes trials times and counts which route ends up first
+func firstPicks(r *Router, n, trials int, strategy *domain.RoutingStrategy, providers map[uint64]*domain.Provider, mc *MatchContext) map[uint64]int {
+	counts := make(map[uint64]int)
+	for i := 0; i < trials; i++ {
+		routes := testRoutes(n)
+		r.sortRoutes(routes, strategy, providers, mc)
+		counts[routes[0].ID]++
+	}
+	return counts
+}
+
+func TestSortWeighted_Distribution(t *testing.T) {
+	r := newTestRouter(42)
+	strategy := &domain.RoutingStrategy{
+		Type: domain.RoutingStrategyWeightedRandom,
+		Config: &domain.RoutingStrategyConfig{
+			Weights: map[uint64]int{1: 1, 2: 3, 3: 6, 4: 0},
+		},
+	}
+	mc := &MatchContext{ClientType: domain.ClientTypeClaude}
+
+	const trials = 100_000
+	counts := firstPicks(r, 4, trials, strategy, nil, mc)
+
+	expected := map[uint64]float64{1: 0.1, 2: 0.3, 3: 0.6, 4: 0}
+	for id, want := range expected {
+		got := float64(counts[id]) / trials
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("route %d picked first %.3f of the time, want %.3f", id, got, want)
+		}
+	}
+}
+
+func TestSortWeighted_DefaultWeight(t *testing.T) {
+	r := newTestRouter(7)
+	strategy := &domain.RoutingStrategy{
+		Type:   domain.RoutingStrategyWeightedRandom,
+		Config: &domain.RoutingStrategyConfig{Weights: map[uint64]int{1: 2}, DefaultWeight: 1},
+	}
+	mc := &MatchContext{ClientType: domain.ClientTypeClaude}
+
+	const trials = 60_000
+	counts := firstPicks(r, 3, trials, strategy, nil, mc)
+
+	// weights 2:1:1
+	expected := map[uint64]float64{1: 0.5, 2: 0.25, 3: 0.25}
+	for id, want := range expected {
+		got := float64(counts[id]) / trials
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("route %d picked first %.3f of the time, want %.3f", id, got, want)
+		}
+	}
+}
+
+func TestSortWeighted_ZeroWeightIsFallback(t *testing.T) {
+	r := newTestRouter(1)
+	strategy := &domain.RoutingStrategy{
+		Type:   domain.RoutingStrategyWeightedRandom,
+		Config: &domain.RoutingStrategyConfig{Weights: map[uint64]int{1: 0}},
+	}
+	for i := 0; i < 1000; i++ {
+		routes := testRoutes(3)
+		r.sortRoutes(routes, strategy, nil, &MatchContext{})
+		if len(routes) != 3 || routes[2].ID != 1 {
+			t.Fatalf("zero-weight route should be kept last, got order %d,%d,%d", routes[0].ID, routes[1].ID, routes[2].ID)
+		}
+	}
+}
+
+func TestSortByLatency_Distribution(t *testing.T) {
+	r := newTestRouter(1)
+	ct := domain.ClientTypeClaude
+	for i := 0; i < 20; i++ {
+		r.RecordLatency(1, ct, 900*time.Millisecond, 5*time.Second)
+		r.RecordLatency(2, ct, 200*time.Millisecond, 9*time.Second)
+		r.RecordLatency(3, ct, 500*time.Millisecond, 2*time.Second)
+	}
+	mc := &MatchContext{ClientType: ct}
+
+	const trials = 1000
+	ttfb := firstPicks(r, 3, trials, &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastLatency}, nil, mc)
+	if ttfb[2] != trials {
+		t.Errorf("ttfb: fastest route picked %d/%d times", ttfb[2], trials)
+	}
+
+	duration := firstPicks(r, 3, trials, &domain.RoutingStrategy{
+		Type:   domain.RoutingStrategyLeastLatency,
+		Config: &domain.RoutingStrategyConfig{LatencyMetric: domain.LatencyMetricDuration},
+	}, nil, mc)
+	if duration[3] != trials {
+		t.Errorf("duration: fastest route picked %d/%d times", duration[3], trials)
+	}
+
+	// Full order follows the averages
+	routes := testRoutes(3)
+	r.sortRoutes(routes, &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastLatency}, nil, mc)
+	if routes[0].ID != 2 || routes[1].ID != 3 || routes[2].ID != 1 {
+		t.Errorf("unexpected order %d,%d,%d", routes[0].ID, routes[1].ID, routes[2].ID)
+	}
+}
+
+func TestSortByLatency_UnmeasuredFirstAndAdapts(t *testing.T) {
+	r := newTestRouter(1)
+	ct := domain.ClientTypeClaude
+	strategy := &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastLatency}
+	mc := &MatchContext{ClientType: ct}
+
+	r.RecordLatency(1, ct, 100*time.Millisecond, time.Second)
+	routes := testRoutes(2)
+	r.sortRoutes(routes, strategy, nil, mc)
+	if routes[0].ID != 2 {
+		t.Fatalf("route without samples should be tried first, got %d", routes[0].ID)
+	}
+
+	// Provider 1 slows down; the moving average should move it behind provider 2
+	r.RecordLatency(2, ct, 300*time.Millisecond, time.Second)
+	for i := 0; i < 20; i++ {
+		r.RecordLatency(1, ct, 2*time.Second, time.Second)
+	}
+	routes = testRoutes(2)
+	r.sortRoutes(routes, strategy, nil, mc)
+	if routes[0].ID != 2 {
+		t.Errorf("expected provider 2 after provider 1 slowed down, got %d", routes[0].ID)
+	}
+}
+
+func TestSortByCost_Distribution(t *testing.T) {
+	r := newTestRouter(1)
+	providers := map[uint64]*domain.Provider{
+		1: {ID: 1, Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{}}},
+		2: {ID: 2, Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			ModelMapping: map[string]string{"claude-sonnet-4-5": "claude-haiku-4-5"},
+		}}},
+		3: {ID: 3, Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			ModelMapping: map[string]string{"claude-sonnet-4-5": "unpriced-model"},
+		}}},
+	}
+	mc := &MatchContext{ClientType: domain.ClientTypeClaude, RequestModel: "claude-sonnet-4-5"}
+	strategy := &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastCost}
+
+	const trials = 1000
+	counts := firstPicks(r, 3, trials, strategy, providers, mc)
+	if counts[2] != trials {
+		t.Errorf("cheapest route picked %d/%d times", counts[2], trials)
+	}
+
+	routes := testRoutes(3)
+	r.sortRoutes(routes, strategy, providers, mc)
+	if routes[1].ID != 1 || routes[2].ID != 3 {
+		t.Errorf("unexpected order %d,%d,%d (unpriced model should be last)", routes[0].ID, routes[1].ID, routes[2].ID)
+	}
+}
+
+func TestSortByCost_ProviderPricing(t *testing.T) {
+	r := newTestRouter(1)
+	strategy := &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastCost}
+	mc := &MatchContext{RequestModel: "claude-sonnet-4-5"}
+
+	// By list price haiku on provider 1 is cheapest, but provider 1 charges 5x
+	// while provider 2 sells sonnet at half price: 6*5 = 30 vs 18*0.5 = 9
+	providers := map[uint64]*domain.Provider{
+		1: {ID: 1, Pricing: &domain.ProviderPricing{Multiplier: 5}, Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+			ModelMapping: map[string]string{"claude-sonnet-4-5": "claude-haiku-4-5"},
+		}}},
+		2: {ID: 2, Pricing: &domain.ProviderPricing{ModelMultipliers: map[string]float64{"claude-sonnet": 0.5}}},
+	}
+	routes := testRoutes(2)
+	r.sortRoutes(routes, strategy, providers, mc)
+	if routes[0].ID != 2 {
+		t.Errorf("expected route 2 (discounted sonnet) first, got %d", routes[0].ID)
+	}
+
+	// Costs in another currency are compared in USD: 18 units at 1 unit = 0.1 USD is 1.8
+	providers[3] = &domain.Provider{ID: 3, Pricing: &domain.ProviderPricing{Currency: "CNY", USDPerUnit: 0.1}}
+	routes = testRoutes(3)
+	r.sortRoutes(routes, strategy, providers, mc)
+	if routes[0].ID != 3 || routes[1].ID != 2 || routes[2].ID != 1 {
+		t.Errorf("unexpected order %d,%d,%d, want 3,2,1", routes[0].ID, routes[1].ID, routes[2].ID)
+	}
+}
+
+func TestSortRoutes_RouteMappingOverridesProvider(t *testing.T) {
+	r := newTestRouter(1)
+	routes := testRoutes(2)
+	routes[0].ModelMapping = map[string]string{"claude-opus-4-5": "claude-haiku-4-5"}
+	providers := map[uint64]*domain.Provider{1: {ID: 1}, 2: {ID: 2}}
+	mc := &MatchContext{RequestModel: "claude-opus-4-5"}
+
+	// Route 2 (unmapped, opus) is second by position; route 1 maps to haiku and stays first
+	r.sortRoutes(routes, &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastCost}, providers, mc)
+	if routes[0].ID != 1 {
+		t.Errorf("expected route 1 (mapped to haiku) first, got %d", routes[0].ID)
+	}
+
+	// Reverse positions: cost still wins over priority
+	routes = testRoutes(2)
+	routes[1].ModelMapping = map[string]string{"claude-opus-4-5": "claude-haiku-4-5"}
+	r.sortRoutes(routes, &domain.RoutingStrategy{Type: domain.RoutingStrategyLeastCost}, providers, mc)
+	if routes[0].ID != 2 {
+		t.Errorf("expected route 2 (mapped to haiku) first, got %d", routes[0].ID)
+	}
+}
+
+func TestMapModel_Rules(t *testing.T) {
+	route := &domain.Route{
+		ModelMapping: map[string]string{"claude-opus-4-5": "exact"},
+		ModelMappingRules: []domain.ModelMappingRule{
+			{Pattern: "claude-*-4-5-2025????", Target: "claude-${1}-4-5"},
+			{Pattern: `^gpt-(?P<size>\w+)-preview$`, Regex: true, Target: "gpt-$size"},
+			{Pattern: "claude-*", Target: "claude-sonnet-4-5"},
+		},
+		FallbackModels: []string{"claude-sonnet-4-5", "claude-haiku-4-5"},
+	}
+	provider := &domain.Provider{Config: &domain.ProviderConfig{Custom: &domain.ProviderConfigCustom{
+		ModelMapping: map[string]string{"gpt-4o": "relay-4o"},
+	}}}
+
+	tests := map[string]string{
+		"claude-opus-4-5":           "exact",             // exact entries win
+		"claude-haiku-4-5-20251001": "claude-haiku-4-5",  // glob captures
+		"gpt-mini-preview":          "gpt-mini",          // named regex group
+		"claude-3-opus":             "claude-sonnet-4-5", // rules apply in order
+		"gpt-4o":                    "relay-4o",          // provider mapping after route rules
+		"gemini-2.5-pro":            "gemini-2.5-pro",
+	}
+	for model, want := range tests {
+		if got := MapModel(model, route, provider); got != want {
+			t.Errorf("MapModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	// The mapped model is not repeated in the fallback chain
+	chain := ModelChain("claude-3-opus", route, provider)
+	if len(chain) != 2 || chain[0] != "claude-sonnet-4-5" || chain[1] != "claude-haiku-4-5" {
+		t.Errorf("unexpected model chain %v", chain)
+	}
+}
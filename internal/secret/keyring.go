@@ -0,0 +1,178 @@
+// Package secret implements envelope encryption for credentials stored in the database.
+//
+// Every value is encrypted with its own random data key (AES-256-GCM). The data key is
+// wrapped with the master key and stored next to the ciphertext, so rotating the master
+// key only rewraps data keys and never touches the encrypted payload.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// Prefix marks a value produced by Keyring.Encrypt
+	Prefix = "enc:v1:"
+
+	// KeySize is the master key length in bytes (AES-256)
+	KeySize = 32
+)
+
+var (
+	ErrNotEncrypted = errors.New("value is not encrypted")
+	ErrMalformed    = errors.New("malformed encrypted value")
+	ErrWrongKey     = errors.New("value was encrypted with a different master key")
+)
+
+// Keyring encrypts and decrypts secrets with a master key
+// Encrypted format: enc:v1:<key id>:<wrapped data key>:<ciphertext> (base64 parts)
+type Keyring struct {
+	id     string
+	master cipher.AEAD
+}
+
+// NewKeyring creates a keyring from a 32-byte master key
+func NewKeyring(masterKey []byte) (*Keyring, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", KeySize, len(masterKey))
+	}
+	aead, err := newGCM(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(masterKey)
+	return &Keyring{id: hex.EncodeToString(sum[:4]), master: aead}, nil
+}
+
+// ID returns a short fingerprint of the master key (safe to log)
+func (k *Keyring) ID() string {
+	return k.id
+}
+
+// IsEncrypted reports whether s was produced by Encrypt
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Encrypt encrypts plaintext with a fresh data key. Empty strings stay empty.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	wrapped, err := seal(k.master, dataKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(data, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return format(k.id, wrapped, ciphertext), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt. Plaintext values are returned unchanged
+// so rows written before encryption was enabled can still be read.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	_, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := open(k.master, wrapped)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	data, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(data, ciphertext)
+	if err != nil {
+		return "", ErrMalformed
+	}
+	return string(plaintext), nil
+}
+
+// Rewrap re-encrypts the data key of value with next's master key
+// The ciphertext itself is left untouched
+func (k *Keyring) Rewrap(value string, next *Keyring) (string, error) {
+	if !IsEncrypted(value) {
+		return "", ErrNotEncrypted
+	}
+	_, wrapped, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := open(k.master, wrapped)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	rewrapped, err := seal(next.master, dataKey)
+	if err != nil {
+		return "", err
+	}
+	return format(next.id, rewrapped, ciphertext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal returns nonce || ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func format(id string, wrapped, ciphertext []byte) string {
+	return Prefix + id + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext)
+}
+
+func parse(value string) (id string, wrapped, ciphertext []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+	if wrapped, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	if ciphertext, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, ErrMalformed
+	}
+	return parts[0], wrapped, ciphertext, nil
+}
@@ -0,0 +1,154 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestKeyring(t *testing.T, fill byte) *Keyring {
+	t.Helper()
+	kr, err := NewKeyring(bytes.Repeat([]byte{fill}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kr
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	kr := newTestKeyring(t, 1)
+
+	for _, plaintext := range []string{"sk-ant-api03-secret", "k-1", "多字节 secret ✓", strings.Repeat("long", 1000)} {
+		enc, err := kr.Encrypt(plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(enc) || strings.Contains(enc, plaintext) {
+			t.Errorf("%q: not encrypted: %q", plaintext, enc)
+		}
+		if !strings.HasPrefix(enc, Prefix+kr.ID()+":") {
+			t.Errorf("%q: missing key id: %q", plaintext, enc)
+		}
+		dec, err := kr.Decrypt(enc)
+		if err != nil {
+			t.Fatalf("%q: %v", plaintext, err)
+		}
+		if dec != plaintext {
+			t.Errorf("got %q, want %q", dec, plaintext)
+		}
+	}
+
+	// A fresh data key and nonce per value
+	a, _ := kr.Encrypt("same")
+	b, _ := kr.Encrypt("same")
+	if a == b {
+		t.Error("encrypting the same value twice gave the same output")
+	}
+}
+
+func TestEncryptPassthrough(t *testing.T) {
+	kr := newTestKeyring(t, 1)
+
+	if enc, _ := kr.Encrypt(""); enc != "" {
+		t.Errorf("empty value encrypted to %q", enc)
+	}
+	enc, _ := kr.Encrypt("secret")
+	if again, _ := kr.Encrypt(enc); again != enc {
+		t.Error("encrypted value was encrypted twice")
+	}
+	// Rows written before encryption was enabled
+	if dec, err := kr.Decrypt("plain-key"); err != nil || dec != "plain-key" {
+		t.Errorf("plaintext: got %q, %v", dec, err)
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	enc, err := newTestKeyring(t, 1).Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestKeyring(t, 2).Decrypt(enc); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("got %v, want ErrWrongKey", err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	kr := newTestKeyring(t, 1)
+	enc, err := kr.Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimPrefix(enc, Prefix), ":")
+
+	// flip changes one character of a base64 part without breaking the encoding
+	flip := func(s string) string {
+		c := byte('A')
+		if s[len(s)/2] == 'A' {
+			c = 'B'
+		}
+		return s[:len(s)/2] + string(c) + s[len(s)/2+1:]
+	}
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"ciphertext", Prefix + parts[0] + ":" + parts[1] + ":" + flip(parts[2]), ErrMalformed},
+		{"wrapped key", Prefix + parts[0] + ":" + flip(parts[1]) + ":" + parts[2], ErrWrongKey},
+		{"truncated", enc[:len(enc)-4], ErrMalformed},
+		{"missing part", Prefix + parts[0] + ":" + parts[1], ErrMalformed},
+		{"bad base64", Prefix + parts[0] + ":" + parts[1] + ":!!!", ErrMalformed},
+		{"short wrapped key", Prefix + parts[0] + ":AAAA:" + parts[2], ErrWrongKey},
+	}
+	for _, tt := range tests {
+		if _, err := kr.Decrypt(tt.value); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	current := newTestKeyring(t, 1)
+	next := newTestKeyring(t, 2)
+	enc, err := current.Encrypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rewrapped, err := current.Rewrap(enc, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rewrapped, Prefix+next.ID()+":") {
+		t.Errorf("rewrapped value has the old key id: %q", rewrapped)
+	}
+	// Only the data key changes, the payload is not re-encrypted
+	if oldParts, newParts := strings.Split(enc, ":"), strings.Split(rewrapped, ":"); oldParts[4] != newParts[4] {
+		t.Error("ciphertext changed during rewrap")
+	}
+	if dec, err := next.Decrypt(rewrapped); err != nil || dec != "secret" {
+		t.Errorf("new key: got %q, %v", dec, err)
+	}
+	if _, err := current.Decrypt(rewrapped); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("old key: got %v, want ErrWrongKey", err)
+	}
+
+	// Rewrapping needs the key the value was encrypted with
+	if _, err := next.Rewrap(enc, current); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("rewrap with the wrong key: got %v, want ErrWrongKey", err)
+	}
+	if _, err := current.Rewrap("plain-key", next); !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("rewrap plaintext: got %v, want ErrNotEncrypted", err)
+	}
+}
+
+func TestNewKeyringKeySize(t *testing.T) {
+	if _, err := NewKeyring(make([]byte, 16)); err == nil {
+		t.Error("expected an error for a 16-byte key")
+	}
+	a, b := newTestKeyring(t, 1), newTestKeyring(t, 2)
+	if a.ID() == b.ID() || a.ID() != newTestKeyring(t, 1).ID() {
+		t.Errorf("key ids should identify the key: %s, %s", a.ID(), b.ID())
+	}
+}
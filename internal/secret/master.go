@@ -0,0 +1,100 @@
+package secret
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// EnvMasterKey holds the master key (base64 or hex encoded 32 bytes)
+	EnvMasterKey = "MAXX_MASTER_KEY"
+
+	// KeyFileName is the master key file created in the data directory
+	KeyFileName = "master.key"
+)
+
+// KeySource describes where the master key was loaded from
+type KeySource string
+
+const (
+	KeySourceEnv  KeySource = "env"
+	KeySourceFile KeySource = "file"
+)
+
+// LoadKeyring loads the master key from MAXX_MASTER_KEY, or from <dataDir>/master.key.
+// If neither exists a new key file is generated with 0600 permissions.
+func LoadKeyring(dataDir string) (*Keyring, KeySource, error) {
+	if env := strings.TrimSpace(os.Getenv(EnvMasterKey)); env != "" {
+		key, err := DecodeKey(env)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", EnvMasterKey, err)
+		}
+		kr, err := NewKeyring(key)
+		return kr, KeySourceEnv, err
+	}
+
+	path := KeyFilePath(dataDir)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key, err := GenerateKey()
+		if err != nil {
+			return nil, "", err
+		}
+		if err := WriteKeyFile(path, key); err != nil {
+			return nil, "", err
+		}
+		kr, err := NewKeyring(key)
+		return kr, KeySourceFile, err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	key, err := DecodeKey(string(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	kr, err := NewKeyring(key)
+	return kr, KeySourceFile, err
+}
+
+// KeyFilePath returns the master key file location inside dataDir
+func KeyFilePath(dataDir string) string {
+	return filepath.Join(dataDir, KeyFileName)
+}
+
+// GenerateKey returns a new random master key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncodeKey encodes a master key for env vars and key files
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// DecodeKey accepts a base64 or hex encoded 32-byte key
+func DecodeKey(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if key, err := base64.StdEncoding.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := hex.DecodeString(s); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("master key must be %d bytes encoded as base64 or hex", KeySize)
+}
+
+// WriteKeyFile writes key to path with owner-only permissions
+func WriteKeyFile(path string, key []byte) error {
+	return os.WriteFile(path, []byte(EncodeKey(key)+"\n"), 0600)
+}
@@ -0,0 +1,89 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKeyringGeneratesKeyFile(t *testing.T) {
+	t.Setenv(EnvMasterKey, "")
+	dir := t.TempDir()
+
+	kr, source, err := LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != KeySourceFile {
+		t.Errorf("source = %s, want %s", source, KeySourceFile)
+	}
+	info, err := os.Stat(filepath.Join(dir, KeyFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("key file permissions = %o, want 600", perm)
+	}
+
+	// The next start reads the same key back
+	again, _, err := LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, _ := kr.Encrypt("secret")
+	if dec, err := again.Decrypt(enc); err != nil || dec != "secret" {
+		t.Errorf("reloaded key: got %q, %v", dec, err)
+	}
+}
+
+func TestLoadKeyringFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	key := bytes.Repeat([]byte{7}, KeySize)
+	t.Setenv(EnvMasterKey, hex.EncodeToString(key))
+
+	kr, source, err := LoadKeyring(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := NewKeyring(key)
+	if source != KeySourceEnv || kr.ID() != want.ID() {
+		t.Errorf("got %s key %s, want env key %s", source, kr.ID(), want.ID())
+	}
+	if _, err := os.Stat(filepath.Join(dir, KeyFileName)); !os.IsNotExist(err) {
+		t.Error("a key file was written although the key came from the environment")
+	}
+
+	t.Setenv(EnvMasterKey, "too-short")
+	if _, _, err := LoadKeyring(dir); err == nil {
+		t.Error("expected an error for an invalid env key")
+	}
+}
+
+func TestDecodeKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, KeySize)
+	tests := []struct {
+		name    string
+		encoded string
+		wantErr bool
+	}{
+		{"base64", EncodeKey(key), false},
+		{"base64 with newline", EncodeKey(key) + "\n", false},
+		{"hex", hex.EncodeToString(key), false},
+		{"short", EncodeKey(key[:16]), true},
+		{"garbage", "not a key", true},
+	}
+	for _, tt := range tests {
+		got, err := DecodeKey(tt.encoded)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("%s: got %x, %v", tt.name, got, err)
+		}
+	}
+}
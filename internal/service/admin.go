@@ -1,12 +1,22 @@
 package service
 
 import (
+	"fmt"
+	"log"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/awsl-project/maxx/internal/budget"
+	"github.com/awsl-project/maxx/internal/circuit"
+	"github.com/awsl-project/maxx/internal/cooldown"
 	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/pricing"
+	"github.com/awsl-project/maxx/internal/ratelimit"
 	"github.com/awsl-project/maxx/internal/repository"
+	"github.com/awsl-project/maxx/internal/router"
+	"github.com/awsl-project/maxx/internal/usage"
 )
 
 // ProviderAdapterRefresher is an interface for refreshing provider adapters
@@ -16,6 +26,22 @@ type ProviderAdapterRefresher interface {
 	RemoveAdapter(providerID uint64)
 }
 
+// RouteExplainer explains how routes match a request
+// Implemented by Router
+type RouteExplainer interface {
+	Explain(mc *router.MatchContext) *router.MatchExplanation
+}
+
+// SignatureCache is the Antigravity signature cache as seen by the admin API:
+// its settings are validated before saving and re-applied after changes.
+// Implemented by antigravity.SignatureCache
+type SignatureCache interface {
+	IsSetting(key string) bool
+	ValidateSetting(key, value string) error
+	ApplySettings(settings repository.SystemSettingRepository)
+	Stats() *domain.SignatureCacheStats
+}
+
 // AdminService provides business logic for admin operations
 // Both HTTP handlers and Wails bindings call this service
 type AdminService struct {
@@ -28,8 +54,16 @@ type AdminService struct {
 	proxyRequestRepo    repository.ProxyRequestRepository
 	attemptRepo         repository.ProxyUpstreamAttemptRepository
 	settingRepo         repository.SystemSettingRepository
+	apiKeyRepo          repository.APIKeyRepository
+	rateLimitRepo       repository.RateLimitRepository
+	budgetRepo          repository.BudgetRepository
+	probeResultRepo     repository.ProbeResultRepository
+	cooldownPolicyRepo  repository.CooldownPolicyRepository
+	modelPriceRepo      repository.ModelPriceRepository
 	serverAddr          string
 	adapterRefresher    ProviderAdapterRefresher
+	routeExplainer      RouteExplainer
+	signatureCache      SignatureCache
 }
 
 // NewAdminService creates a new admin service
@@ -43,8 +77,16 @@ func NewAdminService(
 	proxyRequestRepo repository.ProxyRequestRepository,
 	attemptRepo repository.ProxyUpstreamAttemptRepository,
 	settingRepo repository.SystemSettingRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	rateLimitRepo repository.RateLimitRepository,
+	budgetRepo repository.BudgetRepository,
+	probeResultRepo repository.ProbeResultRepository,
+	cooldownPolicyRepo repository.CooldownPolicyRepository,
+	modelPriceRepo repository.ModelPriceRepository,
 	serverAddr string,
 	adapterRefresher ProviderAdapterRefresher,
+	routeExplainer RouteExplainer,
+	signatureCache SignatureCache,
 ) *AdminService {
 	return &AdminService{
 		providerRepo:        providerRepo,
@@ -56,8 +98,16 @@ func NewAdminService(
 		proxyRequestRepo:    proxyRequestRepo,
 		attemptRepo:         attemptRepo,
 		settingRepo:         settingRepo,
+		apiKeyRepo:          apiKeyRepo,
+		rateLimitRepo:       rateLimitRepo,
+		budgetRepo:          budgetRepo,
+		probeResultRepo:     probeResultRepo,
+		cooldownPolicyRepo:  cooldownPolicyRepo,
+		modelPriceRepo:      modelPriceRepo,
 		serverAddr:          serverAddr,
 		adapterRefresher:    adapterRefresher,
+		routeExplainer:      routeExplainer,
+		signatureCache:      signatureCache,
 	}
 }
 
@@ -72,6 +122,9 @@ func (s *AdminService) GetProvider(id uint64) (*domain.Provider, error) {
 }
 
 func (s *AdminService) CreateProvider(provider *domain.Provider) error {
+	if err := validateProviderPricing(provider.Pricing); err != nil {
+		return err
+	}
 	// Auto-set SupportedClientTypes based on provider type
 	s.autoSetSupportedClientTypes(provider)
 
@@ -86,9 +139,17 @@ func (s *AdminService) CreateProvider(provider *domain.Provider) error {
 }
 
 func (s *AdminService) UpdateProvider(provider *domain.Provider) error {
+	if err := validateProviderPricing(provider.Pricing); err != nil {
+		return err
+	}
 	// Auto-set SupportedClientTypes based on provider type
 	s.autoSetSupportedClientTypes(provider)
 
+	// Keep the stored secrets when the client sent back masked values
+	if existing, err := s.providerRepo.GetByID(provider.ID); err == nil {
+		provider.RestoreMaskedSecrets(existing)
+	}
+
 	if err := s.providerRepo.Update(provider); err != nil {
 		return err
 	}
@@ -176,6 +237,24 @@ type ImportResult struct {
 	Errors   []string `json:"errors"`
 }
 
+func validateProviderPricing(pp *domain.ProviderPricing) error {
+	if pp == nil {
+		return nil
+	}
+	if pp.Multiplier < 0 {
+		return fmt.Errorf("%w: pricing multiplier must not be negative", domain.ErrInvalidInput)
+	}
+	for model, m := range pp.ModelMultipliers {
+		if model == "" || m < 0 {
+			return fmt.Errorf("%w: invalid pricing multiplier for model %q", domain.ErrInvalidInput, model)
+		}
+	}
+	if pp.Currency != "" && pp.USDPerUnit <= 0 {
+		return fmt.Errorf("%w: usdPerUnit is required for currency %q", domain.ErrInvalidInput, pp.Currency)
+	}
+	return nil
+}
+
 // ===== Route API =====
 
 func (s *AdminService) GetRoutes() ([]*domain.Route, error) {
@@ -187,10 +266,22 @@ func (s *AdminService) GetRoute(id uint64) (*domain.Route, error) {
 }
 
 func (s *AdminService) CreateRoute(route *domain.Route) error {
+	if err := router.ValidateMatchRules(route.MatchRules); err != nil {
+		return err
+	}
+	if err := router.ValidateModelMapping(route.ModelMappingRules, route.FallbackModels); err != nil {
+		return err
+	}
 	return s.routeRepo.Create(route)
 }
 
 func (s *AdminService) UpdateRoute(route *domain.Route) error {
+	if err := router.ValidateMatchRules(route.MatchRules); err != nil {
+		return err
+	}
+	if err := router.ValidateModelMapping(route.ModelMappingRules, route.FallbackModels); err != nil {
+		return err
+	}
 	return s.routeRepo.Update(route)
 }
 
@@ -198,6 +289,37 @@ func (s *AdminService) DeleteRoute(id uint64) error {
 	return s.routeRepo.Delete(id)
 }
 
+// ValidateRouteMatchRules checks route match rules without saving them
+func (s *AdminService) ValidateRouteMatchRules(rules []domain.RouteMatchRule) error {
+	return router.ValidateMatchRules(rules)
+}
+
+// RouteExplainRequest describes a hypothetical request for ExplainRouteMatch
+type RouteExplainRequest struct {
+	ClientType  domain.ClientType `json:"clientType"`
+	ProjectID   uint64            `json:"projectID"`
+	Model       string            `json:"model"`
+	Stream      bool              `json:"stream"`
+	InputTokens int               `json:"inputTokens"`
+}
+
+// ExplainRouteMatch reports which routes (and which of their rules) would serve the request
+func (s *AdminService) ExplainRouteMatch(req RouteExplainRequest) (*router.MatchExplanation, error) {
+	if req.ClientType == "" {
+		return nil, fmt.Errorf("%w: clientType is required", domain.ErrInvalidInput)
+	}
+	if s.routeExplainer == nil {
+		return nil, fmt.Errorf("route explanation is not available")
+	}
+	return s.routeExplainer.Explain(&router.MatchContext{
+		ClientType:   req.ClientType,
+		ProjectID:    req.ProjectID,
+		RequestModel: req.Model,
+		IsStream:     req.Stream,
+		InputTokens:  req.InputTokens,
+	}), nil
+}
+
 // ===== Project API =====
 
 func (s *AdminService) GetProjects() ([]*domain.Project, error) {
@@ -324,6 +446,450 @@ func (s *AdminService) DeleteRoutingStrategy(id uint64) error {
 	return s.routingStrategyRepo.Delete(id)
 }
 
+// ===== API Key API =====
+
+func (s *AdminService) GetAPIKeys() ([]*domain.APIKey, error) {
+	return s.apiKeyRepo.List()
+}
+
+func (s *AdminService) GetAPIKey(id uint64) (*domain.APIKey, error) {
+	return s.apiKeyRepo.GetByID(id)
+}
+
+// CreateAPIKey generates a new client key and stores only its hash
+// The returned copy carries the plaintext key, which is shown once and never persisted
+func (s *AdminService) CreateAPIKey(key *domain.APIKey) (*domain.APIKey, error) {
+	plain, prefix, err := domain.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key.ID = 0
+	key.Key = ""
+	key.KeyPrefix = prefix
+	key.KeyHash = domain.HashAPIKey(plain)
+	key.LastUsedAt = nil
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, err
+	}
+	created := *key
+	created.Key = plain
+	return &created, nil
+}
+
+func (s *AdminService) UpdateAPIKey(key *domain.APIKey) error {
+	existing, err := s.apiKeyRepo.GetByID(key.ID)
+	if err != nil {
+		return err
+	}
+	// Key material is immutable, only metadata can be updated
+	key.KeyPrefix = existing.KeyPrefix
+	key.KeyHash = existing.KeyHash
+	key.LastUsedAt = existing.LastUsedAt
+	key.Key = ""
+	return s.apiKeyRepo.Update(key)
+}
+
+func (s *AdminService) DeleteAPIKey(id uint64) error {
+	return s.apiKeyRepo.Delete(id)
+}
+
+// ===== Rate Limit API =====
+
+func (s *AdminService) GetRateLimits() ([]*domain.RateLimit, error) {
+	return s.rateLimitRepo.List()
+}
+
+func (s *AdminService) GetRateLimit(id uint64) (*domain.RateLimit, error) {
+	return s.rateLimitRepo.GetByID(id)
+}
+
+func (s *AdminService) CreateRateLimit(limit *domain.RateLimit) error {
+	if err := validateRateLimit(limit); err != nil {
+		return err
+	}
+	if err := s.rateLimitRepo.Create(limit); err != nil {
+		return err
+	}
+	s.reloadRateLimits()
+	return nil
+}
+
+func (s *AdminService) UpdateRateLimit(limit *domain.RateLimit) error {
+	if err := validateRateLimit(limit); err != nil {
+		return err
+	}
+	if err := s.rateLimitRepo.Update(limit); err != nil {
+		return err
+	}
+	s.reloadRateLimits()
+	return nil
+}
+
+func (s *AdminService) DeleteRateLimit(id uint64) error {
+	if err := s.rateLimitRepo.Delete(id); err != nil {
+		return err
+	}
+	s.reloadRateLimits()
+	return nil
+}
+
+func (s *AdminService) reloadRateLimits() {
+	if err := ratelimit.Default().Load(); err != nil {
+		log.Printf("[RateLimit] Failed to reload limits: %v", err)
+	}
+}
+
+func validateRateLimit(limit *domain.RateLimit) error {
+	switch limit.Scope {
+	case domain.RateLimitScopeProvider, domain.RateLimitScopeProject, domain.RateLimitScopeAPIKey:
+	default:
+		return fmt.Errorf("%w: unknown scope %q", domain.ErrInvalidInput, limit.Scope)
+	}
+	if limit.RPM < 0 || limit.TPM < 0 || limit.MaxConcurrency < 0 || limit.QueueTimeout < 0 {
+		return fmt.Errorf("%w: limits must not be negative", domain.ErrInvalidInput)
+	}
+	return nil
+}
+
+// ===== Budget API =====
+
+func (s *AdminService) GetBudgets() ([]*domain.Budget, error) {
+	return s.budgetRepo.List()
+}
+
+func (s *AdminService) GetBudget(id uint64) (*domain.Budget, error) {
+	return s.budgetRepo.GetByID(id)
+}
+
+// GetBudgetStatus returns the current period's spend of every enabled budget
+func (s *AdminService) GetBudgetStatus() []*domain.BudgetStatus {
+	return budget.Default().Status()
+}
+
+func (s *AdminService) CreateBudget(b *domain.Budget) error {
+	if err := validateBudget(b); err != nil {
+		return err
+	}
+	if err := s.budgetRepo.Create(b); err != nil {
+		return err
+	}
+	s.reloadBudgets()
+	return nil
+}
+
+func (s *AdminService) UpdateBudget(b *domain.Budget) error {
+	if err := validateBudget(b); err != nil {
+		return err
+	}
+	if err := s.budgetRepo.Update(b); err != nil {
+		return err
+	}
+	s.reloadBudgets()
+	return nil
+}
+
+func (s *AdminService) DeleteBudget(id uint64) error {
+	if err := s.budgetRepo.Delete(id); err != nil {
+		return err
+	}
+	s.reloadBudgets()
+	return nil
+}
+
+func (s *AdminService) reloadBudgets() {
+	if err := budget.Default().Load(); err != nil {
+		log.Printf("[Budget] Failed to reload budgets: %v", err)
+	}
+}
+
+func validateBudget(b *domain.Budget) error {
+	switch b.Scope {
+	case domain.BudgetScopeProject, domain.BudgetScopeProvider:
+	default:
+		return fmt.Errorf("%w: unknown scope %q", domain.ErrInvalidInput, b.Scope)
+	}
+	switch b.Period {
+	case domain.BudgetPeriodDaily, domain.BudgetPeriodMonthly:
+	default:
+		return fmt.Errorf("%w: unknown period %q", domain.ErrInvalidInput, b.Period)
+	}
+	if b.Limit == 0 {
+		return fmt.Errorf("%w: limit is required", domain.ErrInvalidInput)
+	}
+	if b.WarnPercent < 0 || b.WarnPercent > 100 {
+		return fmt.Errorf("%w: warnPercent must be between 0 and 100", domain.ErrInvalidInput)
+	}
+	if b.ResetHour < 0 || b.ResetHour > 23 {
+		return fmt.Errorf("%w: resetHour must be between 0 and 23", domain.ErrInvalidInput)
+	}
+	// Days after the 28th don't exist in every month
+	if b.ResetDay < 0 || b.ResetDay > 28 {
+		return fmt.Errorf("%w: resetDay must be between 1 and 28", domain.ErrInvalidInput)
+	}
+	if _, err := budget.LoadLocation(b.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", domain.ErrInvalidInput, b.Timezone)
+	}
+	return nil
+}
+
+// ===== Cooldown Policy API =====
+
+func (s *AdminService) GetCooldownPolicies() ([]*domain.CooldownPolicyConfig, error) {
+	return s.cooldownPolicyRepo.List()
+}
+
+func (s *AdminService) GetCooldownPolicy(id uint64) (*domain.CooldownPolicyConfig, error) {
+	return s.cooldownPolicyRepo.GetByID(id)
+}
+
+func (s *AdminService) CreateCooldownPolicy(p *domain.CooldownPolicyConfig) error {
+	if err := validateCooldownPolicy(p); err != nil {
+		return err
+	}
+	if err := s.cooldownPolicyRepo.Create(p); err != nil {
+		return err
+	}
+	s.reloadCooldownPolicies()
+	return nil
+}
+
+func (s *AdminService) UpdateCooldownPolicy(p *domain.CooldownPolicyConfig) error {
+	if err := validateCooldownPolicy(p); err != nil {
+		return err
+	}
+	if err := s.cooldownPolicyRepo.Update(p); err != nil {
+		return err
+	}
+	s.reloadCooldownPolicies()
+	return nil
+}
+
+func (s *AdminService) DeleteCooldownPolicy(id uint64) error {
+	if err := s.cooldownPolicyRepo.Delete(id); err != nil {
+		return err
+	}
+	s.reloadCooldownPolicies()
+	return nil
+}
+
+func (s *AdminService) reloadCooldownPolicies() {
+	if err := cooldown.Default().LoadPolicies(); err != nil {
+		log.Printf("[Cooldown] Failed to reload policies: %v", err)
+	}
+}
+
+func validateCooldownPolicy(p *domain.CooldownPolicyConfig) error {
+	switch p.Reason {
+	case domain.CooldownReasonServerError, domain.CooldownReasonNetworkError, domain.CooldownReasonQuotaExhausted,
+		domain.CooldownReasonRateLimitExceeded, domain.CooldownReasonConcurrentLimit, domain.CooldownReasonUnknown:
+	default:
+		return fmt.Errorf("%w: unknown reason %q", domain.ErrInvalidInput, p.Reason)
+	}
+	switch p.Type {
+	case domain.CooldownPolicyFixed, domain.CooldownPolicyLinear, domain.CooldownPolicyExponential:
+	default:
+		return fmt.Errorf("%w: unknown policy type %q", domain.ErrInvalidInput, p.Type)
+	}
+	if p.Base <= 0 {
+		return fmt.Errorf("%w: base must be positive", domain.ErrInvalidInput)
+	}
+	if p.Max < 0 || (p.Max > 0 && p.Max < p.Base) {
+		return fmt.Errorf("%w: max must be 0 (no limit) or at least base", domain.ErrInvalidInput)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("%w: jitter must be between 0 and 1", domain.ErrInvalidInput)
+	}
+	return nil
+}
+
+// ===== Pricing API =====
+
+// GetModelPrices returns the effective price table: custom prices plus the
+// built-in prices they don't override (built-in entries have ID 0)
+func (s *AdminService) GetModelPrices() ([]*domain.ModelPrice, error) {
+	custom, err := s.modelPriceRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	prices := make([]*domain.ModelPrice, 0, len(custom))
+	overridden := make(map[string]bool, len(custom))
+	for _, p := range custom {
+		prices = append(prices, p)
+		overridden[p.ModelID] = true
+	}
+	for modelID, p := range pricing.DefaultPriceTable().Models {
+		if !overridden[modelID] {
+			prices = append(prices, pricing.ToModelPrice(p))
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].ModelID < prices[j].ModelID })
+	return prices, nil
+}
+
+func (s *AdminService) GetModelPrice(id uint64) (*domain.ModelPrice, error) {
+	return s.modelPriceRepo.GetByID(id)
+}
+
+func (s *AdminService) CreateModelPrice(p *domain.ModelPrice) error {
+	if err := validateModelPrice(p); err != nil {
+		return err
+	}
+	if _, err := s.modelPriceRepo.GetByModelID(p.ModelID); err == nil {
+		return fmt.Errorf("%w: price for model %q", domain.ErrAlreadyExists, p.ModelID)
+	}
+	if err := s.modelPriceRepo.Create(p); err != nil {
+		return err
+	}
+	s.reloadPrices()
+	return nil
+}
+
+func (s *AdminService) UpdateModelPrice(p *domain.ModelPrice) error {
+	if err := validateModelPrice(p); err != nil {
+		return err
+	}
+	if existing, err := s.modelPriceRepo.GetByModelID(p.ModelID); err == nil && existing.ID != p.ID {
+		return fmt.Errorf("%w: price for model %q", domain.ErrAlreadyExists, p.ModelID)
+	}
+	if err := s.modelPriceRepo.Update(p); err != nil {
+		return err
+	}
+	s.reloadPrices()
+	return nil
+}
+
+// DeleteModelPrice removes a custom price; a built-in price for the model applies again
+func (s *AdminService) DeleteModelPrice(id uint64) error {
+	if err := s.modelPriceRepo.Delete(id); err != nil {
+		return err
+	}
+	s.reloadPrices()
+	return nil
+}
+
+// ImportLiteLLMPrices imports a LiteLLM model_prices_and_context_window.json
+// file, updating custom prices that already exist for a model
+func (s *AdminService) ImportLiteLLMPrices(data []byte) (*ImportResult, error) {
+	parsed, err := pricing.ParseLiteLLM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidInput, err)
+	}
+
+	result := &ImportResult{Errors: []string{}}
+	for _, mp := range parsed {
+		p := pricing.ToModelPrice(mp)
+		if err := validateModelPrice(p); err != nil {
+			result.Skipped++
+			result.Errors = append(result.Errors, "skipped "+p.ModelID+": "+err.Error())
+			continue
+		}
+		if existing, err := s.modelPriceRepo.GetByModelID(p.ModelID); err == nil {
+			p.ID = existing.ID
+			p.CreatedAt = existing.CreatedAt
+			err = s.modelPriceRepo.Update(p)
+		} else {
+			err = s.modelPriceRepo.Create(p)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, "failed to import "+p.ModelID+": "+err.Error())
+			continue
+		}
+		result.Imported++
+	}
+	s.reloadPrices()
+	return result, nil
+}
+
+// ExportLiteLLMPrices exports the effective price table in LiteLLM format
+func (s *AdminService) ExportLiteLLMPrices() ([]byte, error) {
+	prices, err := s.GetModelPrices()
+	if err != nil {
+		return nil, err
+	}
+	models := make([]*pricing.ModelPricing, 0, len(prices))
+	for _, p := range prices {
+		models = append(models, pricing.FromModelPrice(p))
+	}
+	return pricing.ExportLiteLLM(models)
+}
+
+// RecalculateCostsResult holds the result of a cost recalculation
+type RecalculateCostsResult struct {
+	Attempts int64 `json:"attempts"`
+	Requests int64 `json:"requests"`
+}
+
+// RecalculateCosts re-prices attempts created in [since, until) with the current
+// price table. A zero until means no upper bound.
+func (s *AdminService) RecalculateCosts(since, until time.Time) (*RecalculateCostsResult, error) {
+	if !until.IsZero() && !until.After(since) {
+		return nil, fmt.Errorf("%w: until must be after since", domain.ErrInvalidInput)
+	}
+	// Look providers up by ID: List skips soft-deleted providers, but their
+	// attempts are re-priced with their multiplier and subscription flag too
+	providers := make(map[uint64]*domain.Provider)
+	providerByID := func(id uint64) *domain.Provider {
+		p, ok := providers[id]
+		if !ok {
+			p, _ = s.providerRepo.GetByID(id)
+			providers[id] = p
+		}
+		return p
+	}
+
+	calc := pricing.GlobalCalculator()
+	attempts, requests, err := s.attemptRepo.RecalculateCosts(since, until, func(a *domain.ProxyUpstreamAttempt) (uint64, uint64) {
+		// Unknown models keep their recorded cost
+		mp := calc.GetPricing(a.MappedModel)
+		if mp == nil {
+			return a.Cost, a.ShadowCost
+		}
+		cost := calc.CalculateWithPricing(mp, &usage.Metrics{
+			InputTokens:          a.InputTokenCount,
+			OutputTokens:         a.OutputTokenCount,
+			CacheReadCount:       a.CacheReadCount,
+			CacheCreationCount:   a.CacheWriteCount,
+			Cache5mCreationCount: a.Cache5mWriteCount,
+			Cache1hCreationCount: a.Cache1hWriteCount,
+		})
+		p := providerByID(a.ProviderID)
+		if p == nil {
+			return cost, 0
+		}
+		cost = pricing.ApplyProviderPricing(cost, a.MappedModel, p.Pricing)
+		if p.IsSubscription {
+			return 0, cost
+		}
+		return cost, 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Budget spend is summed from attempt costs
+	s.reloadBudgets()
+	return &RecalculateCostsResult{Attempts: attempts, Requests: requests}, nil
+}
+
+func (s *AdminService) reloadPrices() {
+	if err := pricing.LoadPrices(s.modelPriceRepo); err != nil {
+		log.Printf("[Pricing] Failed to reload prices: %v", err)
+	}
+}
+
+func validateModelPrice(p *domain.ModelPrice) error {
+	p.ModelID = strings.TrimSpace(p.ModelID)
+	if p.ModelID == "" {
+		return fmt.Errorf("%w: modelId is required", domain.ErrInvalidInput)
+	}
+	if p.InputPriceMicro == 0 && p.OutputPriceMicro == 0 {
+		return fmt.Errorf("%w: inputPriceMicro or outputPriceMicro is required", domain.ErrInvalidInput)
+	}
+	if (p.InputPremiumNum == 0) != (p.InputPremiumDenom == 0) || (p.OutputPremiumNum == 0) != (p.OutputPremiumDenom == 0) {
+		return fmt.Errorf("%w: premium numerator and denominator must be set together", domain.ErrInvalidInput)
+	}
+	return nil
+}
+
 // ===== ProxyRequest API =====
 
 func (s *AdminService) GetProxyRequests(limit, offset int) ([]*domain.ProxyRequest, error) {
@@ -375,7 +941,47 @@ func (s *AdminService) GetProxyUpstreamAttempts(proxyRequestID uint64) ([]*domai
 }
 
 func (s *AdminService) GetProviderStats(clientType string, projectID uint64) (map[uint64]*domain.ProviderStats, error) {
-	return s.attemptRepo.GetProviderStats(clientType, projectID)
+	stats, err := s.attemptRepo.GetProviderStats(clientType, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Show what relays billing in their own currency or credits actually charged
+	if providers, err := s.providerRepo.List(); err == nil {
+		for _, p := range providers {
+			stat, ok := stats[p.ID]
+			if !ok || p.Pricing == nil || p.Pricing.Currency == "" {
+				continue
+			}
+			stat.BillingCurrency = p.Pricing.Currency
+			stat.TotalBilledCost = pricing.BilledAmount(stat.TotalCost, p.Pricing)
+		}
+	}
+
+	if s.probeResultRepo == nil {
+		return stats, nil
+	}
+
+	// Merge uptime from the last 24 hours of health probes
+	summaries, err := s.probeResultRepo.Summarize(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	for providerID, summary := range summaries {
+		stat, ok := stats[providerID]
+		if !ok {
+			stat = &domain.ProviderStats{ProviderID: providerID}
+			stats[providerID] = stat
+		}
+		stat.ProbeCount = summary.Total
+		if summary.Total > 0 {
+			stat.Uptime = float64(summary.Succeeded) * 100 / float64(summary.Total)
+		}
+		lastProbeAt := summary.LastProbeAt
+		stat.LastProbeAt = &lastProbeAt
+		stat.LastProbeSuccess = summary.LastSuccess
+	}
+	return stats, nil
 }
 
 // ===== Settings API =====
@@ -397,19 +1003,58 @@ func (s *AdminService) GetSetting(key string) (string, error) {
 }
 
 func (s *AdminService) UpdateSetting(key, value string) error {
-	return s.settingRepo.Set(key, value)
+	if s.isSignatureCacheSetting(key) {
+		if err := s.signatureCache.ValidateSetting(key, value); err != nil {
+			return err
+		}
+	}
+	if circuit.IsSetting(key) {
+		if err := circuit.ValidateSetting(key, value); err != nil {
+			return err
+		}
+	}
+	if err := s.settingRepo.Set(key, value); err != nil {
+		return err
+	}
+	if s.isSignatureCacheSetting(key) {
+		s.signatureCache.ApplySettings(s.settingRepo)
+	}
+	if circuit.IsSetting(key) {
+		circuit.Default().ApplySettings(s.settingRepo)
+	}
+	return nil
 }
 
 func (s *AdminService) DeleteSetting(key string) error {
-	return s.settingRepo.Delete(key)
+	if err := s.settingRepo.Delete(key); err != nil {
+		return err
+	}
+	if s.isSignatureCacheSetting(key) {
+		s.signatureCache.ApplySettings(s.settingRepo)
+	}
+	if circuit.IsSetting(key) {
+		circuit.Default().ApplySettings(s.settingRepo)
+	}
+	return nil
+}
+
+func (s *AdminService) isSignatureCacheSetting(key string) bool {
+	return s.signatureCache != nil && s.signatureCache.IsSetting(key)
 }
 
 // ===== Proxy Status API =====
 
 type ProxyStatus struct {
-	Running bool   `json:"running"`
-	Address string `json:"address"`
-	Port    int    `json:"port"`
+	Running    bool                       `json:"running"`
+	Address    string                     `json:"address"`
+	Port       int                        `json:"port"`
+	RateLimits []*ratelimit.LimiterStatus `json:"rateLimits"`
+
+	// Circuit breakers per provider and client type
+	CircuitBreakers []*circuit.Status `json:"circuitBreakers"`
+
+	// Antigravity signature cache backend, sizes and hit/miss counters
+	SignatureCache *domain.SignatureCacheStats `json:"signatureCache"`
 }
 
 func (s *AdminService) GetProxyStatus() *ProxyStatus {
@@ -426,11 +1071,18 @@ func (s *AdminService) GetProxyStatus() *ProxyStatus {
 		displayAddr = "localhost:" + strconv.Itoa(port)
 	}
 
-	return &ProxyStatus{
-		Running: true,
-		Address: displayAddr,
-		Port:    port,
+	status := &ProxyStatus{
+		Running:    true,
+		Address:    displayAddr,
+		Port:       port,
+		RateLimits: ratelimit.Default().Status(),
+
+		CircuitBreakers: circuit.Default().Status(),
+	}
+	if s.signatureCache != nil {
+		status.SignatureCache = s.signatureCache.Stats()
 	}
+	return status
 }
 
 // ===== Logs API =====
@@ -453,10 +1105,11 @@ func (s *AdminService) GetLogs(limit int) (*LogsResult, error) {
 func (s *AdminService) autoSetSupportedClientTypes(provider *domain.Provider) {
 	switch provider.Type {
 	case "antigravity":
-		// Antigravity natively supports Claude, OpenAI, and Gemini
+		// Antigravity natively supports Claude, OpenAI, Codex, and Gemini
 		provider.SupportedClientTypes = []domain.ClientType{
 			domain.ClientTypeClaude,
 			domain.ClientTypeOpenAI,
+			domain.ClientTypeCodex,
 			domain.ClientTypeGemini,
 		}
 	case "custom":
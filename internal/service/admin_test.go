@@ -0,0 +1,176 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/pricing"
+	"github.com/awsl-project/maxx/internal/repository"
+	"github.com/awsl-project/maxx/internal/usage"
+)
+
+// memoryProviders behaves like the sqlite repository: List skips soft-deleted
+// providers, GetByID still returns them
+type memoryProviders struct {
+	repository.ProviderRepository
+	providers map[uint64]*domain.Provider
+}
+
+func (r *memoryProviders) GetByID(id uint64) (*domain.Provider, error) {
+	if p, ok := r.providers[id]; ok {
+		return p, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryProviders) List() ([]*domain.Provider, error) {
+	var list []*domain.Provider
+	for _, p := range r.providers {
+		if p.DeletedAt == nil {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+// memoryAttempts applies RecalculateCosts to an in-memory attempt list
+type memoryAttempts struct {
+	repository.ProxyUpstreamAttemptRepository
+	attempts []*domain.ProxyUpstreamAttempt
+}
+
+func (r *memoryAttempts) RecalculateCosts(since, until time.Time, calc func(*domain.ProxyUpstreamAttempt) (uint64, uint64)) (int64, int64, error) {
+	var updated int64
+	for _, a := range r.attempts {
+		cost, shadowCost := calc(a)
+		if cost != a.Cost || shadowCost != a.ShadowCost {
+			a.Cost, a.ShadowCost = cost, shadowCost
+			updated++
+		}
+	}
+	return updated, updated, nil
+}
+
+func TestRecalculateCosts_DeletedProviders(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	providers := &memoryProviders{providers: map[uint64]*domain.Provider{
+		1: {ID: 1, Pricing: &domain.ProviderPricing{Multiplier: 2}},
+		2: {ID: 2, Pricing: &domain.ProviderPricing{Multiplier: 0.5}, DeletedAt: &deletedAt},
+		3: {ID: 3, IsSubscription: true, DeletedAt: &deletedAt},
+	}}
+	attempts := &memoryAttempts{}
+	for id := uint64(1); id <= 4; id++ {
+		attempts.attempts = append(attempts.attempts, &domain.ProxyUpstreamAttempt{
+			ID:              id,
+			ProviderID:      id, // provider 4 no longer exists at all
+			MappedModel:     "claude-sonnet-4-5",
+			InputTokenCount: 100_000,
+		})
+	}
+	s := &AdminService{providerRepo: providers, attemptRepo: attempts}
+
+	result, err := s.RecalculateCosts(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Attempts != 4 {
+		t.Errorf("updated %d attempts, want 4", result.Attempts)
+	}
+
+	list := pricing.GlobalCalculator().Calculate("claude-sonnet-4-5", &usage.Metrics{InputTokens: 100_000}, nil)
+	tests := []struct {
+		name                 string
+		wantCost, wantShadow uint64
+	}{
+		{"active provider", list * 2, 0},
+		{"deleted provider keeps its multiplier", list / 2, 0},
+		{"deleted subscription provider", 0, list},
+		{"missing provider", list, 0},
+	}
+	for i, tt := range tests {
+		a := attempts.attempts[i]
+		if a.Cost != tt.wantCost || a.ShadowCost != tt.wantShadow {
+			t.Errorf("%s: cost = %d, shadow = %d, want %d, %d", tt.name, a.Cost, a.ShadowCost, tt.wantCost, tt.wantShadow)
+		}
+	}
+}
+
+// memorySettingStore is a key/value system setting repository
+type memorySettingStore struct {
+	repository.SystemSettingRepository
+	values map[string]string
+}
+
+func (r *memorySettingStore) Get(key string) (string, error) {
+	return r.values[key], nil
+}
+
+func (r *memorySettingStore) Set(key, value string) error {
+	r.values[key] = value
+	return nil
+}
+
+func (r *memorySettingStore) Delete(key string) error {
+	delete(r.values, key)
+	return nil
+}
+
+// fakeSignatureCache owns the "cache_size" setting and counts ApplySettings calls
+type fakeSignatureCache struct {
+	applied int
+}
+
+func (c *fakeSignatureCache) IsSetting(key string) bool { return key == "cache_size" }
+
+func (c *fakeSignatureCache) ValidateSetting(key, value string) error {
+	if value == "bad" {
+		return domain.ErrInvalidInput
+	}
+	return nil
+}
+
+func (c *fakeSignatureCache) ApplySettings(settings repository.SystemSettingRepository) {
+	c.applied++
+}
+
+func (c *fakeSignatureCache) Stats() *domain.SignatureCacheStats {
+	return &domain.SignatureCacheStats{Backend: domain.SignatureCacheBackendMemory}
+}
+
+func TestSignatureCacheSettings(t *testing.T) {
+	settings := &memorySettingStore{values: map[string]string{}}
+	cache := &fakeSignatureCache{}
+	s := &AdminService{settingRepo: settings, signatureCache: cache}
+
+	if err := s.UpdateSetting("cache_size", "bad"); err == nil {
+		t.Error("invalid value was accepted")
+	}
+	if _, saved := settings.values["cache_size"]; saved || cache.applied != 0 {
+		t.Errorf("invalid value saved = %v, applied %d times", saved, cache.applied)
+	}
+	if err := s.UpdateSetting("cache_size", "10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateSetting("other", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteSetting("cache_size"); err != nil {
+		t.Fatal(err)
+	}
+	if cache.applied != 2 {
+		t.Errorf("ApplySettings called %d times, want 2", cache.applied)
+	}
+	if stats := s.GetProxyStatus().SignatureCache; stats == nil || stats.Backend != domain.SignatureCacheBackendMemory {
+		t.Errorf("proxy status signature cache = %+v", stats)
+	}
+
+	// Without a signature cache, settings are stored as-is and no stats are reported
+	s = &AdminService{settingRepo: settings}
+	if err := s.UpdateSetting("cache_size", "bad"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := s.GetProxyStatus().SignatureCache; stats != nil {
+		t.Errorf("proxy status signature cache = %+v, want nil", stats)
+	}
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository"
+)
+
+const (
+	// SessionTTL is how long an admin login stays valid
+	SessionTTL = 7 * 24 * time.Hour
+
+	passwordHashAlgo       = "pbkdf2-sha256"
+	passwordHashIterations = 210000
+	passwordHashKeyLen     = 32
+	minPasswordLength      = 8
+)
+
+// AuthService provides admin login, session tokens and user management
+// Authentication is always enforced. The first admin is created either from
+// MAXX_ADMIN_USER / MAXX_ADMIN_PASSWORD or through Setup with the one-time
+// setup token printed at startup
+type AuthService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.AdminSessionRepository
+
+	// setupMu serializes creation of the first admin
+	setupMu    sync.Mutex
+	setupToken string
+}
+
+// NewAuthService creates a new auth service
+func NewAuthService(userRepo repository.UserRepository, sessionRepo repository.AdminSessionRepository) *AuthService {
+	return &AuthService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+	}
+}
+
+// LoginResult holds the session token issued on login
+type LoginResult struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	User      *domain.User `json:"user"`
+}
+
+// SetupRequired reports whether no user exists yet, so the first admin must be created
+func (s *AuthService) SetupRequired() bool {
+	count, err := s.userRepo.Count()
+	return err == nil && count == 0
+}
+
+// Bootstrap creates the initial admin account when no users exist yet
+// Used with MAXX_ADMIN_USER / MAXX_ADMIN_PASSWORD for headless deployments
+func (s *AuthService) Bootstrap(username, password string) error {
+	if password == "" {
+		return nil
+	}
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+	count, err := s.userRepo.Count()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if username == "" {
+		username = "admin"
+	}
+	if _, err := s.CreateUser(&domain.User{Username: username, Role: domain.UserRoleAdmin, IsEnabled: true}, password); err != nil {
+		return err
+	}
+	log.Printf("[Auth] Created initial admin user %q", username)
+	return nil
+}
+
+// NewSetupToken generates the one-time token Setup requires while no users exist
+// Returns "" when an account already exists. Each call replaces the previous token.
+func (s *AuthService) NewSetupToken() (string, error) {
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+	count, err := s.userRepo.Count()
+	if err != nil {
+		return "", err
+	}
+	if count > 0 {
+		s.setupToken = ""
+		return "", nil
+	}
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	s.setupToken = token
+	return token, nil
+}
+
+// Setup creates the first admin account with the one-time setup token.
+// Only allowed while no users exist; the token is consumed on success.
+func (s *AuthService) Setup(setupToken, username, password string) (*LoginResult, error) {
+	s.setupMu.Lock()
+	defer s.setupMu.Unlock()
+	if s.setupToken == "" || subtle.ConstantTimeCompare([]byte(setupToken), []byte(s.setupToken)) != 1 {
+		return nil, domain.ErrInvalidSetupToken
+	}
+	count, err := s.userRepo.Count()
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		s.setupToken = ""
+		return nil, domain.ErrForbidden
+	}
+	user, err := s.CreateUser(&domain.User{Username: username, Role: domain.UserRoleAdmin, IsEnabled: true}, password)
+	if err != nil {
+		return nil, err
+	}
+	s.setupToken = ""
+	return s.issueSession(user)
+}
+
+// Login verifies credentials and issues a new session token
+func (s *AuthService) Login(username, password string) (*LoginResult, error) {
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		// Still run a hash so timing doesn't reveal whether the user exists
+		verifyPassword(password, "")
+		return nil, domain.ErrInvalidCredentials
+	}
+	if !verifyPassword(password, user.PasswordHash) || !user.IsEnabled {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	user.LastLoginAt = &now
+	_ = s.userRepo.Update(user)
+
+	return s.issueSession(user)
+}
+
+// Logout revokes a session token
+func (s *AuthService) Logout(token string) error {
+	if token == "" {
+		return nil
+	}
+	return s.sessionRepo.Delete(hashToken(token))
+}
+
+// Authenticate resolves a session token to an enabled user
+func (s *AuthService) Authenticate(token string) (*domain.User, error) {
+	if token == "" {
+		return nil, domain.ErrUnauthorized
+	}
+	session, err := s.sessionRepo.GetByTokenHash(hashToken(token))
+	if err != nil {
+		return nil, domain.ErrUnauthorized
+	}
+	if time.Now().After(session.ExpiresAt) {
+		_ = s.sessionRepo.Delete(session.TokenHash)
+		return nil, domain.ErrUnauthorized
+	}
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil || !user.IsEnabled {
+		return nil, domain.ErrUnauthorized
+	}
+	return user, nil
+}
+
+// CleanupExpiredSessions removes expired session rows
+func (s *AuthService) CleanupExpiredSessions() (int64, error) {
+	return s.sessionRepo.DeleteExpired()
+}
+
+// ===== User API =====
+
+func (s *AuthService) GetUsers() ([]*domain.User, error) {
+	return s.userRepo.List()
+}
+
+func (s *AuthService) GetUser(id uint64) (*domain.User, error) {
+	return s.userRepo.GetByID(id)
+}
+
+// CreateUser creates a user with the given plaintext password
+func (s *AuthService) CreateUser(user *domain.User, password string) (*domain.User, error) {
+	user.Username = strings.TrimSpace(user.Username)
+	if user.Username == "" {
+		return nil, fmt.Errorf("%w: username required", domain.ErrInvalidInput)
+	}
+	if !validRole(user.Role) {
+		return nil, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidInput, user.Role)
+	}
+	if _, err := s.userRepo.GetByUsername(user.Username); err == nil {
+		return nil, domain.ErrAlreadyExists
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user.ID = 0
+	user.PasswordHash = hash
+	user.LastLoginAt = nil
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateUser updates role, status and optionally the password (empty keeps the current one)
+// Changing the password or disabling the user revokes all of the user's sessions
+func (s *AuthService) UpdateUser(user *domain.User, password string) error {
+	existing, err := s.userRepo.GetByID(user.ID)
+	if err != nil {
+		return err
+	}
+	if !validRole(user.Role) {
+		return fmt.Errorf("%w: unknown role %q", domain.ErrInvalidInput, user.Role)
+	}
+	demoted := existing.Role == domain.UserRoleAdmin && (user.Role != domain.UserRoleAdmin || !user.IsEnabled)
+	if demoted && existing.IsEnabled {
+		if last, err := s.isLastAdmin(existing.ID); err != nil {
+			return err
+		} else if last {
+			return domain.ErrLastAdmin
+		}
+	}
+
+	user.PasswordHash = existing.PasswordHash
+	user.LastLoginAt = existing.LastLoginAt
+	revoke := !user.IsEnabled
+	if password != "" {
+		hash, err := hashPassword(password)
+		if err != nil {
+			return err
+		}
+		user.PasswordHash = hash
+		revoke = true
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	if revoke {
+		return s.sessionRepo.DeleteByUserID(user.ID)
+	}
+	return nil
+}
+
+func (s *AuthService) DeleteUser(id uint64) error {
+	existing, err := s.userRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if existing.Role == domain.UserRoleAdmin && existing.IsEnabled {
+		if last, err := s.isLastAdmin(id); err != nil {
+			return err
+		} else if last {
+			return domain.ErrLastAdmin
+		}
+	}
+	if err := s.sessionRepo.DeleteByUserID(id); err != nil {
+		return err
+	}
+	return s.userRepo.Delete(id)
+}
+
+// ===== Private helpers =====
+
+func (s *AuthService) issueSession(user *domain.User) (*LoginResult, error) {
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+	session := &domain.AdminSession{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(SessionTTL),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return &LoginResult{Token: token, ExpiresAt: session.ExpiresAt, User: user}, nil
+}
+
+// isLastAdmin reports whether id is the only enabled admin
+func (s *AuthService) isLastAdmin(id uint64) (bool, error) {
+	users, err := s.userRepo.List()
+	if err != nil {
+		return false, err
+	}
+	for _, u := range users {
+		if u.ID != id && u.Role == domain.UserRoleAdmin && u.IsEnabled {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func validRole(role domain.UserRole) bool {
+	return role == domain.UserRoleAdmin || role == domain.UserRoleViewer
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// hashPassword returns "pbkdf2-sha256$iterations$salt$hash" (base64 salt/hash)
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("%w: password must be at least %d characters", domain.ErrInvalidInput, minPasswordLength)
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHashIterations, passwordHashKeyLen)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		passwordHashAlgo,
+		strconv.Itoa(passwordHashIterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+func verifyPassword(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != passwordHashAlgo {
+		// Burn comparable time for unknown users
+		_, _ = pbkdf2.Key(sha256.New, password, []byte("maxx-dummy-salt"), passwordHashIterations, passwordHashKeyLen)
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
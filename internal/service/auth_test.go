@@ -0,0 +1,209 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/domain"
+	"github.com/awsl-project/maxx/internal/repository/sqlite"
+)
+
+func newTestAuthService(t *testing.T) (*AuthService, *sqlite.AdminSessionRepository) {
+	t.Helper()
+	db, err := sqlite.NewDB(filepath.Join(t.TempDir(), "maxx.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	sessions := sqlite.NewAdminSessionRepository(db)
+	return NewAuthService(sqlite.NewUserRepository(db), sessions), sessions
+}
+
+func TestAuthService_SetupRequiresToken(t *testing.T) {
+	svc, _ := newTestAuthService(t)
+	if !svc.SetupRequired() {
+		t.Fatal("SetupRequired = false on an empty database")
+	}
+
+	// No token issued yet: setup is closed
+	if _, err := svc.Setup("", "admin", "password123"); !errors.Is(err, domain.ErrInvalidSetupToken) {
+		t.Fatalf("Setup without token: err = %v, want ErrInvalidSetupToken", err)
+	}
+
+	token, err := svc.NewSetupToken()
+	if err != nil || token == "" {
+		t.Fatalf("NewSetupToken = %q, %v", token, err)
+	}
+	if _, err := svc.Setup("guess", "admin", "password123"); !errors.Is(err, domain.ErrInvalidSetupToken) {
+		t.Fatalf("Setup with wrong token: err = %v, want ErrInvalidSetupToken", err)
+	}
+
+	result, err := svc.Setup(token, "admin", "password123")
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if result.User.Role != domain.UserRoleAdmin || result.Token == "" {
+		t.Fatalf("Setup result = %+v", result)
+	}
+	if svc.SetupRequired() {
+		t.Error("SetupRequired = true after setup")
+	}
+
+	// The token is single use
+	if _, err := svc.Setup(token, "second", "password123"); !errors.Is(err, domain.ErrInvalidSetupToken) {
+		t.Errorf("reused setup token: err = %v, want ErrInvalidSetupToken", err)
+	}
+	if token, err := svc.NewSetupToken(); err != nil || token != "" {
+		t.Errorf("NewSetupToken after setup = %q, %v; want empty", token, err)
+	}
+}
+
+func TestAuthService_SetupRace(t *testing.T) {
+	svc, _ := newTestAuthService(t)
+	token, err := svc.NewSetupToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := svc.Setup(token, "admin"+string(rune('a'+i)), "password123"); err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if succeeded != 1 {
+		t.Errorf("%d concurrent setups succeeded, want 1", succeeded)
+	}
+	users, err := svc.GetUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 {
+		t.Errorf("%d users after concurrent setup, want 1", len(users))
+	}
+}
+
+func TestAuthService_Bootstrap(t *testing.T) {
+	svc, _ := newTestAuthService(t)
+	if err := svc.Bootstrap("", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Login("admin", "password123"); err != nil {
+		t.Fatalf("login as bootstrapped admin: %v", err)
+	}
+	// Only the first start creates the account
+	if err := svc.Bootstrap("other", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := svc.GetUsers(); len(users) != 1 {
+		t.Errorf("%d users after second bootstrap, want 1", len(users))
+	}
+	if token, _ := svc.NewSetupToken(); token != "" {
+		t.Error("setup token issued although an admin exists")
+	}
+}
+
+func TestAuthService_LoginAndSessions(t *testing.T) {
+	svc, sessions := newTestAuthService(t)
+	if err := svc.Bootstrap("admin", "password123"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.Login("admin", "wrong-password"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("wrong password: err = %v", err)
+	}
+	if _, err := svc.Login("nobody", "password123"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("unknown user: err = %v", err)
+	}
+
+	result, err := svc.Login("admin", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := svc.Authenticate(result.Token)
+	if err != nil || user.Username != "admin" {
+		t.Fatalf("Authenticate = %v, %v", user, err)
+	}
+	if _, err := svc.Authenticate("not-a-token"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("unknown token: err = %v", err)
+	}
+
+	if err := svc.Logout(result.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Authenticate(result.Token); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("token after logout: err = %v", err)
+	}
+
+	// Expired sessions are rejected and removed
+	expired := &domain.AdminSession{UserID: user.ID, TokenHash: hashToken("expired"), ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := sessions.Create(expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Authenticate("expired"); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("expired session: err = %v", err)
+	}
+	if _, err := sessions.GetByTokenHash(expired.TokenHash); err == nil {
+		t.Error("expired session was not removed")
+	}
+}
+
+func TestAuthService_DisableRevokesSessions(t *testing.T) {
+	svc, _ := newTestAuthService(t)
+	if err := svc.Bootstrap("admin", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	viewer, err := svc.CreateUser(&domain.User{Username: "viewer", Role: domain.UserRoleViewer, IsEnabled: true}, "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := svc.Login("viewer", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabled := *viewer
+	disabled.IsEnabled = false
+	if err := svc.UpdateUser(&disabled, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Authenticate(result.Token); !errors.Is(err, domain.ErrUnauthorized) {
+		t.Errorf("session of disabled user: err = %v", err)
+	}
+	if _, err := svc.Login("viewer", "password123"); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("login of disabled user: err = %v", err)
+	}
+}
+
+func TestAuthService_LastAdmin(t *testing.T) {
+	svc, _ := newTestAuthService(t)
+	if err := svc.Bootstrap("admin", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	admin, err := svc.userRepo.GetByUsername("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.DeleteUser(admin.ID); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Errorf("delete last admin: err = %v", err)
+	}
+	demoted := *admin
+	demoted.Role = domain.UserRoleViewer
+	if err := svc.UpdateUser(&demoted, ""); !errors.Is(err, domain.ErrLastAdmin) {
+		t.Errorf("demote last admin: err = %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package tokenizer
+
+import (
+	"encoding/json"
+)
+
+// Approximate fixed costs of Claude's prompt format
+const (
+	claudeRequestOverhead  = 3    // start of conversation
+	claudeMessageOverhead  = 4    // role markers around each message
+	claudeToolsOverhead    = 300  // system prompt added when tools are present
+	claudeToolOverhead     = 10   // per tool definition framing
+	claudeImageTokens      = 1600 // image of unknown size (max size is ~1.15 megapixels / 750)
+	claudeDocumentTokens   = 2000 // base64 document of unknown page count
+	claudeBytesPerDocToken = 3    // rough density of PDF bytes per token
+)
+
+// EstimateClaudeInput estimates the input tokens of a Claude Messages API request body
+// (the same body /v1/messages/count_tokens accepts)
+func EstimateClaudeInput(body []byte) (int, error) {
+	var req struct {
+		System   json.RawMessage   `json:"system"`
+		Messages []json.RawMessage `json:"messages"`
+		Tools    []json.RawMessage `json:"tools"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return 0, err
+	}
+
+	tokens := claudeRequestOverhead
+	if len(req.System) > 0 {
+		tokens += claudeMessageOverhead + countContent(req.System)
+	}
+	for _, raw := range req.Messages {
+		var msg struct {
+			Content json.RawMessage `json:"content"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return 0, err
+		}
+		tokens += claudeMessageOverhead + countContent(msg.Content)
+	}
+	if len(req.Tools) > 0 {
+		tokens += claudeToolsOverhead
+		for _, tool := range req.Tools {
+			// Name, description and schema all end up in the prompt; JSON is a fair stand-in
+			tokens += claudeToolOverhead + Count(string(tool))
+		}
+	}
+	return tokens, nil
+}
+
+// EstimateRequest estimates the input tokens of a proxied request body of any client type.
+// Message-based bodies (Claude, OpenAI chat) are counted per message; anything else
+// (Gemini contents, Codex input) falls back to counting the raw JSON, which overestimates slightly.
+func EstimateRequest(body []byte) int {
+	if tokens, err := EstimateClaudeInput(body); err == nil && tokens > claudeRequestOverhead {
+		return tokens
+	}
+	return Count(string(body))
+}
+
+// countContent counts a string or an array of content blocks
+func countContent(raw json.RawMessage) int {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return Count(text)
+	}
+	var blocks []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return Count(string(raw))
+	}
+	tokens := 0
+	for _, block := range blocks {
+		tokens += countBlock(block)
+	}
+	return tokens
+}
+
+func countBlock(block map[string]json.RawMessage) int {
+	var blockType string
+	_ = json.Unmarshal(block["type"], &blockType)
+
+	switch blockType {
+	case "text":
+		return countString(block["text"])
+	case "thinking":
+		return countString(block["thinking"])
+	case "redacted_thinking":
+		return len(block["data"]) / 4
+	case "tool_use", "server_tool_use":
+		return countString(block["name"]) + Count(string(block["input"]))
+	case "tool_result", "web_search_tool_result":
+		if content, ok := block["content"]; ok {
+			return countContent(content)
+		}
+		return 0
+	case "image":
+		return claudeImageTokens
+	case "document":
+		var source struct {
+			Type string `json:"type"`
+			Data string `json:"data"`
+		}
+		_ = json.Unmarshal(block["source"], &source)
+		if source.Type == "text" {
+			return Count(source.Data)
+		}
+		if source.Data != "" {
+			// base64 length * 3/4 = bytes
+			return len(source.Data) * 3 / 4 / claudeBytesPerDocToken
+		}
+		return claudeDocumentTokens
+	default:
+		// Unknown block types: count whatever JSON they carry
+		raw, _ := json.Marshal(block)
+		return Count(string(raw))
+	}
+}
+
+func countString(raw json.RawMessage) int {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return 0
+	}
+	return Count(s)
+}
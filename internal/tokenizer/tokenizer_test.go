@@ -0,0 +1,68 @@
+package tokenizer
+
+import "testing"
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int
+	}{
+		{"", 0, 0},
+		{"Hello, world!", 3, 5},
+		{"The quick brown fox jumps over the lazy dog.", 9, 12},
+		{"func main() { fmt.Println(\"hi\") }", 9, 16},
+		{"你好，世界", 4, 6},
+		{"1234567890", 3, 4},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.text); got < tt.min || got > tt.max {
+			t.Errorf("Count(%q) = %d, want %d-%d", tt.text, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestEstimateClaudeInput(t *testing.T) {
+	body := []byte(`{
+		"model": "claude-sonnet-4-5",
+		"system": [{"type": "text", "text": "You are a helpful assistant."}],
+		"messages": [
+			{"role": "user", "content": "What is the weather in Paris?"},
+			{"role": "assistant", "content": [{"type": "tool_use", "id": "t1", "name": "get_weather", "input": {"city": "Paris"}}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "t1", "content": "Sunny, 21C"}]}
+		],
+		"tools": [{"name": "get_weather", "description": "Get the weather for a city", "input_schema": {"type": "object", "properties": {"city": {"type": "string"}}}}]
+	}`)
+	got, err := EstimateClaudeInput(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Most of the estimate is the fixed tool-use system prompt
+	if got < 340 || got > 460 {
+		t.Errorf("EstimateClaudeInput = %d, want ~400", got)
+	}
+
+	if _, err := EstimateClaudeInput([]byte("not json")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestEstimateRequest(t *testing.T) {
+	claude := []byte(`{"model": "claude-sonnet-4-5", "messages": [{"role": "user", "content": "What is the weather in Paris?"}]}`)
+	want, err := EstimateClaudeInput(claude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := EstimateRequest(claude); got != want {
+		t.Errorf("claude body: EstimateRequest = %d, want %d", got, want)
+	}
+
+	// Bodies without messages are counted as raw JSON instead of as an empty conversation
+	gemini := []byte(`{"contents": [{"role": "user", "parts": [{"text": "What is the weather in Paris?"}]}]}`)
+	if got := EstimateRequest(gemini); got != Count(string(gemini)) {
+		t.Errorf("gemini body: EstimateRequest = %d, want %d", got, Count(string(gemini)))
+	}
+
+	if got := EstimateRequest(nil); got != 0 {
+		t.Errorf("empty body: EstimateRequest = %d, want 0", got)
+	}
+}
@@ -0,0 +1,390 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/awsl-project/maxx/internal/version"
+)
+
+// Config configures the OTLP exporter
+type Config struct {
+	// Endpoint is the full OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces
+	Endpoint string
+	// Headers are sent with every export request (e.g. collector auth)
+	Headers map[string]string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	// BatchSize and FlushInterval control batching (defaults: 512 spans, 5s)
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+const (
+	defaultServiceName   = "maxx"
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	queueSize            = 4096
+	exportTimeout        = 10 * time.Second
+)
+
+// ConfigFromEnv reads the standard OpenTelemetry exporter variables:
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT (used as is), OTEL_EXPORTER_OTLP_ENDPOINT (/v1/traces is appended),
+// OTEL_EXPORTER_OTLP_HEADERS / OTEL_EXPORTER_OTLP_TRACES_HEADERS ("k=v,k=v") and OTEL_SERVICE_NAME
+func ConfigFromEnv() Config {
+	cfg := Config{ServiceName: os.Getenv("OTEL_SERVICE_NAME")}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		cfg.Endpoint = endpoint
+	} else if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+		cfg.Endpoint = TracesURL(base)
+	}
+	cfg.Headers = parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	for k, v := range parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_HEADERS")) {
+		cfg.Headers[k] = v
+	}
+	return cfg
+}
+
+// TracesURL appends the OTLP traces path to a collector base URL (unless it's already there)
+func TracesURL(base string) string {
+	base = strings.TrimRight(base, "/")
+	if strings.HasSuffix(base, "/v1/traces") {
+		return base
+	}
+	return base + "/v1/traces"
+}
+
+func parseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		headers[strings.TrimSpace(k)] = v
+	}
+	return headers
+}
+
+var active atomic.Pointer[exporter]
+
+func currentExporter() *exporter {
+	return active.Load()
+}
+
+// Enabled reports whether spans are being exported
+func Enabled() bool {
+	return currentExporter() != nil
+}
+
+// Init starts exporting to cfg.Endpoint, replacing (and flushing) any previous exporter.
+// An empty endpoint disables tracing.
+func Init(cfg Config) error {
+	var next *exporter
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid OTLP endpoint %q", cfg.Endpoint)
+		}
+		next = newExporter(cfg)
+	}
+	if prev := active.Swap(next); prev != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		prev.shutdown(ctx)
+	}
+	if next != nil {
+		log.Printf("[Tracing] Exporting traces to %s", cfg.Endpoint)
+	}
+	return nil
+}
+
+// Shutdown flushes queued spans and stops exporting
+func Shutdown(ctx context.Context) {
+	if prev := active.Swap(nil); prev != nil {
+		prev.shutdown(ctx)
+	}
+}
+
+// ForceFlush exports all queued spans now
+func ForceFlush(ctx context.Context) {
+	if exp := currentExporter(); exp != nil {
+		exp.flush(ctx)
+	}
+}
+
+// ===== Exporter =====
+
+type exporter struct {
+	cfg    Config
+	client *http.Client
+	queue  chan *Span
+	flushC chan chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	dropped atomic.Uint64
+}
+
+func newExporter(cfg Config) *exporter {
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaultServiceName
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	e := &exporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: exportTimeout},
+		queue:  make(chan *Span, queueSize),
+		flushC: make(chan chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// enqueue never blocks the request path; spans are dropped when the collector can't keep up
+func (e *exporter) enqueue(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		if e.dropped.Add(1)%1000 == 1 {
+			log.Printf("[Tracing] Export queue full, dropping spans")
+		}
+	}
+}
+
+func (e *exporter) run() {
+	ticker := time.NewTicker(e.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, e.cfg.BatchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.export(batch); err != nil {
+			log.Printf("[Tracing] Failed to export %d spans: %v", len(batch), err)
+		}
+		batch = make([]*Span, 0, e.cfg.BatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-e.queue:
+				batch = append(batch, s)
+				if len(batch) >= e.cfg.BatchSize {
+					send()
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= e.cfg.BatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-e.flushC:
+			drain()
+			send()
+			close(ack)
+		case <-e.done:
+			drain()
+			send()
+			return
+		}
+	}
+}
+
+func (e *exporter) flush(ctx context.Context) {
+	ack := make(chan struct{})
+	select {
+	case e.flushC <- ack:
+	case <-e.done:
+		return
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+func (e *exporter) shutdown(ctx context.Context) {
+	e.once.Do(func() {
+		e.flush(ctx)
+		close(e.done)
+	})
+}
+
+func (e *exporter) export(spans []*Span) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// ===== OTLP JSON encoding =====
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              Kind           `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is a string in OTLP JSON
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func (e *exporter) encode(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           s.sc.TraceID.String(),
+			SpanID:            s.sc.SpanID.String(),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: s.statusCode, Message: s.statusMessage},
+		}
+		if s.parent.IsValid() {
+			span.ParentSpanID = s.parent.String()
+		}
+		for _, a := range s.attrs {
+			span.Attributes = append(span.Attributes, keyValue(a.key, a.value))
+		}
+		s.mu.Unlock()
+		out = append(out, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			keyValue("service.name", e.cfg.ServiceName),
+			keyValue("service.version", version.Version),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/awsl-project/maxx", Version: version.Version},
+			Spans: out,
+		}},
+	}}}
+}
+
+func keyValue(key string, value any) otlpKeyValue {
+	var v otlpValue
+	switch val := value.(type) {
+	case string:
+		v.StringValue = &val
+	case bool:
+		v.BoolValue = &val
+	case int:
+		v.IntValue = ptr(strconv.FormatInt(int64(val), 10))
+	case int64:
+		v.IntValue = ptr(strconv.FormatInt(val, 10))
+	case uint64:
+		v.IntValue = ptr(strconv.FormatUint(val, 10))
+	case float64:
+		v.DoubleValue = &val
+	case time.Duration:
+		// Durations are reported in milliseconds
+		ms := float64(val) / float64(time.Millisecond)
+		v.DoubleValue = &ms
+	default:
+		v.StringValue = ptr(fmt.Sprint(val))
+	}
+	return otlpKeyValue{Key: key, Value: v}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,141 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// TraceparentHeader is the W3C Trace Context header
+const TraceparentHeader = "traceparent"
+
+// Extract returns ctx with the caller's span context from a traceparent header, if present and valid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get(TraceparentHeader))
+	if !ok {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// Inject writes the active span context in ctx as a traceparent header
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, FormatTraceparent(sc))
+	// tracestate belongs to the caller's vendor; we don't add entries, so it's passed through as is
+}
+
+// ParseTraceparent parses "version-traceid-spanid-flags"
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&0x01 != 0
+	sc.Remote = true
+	return sc, true
+}
+
+// FormatTraceparent formats a span context as a version 00 traceparent
+func FormatTraceparent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != hex.EncodedLen(len(dst)) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// NewTransport wraps an HTTP transport so each upstream call gets a client span
+// and carries a traceparent pointing at it. The span ends when the response body
+// is fully read or closed, so it covers the whole stream.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Start(req.Context(), "HTTP "+req.Method, KindClient)
+	if !SpanContextFromContext(ctx).IsValid() {
+		return t.base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the caller's request
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	// The path and host only: query strings may carry API keys
+	span.SetAttr("http.request.method", req.Method)
+	span.SetAttr("server.address", req.URL.Host)
+	span.SetAttr("url.path", req.URL.Path)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		span.End()
+		return nil, err
+	}
+	span.SetAttr("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 400 {
+		span.SetError(fmt.Errorf("upstream returned status %d", resp.StatusCode))
+	}
+	if span == nil {
+		return resp, nil
+	}
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+	return resp, nil
+}
+
+// spanBody ends the client span once the response has been consumed
+type spanBody struct {
+	io.ReadCloser
+	span *Span
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.span.End()
+	} else if err != nil {
+		b.span.SetError(err)
+		b.span.End()
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	b.span.End()
+	return b.ReadCloser.Close()
+}
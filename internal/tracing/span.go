@@ -0,0 +1,200 @@
+// Package tracing records request traces and exports them to an OpenTelemetry collector
+// over OTLP/HTTP (JSON encoding). Like the metrics package it implements only what maxx
+// needs: spans with attributes and status, W3C traceparent propagation and a batching
+// exporter. When no endpoint is configured every call is a cheap no-op.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+func (s SpanID) String() string  { return hex.EncodeToString(s[:]) }
+
+// IsValid reports whether the ID is non-zero
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// IsValid reports whether the ID is non-zero
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// SpanContext is the part of a span that crosses process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	Remote  bool
+}
+
+// IsValid reports whether both IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Kind is the OTLP span kind
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// OTLP status codes
+const (
+	statusUnset = 0
+	statusOK    = 1
+	statusError = 2
+)
+
+type attribute struct {
+	key   string
+	value any
+}
+
+// Span is one timed operation. A nil *Span is valid and ignores all calls,
+// which is what Start returns when tracing is disabled or the trace isn't sampled.
+type Span struct {
+	mu            sync.Mutex
+	sc            SpanContext
+	parent        SpanID
+	name          string
+	kind          Kind
+	start         time.Time
+	end           time.Time
+	attrs         []attribute
+	statusCode    int
+	statusMessage string
+	ended         bool
+	exporter      *exporter
+}
+
+type spanContextKey struct{}
+
+// Start begins a span as a child of the span (or remote parent) in ctx
+// and returns a context carrying the new span
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	exp := currentExporter()
+	if exp == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+	if parent.IsValid() && !parent.Sampled {
+		// Upstream decided not to sample this trace; keep propagating its context only
+		return ctx, nil
+	}
+
+	s := &Span{
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+		exporter: exp,
+	}
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.parent = parent.SpanID
+	} else {
+		_, _ = rand.Read(s.sc.TraceID[:])
+	}
+	_, _ = rand.Read(s.sc.SpanID[:])
+	s.sc.Sampled = true
+
+	return context.WithValue(ctx, spanContextKey{}, s.sc), s
+}
+
+// SpanContextFromContext returns the active span context (local or remote), if any
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if ctx == nil {
+		return SpanContext{}
+	}
+	sc, _ := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithRemoteSpanContext returns ctx with a parent span context received from a caller
+func ContextWithRemoteSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	sc.Remote = true
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContext returns the span's identity
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// SetAttr sets an attribute; strings, bools, integers and floats are supported,
+// anything else is recorded as its string form
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+}
+
+// SetError marks the span as failed
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.statusCode = statusError
+	s.statusMessage = err.Error()
+	s.mu.Unlock()
+}
+
+// SetOK marks the span as explicitly successful
+func (s *Span) SetOK() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.statusCode == statusUnset {
+		s.statusCode = statusOK
+	}
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export; later calls are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.exporter.enqueue(s)
+}
+
+// StartConversion starts a span for a format conversion step (e.g. claude -> gemini)
+func StartConversion(ctx context.Context, name, from, to string) (context.Context, *Span) {
+	ctx, span := Start(ctx, name, KindInternal)
+	span.SetAttr("maxx.convert.from", from)
+	span.SetAttr("maxx.convert.to", to)
+	return ctx, span
+}
@@ -0,0 +1,210 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	valid := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(valid)
+	if !ok || !sc.Sampled || !sc.Remote {
+		t.Fatalf("ParseTraceparent(%q) = %+v, %v", valid, sc, ok)
+	}
+	if got := FormatTraceparent(sc); got != valid {
+		t.Errorf("FormatTraceparent = %q, want %q", got, valid)
+	}
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",        // missing flags
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",     // zero trace id
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",     // zero span id
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",     // uppercase
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",     // forbidden version
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz", // extra field in version 00
+	} {
+		if _, ok := ParseTraceparent(v); ok {
+			t.Errorf("ParseTraceparent(%q) accepted an invalid header", v)
+		}
+	}
+}
+
+func TestDisabledPropagatesCallerContext(t *testing.T) {
+	Shutdown(context.Background())
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := Start(Extract(context.Background(), header), "request", KindServer)
+	if span != nil {
+		t.Fatal("Start returned a span with tracing disabled")
+	}
+	span.SetAttr("ignored", 1) // nil spans are no-ops
+	span.End()
+
+	out := http.Header{}
+	Inject(ctx, out)
+	if got := out.Get(TraceparentHeader); got != header.Get(TraceparentHeader) {
+		t.Errorf("traceparent = %q, want caller's %q", got, header.Get(TraceparentHeader))
+	}
+}
+
+// collector is a stand-in for an OTLP/HTTP collector
+type collector struct {
+	mu       sync.Mutex
+	requests []otlpRequest
+	headers  []http.Header
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpRequest
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header.Clone())
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func (c *collector) spans() map[string]otlpSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	spans := make(map[string]otlpSpan)
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans[s.Name] = s
+				}
+			}
+		}
+	}
+	return spans
+}
+
+func attr(s otlpSpan, key string) *otlpValue {
+	for _, kv := range s.Attributes {
+		if kv.Key == key {
+			return &kv.Value
+		}
+	}
+	return nil
+}
+
+func TestExportToCollector(t *testing.T) {
+	col := &collector{}
+	collectorServer := httptest.NewServer(col)
+	defer collectorServer.Close()
+
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get(TraceparentHeader)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	if err := Init(Config{
+		Endpoint:      TracesURL(collectorServer.URL),
+		Headers:       parseHeaders("x-api-key=secret%20key"),
+		FlushInterval: time.Hour,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown(context.Background())
+
+	// Incoming request from a traced client
+	incoming := http.Header{}
+	incoming.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := Start(Extract(context.Background(), incoming), "proxy_request", KindServer)
+	root.SetAttr("maxx.client_type", "claude")
+	root.SetAttr("maxx.request_id", uint64(42))
+
+	_, convert := StartConversion(ctx, "transform_request", "claude", "gemini")
+	convert.SetError(errors.New("boom"))
+	convert.End()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, upstream.URL+"/v1/messages?key=hidden", nil)
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if req.Header.Get(TraceparentHeader) != "" {
+		t.Error("transport modified the caller's request")
+	}
+
+	root.SetOK()
+	root.End()
+	root.End() // ignored
+	ForceFlush(context.Background())
+
+	spans := col.spans()
+	if len(spans) != 3 {
+		t.Fatalf("collector got %d spans, want 3: %+v", len(spans), spans)
+	}
+	if got := col.headers[0].Get("x-api-key"); got != "secret key" {
+		t.Errorf("collector header = %q, want %q", got, "secret key")
+	}
+
+	rootSpan, convertSpan, httpSpan := spans["proxy_request"], spans["transform_request"], spans["HTTP POST"]
+	if rootSpan.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || rootSpan.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("root span didn't continue the caller's trace: %+v", rootSpan)
+	}
+	if rootSpan.Kind != KindServer || rootSpan.Status.Code != statusOK {
+		t.Errorf("root span kind/status = %d/%d", rootSpan.Kind, rootSpan.Status.Code)
+	}
+	if v := attr(rootSpan, "maxx.request_id"); v == nil || v.IntValue == nil || *v.IntValue != "42" {
+		t.Errorf("maxx.request_id = %+v, want intValue 42", v)
+	}
+	if convertSpan.ParentSpanID != rootSpan.SpanID || convertSpan.Status.Code != statusError || convertSpan.Status.Message != "boom" {
+		t.Errorf("conversion span = %+v", convertSpan)
+	}
+	if httpSpan.ParentSpanID != rootSpan.SpanID || httpSpan.Kind != KindClient {
+		t.Errorf("http span = %+v", httpSpan)
+	}
+	if want := "00-" + httpSpan.TraceID + "-" + httpSpan.SpanID + "-01"; upstreamTraceparent != want {
+		t.Errorf("upstream traceparent = %q, want %q", upstreamTraceparent, want)
+	}
+	if v := attr(httpSpan, "url.path"); v == nil || v.StringValue == nil || *v.StringValue != "/v1/messages" {
+		t.Errorf("url.path = %+v", v)
+	}
+	if v := attr(httpSpan, "http.response.status_code"); v == nil || v.IntValue == nil || *v.IntValue != "200" {
+		t.Errorf("http.response.status_code = %+v", v)
+	}
+}
+
+func TestUnsampledCallerIsNotRecorded(t *testing.T) {
+	col := &collector{}
+	server := httptest.NewServer(col)
+	defer server.Close()
+	if err := Init(Config{Endpoint: TracesURL(server.URL), FlushInterval: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown(context.Background())
+
+	header := http.Header{}
+	header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	_, span := Start(Extract(context.Background(), header), "request", KindServer)
+	span.End()
+	ForceFlush(context.Background())
+
+	if n := len(col.spans()); n != 0 {
+		t.Errorf("collector got %d spans for an unsampled trace", n)
+	}
+}
@@ -141,10 +141,14 @@ func extractUsageFromMap(data map[string]interface{}) *Metrics {
 
 	// Try Codex/OpenAI Response API format: { "response": { "usage": { ... } } }
 	// This is used in response.completed events
+	// Gemini v1internal (Antigravity / Gemini CLI) wraps responses the same way: { "response": { "usageMetadata": { ... } } }
 	if response, ok := data["response"].(map[string]interface{}); ok {
 		if usage, ok := response["usage"].(map[string]interface{}); ok {
 			return extractOpenAIUsage(usage)
 		}
+		if usage, ok := response["usageMetadata"].(map[string]interface{}); ok {
+			return extractGeminiUsage(usage)
+		}
 	}
 
 	// Try OpenAI choices format for some responses
@@ -0,0 +1,247 @@
+import {
+  createContext,
+  useCallback,
+  useContext,
+  useEffect,
+  useState,
+  type ComponentProps,
+  type FormEvent,
+  type ReactNode,
+} from 'react'
+import { Button, Card, CardContent, CardDescription, CardHeader, CardTitle, Input } from '@/components/ui'
+import { Label } from '@/components/ui/label'
+import { isWailsEnvironment, resetTransport } from '@/lib/transport'
+import { queryClient } from '@/lib/query-client'
+import {
+  authErrorMessage,
+  getAuthStatus,
+  login,
+  logout,
+  onUnauthorized,
+  setupAdmin,
+  type AdminUser,
+} from '@/lib/auth'
+
+interface AuthContextValue {
+  /** 当前登录用户（Wails 桌面模式下为 null） */
+  user: AdminUser | null
+  logout: () => Promise<void>
+}
+
+const AuthContext = createContext<AuthContextValue>({
+  user: null,
+  logout: async () => {},
+})
+
+export function useAuth(): AuthContextValue {
+  return useContext(AuthContext)
+}
+
+type AuthState =
+  | { status: 'loading' }
+  | { status: 'setup' }
+  | { status: 'login'; message?: string }
+  | { status: 'ready'; user: AdminUser | null }
+  | { status: 'error'; error: string }
+
+/**
+ * AuthGate 在 Transport 初始化前完成登录
+ * 首次启动时要求输入服务端日志中打印的 setup token 创建管理员；
+ * Wails 桌面模式不经过 HTTP，直接渲染子组件
+ */
+export function AuthGate({ children }: { children: ReactNode }) {
+  const [state, setState] = useState<AuthState>(() =>
+    isWailsEnvironment() ? { status: 'ready', user: null } : { status: 'loading' }
+  )
+
+  useEffect(() => {
+    if (state.status !== 'loading') {
+      return
+    }
+    let cancelled = false
+    getAuthStatus()
+      .then((status) => {
+        if (cancelled) return
+        if (status.user) {
+          setState({ status: 'ready', user: status.user })
+        } else {
+          setState(status.setupRequired ? { status: 'setup' } : { status: 'login' })
+        }
+      })
+      .catch((error) => {
+        if (!cancelled) setState({ status: 'error', error: authErrorMessage(error) })
+      })
+    return () => {
+      cancelled = true
+    }
+  }, [state.status])
+
+  // 会话过期或被吊销：断开 WebSocket，回到登录页
+  useEffect(() => {
+    if (isWailsEnvironment()) {
+      return
+    }
+    return onUnauthorized(() => {
+      resetTransport()
+      queryClient.clear()
+      setState({ status: 'login', message: 'Your session has expired. Please log in again.' })
+    })
+  }, [])
+
+  const handleLogout = useCallback(async () => {
+    try {
+      await logout()
+    } finally {
+      resetTransport()
+      queryClient.clear()
+      setState({ status: 'login' })
+    }
+  }, [])
+
+  switch (state.status) {
+    case 'loading':
+      return <CenteredMessage>Initializing...</CenteredMessage>
+    case 'error':
+      return <CenteredMessage>Failed to reach the server: {state.error}</CenteredMessage>
+    case 'setup':
+      return <SetupForm onDone={(user) => setState({ status: 'ready', user })} />
+    case 'login':
+      return <LoginForm message={state.message} onDone={(user) => setState({ status: 'ready', user })} />
+    case 'ready':
+      return (
+        <AuthContext.Provider value={{ user: state.user, logout: handleLogout }}>
+          {children}
+        </AuthContext.Provider>
+      )
+  }
+}
+
+function CenteredMessage({ children }: { children: ReactNode }) {
+  return (
+    <div className="flex h-screen items-center justify-center text-sm text-muted-foreground">{children}</div>
+  )
+}
+
+function AuthCard({
+  title,
+  description,
+  error,
+  submitting,
+  submitLabel,
+  onSubmit,
+  children,
+}: {
+  title: string
+  description: string
+  error: string | null
+  submitting: boolean
+  submitLabel: string
+  onSubmit: () => Promise<void>
+  children: ReactNode
+}) {
+  const handleSubmit = (e: FormEvent) => {
+    e.preventDefault()
+    onSubmit()
+  }
+
+  return (
+    <div className="flex h-screen items-center justify-center bg-background p-4">
+      <Card className="w-full max-w-sm">
+        <CardHeader>
+          <CardTitle>{title}</CardTitle>
+          <CardDescription>{description}</CardDescription>
+        </CardHeader>
+        <CardContent>
+          <form onSubmit={handleSubmit} className="flex flex-col gap-4">
+            {children}
+            {error && <p className="text-sm text-destructive">{error}</p>}
+            <Button type="submit" disabled={submitting}>
+              {submitting ? 'Please wait...' : submitLabel}
+            </Button>
+          </form>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
+
+function Field({
+  id,
+  label,
+  ...props
+}: { id: string; label: string } & ComponentProps<typeof Input>) {
+  return (
+    <div className="flex flex-col gap-2">
+      <Label htmlFor={id}>{label}</Label>
+      <Input id={id} required {...props} />
+    </div>
+  )
+}
+
+function LoginForm({ message, onDone }: { message?: string; onDone: (user: AdminUser) => void }) {
+  const [username, setUsername] = useState('')
+  const [password, setPassword] = useState('')
+  const [error, setError] = useState<string | null>(null)
+  const [submitting, setSubmitting] = useState(false)
+
+  const submit = async () => {
+    setSubmitting(true)
+    setError(null)
+    try {
+      onDone(await login(username, password))
+    } catch (e) {
+      setError(authErrorMessage(e))
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  return (
+    <AuthCard
+      title="Sign in to maxx"
+      description={message ?? 'Log in with your admin account.'}
+      error={error}
+      submitting={submitting}
+      submitLabel="Log in"
+      onSubmit={submit}
+    >
+      <Field id="username" label="Username" autoComplete="username" value={username} onChange={(e) => setUsername(e.target.value)} />
+      <Field id="password" label="Password" type="password" autoComplete="current-password" value={password} onChange={(e) => setPassword(e.target.value)} />
+    </AuthCard>
+  )
+}
+
+function SetupForm({ onDone }: { onDone: (user: AdminUser) => void }) {
+  const [setupToken, setSetupToken] = useState('')
+  const [username, setUsername] = useState('admin')
+  const [password, setPassword] = useState('')
+  const [error, setError] = useState<string | null>(null)
+  const [submitting, setSubmitting] = useState(false)
+
+  const submit = async () => {
+    setSubmitting(true)
+    setError(null)
+    try {
+      onDone(await setupAdmin(setupToken.trim(), username, password))
+    } catch (e) {
+      setError(authErrorMessage(e))
+    } finally {
+      setSubmitting(false)
+    }
+  }
+
+  return (
+    <AuthCard
+      title="Create the first admin"
+      description="Enter the setup token printed in the maxx server log on startup."
+      error={error}
+      submitting={submitting}
+      submitLabel="Create admin"
+      onSubmit={submit}
+    >
+      <Field id="setup-token" label="Setup token" autoComplete="off" value={setupToken} onChange={(e) => setSetupToken(e.target.value)} />
+      <Field id="username" label="Username" autoComplete="username" value={username} onChange={(e) => setUsername(e.target.value)} />
+      <Field id="password" label="Password (min. 8 characters)" type="password" autoComplete="new-password" minLength={8} value={password} onChange={(e) => setPassword(e.target.value)} />
+    </AuthCard>
+  )
+}
@@ -8,6 +8,7 @@ import {
   RefreshCw,
   Terminal,
   Settings,
+  LogOut,
 } from 'lucide-react'
 import { StreamingBadge } from '@/components/ui/streaming-badge'
 import { useStreamingRequests } from '@/hooks/use-streaming'
@@ -26,6 +27,8 @@ import { NavRoutes } from './nav-routes'
 import { NavManagement } from './nav-management'
 import { NavProxyStatus } from './nav-proxy-status'
 import { ThemeToggle } from '@/components/theme-toggle'
+import { useAuth } from '@/components/auth-gate'
+import { Button } from '@/components/ui/button'
 
 const mainNavItems = [
   { to: '/', icon: LayoutDashboard, label: 'Dashboard' },
@@ -113,8 +116,22 @@ export function SidebarNav() {
           </p>
           <SidebarTrigger />
           <ThemeToggle />
+          <LogoutButton />
         </div>
       </SidebarFooter>
     </Sidebar>
   )
 }
+
+function LogoutButton() {
+  const { user, logout } = useAuth()
+  if (!user) {
+    return null
+  }
+  return (
+    <Button variant="ghost" size="icon-sm" onClick={() => logout()} title={`Log out ${user.username}`}>
+      <LogOut className="h-[1.2rem] w-[1.2rem]" />
+      <span className="sr-only">Log out</span>
+    </Button>
+  )
+}
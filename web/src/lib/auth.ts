@@ -0,0 +1,125 @@
+/**
+ * 管理后台登录
+ * 会话 token 保存在 localStorage，并通过 Authorization 头发送；
+ * 登录时服务端同时设置 HttpOnly 的 maxx_session Cookie，WebSocket 握手依赖该 Cookie
+ */
+
+import axios, { type AxiosInstance } from 'axios';
+
+const TOKEN_STORAGE_KEY = 'maxx-session-token';
+
+export type UserRole = 'admin' | 'viewer';
+
+export interface AdminUser {
+  id: number;
+  username: string;
+  role: UserRole;
+  isEnabled: boolean;
+  lastLoginAt?: string;
+}
+
+export interface AuthStatus {
+  setupRequired: boolean;
+  user?: AdminUser;
+}
+
+interface LoginResult {
+  token: string;
+  expiresAt: string;
+  user: AdminUser;
+}
+
+export function getSessionToken(): string | null {
+  return localStorage.getItem(TOKEN_STORAGE_KEY);
+}
+
+function setSessionToken(token: string | null): void {
+  if (token) {
+    localStorage.setItem(TOKEN_STORAGE_KEY, token);
+  } else {
+    localStorage.removeItem(TOKEN_STORAGE_KEY);
+  }
+}
+
+// ===== 401 通知 =====
+
+type UnauthorizedListener = () => void;
+const unauthorizedListeners = new Set<UnauthorizedListener>();
+
+/** 订阅会话失效事件（任一请求返回 401） */
+export function onUnauthorized(listener: UnauthorizedListener): () => void {
+  unauthorizedListeners.add(listener);
+  return () => {
+    unauthorizedListeners.delete(listener);
+  };
+}
+
+/**
+ * 为 axios 实例附加 token，并在 401 时清除 token、通知登录界面
+ */
+export function installAuthInterceptors(instance: AxiosInstance): void {
+  instance.interceptors.request.use((config) => {
+    const token = getSessionToken();
+    if (token) {
+      config.headers.set('Authorization', `Bearer ${token}`);
+    }
+    return config;
+  });
+  instance.interceptors.response.use(undefined, (error) => {
+    const url: string = error.config?.url ?? '';
+    if (error.response?.status === 401 && !url.startsWith('/admin/auth/')) {
+      setSessionToken(null);
+      unauthorizedListeners.forEach((listener) => listener());
+    }
+    return Promise.reject(error);
+  });
+}
+
+// ===== Auth API =====
+
+const authClient = axios.create({
+  headers: {
+    'Content-Type': 'application/json',
+  },
+});
+installAuthInterceptors(authClient);
+
+export async function getAuthStatus(): Promise<AuthStatus> {
+  const { data } = await authClient.get<AuthStatus>('/admin/auth/status');
+  return data;
+}
+
+export async function login(username: string, password: string): Promise<AdminUser> {
+  const { data } = await authClient.post<LoginResult>('/admin/auth/login', { username, password });
+  setSessionToken(data.token);
+  return data.user;
+}
+
+export async function setupAdmin(setupToken: string, username: string, password: string): Promise<AdminUser> {
+  const { data } = await authClient.post<LoginResult>('/admin/auth/setup', {
+    setupToken,
+    username,
+    password,
+  });
+  setSessionToken(data.token);
+  return data.user;
+}
+
+export async function logout(): Promise<void> {
+  try {
+    await authClient.post('/admin/auth/logout');
+  } finally {
+    setSessionToken(null);
+  }
+}
+
+/** 从 axios 错误中提取服务端返回的错误信息 */
+export function authErrorMessage(error: unknown): string {
+  if (axios.isAxiosError(error)) {
+    const message = (error.response?.data as { error?: string } | undefined)?.error;
+    if (message) {
+      return message;
+    }
+  }
+  return error instanceof Error ? error.message : String(error);
+}
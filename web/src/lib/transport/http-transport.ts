@@ -5,6 +5,7 @@
 
 import axios, { type AxiosInstance } from 'axios';
 import type { Transport, TransportConfig } from './interface';
+import { installAuthInterceptors } from '@/lib/auth';
 import type {
   Provider,
   CreateProviderData,
@@ -36,6 +37,7 @@ import type {
 
 export class HttpTransport implements Transport {
   private client: AxiosInstance;
+  private antigravity: AxiosInstance;
   private ws: WebSocket | null = null;
   private config: Required<TransportConfig>;
   private eventListeners: Map<WSMessageType, Set<EventCallback>> = new Map();
@@ -57,6 +59,10 @@ export class HttpTransport implements Transport {
         'Content-Type': 'application/json',
       },
     });
+    installAuthInterceptors(this.client);
+    // Antigravity 接口不在 /admin 下，使用独立实例
+    this.antigravity = axios.create();
+    installAuthInterceptors(this.antigravity);
   }
 
   // ===== Provider API =====
@@ -298,7 +304,7 @@ export class HttpTransport implements Transport {
   // ===== Antigravity API =====
 
   async validateAntigravityToken(refreshToken: string): Promise<AntigravityTokenValidationResult> {
-    const { data } = await axios.post<AntigravityTokenValidationResult>(
+    const { data } = await this.antigravity.post<AntigravityTokenValidationResult>(
       '/antigravity/validate-token',
       { refreshToken }
     );
@@ -306,7 +312,7 @@ export class HttpTransport implements Transport {
   }
 
   async validateAntigravityTokens(tokens: string[]): Promise<AntigravityBatchValidationResult> {
-    const { data } = await axios.post<AntigravityBatchValidationResult>(
+    const { data } = await this.antigravity.post<AntigravityBatchValidationResult>(
       '/antigravity/validate-tokens',
       { tokens }
     );
@@ -314,7 +320,7 @@ export class HttpTransport implements Transport {
   }
 
   async validateAntigravityTokenText(tokenText: string): Promise<AntigravityBatchValidationResult> {
-    const { data } = await axios.post<AntigravityBatchValidationResult>(
+    const { data } = await this.antigravity.post<AntigravityBatchValidationResult>(
       '/antigravity/validate-tokens',
       { tokenText }
     );
@@ -323,7 +329,7 @@ export class HttpTransport implements Transport {
 
   async getAntigravityProviderQuota(providerId: number, forceRefresh?: boolean): Promise<AntigravityQuotaData> {
     const params = forceRefresh ? { refresh: 'true' } : undefined;
-    const { data } = await axios.get<AntigravityQuotaData>(
+    const { data } = await this.antigravity.get<AntigravityQuotaData>(
       `/antigravity/providers/${providerId}/quota`,
       { params }
     );
@@ -331,7 +337,7 @@ export class HttpTransport implements Transport {
   }
 
   async startAntigravityOAuth(): Promise<{ authURL: string; state: string }> {
-    const { data } = await axios.post<{ authURL: string; state: string }>(
+    const { data } = await this.antigravity.post<{ authURL: string; state: string }>(
       '/antigravity/oauth/start'
     );
     return data;
@@ -420,7 +426,11 @@ export class HttpTransport implements Transport {
       clearTimeout(this.reconnectTimer);
       this.reconnectTimer = null;
     }
-    this.ws?.close();
+    if (this.ws) {
+      // 主动断开时不再自动重连
+      this.ws.onclose = null;
+      this.ws.close();
+    }
     this.ws = null;
   }
 
@@ -27,6 +27,7 @@ export interface ProviderConfigAntigravity {
 export interface ProviderConfig {
   custom?: ProviderConfigCustom;
   antigravity?: ProviderConfigAntigravity;
+  probeModels?: Partial<Record<ClientType, string>>;
 }
 
 export interface Provider {
@@ -106,10 +107,16 @@ export type CreateRetryConfigData = Omit<RetryConfig, 'id' | 'createdAt' | 'upda
 
 // ===== RoutingStrategy =====
 
-export type RoutingStrategyType = 'priority' | 'weighted_random';
+export type RoutingStrategyType = 'priority' | 'weighted_random' | 'least_latency' | 'least_cost';
+
+export type LatencyMetric = 'ttfb' | 'duration';
 
 export interface RoutingStrategyConfig {
-  // 扩展字段
+  weights?: Record<number, number>; // routeID → weight (weighted_random)
+  defaultWeight?: number;
+  latencyMetric?: LatencyMetric; // least_latency, default ttfb
+  stickySession?: boolean; // prefer the provider that last served the session
+  stickySessionTTL?: number; // nanoseconds, default 1h
 }
 
 export interface RoutingStrategy {
@@ -185,6 +192,7 @@ export interface ProxyUpstreamAttempt {
   startTime: string;
   endTime: string;
   duration: number; // nanoseconds
+  ttfb: number; // nanoseconds, 0 if nothing was written
   status: ProxyUpstreamAttemptStatus;
   proxyRequestID: number;
   isStream: boolean; // 是否为 SSE 流式请求
@@ -4,6 +4,7 @@ import { QueryClientProvider } from '@tanstack/react-query';
 import { queryClient } from '@/lib/query-client';
 import { TransportProvider } from '@/lib/transport';
 import { ThemeProvider } from '@/components/theme-provider';
+import { AuthGate } from '@/components/auth-gate';
 import App from './App';
 import './index.css';
 
@@ -59,11 +60,13 @@ function ErrorFallback(error: Error) {
 createRoot(document.getElementById('root')!).render(
   <StrictMode>
     <ThemeProvider defaultTheme="light" storageKey="maxx-ui-theme">
-      <TransportProvider fallback={<LoadingFallback />} errorFallback={ErrorFallback}>
-        <QueryClientProvider client={queryClient}>
-          <App />
-        </QueryClientProvider>
-      </TransportProvider>
+      <AuthGate>
+        <TransportProvider fallback={<LoadingFallback />} errorFallback={ErrorFallback}>
+          <QueryClientProvider client={queryClient}>
+            <App />
+          </QueryClientProvider>
+        </TransportProvider>
+      </AuthGate>
     </ThemeProvider>
   </StrictMode>
 );
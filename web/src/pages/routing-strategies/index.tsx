@@ -136,6 +136,8 @@ export function RoutingStrategiesPage() {
                   >
                     <option value="priority">Priority (by position)</option>
                     <option value="weighted_random">Weighted Random</option>
+                    <option value="least_latency">Least Latency</option>
+                    <option value="least_cost">Least Cost</option>
                   </select>
                 </div>
               </div>